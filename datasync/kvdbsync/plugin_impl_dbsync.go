@@ -100,7 +100,7 @@ func (p *Plugin) initKvPlugin() error {
 
 	if p.ResyncOrch != nil {
 		for name, sub := range p.registry.Subscriptions() {
-			reg := p.ResyncOrch.Register(name)
+			reg := p.registerResync(name, sub.KeyPrefixes)
 			_, err := watchAndResyncBrokerKeys(reg, sub.ChangeChan, sub.ResyncChan, sub.CloseChan,
 				p.adapter, sub.KeyPrefixes...)
 			if err != nil {
@@ -114,6 +114,21 @@ func (p *Plugin) initKvPlugin() error {
 	return nil
 }
 
+// registerResync registers resync of the subscription. If supported by ResyncOrch, the registration
+// is bound to the watched key prefixes (including the agent prefix), so that the data store
+// can request resync of just the affected subscriptions (e.g. after a watch missed some events).
+func (p *Plugin) registerResync(name string, keyPrefixes []string) resync.Registration {
+	prefixSubscriber, ok := p.ResyncOrch.(resync.PrefixSubscriber)
+	if !ok {
+		return p.ResyncOrch.Register(name)
+	}
+	fullPrefixes := make([]string, 0, len(keyPrefixes))
+	for _, keyPrefix := range keyPrefixes {
+		fullPrefixes = append(fullPrefixes, p.ServiceLabel.GetAgentPrefix()+keyPrefix)
+	}
+	return prefixSubscriber.RegisterKeyPrefixes(name, fullPrefixes...)
+}
+
 // Watch adds entry to the plugin.registry. By doing this, other plugins will receive notifications
 // about data changes and data resynchronization.
 //
@@ -23,6 +23,23 @@ type Subscriber interface {
 	Register(resyncName string) Registration
 }
 
+// PrefixSubscriber is implemented by orchestrators which are able to resync
+// only registrations affected by changes of data under some key prefix.
+type PrefixSubscriber interface {
+	Subscriber
+	// RegisterKeyPrefixes works like Register, but the registration also
+	// receives resync started for any of the given key prefixes.
+	RegisterKeyPrefixes(resyncName string, keyPrefixes ...string) Registration
+}
+
+// PrefixResyncer starts resync of registrations watching data under given
+// key prefixes, e.g. when a data store watch has missed some events.
+type PrefixResyncer interface {
+	// ResyncPrefixes starts resync of registrations with key prefixes
+	// overlapping any of the given key prefixes.
+	ResyncPrefixes(keyPrefixes ...string)
+}
+
 // Registration is an interface that is returned by the Register() call.
 type Registration interface {
 	StatusChan() <-chan StatusEvent
@@ -37,6 +37,9 @@ type Plugin struct {
 	mu            sync.Mutex
 	regOrder      []string
 	registrations map[string]*registration
+
+	// resyncMu serializes full and prefix resyncs
+	resyncMu sync.Mutex
 }
 
 // Deps groups dependencies injected into the plugin so that they are
@@ -87,11 +90,68 @@ func (p *Plugin) Register(resyncName string) Registration {
 	return reg
 }
 
+// RegisterKeyPrefixes works like Register, the registration receives also
+// resync started by ResyncPrefixes for any of the given key prefixes.
+func (p *Plugin) RegisterKeyPrefixes(resyncName string, keyPrefixes ...string) Registration {
+	reg := p.Register(resyncName)
+	if reg != nil {
+		p.mu.Lock()
+		p.registrations[resyncName].keyPrefixes = keyPrefixes
+		p.mu.Unlock()
+	}
+	return reg
+}
+
 // DoResync can be used to start resync procedure outside of after init
 func (p *Plugin) DoResync() {
+	p.resyncMu.Lock()
+	defer p.resyncMu.Unlock()
+
 	p.startResync()
 }
 
+// ResyncPrefixes starts resync only for registrations with key prefixes
+// overlapping any of the given key prefixes. Registrations without key
+// prefixes are not affected.
+func (p *Plugin) ResyncPrefixes(keyPrefixes ...string) {
+	p.resyncMu.Lock()
+	defer p.resyncMu.Unlock()
+
+	p.mu.Lock()
+	var regNames []string
+	for _, regName := range p.regOrder {
+		reg, found := p.registrations[regName]
+		if !found {
+			continue
+		}
+		for _, keyPrefix := range keyPrefixes {
+			if reg.watchesPrefix(keyPrefix) {
+				regNames = append(regNames, regName)
+				break
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	if len(regNames) == 0 {
+		p.Log.Debugf("No registrations for key prefixes %v, skipping resync", keyPrefixes)
+		return
+	}
+	p.Log.Infof("Resync starting for key prefixes %v (%v)", keyPrefixes, strings.Join(regNames, ", "))
+
+	resyncStart := time.Now()
+	for _, regName := range regNames {
+		p.mu.Lock()
+		reg, found := p.registrations[regName]
+		p.mu.Unlock()
+		if found {
+			p.startSingleResync(regName, reg)
+		}
+	}
+
+	p.Log.Infof("Resync of key prefixes done (took: %v)", time.Since(resyncStart).Round(time.Millisecond))
+}
+
 // Call callback on plugins to create/delete/modify objects.
 func (p *Plugin) startResync() {
 	if len(p.regOrder) == 0 {
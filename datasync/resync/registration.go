@@ -14,12 +14,16 @@
 
 package resync
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // registration for Resync (implementation of Registration interface)
 type registration struct {
-	resyncName string
-	statusChan chan StatusEvent
+	resyncName  string
+	statusChan  chan StatusEvent
+	keyPrefixes []string
 }
 
 // newRegistration is a constructor.
@@ -27,6 +31,17 @@ func newRegistration(resyncName string, statusChan chan StatusEvent) *registrati
 	return &registration{resyncName: resyncName, statusChan: statusChan}
 }
 
+// watchesPrefix returns true if any key prefix of the registration overlaps
+// with the given key prefix.
+func (reg *registration) watchesPrefix(keyPrefix string) bool {
+	for _, regPrefix := range reg.keyPrefixes {
+		if strings.HasPrefix(regPrefix, keyPrefix) || strings.HasPrefix(keyPrefix, regPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // StatusChan enables Plugins to get channel for notifications about Resync status.
 func (reg *registration) StatusChan() <-chan StatusEvent {
 	return reg.statusChan
@@ -19,6 +19,7 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"errors"
+	"fmt"
 	"hash"
 	"io"
 
@@ -29,27 +30,46 @@ import (
 type ClientAPI interface {
 	// EncryptData encrypts input data using provided public key
 	EncryptData(inData []byte, pub *rsa.PublicKey) (data []byte, err error)
-	// DecryptData decrypts input data
+	// EncryptDataEnvelope encrypts input data of arbitrary size using random AES-GCM key
+	// that is encrypted by provided public key (see EncryptEnvelope)
+	EncryptDataEnvelope(inData []byte, pub *rsa.PublicKey) (data []byte, err error)
+	// DecryptData decrypts input data (both RSA-only and envelope encrypted)
 	DecryptData(inData []byte) (data []byte, err error)
 	// WrapBytes wraps kv bytes plugin with support for decrypting encrypted data in values
 	WrapBytes(cbw keyval.KvBytesPlugin, decrypter ArbitraryDecrypter) keyval.KvBytesPlugin
 	// WrapBytes wraps kv proto plugin with support for decrypting encrypted data in values
 	WrapProto(kvp keyval.KvProtoPlugin, decrypter ArbitraryDecrypter) keyval.KvProtoPlugin
+	// ReEncrypt re-encrypts values under the prefix that are not encrypted by the active key
+	ReEncrypt(cbw keyval.KvBytesPlugin, prefix string, decrypter ArbitraryDecrypter) (count int, err error)
 }
 
 // ClientConfig is result of converting Config.PrivateKeyFile to PrivateKey
 type ClientConfig struct {
 	// Private key is used to decrypt encrypted keys while reading them from store
 	PrivateKeys []*rsa.PrivateKey
+	// Public keys are used to encrypt values while writing them to store (by wrapped
+	// brokers), the first key is used unless Keyring has active key.
+	// Encrypting on write is disabled if there is no key to use.
+	PublicKeys []*rsa.PublicKey
+	// Keyring holds keys of any supported type identified by key ID, PrivateKeys
+	// and PublicKeys are added into it by NewClient (new keyring is created if nil)
+	Keyring *Keyring
+	// Key providers are used for keys not found in the Keyring (e.g. keys of external
+	// key management system), the first provider with active key is used for encrypting
+	// if the Keyring has no active key
+	KeyProviders []KeyProvider
 	// Reader used for encrypting/decrypting
 	Reader io.Reader
-	// Hash function used for hashing while encrypting
-	Hash hash.Hash
+	// Hash creates hash function used by RSA-OAEP, new hash is created for every operation
+	// since hash.Hash is not safe for concurrent use (sha256.New by default)
+	Hash func() hash.Hash
 }
 
 // Client implements ClientAPI and ClientConfig
 type Client struct {
 	ClientConfig
+	// providers are the Keyring provider followed by KeyProviders
+	providers []KeyProvider
 }
 
 // NewClient creates new client from provided config and reader
@@ -65,36 +85,139 @@ func NewClient(clientConfig ClientConfig) *Client {
 
 	// If hash is nil use default sha256
 	if clientConfig.Hash == nil {
-		client.Hash = sha256.New()
+		client.Hash = sha256.New
 	}
 
+	// If keyring is nil create new one, then add keys configured by fields
+	if clientConfig.Keyring == nil {
+		client.Keyring = NewKeyring()
+	}
+	for _, key := range clientConfig.PrivateKeys {
+		client.Keyring.Add(NewRSAPrivateKey(key))
+	}
+	if client.Keyring.Active() == nil && len(clientConfig.PublicKeys) > 0 {
+		publicKey := NewRSAPublicKey(clientConfig.PublicKeys[0])
+		client.Keyring.AddPublic(publicKey)
+		_ = client.Keyring.SetActive(publicKey.ID())
+	}
+
+	client.providers = append([]KeyProvider{NewKeyringProvider(client.Keyring, client.Reader, client.Hash)},
+		clientConfig.KeyProviders...)
+
 	return client
 }
 
 // EncryptData implements ClientAPI.EncryptData
 func (client *Client) EncryptData(inData []byte, pub *rsa.PublicKey) (data []byte, err error) {
-	return rsa.EncryptOAEP(client.Hash, client.Reader, pub, inData, nil)
+	return rsa.EncryptOAEP(client.Hash(), client.Reader, pub, inData, nil)
+}
+
+// EncryptDataEnvelope implements ClientAPI.EncryptDataEnvelope
+func (client *Client) EncryptDataEnvelope(inData []byte, pub *rsa.PublicKey) (data []byte, err error) {
+	return EncryptEnvelope(client.Reader, client.Hash(), pub, inData)
+}
+
+// EncryptDataWithKey encrypts input data using envelope encryption with provided key of any supported type
+func (client *Client) EncryptDataWithKey(inData []byte, pub PublicKey) (data []byte, err error) {
+	return EncryptEnvelopeWithKey(client.Reader, client.Hash(), pub, inData)
 }
 
-// DecryptData implements ClientAPI.DecryptData
+// EncryptDataWithActiveKey encrypts input data using envelope encryption with the active
+// key of the keyring or of the first key provider with active key
+func (client *Client) EncryptDataWithActiveKey(inData []byte) (data []byte, err error) {
+	provider, _ := client.activeProvider()
+	if provider == nil {
+		return nil, errors.New("failed to encrypt data due to no active key")
+	}
+	return EncryptEnvelopeWithProvider(client.Reader, provider, inData)
+}
+
+// ActiveKeyID returns ID of the key used by EncryptDataWithActiveKey, false if there is no such key
+func (client *Client) ActiveKeyID() (KeyID, bool) {
+	provider, keyID := client.activeProvider()
+	return keyID, provider != nil
+}
+
+func (client *Client) activeProvider() (KeyProvider, KeyID) {
+	for _, provider := range client.providers {
+		if keyID, ok := provider.ActiveKeyID(); ok {
+			return provider, keyID
+		}
+	}
+	return nil, KeyID{}
+}
+
+// DecryptData implements ClientAPI.DecryptData. Envelopes identifying the key are
+// decrypted by the key provider having the key (the keyring first), other envelopes
+// and RSA-only values by trying all RSA keys of the keyring.
 func (client *Client) DecryptData(inData []byte) (data []byte, err error) {
-	for _, key := range client.PrivateKeys {
-		data, err := rsa.DecryptOAEP(client.Hash, client.Reader, key, inData, nil)
+	var envelopeErr error
+	if keyID, ok := EnvelopeKeyID(inData); ok {
+		envelopeErr = fmt.Errorf("failed to decrypt data due to missing key %v", keyID)
+		for _, provider := range client.providers {
+			data, err := DecryptEnvelopeWithProvider(provider, inData)
+			if err == ErrKeyNotFound {
+				continue
+			}
+			if err == nil {
+				return data, nil
+			}
+			envelopeErr = err
+			break
+		}
+	}
+
+	rsaKeys := client.rsaPrivateKeys()
+	if envelopeErr == nil && IsEnvelope(inData) {
+		for _, key := range rsaKeys {
+			data, err := DecryptEnvelope(client.Reader, client.Hash(), key, inData)
+
+			if err == nil {
+				return data, nil
+			}
+		}
+	}
+
+	for _, key := range rsaKeys {
+		data, err := rsa.DecryptOAEP(client.Hash(), client.Reader, key, inData, nil)
 
 		if err == nil {
 			return data, nil
 		}
 	}
 
+	if envelopeErr != nil {
+		return nil, envelopeErr
+	}
 	return nil, errors.New("failed to decrypt data due to no private key matching")
 }
 
-// WrapBytes implements ClientAPI.WrapBytes
+// rsaPrivateKeys returns RSA keys of the keyring
+func (client *Client) rsaPrivateKeys() (keys []*rsa.PrivateKey) {
+	for _, key := range client.Keyring.PrivateKeys() {
+		if rsaKey, ok := key.(*rsaPrivateKey); ok {
+			keys = append(keys, rsaKey.key)
+		}
+	}
+	return keys
+}
+
+// WrapBytes implements ClientAPI.WrapBytes. Values are encrypted on write
+// if there is active key (see EncryptDataWithActiveKey).
 func (client *Client) WrapBytes(cbw keyval.KvBytesPlugin, decrypter ArbitraryDecrypter) keyval.KvBytesPlugin {
-	return NewKvBytesPluginWrapper(cbw, decrypter, client.DecryptData)
+	return NewKvBytesPluginWrapperWithEncryption(cbw, decrypter, client.DecryptData, client.encryptFunc())
 }
 
-// WrapProto implements ClientAPI.WrapProto
+// WrapProto implements ClientAPI.WrapProto. Values are encrypted on write
+// if there is active key (see EncryptDataWithActiveKey).
 func (client *Client) WrapProto(kvp keyval.KvProtoPlugin, decrypter ArbitraryDecrypter) keyval.KvProtoPlugin {
-	return NewKvProtoPluginWrapper(kvp, decrypter, client.DecryptData)
+	return NewKvProtoPluginWrapperWithEncryption(kvp, decrypter, client.DecryptData, client.encryptFunc())
+}
+
+// encryptFunc returns function used by wrappers to encrypt values, nil if there is no active key
+func (client *Client) encryptFunc() EncryptFunc {
+	if _, ok := client.ActiveKeyID(); !ok {
+		return nil
+	}
+	return client.EncryptDataWithActiveKey
 }
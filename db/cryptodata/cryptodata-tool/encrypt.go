@@ -0,0 +1,247 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"go.ligato.io/cn-infra/v2/db/cryptodata"
+)
+
+// Supported formats of the encrypted documents
+const (
+	// formatValue is single value, base64 encoded envelope
+	formatValue = "value"
+	// formatJSON is JSON document for DecrypterJSON, encrypted values have prefix
+	formatJSON = "json"
+	// formatProto is protobuf message in JSON (protojson) for DecrypterProto, encrypted values are base64 encoded
+	formatProto = "proto"
+)
+
+// cryptoPrefix is prefix of encrypted values expected by DecrypterJSON
+const cryptoPrefix = "$crypto$"
+
+// encrypter encrypts values and documents using the public key
+type encrypter struct {
+	client *cryptodata.Client
+}
+
+// newEncrypter creates encrypter using the first public key from the PEM file
+func newEncrypter(publicKeyFile string) (*encrypter, error) {
+	if publicKeyFile == "" {
+		return nil, errors.New("public key file is not set")
+	}
+	data, err := ioutil.ReadFile(publicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	publicKeys, err := cryptodata.ParsePublicKeysPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("no public key found in %s", publicKeyFile)
+	}
+
+	keyring := cryptodata.NewKeyring()
+	keyring.AddPublic(publicKeys[0])
+	if err := keyring.SetActive(publicKeys[0].ID()); err != nil {
+		return nil, err
+	}
+	return &encrypter{client: cryptodata.NewClient(cryptodata.ClientConfig{Keyring: keyring})}, nil
+}
+
+// encryptValue encrypts single value and encodes it with base64
+func (e *encrypter) encryptValue(value string) (string, error) {
+	encrypted, err := e.client.EncryptDataWithActiveKey([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(encrypted), nil
+}
+
+// encryptDocument encrypts fields on given paths of the JSON (or YAML) document. In JSON format
+// the document is marked as encrypted and values already marked by the prefix are encrypted as well.
+func (e *encrypter) encryptDocument(format string, document []byte, fields []string) ([]byte, error) {
+	document, err := yaml.YAMLToJSON(document)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(document)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case formatJSON:
+		data["encrypted"] = true
+		for _, field := range fields {
+			// DecrypterJSON does not descend into arrays, values in them would stay in plain text
+			err := processField(data, strings.Split(field, "."), false, func(value string) (string, error) {
+				if strings.HasPrefix(value, cryptoPrefix) {
+					return value, nil
+				}
+				return cryptoPrefix + value, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		marked, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := cryptodata.NewDecrypterJSON().Encrypt(marked, e.client.EncryptDataWithActiveKey)
+		if err != nil {
+			return nil, err
+		}
+		return encrypted.([]byte), nil
+	case formatProto:
+		if len(fields) == 0 {
+			return nil, errors.New("no field to encrypt")
+		}
+		for _, field := range fields {
+			if err := processField(data, strings.Split(field, "."), true, e.encryptValue); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(data)
+	}
+	return nil, fmt.Errorf("unsupported document format %q", format)
+}
+
+// processField replaces string values on the path using processFunc, paths going through
+// arrays are applied to all items of the array if arrays are allowed
+func processField(data interface{}, path []string, arrays bool, processFunc func(string) (string, error)) error {
+	switch t := data.(type) {
+	case []interface{}:
+		if !arrays {
+			return fmt.Errorf("field %q is inside an array, arrays are not supported in this format", path[0])
+		}
+		for _, item := range t {
+			if err := processField(item, path, arrays, processFunc); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		value, ok := t[path[0]]
+		if !ok {
+			return fmt.Errorf("field %q not found", path[0])
+		}
+		if len(path) > 1 {
+			return processField(value, path[1:], arrays, processFunc)
+		}
+		switch v := value.(type) {
+		case string:
+			processed, err := processFunc(v)
+			if err != nil {
+				return err
+			}
+			t[path[0]] = processed
+			return nil
+		case []interface{}:
+			if !arrays {
+				return fmt.Errorf("field %q is an array, arrays are not supported in this format", path[0])
+			}
+			for i, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("field %q is not a string", path[0])
+				}
+				processed, err := processFunc(s)
+				if err != nil {
+					return err
+				}
+				v[i] = processed
+			}
+			return nil
+		}
+		return fmt.Errorf("field %q is not a string", path[0])
+	}
+	return fmt.Errorf("field %q not found", path[0])
+}
+
+// batchItem is one item of the batch file
+type batchItem struct {
+	// Key under which the encrypted document is stored
+	Key string `json:"key"`
+	// Format of the document (value, json or proto), json by default
+	Format string `json:"format"`
+	// Fields are dot separated paths of the fields to encrypt
+	Fields []string `json:"fields"`
+	// Value is the document (or single value in value format)
+	Value interface{} `json:"value"`
+}
+
+// encryptBatch encrypts all items of the batch file, returned are encrypted documents by keys
+func (e *encrypter) encryptBatch(batch []byte) (keys []string, documents map[string]string, err error) {
+	batch, err = yaml.YAMLToJSON(batch)
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []batchItem
+	decoder := json.NewDecoder(strings.NewReader(string(batch)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&items); err != nil {
+		return nil, nil, err
+	}
+
+	documents = make(map[string]string)
+	for _, item := range items {
+		if item.Key == "" {
+			return nil, nil, errors.New("batch item without key")
+		}
+		var document string
+		switch item.Format {
+		case formatValue:
+			value, ok := item.Value.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("value of %s is not a string", item.Key)
+			}
+			document, err = e.encryptValue(value)
+		case "", formatJSON, formatProto:
+			format := item.Format
+			if format == "" {
+				format = formatJSON
+			}
+			var value []byte
+			if value, err = json.Marshal(item.Value); err == nil {
+				var encrypted []byte
+				encrypted, err = e.encryptDocument(format, value, item.Fields)
+				document = string(encrypted)
+			}
+		default:
+			err = fmt.Errorf("unsupported document format %q", item.Format)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encrypt %s: %v", item.Key, err)
+		}
+		if _, exists := documents[item.Key]; !exists {
+			keys = append(keys, item.Key)
+		}
+		documents[item.Key] = document
+	}
+	return keys, documents, nil
+}
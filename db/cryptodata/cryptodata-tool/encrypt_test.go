@@ -0,0 +1,136 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/apipb"
+
+	"go.ligato.io/cn-infra/v2/db/cryptodata"
+)
+
+// newTestEncrypter generates key of given type and returns encrypter using its public key
+// with client able to decrypt the encrypted data
+func newTestEncrypter(t *testing.T, keyType cryptodata.KeyType) (*encrypter, *cryptodata.Client) {
+	key, err := cryptodata.GenerateKey(rand.Reader, keyType)
+	Expect(err).ToNot(HaveOccurred())
+	publicPEM, err := cryptodata.MarshalPublicKeyPEM(key.Public())
+	Expect(err).ToNot(HaveOccurred())
+	publicKeyFile := filepath.Join(t.TempDir(), "key-pub.pem")
+	Expect(ioutil.WriteFile(publicKeyFile, publicPEM, 0644)).To(Succeed())
+
+	e, err := newEncrypter(publicKeyFile)
+	Expect(err).ToNot(HaveOccurred())
+	return e, cryptodata.NewClient(cryptodata.ClientConfig{Keyring: cryptodata.NewKeyring(key)})
+}
+
+func TestEncryptValue(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, keyType := range []cryptodata.KeyType{cryptodata.KeyTypeRSA, cryptodata.KeyTypeX25519, cryptodata.KeyTypeP256} {
+		e, client := newTestEncrypter(t, keyType)
+
+		encrypted, err := e.encryptValue("secret")
+		Expect(err).ToNot(HaveOccurred())
+		decoded, err := base64.URLEncoding.DecodeString(encrypted)
+		Expect(err).ToNot(HaveOccurred())
+		decrypted, err := client.DecryptData(decoded)
+		Expect(err).ToNot(HaveOccurred(), keyType.String())
+		Expect(string(decrypted)).To(Equal("secret"))
+	}
+}
+
+func TestEncryptJSON(t *testing.T) {
+	RegisterTestingT(t)
+	e, client := newTestEncrypter(t, cryptodata.KeyTypeX25519)
+
+	document := []byte("user: admin\npassword: secret\ntoken: $crypto$token-value\nnested:\n  key: nested-secret\n")
+	encrypted, err := e.encryptDocument(formatJSON, document, []string{"password", "nested.key"})
+	Expect(err).ToNot(HaveOccurred())
+	for _, secret := range []string{"secret", "token-value", "nested-secret"} {
+		Expect(string(encrypted)).ToNot(ContainSubstring(`"` + secret + `"`))
+	}
+
+	decrypted, err := cryptodata.NewDecrypterJSON().Decrypt(encrypted, client.DecryptData)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(decrypted.([]byte))).To(MatchJSON(`{"encrypted":true,"user":"admin","password":"secret",
+		"token":"token-value","nested":{"key":"nested-secret"}}`))
+
+	// arrays are not supported by DecrypterJSON
+	_, err = e.encryptDocument(formatJSON, []byte(`{"list":[{"key":"a"}]}`), []string{"list.key"})
+	Expect(err).To(HaveOccurred())
+	_, err = e.encryptDocument(formatJSON, document, []string{"missing"})
+	Expect(err).To(HaveOccurred())
+}
+
+func TestEncryptProto(t *testing.T) {
+	RegisterTestingT(t)
+	e, client := newTestEncrypter(t, cryptodata.KeyTypeP256)
+
+	document := []byte(`{"name":"secret","requestTypeUrl":"type"}`)
+	encrypted, err := e.encryptDocument(formatProto, document, []string{"name"})
+	Expect(err).ToNot(HaveOccurred())
+	msg := &apipb.Method{}
+	Expect(protojson.Unmarshal(encrypted, msg)).To(Succeed())
+	Expect(msg.GetName()).ToNot(Equal("secret"))
+	Expect(msg.GetRequestTypeUrl()).To(Equal("type"))
+
+	decrypter := cryptodata.NewDecrypterProto()
+	decrypter.RegisterMapping(&apipb.Method{}, []string{"Name"})
+	decrypted, err := decrypter.Decrypt(msg, client.DecryptData)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(proto.Equal(decrypted.(proto.Message), &apipb.Method{Name: "secret", RequestTypeUrl: "type"})).To(BeTrue())
+
+	_, err = e.encryptDocument(formatProto, document, nil)
+	Expect(err).To(HaveOccurred())
+}
+
+func TestEncryptBatch(t *testing.T) {
+	RegisterTestingT(t)
+	e, client := newTestEncrypter(t, cryptodata.KeyTypeRSA)
+
+	batch := []byte(`
+- key: /config/user
+  fields: [password]
+  value: {user: admin, password: secret}
+- key: /config/token
+  format: value
+  value: token
+`)
+	keys, documents, err := e.encryptBatch(batch)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(keys).To(Equal([]string{"/config/user", "/config/token"}))
+
+	decrypted, err := cryptodata.NewDecrypterJSON().Decrypt([]byte(documents["/config/user"]), client.DecryptData)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(decrypted.([]byte))).To(MatchJSON(`{"encrypted":true,"user":"admin","password":"secret"}`))
+	decoded, err := base64.URLEncoding.DecodeString(documents["/config/token"])
+	Expect(err).ToNot(HaveOccurred())
+	token, err := client.DecryptData(decoded)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(token)).To(Equal("token"))
+
+	_, _, err = e.encryptBatch([]byte(strings.Replace(string(batch), "/config/token", "", 1)))
+	Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"go.ligato.io/cn-infra/v2/config"
+	"go.ligato.io/cn-infra/v2/db/cryptodata"
+	"go.ligato.io/cn-infra/v2/db/keyval/etcd"
+	"go.ligato.io/cn-infra/v2/logging/logrus"
+)
+
+// A utility to encrypt values for the cryptodata plugin. It creates documents
+// in the format expected by DecrypterJSON and DecrypterProto, so they do not
+// have to be crafted by hand.
+
+func main() {
+	args := os.Args
+	if len(args) < 2 {
+		usage()
+		return
+	}
+
+	var err error
+	switch args[1] {
+	case "encrypt-value":
+		err = encryptValueCmd(args[2:])
+	case "encrypt-json":
+		err = encryptDocumentCmd(formatJSON, args[2:])
+	case "encrypt-proto":
+		err = encryptDocumentCmd(formatProto, args[2:])
+	case "batch":
+		err = batchCmd(args[2:])
+	case "keygen":
+		err = keygenCmd(args[2:])
+	default:
+		usage()
+		return
+	}
+	if err != nil {
+		logrus.DefaultLogger().Errorf("%s failed: %v", args[1], err)
+		os.Exit(1)
+	}
+}
+
+// fieldsFlag collects repeated -field flags
+type fieldsFlag []string
+
+func (f *fieldsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fieldsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// encryptValueCmd encrypts single value and prints it base64 encoded
+func encryptValueCmd(args []string) error {
+	flags := flag.NewFlagSet("encrypt-value", flag.ExitOnError)
+	keyFile := flags.String("key", "", "PEM file with public key")
+	prefix := flags.Bool("prefix", false, "prepend "+cryptoPrefix+" prefix (for values of JSON documents)")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one value to encrypt, got %d", flags.NArg())
+	}
+
+	e, err := newEncrypter(*keyFile)
+	if err != nil {
+		return err
+	}
+	encrypted, err := e.encryptValue(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *prefix {
+		encrypted = cryptoPrefix + encrypted
+	}
+	fmt.Println(encrypted)
+	return nil
+}
+
+// encryptDocumentCmd encrypts fields of JSON (or YAML) document read from file or standard input
+func encryptDocumentCmd(format string, args []string) error {
+	flags := flag.NewFlagSet("encrypt-"+format, flag.ExitOnError)
+	keyFile := flags.String("key", "", "PEM file with public key")
+	var fields fieldsFlag
+	flags.Var(&fields, "field", "dot separated path of the field to encrypt (repeatable)")
+	_ = flags.Parse(args)
+
+	document, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	e, err := newEncrypter(*keyFile)
+	if err != nil {
+		return err
+	}
+	encrypted, err := e.encryptDocument(format, document, fields)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encrypted))
+	return nil
+}
+
+// batchCmd encrypts all items of the batch file and prints them or puts them into etcd
+func batchCmd(args []string) error {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	keyFile := flags.String("key", "", "PEM file with public key")
+	etcdConfig := flags.String("etcd-config", "", "put encrypted documents into etcd configured by this file")
+	_ = flags.Parse(args)
+
+	batch, err := readInput(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	e, err := newEncrypter(*keyFile)
+	if err != nil {
+		return err
+	}
+	keys, documents, err := e.encryptBatch(batch)
+	if err != nil {
+		return err
+	}
+
+	if *etcdConfig == "" {
+		out, err := yaml.Marshal(documents)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+		return nil
+	}
+
+	db, err := newEtcdConnection(*etcdConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for _, key := range keys {
+		if err := db.Put(key, []byte(documents[key])); err != nil {
+			return fmt.Errorf("failed to put %s: %v", key, err)
+		}
+		fmt.Println("put", key)
+	}
+	return nil
+}
+
+// keygenCmd generates private key and writes it with its public key into PEM files
+func keygenCmd(args []string) error {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	keyType := flags.String("type", "x25519", "type of the key (rsa, x25519 or p256)")
+	out := flags.String("out", "key", "name of the files, <out>.pem and <out>-pub.pem are written")
+	_ = flags.Parse(args)
+
+	t, err := cryptodata.ParseKeyType(*keyType)
+	if err != nil {
+		return err
+	}
+	key, err := cryptodata.GenerateKey(rand.Reader, t)
+	if err != nil {
+		return err
+	}
+	privatePEM, err := cryptodata.MarshalPrivateKeyPEM(key)
+	if err != nil {
+		return err
+	}
+	publicPEM, err := cryptodata.MarshalPublicKeyPEM(key.Public())
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*out+".pem", privatePEM, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*out+"-pub.pem", publicPEM, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("generated %v key %v\n", t, key.Public().ID())
+	return nil
+}
+
+// readInput reads file or standard input if the file is empty or "-"
+func readInput(file string) ([]byte, error) {
+	if file == "" || file == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// newEtcdConnection creates new etcd bytes connection from provided etcd config path
+func newEtcdConnection(configPath string) (*etcd.BytesConnectionEtcd, error) {
+	etcdFileConfig := &etcd.Config{}
+	if err := config.ParseConfigFromYamlFile(configPath, etcdFileConfig); err != nil {
+		return nil, err
+	}
+	etcdConfig, err := etcd.ConfigToClient(etcdFileConfig)
+	if err != nil {
+		return nil, err
+	}
+	return etcd.NewEtcdConnectionWithBytes(*etcdConfig, logrus.DefaultLogger())
+}
+
+// Show info
+func usage() {
+	var buffer bytes.Buffer
+	buffer.WriteString(`
+	Encrypts values for the cryptodata plugin with a public key
+	(RSA, X25519 or P-256 in PEM). Documents are read from the file
+	or from standard input and can be written in JSON or YAML.
+
+	./cryptodata-tool encrypt-value -key <key.pem> [-prefix] <value>
+	./cryptodata-tool encrypt-json -key <key.pem> [-field <path>]... [<file>]
+	./cryptodata-tool encrypt-proto -key <key.pem> -field <path>... [<file>]
+	./cryptodata-tool batch -key <key.pem> [-etcd-config <etcd.conf>] [<file>]
+	./cryptodata-tool keygen [-type x25519] [-out key]
+
+	encrypt-json marks the document as encrypted and encrypts fields
+	on given paths and values with the $crypto$ prefix (DecrypterJSON).
+	encrypt-proto encrypts fields on given paths of protobuf message
+	in JSON format (DecrypterProto).
+	Paths going through arrays (encrypt-proto only) apply to all
+	items of the array.
+	`)
+
+	logrus.DefaultLogger().Print(buffer.String())
+}
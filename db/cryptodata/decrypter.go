@@ -74,7 +74,8 @@ func (d DecrypterJSON) IsEncrypted(object interface{}) bool {
 // Decrypt tries to find encrypted values in JSON data and decrypt them. It uses IsEncrypted function on the
 // data to check if it contains any encrypted data.
 // Then it parses data as JSON as tries to lookup all values that begin with `Prefix`, then trim prefix, base64
-// decode the data and decrypt them using provided decrypt function.
+// decode the data and decrypt them using provided decrypt function. Values can be both RSA-only
+// and envelope encrypted (see EncryptEnvelope), the format is recognized by Client.DecryptData.
 // This function can accept only []byte and return []byte
 func (d DecrypterJSON) Decrypt(object interface{}, decryptFunc DecryptFunc) (interface{}, error) {
 	if !d.IsEncrypted(object) {
@@ -154,7 +155,7 @@ func (d DecrypterProto) IsEncrypted(object interface{}) bool {
 // Decrypt tries to find encrypted values in protobuf data and decrypt them. It uses IsEncrypted function on the
 // data to check if it contains any encrypted data.
 // Then it goes through provided mapping and tries to reflect all fields in the mapping and decrypt string values the
-// mappings must point to.
+// mappings must point to. Values are base64 encoded RSA-only or envelope encrypted data.
 // This function can accept only proto.Message and return proto.Message
 func (d DecrypterProto) Decrypt(object interface{}, decryptFunc DecryptFunc) (interface{}, error) {
 	if !d.IsEncrypted(object) {
@@ -162,7 +163,19 @@ func (d DecrypterProto) Decrypt(object interface{}, decryptFunc DecryptFunc) (in
 	}
 
 	for _, path := range d.mapping[reflect.TypeOf(object)] {
-		if err := d.decryptStruct(object, path, decryptFunc); err != nil {
+		err := d.processStruct(object, path, func(value string) (string, error) {
+			decoded, err := base64.URLEncoding.DecodeString(value)
+			if err != nil {
+				return "", err
+			}
+
+			decrypted, err := decryptFunc(decoded)
+			if err != nil {
+				return "", err
+			}
+			return string(decrypted), nil
+		})
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -170,8 +183,9 @@ func (d DecrypterProto) Decrypt(object interface{}, decryptFunc DecryptFunc) (in
 	return object, nil
 }
 
-// decryptStruct recursively tries to decrypt fields in object on provided path using provided decryptFunc
-func (d DecrypterProto) decryptStruct(object interface{}, path []string, decryptFunc DecryptFunc) error {
+// processStruct recursively navigates to string fields in object on provided path and replaces
+// their values using provided processFunc (decrypt or encrypt)
+func (d DecrypterProto) processStruct(object interface{}, path []string, processFunc func(string) (string, error)) error {
 	v, ok := object.(reflect.Value)
 	if !ok {
 		v = reflect.ValueOf(object)
@@ -196,7 +210,7 @@ func (d DecrypterProto) decryptStruct(object interface{}, path []string, decrypt
 					index++
 				}
 
-				if err := d.decryptStruct(val, path[index:], decryptFunc); err != nil {
+				if err := d.processStruct(val, path[index:], processFunc); err != nil {
 					return err
 				}
 			}
@@ -210,17 +224,12 @@ func (d DecrypterProto) decryptStruct(object interface{}, path []string, decrypt
 				continue
 			}
 
-			decoded, err := base64.URLEncoding.DecodeString(val)
-			if err != nil {
-				return err
-			}
-
-			decrypted, err := decryptFunc(decoded)
+			processed, err := processFunc(val)
 			if err != nil {
 				return err
 			}
 
-			v.SetString(string(decrypted))
+			v.SetString(processed)
 			return nil
 		}
 
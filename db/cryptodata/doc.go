@@ -14,4 +14,20 @@
 
 // Package cryptodata provides support for wrapping key-value store with
 // crypto layer that will automatically decrypt all data passing through.
+//
+// Values are encrypted either directly by RSA-OAEP (limited by the key size)
+// or using envelope encryption, where the data are encrypted by random
+// AES-256-GCM key and only this key is encrypted by RSA-OAEP.
+//
+// If public keys are configured, wrapped brokers encrypt values on write as well,
+// using the same JSON prefix or protobuf path mappings that are used for decrypting.
+//
+// Envelopes identify the key that encrypted them by key ID (fingerprint of the public key),
+// so the matching private key is found in the Keyring directly. Keys can be RSA, X25519
+// or P-256 (ECDH with ephemeral key). The keyring has one active key used for encrypting,
+// other keys are retired and Client.ReEncrypt rewrites values to the active key.
+//
+// Operations with keys can be delegated to external key management systems by KeyProvider
+// (e.g. HashiCorp Vault transit secrets engine by VaultTransitProvider), so the private
+// keys do not have to be stored with the agent.
 package cryptodata
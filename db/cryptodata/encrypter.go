@@ -0,0 +1,175 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// EncryptFunc is function that encrypts input data
+type EncryptFunc func(inData []byte) (data []byte, err error)
+
+// ArbitraryEncrypter represents encrypter that looks for values to be encrypted inside arbitrary data and returns
+// the data with the values encrypted. It is counterpart of ArbitraryDecrypter used when writing data.
+type ArbitraryEncrypter interface {
+	// Encrypt processes input data and encrypts specific fields using encryptFunc
+	Encrypt(inData interface{}, encryptFunc EncryptFunc) (data interface{}, err error)
+}
+
+// ArbitraryReEncrypter represents encrypter that is able to replace encrypted values inside arbitrary data
+// by values re-encrypted (e.g. by another key).
+type ArbitraryReEncrypter interface {
+	// ReEncrypt processes input data and replaces encrypted values by the result of reEncryptFunc
+	ReEncrypt(inData interface{}, reEncryptFunc EncryptFunc) (data interface{}, err error)
+}
+
+// Encrypt looks up all values that begin with `Prefix` in JSON data marked as encrypted, then trims the prefix,
+// encrypts the value using provided encrypt function and stores it base64 encoded behind the prefix.
+// Values that are already envelope encrypted are left untouched.
+// This function can accept only []byte and return []byte
+func (d DecrypterJSON) Encrypt(object interface{}, encryptFunc EncryptFunc) (interface{}, error) {
+	if !d.IsEncrypted(object) {
+		return object, nil
+	}
+
+	return d.processJSON(object.([]byte), func(value string) (string, error) {
+		return encryptValue(value, encryptFunc)
+	})
+}
+
+// ReEncrypt looks up all values that begin with `Prefix` in JSON data marked as encrypted and replaces
+// them by values re-encrypted using provided function.
+// This function can accept only []byte and return []byte
+func (d DecrypterJSON) ReEncrypt(object interface{}, reEncryptFunc EncryptFunc) (interface{}, error) {
+	if !d.IsEncrypted(object) {
+		return object, nil
+	}
+
+	return d.processJSON(object.([]byte), func(value string) (string, error) {
+		return reEncryptValue(value, reEncryptFunc)
+	})
+}
+
+// processJSON replaces all string values with Prefix in JSON data using processFunc
+func (d DecrypterJSON) processJSON(inData []byte, processFunc func(string) (string, error)) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(inData))
+	decoder.UseNumber()
+	var jsonData map[string]interface{}
+	if err := decoder.Decode(&jsonData); err != nil {
+		return nil, err
+	}
+
+	if err := d.processJSONMap(jsonData, processFunc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonData)
+}
+
+// processJSONMap recursively navigates JSON structure and replaces all string values with Prefix
+func (d DecrypterJSON) processJSONMap(data map[string]interface{}, processFunc func(string) (string, error)) error {
+	for k, v := range data {
+		switch t := v.(type) {
+		case string:
+			if s := strings.TrimPrefix(t, d.prefix); s != t {
+				processed, err := processFunc(s)
+				if err != nil {
+					return err
+				}
+				data[k] = d.prefix + processed
+			}
+		case map[string]interface{}:
+			if err := d.processJSONMap(t, processFunc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Encrypt encrypts values on the paths registered for the type of the protobuf data. The data are cloned
+// before encrypting, so the input message is not modified.
+// Values that are already envelope encrypted are left untouched.
+// This function can accept only proto.Message and return proto.Message
+func (d DecrypterProto) Encrypt(object interface{}, encryptFunc EncryptFunc) (interface{}, error) {
+	if !d.IsEncrypted(object) {
+		return object, nil
+	}
+
+	clone := proto.Clone(object.(proto.Message))
+	for _, path := range d.mapping[reflect.TypeOf(object)] {
+		err := d.processStruct(clone, path, func(value string) (string, error) {
+			return encryptValue(value, encryptFunc)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return clone, nil
+}
+
+// ReEncrypt replaces values on the paths registered for the type of the protobuf data by values re-encrypted
+// using provided function. The data are cloned, so the input message is not modified.
+// This function can accept only proto.Message and return proto.Message
+func (d DecrypterProto) ReEncrypt(object interface{}, reEncryptFunc EncryptFunc) (interface{}, error) {
+	if !d.IsEncrypted(object) {
+		return object, nil
+	}
+
+	clone := proto.Clone(object.(proto.Message))
+	for _, path := range d.mapping[reflect.TypeOf(object)] {
+		err := d.processStruct(clone, path, func(value string) (string, error) {
+			return reEncryptValue(value, reEncryptFunc)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return clone, nil
+}
+
+// encryptValue encrypts value and encodes it with base64, envelope encrypted values are returned as they are
+func encryptValue(value string, encryptFunc EncryptFunc) (string, error) {
+	if decoded, err := base64.URLEncoding.DecodeString(value); err == nil && IsEnvelope(decoded) {
+		return value, nil
+	}
+	encrypted, err := encryptFunc([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(encrypted), nil
+}
+
+// reEncryptValue decodes encrypted value, re-encrypts it and encodes it with base64 again
+func reEncryptValue(value string, reEncryptFunc EncryptFunc) (string, error) {
+	decoded, err := base64.URLEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	reEncrypted, err := reEncryptFunc(decoded)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(reEncrypted), nil
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+)
+
+// Envelope format (all integers are big endian):
+//
+//	version 1: magic "CDE" | 1 | key length (2 bytes) | RSA-OAEP encrypted data key |
+//	           GCM nonce (12 bytes) | AES-256-GCM ciphertext with tag
+//	version 2: magic "CDE" | 2 | key type (1 byte) | key ID (8 bytes) | key length (2 bytes) |
+//	           encrypted data key | GCM nonce (12 bytes) | AES-256-GCM ciphertext with tag
+//
+// The header (version 1: magic + version, version 2: everything before the nonce) is authenticated
+// as additional data of GCM. Version 2 identifies the key that encrypted the data key (see KeyID),
+// so the matching private key is looked up directly.
+// In JSON and proto values the envelope is base64 (URL) encoded, same as
+// the RSA-only values, so the decrypters handle both formats transparently.
+const (
+	// EnvelopeVersion1 is version of the envelope format using RSA-OAEP
+	// encrypted AES-256-GCM data key.
+	EnvelopeVersion1 byte = 1
+	// EnvelopeVersion2 is version of the envelope format with key type and key ID
+	// of the key that encrypted AES-256-GCM data key.
+	EnvelopeVersion2 byte = 2
+
+	dataKeyLen = 32
+	// minimal length of GCM nonce and tag following the header
+	minPayloadLen = 12 + 16
+)
+
+var envelopeMagic = []byte("CDE")
+
+// ErrInvalidEnvelope is returned when data have envelope header but cannot be parsed.
+var ErrInvalidEnvelope = errors.New("invalid envelope")
+
+// envelope is parsed envelope
+type envelope struct {
+	version    byte
+	keyType    KeyType
+	keyID      KeyID
+	wrappedKey []byte
+	header     []byte
+	payload    []byte
+}
+
+// IsEnvelope checks if provided data start with the envelope header.
+func IsEnvelope(data []byte) bool {
+	return len(data) > len(envelopeMagic) && bytes.HasPrefix(data, envelopeMagic)
+}
+
+// EnvelopeKeyID returns ID of the key that encrypted the envelope. It returns false
+// for data that are not envelope of version 2.
+func EnvelopeKeyID(data []byte) (KeyID, bool) {
+	env, err := parseEnvelope(data)
+	if err != nil || env.version != EnvelopeVersion2 {
+		return KeyID{}, false
+	}
+	return env.keyID, true
+}
+
+// EncryptEnvelope encrypts data with random AES-256-GCM data key and
+// encrypts the data key with RSA-OAEP using provided public key.
+func EncryptEnvelope(random io.Reader, hash hash.Hash, pub *rsa.PublicKey, inData []byte) ([]byte, error) {
+	return EncryptEnvelopeWithKey(random, hash, NewRSAPublicKey(pub), inData)
+}
+
+// EncryptEnvelopeWithKey encrypts data with random AES-256-GCM data key and
+// encrypts the data key using provided public key of any supported type.
+func EncryptEnvelopeWithKey(random io.Reader, hash hash.Hash, pub PublicKey, inData []byte) ([]byte, error) {
+	return sealEnvelope(random, inData, func(dataKey []byte) (KeyType, KeyID, []byte, error) {
+		wrappedKey, err := pub.wrapKey(random, hash, dataKey)
+		return pub.Type(), pub.ID(), wrappedKey, err
+	})
+}
+
+// EncryptEnvelopeWithProvider encrypts data with random AES-256-GCM data key and
+// encrypts the data key by the active key of the provider.
+func EncryptEnvelopeWithProvider(random io.Reader, provider KeyProvider, inData []byte) ([]byte, error) {
+	return sealEnvelope(random, inData, provider.WrapKey)
+}
+
+// sealEnvelope creates envelope of version 2 with the data key encrypted by wrapKey
+func sealEnvelope(random io.Reader, inData []byte,
+	wrapKey func(dataKey []byte) (KeyType, KeyID, []byte, error)) ([]byte, error) {
+	dataKey := make([]byte, dataKeyLen)
+	if _, err := io.ReadFull(random, dataKey); err != nil {
+		return nil, err
+	}
+	keyType, keyID, wrappedKey, err := wrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrappedKey) > math.MaxUint16 {
+		return nil, errors.New("encrypted data key is too long")
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(envelopeMagic)+2+KeyIDLen+2+len(wrappedKey))
+	header = append(header, envelopeMagic...)
+	header = append(header, EnvelopeVersion2, byte(keyType))
+	header = append(header, keyID[:]...)
+	keyLen := make([]byte, 2)
+	binary.BigEndian.PutUint16(keyLen, uint16(len(wrappedKey)))
+	header = append(header, keyLen...)
+	header = append(header, wrappedKey...)
+
+	out := make([]byte, 0, len(header)+len(nonce)+len(inData)+gcm.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, inData, header), nil
+}
+
+// DecryptEnvelope decrypts data encrypted by EncryptEnvelope using provided private key.
+func DecryptEnvelope(random io.Reader, hash hash.Hash, key *rsa.PrivateKey, inData []byte) ([]byte, error) {
+	return DecryptEnvelopeWithKey(random, hash, NewRSAPrivateKey(key), inData)
+}
+
+// DecryptEnvelopeWithKey decrypts data encrypted by EncryptEnvelopeWithKey (or by EncryptEnvelope
+// in version 1 of the format) using provided private key.
+func DecryptEnvelopeWithKey(random io.Reader, hash hash.Hash, key PrivateKey, inData []byte) ([]byte, error) {
+	env, err := parseEnvelope(inData)
+	if err != nil {
+		return nil, err
+	}
+	public := key.Public()
+	if env.keyType != public.Type() {
+		return nil, fmt.Errorf("envelope encrypted by %v key cannot be decrypted by %v key", env.keyType, public.Type())
+	}
+	if env.version == EnvelopeVersion2 && env.keyID != public.ID() {
+		return nil, fmt.Errorf("envelope encrypted by key %v cannot be decrypted by key %v", env.keyID, public.ID())
+	}
+
+	dataKey, err := key.unwrapKey(random, hash, env.wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return env.open(dataKey)
+}
+
+// DecryptEnvelopeWithProvider decrypts data encrypted by EncryptEnvelopeWithProvider (or any other
+// envelope of version 2) using key of the provider. ErrKeyNotFound is returned if the provider does
+// not have the key identified by the envelope.
+func DecryptEnvelopeWithProvider(provider KeyProvider, inData []byte) ([]byte, error) {
+	env, err := parseEnvelope(inData)
+	if err != nil {
+		return nil, err
+	}
+	if env.version != EnvelopeVersion2 {
+		return nil, ErrKeyNotFound
+	}
+
+	dataKey, err := provider.UnwrapKey(env.keyType, env.keyID, env.wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return env.open(dataKey)
+}
+
+// open decrypts payload of the envelope using decrypted data key
+func (env *envelope) open(dataKey []byte) ([]byte, error) {
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.payload) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, ciphertext := env.payload[:gcm.NonceSize()], env.payload[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, env.header)
+}
+
+// parseEnvelope splits envelope of any supported version into its parts
+func parseEnvelope(data []byte) (*envelope, error) {
+	if !IsEnvelope(data) {
+		return nil, ErrInvalidEnvelope
+	}
+	env := &envelope{version: data[len(envelopeMagic)]}
+	rest := data[len(envelopeMagic)+1:]
+
+	switch env.version {
+	case EnvelopeVersion1:
+		env.keyType = KeyTypeRSA
+		env.header = data[:len(envelopeMagic)+1]
+	case EnvelopeVersion2:
+		if len(rest) < 1+KeyIDLen {
+			return nil, ErrInvalidEnvelope
+		}
+		env.keyType = KeyType(rest[0])
+		copy(env.keyID[:], rest[1:1+KeyIDLen])
+		rest = rest[1+KeyIDLen:]
+	default:
+		return nil, ErrInvalidEnvelope
+	}
+
+	if len(rest) < 2 {
+		return nil, ErrInvalidEnvelope
+	}
+	keyLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < keyLen {
+		return nil, ErrInvalidEnvelope
+	}
+	env.wrappedKey, env.payload = rest[:keyLen], rest[keyLen:]
+	if len(env.payload) < minPayloadLen {
+		return nil, ErrInvalidEnvelope
+	}
+	if env.version == EnvelopeVersion2 {
+		env.header = data[:len(data)-len(env.payload)]
+	}
+	return env, nil
+}
+
+func newGCM(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/cryptodata"
+)
+
+var rsaTestKey *rsa.PrivateKey
+
+// rsaKey returns RSA key shared by the tests, since generating is slow
+func rsaKey() *rsa.PrivateKey {
+	if rsaTestKey == nil {
+		var err error
+		rsaTestKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+	}
+	return rsaTestKey
+}
+
+// encryptEnvelopeV1 creates envelope of version 1 as written by previous versions
+func encryptEnvelopeV1(pub *rsa.PublicKey, data []byte) []byte {
+	dataKey := make([]byte, 32)
+	_, err := rand.Read(dataKey)
+	Expect(err).ToNot(HaveOccurred())
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, dataKey, nil)
+	Expect(err).ToNot(HaveOccurred())
+	block, err := aes.NewCipher(dataKey)
+	Expect(err).ToNot(HaveOccurred())
+	gcm, err := cipher.NewGCM(block)
+	Expect(err).ToNot(HaveOccurred())
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	Expect(err).ToNot(HaveOccurred())
+
+	header := []byte{'C', 'D', 'E', cryptodata.EnvelopeVersion1}
+	out := append([]byte{}, header...)
+	out = append(out, 0, 0)
+	binary.BigEndian.PutUint16(out[len(header):], uint16(len(wrappedKey)))
+	out = append(out, wrappedKey...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, header)
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	RegisterTestingT(t)
+	key := rsaKey()
+
+	for _, size := range []int{0, 1, 190, 600, 4 << 20} {
+		data := make([]byte, size)
+		_, err := rand.Read(data)
+		Expect(err).ToNot(HaveOccurred())
+
+		// version 2
+		encrypted, err := cryptodata.EncryptEnvelope(rand.Reader, sha256.New(), &key.PublicKey, data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cryptodata.IsEnvelope(encrypted)).To(BeTrue())
+		Expect(encrypted[3]).To(Equal(cryptodata.EnvelopeVersion2))
+		keyID, ok := cryptodata.EnvelopeKeyID(encrypted)
+		Expect(ok).To(BeTrue())
+		Expect(keyID).To(Equal(cryptodata.NewRSAPublicKey(&key.PublicKey).ID()))
+		decrypted, err := cryptodata.DecryptEnvelope(rand.Reader, sha256.New(), key, encrypted)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bytes.Equal(decrypted, data)).To(BeTrue())
+
+		// version 1
+		encrypted = encryptEnvelopeV1(&key.PublicKey, data)
+		Expect(cryptodata.IsEnvelope(encrypted)).To(BeTrue())
+		_, ok = cryptodata.EnvelopeKeyID(encrypted)
+		Expect(ok).To(BeFalse())
+		decrypted, err = cryptodata.DecryptEnvelope(rand.Reader, sha256.New(), key, encrypted)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bytes.Equal(decrypted, data)).To(BeTrue())
+	}
+}
+
+func TestEnvelopeTampered(t *testing.T) {
+	RegisterTestingT(t)
+	key := rsaKey()
+	data := []byte("secret data")
+
+	encrypted, err := cryptodata.EncryptEnvelope(rand.Reader, sha256.New(), &key.PublicKey, data)
+	Expect(err).ToNot(HaveOccurred())
+	wrappedKeyLen := int(binary.BigEndian.Uint16(encrypted[13:]))
+	nonceStart := 15 + wrappedKeyLen
+	positions := map[string]int{
+		"key type":    4,
+		"key ID":      5,
+		"key length":  13,
+		"wrapped key": 15,
+		"nonce":       nonceStart,
+		"ciphertext":  nonceStart + 12,
+		"tag":         len(encrypted) - 1,
+	}
+	for name, pos := range positions {
+		tampered := append([]byte{}, encrypted...)
+		tampered[pos] ^= 0x01
+		_, err := cryptodata.DecryptEnvelope(rand.Reader, sha256.New(), key, tampered)
+		Expect(err).To(HaveOccurred(), "tampered %s", name)
+	}
+
+	// authenticated header of version 1
+	encrypted = encryptEnvelopeV1(&key.PublicKey, data)
+	tampered := append([]byte{}, encrypted...)
+	tampered[3] = cryptodata.EnvelopeVersion2
+	_, err = cryptodata.DecryptEnvelope(rand.Reader, sha256.New(), key, tampered)
+	Expect(err).To(HaveOccurred())
+	tampered = append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 0x01
+	_, err = cryptodata.DecryptEnvelope(rand.Reader, sha256.New(), key, tampered)
+	Expect(err).To(HaveOccurred())
+}
+
+func TestEnvelopeTruncated(t *testing.T) {
+	RegisterTestingT(t)
+	key := rsaKey()
+
+	encrypted, err := cryptodata.EncryptEnvelope(rand.Reader, sha256.New(), &key.PublicKey, []byte("secret data"))
+	Expect(err).ToNot(HaveOccurred())
+	wrappedKeyLen := int(binary.BigEndian.Uint16(encrypted[13:]))
+	headerLen := 15 + wrappedKeyLen
+
+	// inside of magic, version, key ID, key length, wrapped key, nonce and tag
+	for _, length := range []int{0, 2, 4, 8, 14, 15 + wrappedKeyLen/2, headerLen, headerLen + 6, headerLen + 12 + 15} {
+		_, err := cryptodata.DecryptEnvelope(rand.Reader, sha256.New(), key, encrypted[:length])
+		Expect(err).To(Equal(cryptodata.ErrInvalidEnvelope), "truncated to %d bytes", length)
+	}
+	// inside of ciphertext
+	_, err = cryptodata.DecryptEnvelope(rand.Reader, sha256.New(), key, encrypted[:len(encrypted)-1])
+	Expect(err).To(HaveOccurred())
+
+	// unknown version
+	unknown := append([]byte{}, encrypted...)
+	unknown[3] = 9
+	_, err = cryptodata.DecryptEnvelope(rand.Reader, sha256.New(), key, unknown)
+	Expect(err).To(Equal(cryptodata.ErrInvalidEnvelope))
+}
+
+func TestDecryptDataLegacy(t *testing.T) {
+	RegisterTestingT(t)
+	key := rsaKey()
+	client := cryptodata.NewClient(cryptodata.ClientConfig{PrivateKeys: []*rsa.PrivateKey{key}})
+
+	// RSA-only value
+	encrypted, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, []byte("rsa-only"), nil)
+	Expect(err).ToNot(HaveOccurred())
+	decrypted, err := client.DecryptData(encrypted)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(decrypted)).To(Equal("rsa-only"))
+
+	// envelope of version 1
+	decrypted, err = client.DecryptData(encryptEnvelopeV1(&key.PublicKey, []byte("version 1")))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(decrypted)).To(Equal("version 1"))
+
+	// envelope of version 2
+	encrypted, err = client.EncryptDataEnvelope([]byte("version 2"), &key.PublicKey)
+	Expect(err).ToNot(HaveOccurred())
+	decrypted, err = client.DecryptData(encrypted)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(decrypted)).To(Equal("version 2"))
+
+	// other key
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).ToNot(HaveOccurred())
+	encrypted, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, &other.PublicKey, []byte("other"), nil)
+	Expect(err).ToNot(HaveOccurred())
+	_, err = client.DecryptData(encrypted)
+	Expect(err).To(HaveOccurred())
+}
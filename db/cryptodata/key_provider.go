@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+var (
+	// ErrKeyNotFound is returned by KeyProvider that does not have the requested key
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrNoActiveKey is returned by KeyProvider that has no key for encrypting
+	ErrNoActiveKey = errors.New("no active key")
+)
+
+// KeyProvider performs operations with keys used to encrypt and decrypt data keys of envelopes.
+// The keys can be held in memory (see NewKeyringProvider) or by an external key management
+// system, so the private keys never leave it (see VaultTransitProvider).
+type KeyProvider interface {
+	// ActiveKeyID returns ID of the key used for encrypting, false if the provider has no such key
+	ActiveKeyID() (KeyID, bool)
+	// WrapKey encrypts data key by the active key and returns type and ID of the key
+	// with the encrypted data key (ErrNoActiveKey if there is no active key)
+	WrapKey(dataKey []byte) (keyType KeyType, keyID KeyID, wrappedKey []byte, err error)
+	// UnwrapKey decrypts data key encrypted by the key with given type and ID
+	// (ErrKeyNotFound if the provider does not have the key)
+	UnwrapKey(keyType KeyType, keyID KeyID, wrappedKey []byte) (dataKey []byte, err error)
+}
+
+// keyringProvider is KeyProvider using keys of the Keyring
+type keyringProvider struct {
+	keyring *Keyring
+	random  io.Reader
+	newHash func() hash.Hash
+}
+
+// NewKeyringProvider creates KeyProvider using keys of the keyring, the <random> and hash
+// created by <newHash> are used by the key operations (see Client.Reader and Client.Hash)
+func NewKeyringProvider(keyring *Keyring, random io.Reader, newHash func() hash.Hash) KeyProvider {
+	return &keyringProvider{keyring: keyring, random: random, newHash: newHash}
+}
+
+func (p *keyringProvider) ActiveKeyID() (KeyID, bool) {
+	active := p.keyring.Active()
+	if active == nil {
+		return KeyID{}, false
+	}
+	return active.ID(), true
+}
+
+func (p *keyringProvider) WrapKey(dataKey []byte) (KeyType, KeyID, []byte, error) {
+	active := p.keyring.Active()
+	if active == nil {
+		return 0, KeyID{}, nil, ErrNoActiveKey
+	}
+	wrappedKey, err := active.wrapKey(p.random, p.newHash(), dataKey)
+	return active.Type(), active.ID(), wrappedKey, err
+}
+
+func (p *keyringProvider) UnwrapKey(keyType KeyType, keyID KeyID, wrappedKey []byte) ([]byte, error) {
+	key := p.keyring.PrivateKey(keyID)
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+	if key.Public().Type() != keyType {
+		return nil, fmt.Errorf("envelope encrypted by %v key cannot be decrypted by %v key", keyType, key.Public().Type())
+	}
+	return key.unwrapKey(p.random, p.newHash(), wrappedKey)
+}
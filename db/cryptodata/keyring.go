@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Keyring holds keys of the client indexed by key ID. Private keys are used for decrypting,
+// the active key is used for encrypting. Private keys other than the active key are retired,
+// they are kept only to decrypt values that were not re-encrypted yet (see ReEncrypt).
+// Keyring can hold also public keys without private part, which can be only used as active key.
+// Keyring is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	ids     []KeyID
+	private map[KeyID]PrivateKey
+	public  map[KeyID]PublicKey
+	active  PublicKey
+}
+
+// NewKeyring creates new keyring with provided private keys, the first key is
+// set as active (if any)
+func NewKeyring(keys ...PrivateKey) *Keyring {
+	k := &Keyring{
+		private: make(map[KeyID]PrivateKey),
+		public:  make(map[KeyID]PublicKey),
+	}
+	k.Add(keys...)
+	if len(keys) > 0 {
+		k.active = keys[0].Public()
+	}
+	return k
+}
+
+// Add adds private keys into the keyring as retired keys
+func (k *Keyring) Add(keys ...PrivateKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for _, key := range keys {
+		id := k.add(key.Public())
+		k.private[id] = key
+	}
+}
+
+// AddPublic adds public keys (without private part) into the keyring
+func (k *Keyring) AddPublic(keys ...PublicKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for _, key := range keys {
+		k.add(key)
+	}
+}
+
+func (k *Keyring) add(key PublicKey) KeyID {
+	id := key.ID()
+	if _, exists := k.public[id]; !exists {
+		k.ids = append(k.ids, id)
+	}
+	k.public[id] = key
+	return id
+}
+
+// SetActive sets key with given ID as active, the previously active key is retired
+func (k *Keyring) SetActive(id KeyID) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.public[id]
+	if !ok {
+		return fmt.Errorf("key %v not found in keyring", id)
+	}
+	k.active = key
+	return nil
+}
+
+// Rotate adds private key into the keyring and sets it as active,
+// the previously active key is retired
+func (k *Keyring) Rotate(key PrivateKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	id := k.add(key.Public())
+	k.private[id] = key
+	k.active = key.Public()
+}
+
+// Remove removes key from the keyring, values encrypted by this key cannot be decrypted anymore
+func (k *Keyring) Remove(id KeyID) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.public[id]; !ok {
+		return
+	}
+	delete(k.public, id)
+	delete(k.private, id)
+	for i := range k.ids {
+		if k.ids[i] == id {
+			k.ids = append(k.ids[:i], k.ids[i+1:]...)
+			break
+		}
+	}
+	if k.active != nil && k.active.ID() == id {
+		k.active = nil
+	}
+}
+
+// Active returns key used for encrypting or nil if there is no active key
+func (k *Keyring) Active() PublicKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.active
+}
+
+// IsRetired returns true if keyring contains key with given ID which is not active
+func (k *Keyring) IsRetired(id KeyID) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	_, ok := k.public[id]
+	return ok && (k.active == nil || k.active.ID() != id)
+}
+
+// PrivateKey returns private key with given ID or nil if there is no such key
+func (k *Keyring) PrivateKey(id KeyID) PrivateKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.private[id]
+}
+
+// PrivateKeys returns all private keys in the order they were added
+func (k *Keyring) PrivateKeys() []PrivateKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var keys []PrivateKey
+	for _, id := range k.ids {
+		if key, ok := k.private[id]; ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// IDs returns IDs of all keys in the order they were added
+func (k *Keyring) IDs() []KeyID {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return append([]KeyID{}, k.ids...)
+}
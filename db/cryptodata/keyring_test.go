@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/cryptodata"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	RegisterTestingT(t)
+	oldKey, err := cryptodata.GenerateKey(rand.Reader, cryptodata.KeyTypeX25519)
+	Expect(err).ToNot(HaveOccurred())
+	newKey, err := cryptodata.GenerateKey(rand.Reader, cryptodata.KeyTypeP256)
+	Expect(err).ToNot(HaveOccurred())
+
+	keyring := cryptodata.NewKeyring(oldKey)
+	client := cryptodata.NewClient(cryptodata.ClientConfig{Keyring: keyring})
+	activeID, ok := client.ActiveKeyID()
+	Expect(ok).To(BeTrue())
+	Expect(activeID).To(Equal(oldKey.Public().ID()))
+	encryptedOld, err := client.EncryptDataWithActiveKey([]byte("old"))
+	Expect(err).ToNot(HaveOccurred())
+
+	// new key is added as retired, then activated
+	keyring.Add(newKey)
+	Expect(keyring.IsRetired(newKey.Public().ID())).To(BeTrue())
+	Expect(keyring.SetActive(newKey.Public().ID())).To(Succeed())
+	Expect(keyring.IsRetired(oldKey.Public().ID())).To(BeTrue())
+	Expect(keyring.IsRetired(newKey.Public().ID())).To(BeFalse())
+	Expect(keyring.IDs()).To(Equal([]cryptodata.KeyID{oldKey.Public().ID(), newKey.Public().ID()}))
+
+	encryptedNew, err := client.EncryptDataWithActiveKey([]byte("new"))
+	Expect(err).ToNot(HaveOccurred())
+	keyID, _ := cryptodata.EnvelopeKeyID(encryptedNew)
+	Expect(keyID).To(Equal(newKey.Public().ID()))
+
+	// data encrypted by the retired key are still decrypted
+	decrypted, err := client.DecryptData(encryptedOld)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(decrypted)).To(Equal("old"))
+	decrypted, err = client.DecryptData(encryptedNew)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(decrypted)).To(Equal("new"))
+
+	// rotation to another key
+	rotated, err := cryptodata.GenerateKey(rand.Reader, cryptodata.KeyTypeX25519)
+	Expect(err).ToNot(HaveOccurred())
+	keyring.Rotate(rotated)
+	activeID, _ = client.ActiveKeyID()
+	Expect(activeID).To(Equal(rotated.Public().ID()))
+	Expect(keyring.SetActive(cryptodata.KeyID{})).ToNot(Succeed())
+
+	// data encrypted by removed key cannot be decrypted
+	keyring.Remove(oldKey.Public().ID())
+	Expect(keyring.PrivateKey(oldKey.Public().ID())).To(BeNil())
+	_, err = client.DecryptData(encryptedOld)
+	Expect(err).To(HaveOccurred())
+	_, err = client.DecryptData(encryptedNew)
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// encryptedKeyIDs returns IDs of the keys that encrypted values of the JSON document, RSA-only
+// values are reported with zero ID
+func encryptedKeyIDs(document []byte) map[string]cryptodata.KeyID {
+	var data map[string]interface{}
+	Expect(json.Unmarshal(document, &data)).To(Succeed())
+	ids := make(map[string]cryptodata.KeyID)
+	for field, value := range data {
+		s, ok := value.(string)
+		if !ok || !strings.HasPrefix(s, "$crypto$") {
+			continue
+		}
+		decoded, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(s, "$crypto$"))
+		Expect(err).ToNot(HaveOccurred())
+		ids[field], _ = cryptodata.EnvelopeKeyID(decoded)
+	}
+	return ids
+}
+
+func TestReEncrypt(t *testing.T) {
+	RegisterTestingT(t)
+	db := newBoltClient(t)
+	rsaKey := rsaKey()
+	newKey, err := cryptodata.GenerateKey(rand.Reader, cryptodata.KeyTypeX25519)
+	Expect(err).ToNot(HaveOccurred())
+	newID := newKey.Public().ID()
+
+	keyring := cryptodata.NewKeyring(cryptodata.NewRSAPrivateKey(rsaKey))
+	client := cryptodata.NewClient(cryptodata.ClientConfig{Keyring: keyring})
+	decrypter := cryptodata.NewDecrypterJSON()
+	broker := client.WrapBytes(db, decrypter).NewBroker(keyval.Root)
+
+	// value encrypted by the old key
+	Expect(broker.Put("/data/old", []byte(`{"encrypted":true,"a":"$crypto$old-a","b":"$crypto$old-b"}`))).To(Succeed())
+	// RSA-only value
+	rsaOnly, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &rsaKey.PublicKey, []byte("legacy"), nil)
+	Expect(err).ToNot(HaveOccurred())
+	legacy := `{"encrypted":true,"a":"$crypto$` + base64.URLEncoding.EncodeToString(rsaOnly) + `"}`
+	Expect(db.Put("/data/legacy", []byte(legacy))).To(Succeed())
+	// value without encrypted data
+	Expect(db.Put("/data/plain", []byte(`{"a":"plain"}`))).To(Succeed())
+	// value outside of the prefix
+	Expect(broker.Put("/other/old", []byte(`{"encrypted":true,"a":"$crypto$other"}`))).To(Succeed())
+
+	keyring.Rotate(newKey)
+	Expect(broker.Put("/data/new", []byte(`{"encrypted":true,"a":"$crypto$new"}`))).To(Succeed())
+	newValue := getRawValue(db, "/data/new")
+
+	count, err := client.ReEncrypt(db, "/data/", decrypter)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(count).To(Equal(2))
+
+	// re-encrypted values are under the active key
+	Expect(encryptedKeyIDs(getRawValue(db, "/data/old"))).To(Equal(map[string]cryptodata.KeyID{"a": newID, "b": newID}))
+	Expect(encryptedKeyIDs(getRawValue(db, "/data/legacy"))).To(Equal(map[string]cryptodata.KeyID{"a": newID}))
+	Expect(getRawValue(db, "/data/new")).To(Equal(newValue))
+	Expect(string(getRawValue(db, "/data/plain"))).To(Equal(`{"a":"plain"}`))
+	Expect(encryptedKeyIDs(getRawValue(db, "/other/old"))["a"]).ToNot(Equal(newID))
+
+	// values are decrypted after the old key is removed
+	keyring.Remove(cryptodata.NewRSAPublicKey(&rsaKey.PublicKey).ID())
+	for key, expected := range map[string]string{
+		"/data/old":    `{"a":"old-a","b":"old-b","encrypted":true}`,
+		"/data/legacy": `{"a":"legacy","encrypted":true}`,
+		"/data/new":    `{"a":"new","encrypted":true}`,
+	} {
+		value, _, _, err := broker.GetValue(key)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(value)).To(MatchJSON(expected), key)
+	}
+
+	// nothing is left to re-encrypt
+	count, err = client.ReEncrypt(db, "/data/", decrypter)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(count).To(Equal(0))
+}
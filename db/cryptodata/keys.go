@@ -0,0 +1,376 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata
+
+import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// KeyType identifies algorithm of the key used to encrypt data key of the envelope
+type KeyType byte
+
+const (
+	// KeyTypeRSA is RSA key, data key is encrypted by RSA-OAEP
+	KeyTypeRSA KeyType = iota + 1
+	// KeyTypeX25519 is X25519 key, data key is encrypted by AES-GCM key derived from ECDH with ephemeral key
+	KeyTypeX25519
+	// KeyTypeP256 is NIST P-256 key, data key is encrypted by AES-GCM key derived from ECDH with ephemeral key
+	KeyTypeP256
+	// KeyTypeKMS is key of external key management system, data key is encrypted by the system
+	// (see KeyProvider)
+	KeyTypeKMS
+)
+
+// String returns name of the key type
+func (t KeyType) String() string {
+	switch t {
+	case KeyTypeRSA:
+		return "rsa"
+	case KeyTypeX25519:
+		return "x25519"
+	case KeyTypeP256:
+		return "p256"
+	case KeyTypeKMS:
+		return "kms"
+	}
+	return fmt.Sprintf("unknown(%d)", byte(t))
+}
+
+// ParseKeyType returns type of local key with given name (see KeyType.String)
+func ParseKeyType(name string) (KeyType, error) {
+	for _, t := range []KeyType{KeyTypeRSA, KeyTypeX25519, KeyTypeP256} {
+		if strings.EqualFold(name, t.String()) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key type %q", name)
+}
+
+// KeyIDLen is length of the key identifier
+const KeyIDLen = 8
+
+// KeyID identifies key by fingerprint of its public part, which is prefix of SHA-256
+// of the key type and the public key bytes
+type KeyID [KeyIDLen]byte
+
+// String returns hex encoded key identifier
+func (id KeyID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// ParseKeyID parses hex encoded key identifier
+func ParseKeyID(s string) (id KeyID, err error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return id, err
+	}
+	if len(b) != KeyIDLen {
+		return id, fmt.Errorf("invalid length of key ID %q", s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// NewKeyID creates key ID of the key with given type from bytes identifying the key
+// (public key for local keys, e.g. name of the key for external key management system)
+func NewKeyID(keyType KeyType, public []byte) (id KeyID) {
+	h := sha256.New()
+	h.Write([]byte{byte(keyType)})
+	h.Write(public)
+	copy(id[:], h.Sum(nil))
+	return id
+}
+
+// PublicKey is key used for encrypting data keys of envelopes
+type PublicKey interface {
+	// ID returns fingerprint of the key
+	ID() KeyID
+	// Type returns type of the key
+	Type() KeyType
+	// wrapKey encrypts data key
+	wrapKey(random io.Reader, hash hash.Hash, dataKey []byte) ([]byte, error)
+}
+
+// PrivateKey is key used for decrypting data keys of envelopes
+type PrivateKey interface {
+	// Public returns public part of the key
+	Public() PublicKey
+	// unwrapKey decrypts data key
+	unwrapKey(random io.Reader, hash hash.Hash, wrappedKey []byte) ([]byte, error)
+}
+
+// GenerateKey generates new private key of given type (2048-bit key for RSA)
+func GenerateKey(random io.Reader, keyType KeyType) (PrivateKey, error) {
+	switch keyType {
+	case KeyTypeRSA:
+		key, err := rsa.GenerateKey(random, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSAPrivateKey(key), nil
+	case KeyTypeX25519:
+		key := make([]byte, curve25519.ScalarSize)
+		if _, err := io.ReadFull(random, key); err != nil {
+			return nil, err
+		}
+		return NewX25519PrivateKey(key)
+	case KeyTypeP256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), random)
+		if err != nil {
+			return nil, err
+		}
+		return NewP256PrivateKey(key)
+	}
+	return nil, fmt.Errorf("unknown key type %v", keyType)
+}
+
+// rsaPublicKey is RSA implementation of PublicKey
+type rsaPublicKey struct {
+	key *rsa.PublicKey
+	id  KeyID
+}
+
+// NewRSAPublicKey creates PublicKey from RSA public key
+func NewRSAPublicKey(key *rsa.PublicKey) PublicKey {
+	return &rsaPublicKey{key: key, id: NewKeyID(KeyTypeRSA, x509.MarshalPKCS1PublicKey(key))}
+}
+
+func (k *rsaPublicKey) ID() KeyID {
+	return k.id
+}
+
+func (k *rsaPublicKey) Type() KeyType {
+	return KeyTypeRSA
+}
+
+func (k *rsaPublicKey) wrapKey(random io.Reader, hash hash.Hash, dataKey []byte) ([]byte, error) {
+	return rsa.EncryptOAEP(hash, random, k.key, dataKey, nil)
+}
+
+// rsaPrivateKey is RSA implementation of PrivateKey
+type rsaPrivateKey struct {
+	key    *rsa.PrivateKey
+	public PublicKey
+}
+
+// NewRSAPrivateKey creates PrivateKey from RSA private key
+func NewRSAPrivateKey(key *rsa.PrivateKey) PrivateKey {
+	return &rsaPrivateKey{key: key, public: NewRSAPublicKey(&key.PublicKey)}
+}
+
+func (k *rsaPrivateKey) Public() PublicKey {
+	return k.public
+}
+
+func (k *rsaPrivateKey) unwrapKey(random io.Reader, hash hash.Hash, wrappedKey []byte) ([]byte, error) {
+	return rsa.DecryptOAEP(hash, random, k.key, wrappedKey, nil)
+}
+
+// x25519PublicKey is X25519 implementation of PublicKey
+type x25519PublicKey struct {
+	key []byte
+	id  KeyID
+}
+
+// NewX25519PublicKey creates PublicKey from 32 bytes of X25519 public key
+func NewX25519PublicKey(key []byte) (PublicKey, error) {
+	if len(key) != curve25519.PointSize {
+		return nil, errors.New("invalid length of X25519 public key")
+	}
+	key = append([]byte{}, key...)
+	return &x25519PublicKey{key: key, id: NewKeyID(KeyTypeX25519, key)}, nil
+}
+
+func (k *x25519PublicKey) ID() KeyID {
+	return k.id
+}
+
+func (k *x25519PublicKey) Type() KeyType {
+	return KeyTypeX25519
+}
+
+func (k *x25519PublicKey) wrapKey(random io.Reader, _ hash.Hash, dataKey []byte) ([]byte, error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := io.ReadFull(random, ephemeral); err != nil {
+		return nil, err
+	}
+	ephemeralPublic, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(ephemeral, k.key)
+	if err != nil {
+		return nil, err
+	}
+	return sealECDH(shared, ephemeralPublic, k.key, dataKey)
+}
+
+// x25519PrivateKey is X25519 implementation of PrivateKey
+type x25519PrivateKey struct {
+	key    []byte
+	public *x25519PublicKey
+}
+
+// NewX25519PrivateKey creates PrivateKey from 32 bytes of X25519 private key
+func NewX25519PrivateKey(key []byte) (PrivateKey, error) {
+	if len(key) != curve25519.ScalarSize {
+		return nil, errors.New("invalid length of X25519 private key")
+	}
+	publicKey, err := curve25519.X25519(key, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	public, err := NewX25519PublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &x25519PrivateKey{key: append([]byte{}, key...), public: public.(*x25519PublicKey)}, nil
+}
+
+func (k *x25519PrivateKey) Public() PublicKey {
+	return k.public
+}
+
+func (k *x25519PrivateKey) unwrapKey(_ io.Reader, _ hash.Hash, wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey) < curve25519.PointSize {
+		return nil, ErrInvalidEnvelope
+	}
+	ephemeralPublic := wrappedKey[:curve25519.PointSize]
+	shared, err := curve25519.X25519(k.key, ephemeralPublic)
+	if err != nil {
+		return nil, err
+	}
+	return openECDH(shared, ephemeralPublic, k.public.key, wrappedKey[curve25519.PointSize:])
+}
+
+// p256PublicKey is NIST P-256 implementation of PublicKey
+type p256PublicKey struct {
+	key *ecdsa.PublicKey
+	id  KeyID
+}
+
+// NewP256PublicKey creates PublicKey from ECDSA public key on P-256 curve
+func NewP256PublicKey(key *ecdsa.PublicKey) (PublicKey, error) {
+	if key.Curve != elliptic.P256() {
+		return nil, errors.New("EC public key is not on P-256 curve")
+	}
+	return &p256PublicKey{key: key, id: NewKeyID(KeyTypeP256, elliptic.Marshal(key.Curve, key.X, key.Y))}, nil
+}
+
+func (k *p256PublicKey) ID() KeyID {
+	return k.id
+}
+
+func (k *p256PublicKey) Type() KeyType {
+	return KeyTypeP256
+}
+
+func (k *p256PublicKey) wrapKey(random io.Reader, _ hash.Hash, dataKey []byte) ([]byte, error) {
+	ephemeral, err := ecdsa.GenerateKey(k.key.Curve, random)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPublic := elliptic.Marshal(k.key.Curve, ephemeral.X, ephemeral.Y)
+	shared := p256SharedSecret(ephemeral, k.key)
+	return sealECDH(shared, ephemeralPublic, elliptic.Marshal(k.key.Curve, k.key.X, k.key.Y), dataKey)
+}
+
+// p256PrivateKey is NIST P-256 implementation of PrivateKey
+type p256PrivateKey struct {
+	key    *ecdsa.PrivateKey
+	public *p256PublicKey
+}
+
+// NewP256PrivateKey creates PrivateKey from ECDSA private key on P-256 curve
+func NewP256PrivateKey(key *ecdsa.PrivateKey) (PrivateKey, error) {
+	public, err := NewP256PublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &p256PrivateKey{key: key, public: public.(*p256PublicKey)}, nil
+}
+
+func (k *p256PrivateKey) Public() PublicKey {
+	return k.public
+}
+
+func (k *p256PrivateKey) unwrapKey(_ io.Reader, _ hash.Hash, wrappedKey []byte) ([]byte, error) {
+	curve := k.key.Curve
+	pointLen := 1 + 2*((curve.Params().BitSize+7)/8)
+	if len(wrappedKey) < pointLen {
+		return nil, ErrInvalidEnvelope
+	}
+	ephemeralPublic := wrappedKey[:pointLen]
+	x, y := elliptic.Unmarshal(curve, ephemeralPublic)
+	if x == nil {
+		return nil, ErrInvalidEnvelope
+	}
+	shared := p256SharedSecret(k.key, &ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+	publicKey := elliptic.Marshal(curve, k.key.X, k.key.Y)
+	return openECDH(shared, ephemeralPublic, publicKey, wrappedKey[pointLen:])
+}
+
+// p256SharedSecret computes ECDH shared secret (x coordinate of the shared point)
+func p256SharedSecret(private *ecdsa.PrivateKey, public *ecdsa.PublicKey) []byte {
+	x, _ := public.Curve.ScalarMult(public.X, public.Y, private.D.Bytes())
+	return x.FillBytes(make([]byte, (public.Curve.Params().BitSize+7)/8))
+}
+
+// sealECDH encrypts data key by AES-GCM key derived from ECDH shared secret, the ephemeral
+// public key is prepended to the result
+func sealECDH(shared, ephemeralPublic, recipientPublic, dataKey []byte) ([]byte, error) {
+	gcm, err := newECDHCipher(shared, ephemeralPublic, recipientPublic)
+	if err != nil {
+		return nil, err
+	}
+	// the derived key is unique for every ephemeral key, so zero nonce is safe
+	nonce := make([]byte, gcm.NonceSize())
+	return gcm.Seal(append([]byte{}, ephemeralPublic...), nonce, dataKey, nil), nil
+}
+
+// openECDH decrypts data key encrypted by sealECDH (without ephemeral public key prefix)
+func openECDH(shared, ephemeralPublic, recipientPublic, sealed []byte) ([]byte, error) {
+	gcm, err := newECDHCipher(shared, ephemeralPublic, recipientPublic)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+// newECDHCipher derives AES-256-GCM key from ECDH shared secret using HKDF-SHA256
+// bound to both public keys
+func newECDHCipher(shared, ephemeralPublic, recipientPublic []byte) (cipher.AEAD, error) {
+	info := append(append([]byte("cryptodata envelope"), ephemeralPublic...), recipientPublic...)
+	key := make([]byte, dataKeyLen)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, info), key); err != nil {
+		return nil, err
+	}
+	return newGCM(key)
+}
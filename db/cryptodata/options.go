@@ -41,3 +41,11 @@ func UseDeps(cb func(*Deps)) Option {
 		cb(&p.Deps)
 	}
 }
+
+// UseKeyProviders returns Option that adds key providers (e.g. external key management
+// systems) used besides the keys configured by files.
+func UseKeyProviders(providers ...KeyProvider) Option {
+	return func(p *Plugin) {
+		p.keyProviders = append(p.keyProviders, providers...)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// oidX25519 is object identifier of X25519 keys (RFC 8410)
+var oidX25519 = asn1.ObjectIdentifier{1, 3, 101, 110}
+
+// pkcs8 is ASN.1 structure of PKCS#8 private key (optional attributes are omitted)
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// publicKeyInfo is ASN.1 structure of PKIX public key
+type publicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// ParsePrivateKeysPEM parses all private keys from PEM data. Supported are RSA keys
+// in PKCS#1 ("RSA PRIVATE KEY"), P-256 keys in SEC 1 ("EC PRIVATE KEY") and RSA,
+// P-256 and X25519 keys in PKCS#8 ("PRIVATE KEY").
+func ParsePrivateKeysPEM(data []byte) (keys []PrivateKey, err error) {
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		key, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		data = rest
+	}
+	return keys, nil
+}
+
+func parsePrivateKey(block *pem.Block) (PrivateKey, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newRSAPrivateKeyValidated(key)
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewP256PrivateKey(key)
+	case "PRIVATE KEY":
+		var info pkcs8
+		if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+			return nil, err
+		}
+		if info.Algo.Algorithm.Equal(oidX25519) {
+			var key []byte
+			if _, err := asn1.Unmarshal(info.PrivateKey, &key); err != nil {
+				return nil, err
+			}
+			return NewX25519PrivateKey(key)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			return newRSAPrivateKeyValidated(key)
+		case *ecdsa.PrivateKey:
+			return NewP256PrivateKey(key)
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+}
+
+func newRSAPrivateKeyValidated(key *rsa.PrivateKey) (PrivateKey, error) {
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	key.Precompute()
+	return NewRSAPrivateKey(key), nil
+}
+
+// ParsePublicKeysPEM parses all public keys from PEM data. Supported are RSA keys
+// in PKCS#1 ("RSA PUBLIC KEY") and RSA, P-256 and X25519 keys in PKIX ("PUBLIC KEY").
+func ParsePublicKeysPEM(data []byte) (keys []PublicKey, err error) {
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		key, err := parsePublicKey(block)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		data = rest
+	}
+	return keys, nil
+}
+
+func parsePublicKey(block *pem.Block) (PublicKey, error) {
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSAPublicKey(key), nil
+	case "PUBLIC KEY":
+		var info publicKeyInfo
+		if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+			return nil, err
+		}
+		if info.Algorithm.Algorithm.Equal(oidX25519) {
+			return NewX25519PublicKey(info.PublicKey.RightAlign())
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			return NewRSAPublicKey(key), nil
+		case *ecdsa.PublicKey:
+			return NewP256PublicKey(key)
+		}
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+}
+
+// MarshalPrivateKeyPEM encodes private key in PKCS#8 PEM ("PRIVATE KEY")
+func MarshalPrivateKeyPEM(key PrivateKey) ([]byte, error) {
+	var der []byte
+	var err error
+	switch key := key.(type) {
+	case *rsaPrivateKey:
+		der, err = x509.MarshalPKCS8PrivateKey(key.key)
+	case *p256PrivateKey:
+		der, err = x509.MarshalPKCS8PrivateKey(key.key)
+	case *x25519PrivateKey:
+		var privateKey []byte
+		if privateKey, err = asn1.Marshal(key.key); err == nil {
+			der, err = asn1.Marshal(pkcs8{
+				Algo:       pkix.AlgorithmIdentifier{Algorithm: oidX25519},
+				PrivateKey: privateKey,
+			})
+		}
+	default:
+		return nil, errors.New("unsupported private key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// MarshalPublicKeyPEM encodes public key in PKIX PEM ("PUBLIC KEY")
+func MarshalPublicKeyPEM(key PublicKey) ([]byte, error) {
+	var der []byte
+	var err error
+	switch key := key.(type) {
+	case *rsaPublicKey:
+		der, err = x509.MarshalPKIXPublicKey(key.key)
+	case *p256PublicKey:
+		der, err = x509.MarshalPKIXPublicKey(key.key)
+	case *x25519PublicKey:
+		der, err = asn1.Marshal(publicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidX25519},
+			PublicKey: asn1.BitString{Bytes: key.key, BitLength: 8 * len(key.key)},
+		})
+	default:
+		return nil, errors.New("unsupported public key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
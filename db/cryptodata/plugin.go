@@ -15,8 +15,6 @@
 package cryptodata
 
 import (
-	"crypto/x509"
-	"encoding/pem"
 	"io/ioutil"
 
 	"go.ligato.io/cn-infra/v2/infra"
@@ -24,8 +22,18 @@ import (
 
 // Config is used to read private key from file
 type Config struct {
-	// Private key file is used to create rsa.PrivateKey from this PEM path
+	// Private key files are PEM files with private keys (RSA in PKCS#1 or PKCS#8, P-256 in SEC 1
+	// or PKCS#8 and X25519 in PKCS#8) used to decrypt values
 	PrivateKeyFiles []string `json:"private-key-files"`
+	// Public key files are used to encrypt values written through wrapped brokers
+	// (the first key is used), encrypting on write is disabled if empty
+	PublicKeyFiles []string `json:"public-key-files"`
+	// Active key ID selects the key (loaded from private or public key files) used to encrypt
+	// values instead of the first public key, other keys are retired
+	ActiveKeyID string `json:"active-key-id"`
+	// Vault transit configures HashiCorp Vault transit secrets engine used to decrypt (and optionally
+	// encrypt) data keys, so no private key has to be stored with the agent
+	VaultTransit *VaultTransitConfig `json:"vault-transit"`
 }
 
 // Deps lists dependencies of the cryptodata plugin.
@@ -37,6 +45,8 @@ type Deps struct {
 type Plugin struct {
 	Deps
 	ClientAPI
+	// Key providers are used besides keys loaded from files (see UseKeyProviders)
+	keyProviders []KeyProvider
 	// Plugin is disabled if there is no config file available
 	disabled bool
 }
@@ -55,8 +65,8 @@ func (p *Plugin) Init() (err error) {
 		return nil
 	}
 
-	// Read client config and create it
-	clientConfig := ClientConfig{}
+	// Read keys into keyring and create client with it
+	keyring := NewKeyring()
 	for _, file := range config.PrivateKeyFiles {
 		bytes, err := ioutil.ReadFile(file)
 		if err != nil {
@@ -64,36 +74,60 @@ func (p *Plugin) Init() (err error) {
 			return err
 		}
 
-		for {
-			block, rest := pem.Decode(bytes)
-			if block == nil {
-				break
-			}
-
-			privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-			if err != nil {
-				p.Log.Infof("%v", err)
-				return err
-			}
+		privateKeys, err := ParsePrivateKeysPEM(bytes)
+		if err != nil {
+			p.Log.Infof("%v", err)
+			return err
+		}
+		keyring.Add(privateKeys...)
+	}
 
-			err = privateKey.Validate()
-			if err != nil {
-				p.Log.Infof("%v", err)
-				return err
-			}
+	var publicKeys []PublicKey
+	for _, file := range config.PublicKeyFiles {
+		bytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			p.Log.Infof("%v", err)
+			return err
+		}
 
-			privateKey.Precompute()
-			clientConfig.PrivateKeys = append(clientConfig.PrivateKeys, privateKey)
+		keys, err := ParsePublicKeysPEM(bytes)
+		if err != nil {
+			p.Log.Infof("%v", err)
+			return err
+		}
+		publicKeys = append(publicKeys, keys...)
+	}
+	keyring.AddPublic(publicKeys...)
 
-			if rest == nil {
-				break
-			}
+	if config.ActiveKeyID != "" {
+		keyID, err := ParseKeyID(config.ActiveKeyID)
+		if err != nil {
+			return err
+		}
+		if err := keyring.SetActive(keyID); err != nil {
+			return err
+		}
+	} else if len(publicKeys) > 0 {
+		if err := keyring.SetActive(publicKeys[0].ID()); err != nil {
+			return err
+		}
+	}
+	if active := keyring.Active(); active != nil {
+		p.Log.Infof("using %v key %v for encrypting", active.Type(), active.ID())
+	}
 
-			bytes = rest
+	keyProviders := p.keyProviders
+	if config.VaultTransit != nil {
+		vault, err := NewVaultTransitProvider(*config.VaultTransit)
+		if err != nil {
+			p.Log.Infof("%v", err)
+			return err
 		}
+		p.Log.Infof("using vault transit key %s (key ID %v)", config.VaultTransit.KeyName, vault.KeyID())
+		keyProviders = append(keyProviders, vault)
 	}
 
-	p.ClientAPI = NewClient(clientConfig)
+	p.ClientAPI = NewClient(ClientConfig{Keyring: keyring, KeyProviders: keyProviders})
 	return
 }
 
@@ -0,0 +1,101 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata
+
+import (
+	"errors"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+)
+
+// ReEncrypt rewrites values stored under the <prefix> that contain values encrypted by other than
+// the active key (see EncryptDataWithActiveKey) (retired keys, RSA-only values or envelopes without key ID), so they
+// are encrypted by the active key. The <cbw> must not be wrapped by the crypto layer and the
+// <decrypter> must be able to re-encrypt values as well (e.g. DecrypterJSON).
+// Number of rewritten values is returned.
+func (client *Client) ReEncrypt(cbw keyval.KvBytesPlugin, prefix string, decrypter ArbitraryDecrypter) (count int, err error) {
+	reEncrypter, ok := decrypter.(ArbitraryReEncrypter)
+	if !ok {
+		return 0, errors.New("decrypter is not able to re-encrypt values")
+	}
+	activeID, ok := client.ActiveKeyID()
+	if !ok {
+		return 0, errors.New("failed to re-encrypt data due to no active key")
+	}
+
+	broker := cbw.NewBroker(keyval.Root)
+	values, err := listValues(broker, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, kv := range values {
+		if !decrypter.IsEncrypted(kv.value) {
+			continue
+		}
+
+		stale := false
+		reEncrypted, err := reEncrypter.ReEncrypt(kv.value, func(inData []byte) ([]byte, error) {
+			if keyID, ok := EnvelopeKeyID(inData); ok && keyID == activeID {
+				return inData, nil
+			}
+			stale = true
+			decrypted, err := client.DecryptData(inData)
+			if err != nil {
+				return nil, err
+			}
+			return client.EncryptDataWithActiveKey(decrypted)
+		})
+		if err != nil {
+			return count, err
+		}
+		if !stale {
+			continue
+		}
+
+		data, ok := reEncrypted.([]byte)
+		if !ok {
+			return count, errors.New("re-encrypter returned data of unexpected type")
+		}
+		if err := broker.Put(kv.key, data); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+type keyValue struct {
+	key   string
+	value []byte
+}
+
+// listValues reads all values under the prefix, so the store is not modified while iterating
+func listValues(broker keyval.BytesBroker, prefix string) (values []keyValue, err error) {
+	it, err := broker.ListValues(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		kv, stop := it.GetNext()
+		if stop {
+			break
+		}
+		values = append(values, keyValue{key: kv.GetKey(), value: kv.GetValue()})
+	}
+	return values, nil
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultVaultTransitMount is default mount path of Vault transit secrets engine
+const DefaultVaultTransitMount = "transit"
+
+// VaultTransitConfig configures KeyProvider using HashiCorp Vault transit secrets engine
+type VaultTransitConfig struct {
+	// Address of the Vault server (VAULT_ADDR environment variable is used if empty)
+	Address string `json:"address"`
+	// Token used to authenticate (VAULT_TOKEN environment variable is used if empty)
+	Token string `json:"token"`
+	// Token file is read before each request instead of using Token (e.g. sink of Vault agent)
+	TokenFile string `json:"token-file"`
+	// Namespace of Vault Enterprise
+	Namespace string `json:"namespace"`
+	// Mount path of the transit secrets engine, "transit" by default
+	Mount string `json:"mount"`
+	// Name of the transit key used to encrypt data keys
+	KeyName string `json:"key-name"`
+	// CA certificate file used to verify Vault server
+	CACertFile string `json:"ca-cert-file"`
+	// Timeout of the requests to Vault
+	Timeout time.Duration `json:"timeout"`
+	// Encrypt enables encrypting by the transit key, otherwise it is used only for decrypting
+	Encrypt bool `json:"encrypt"`
+}
+
+// VaultTransitProvider is KeyProvider that encrypts and decrypts data keys using HashiCorp
+// Vault transit secrets engine, so the key never leaves Vault. The key ID is derived from
+// the mount and name of the transit key, key versions are handled by Vault.
+type VaultTransitProvider struct {
+	config VaultTransitConfig
+	keyID  KeyID
+	client *http.Client
+}
+
+// NewVaultTransitProvider creates Vault transit KeyProvider from provided config
+func NewVaultTransitProvider(config VaultTransitConfig) (*VaultTransitProvider, error) {
+	if config.Address == "" {
+		config.Address = os.Getenv("VAULT_ADDR")
+	}
+	if config.Address == "" {
+		return nil, errors.New("vault address is not configured")
+	}
+	if config.Token == "" && config.TokenFile == "" {
+		config.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if config.Mount == "" {
+		config.Mount = DefaultVaultTransitMount
+	}
+	config.Mount = strings.Trim(config.Mount, "/")
+	if config.KeyName == "" {
+		return nil, errors.New("vault transit key name is not configured")
+	}
+
+	client := &http.Client{Timeout: config.Timeout}
+	if config.CACertFile != "" {
+		caCert, err := ioutil.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to load CA certificate from %s", config.CACertFile)
+		}
+		client.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: certPool},
+		}
+	}
+
+	return &VaultTransitProvider{
+		config: config,
+		keyID:  NewKeyID(KeyTypeKMS, []byte("vault-transit:"+config.Mount+"/"+config.KeyName)),
+		client: client,
+	}, nil
+}
+
+// KeyID returns ID of the transit key
+func (p *VaultTransitProvider) KeyID() KeyID {
+	return p.keyID
+}
+
+// ActiveKeyID returns ID of the transit key if encrypting is enabled
+func (p *VaultTransitProvider) ActiveKeyID() (KeyID, bool) {
+	return p.keyID, p.config.Encrypt
+}
+
+// WrapKey encrypts data key by the transit key
+func (p *VaultTransitProvider) WrapKey(dataKey []byte) (KeyType, KeyID, []byte, error) {
+	if !p.config.Encrypt {
+		return 0, KeyID{}, nil, ErrNoActiveKey
+	}
+	var resp struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	err := p.call("encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(dataKey),
+	}, &resp)
+	if err != nil {
+		return 0, KeyID{}, nil, err
+	}
+	return KeyTypeKMS, p.keyID, []byte(resp.Ciphertext), nil
+}
+
+// UnwrapKey decrypts data key encrypted by the transit key
+func (p *VaultTransitProvider) UnwrapKey(keyType KeyType, keyID KeyID, wrappedKey []byte) ([]byte, error) {
+	if keyType != KeyTypeKMS || keyID != p.keyID {
+		return nil, ErrKeyNotFound
+	}
+	var resp struct {
+		Plaintext string `json:"plaintext"`
+	}
+	err := p.call("decrypt", map[string]string{
+		"ciphertext": string(wrappedKey),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
+}
+
+// call sends request to transit endpoint (encrypt or decrypt) of the key and decodes data of the response
+func (p *VaultTransitProvider) call(operation string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(p.config.Address, "/") + "/v1/" + p.config.Mount + "/" +
+		operation + "/" + url.PathEscape(p.config.KeyName)
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	token := p.config.Token
+	if p.config.TokenFile != "" {
+		tokenData, err := ioutil.ReadFile(p.config.TokenFile)
+		if err != nil {
+			return err
+		}
+		token = strings.TrimSpace(string(tokenData))
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []string        `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("vault transit %s failed with status %d: %v", operation, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || len(result.Errors) > 0 {
+		return fmt.Errorf("vault transit %s failed with status %d: %s", operation, resp.StatusCode,
+			strings.Join(result.Errors, "; "))
+	}
+	return json.Unmarshal(result.Data, response)
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/cryptodata"
+)
+
+const (
+	vaultToken = "s.test-token"
+	vaultKey   = "agent"
+)
+
+// newVaultStandIn starts HTTP server emulating encrypt and decrypt endpoints of Vault transit
+// secrets engine for key <vaultKey> mounted at "transit"
+func newVaultStandIn(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := func(status int, data interface{}, errs ...string) {
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data, "errors": errs})
+		}
+		if r.Header.Get("X-Vault-Token") != vaultToken {
+			reply(http.StatusForbidden, nil, "permission denied")
+			return
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			reply(http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/" + vaultKey:
+			reply(http.StatusOK, map[string]string{"ciphertext": "vault:v1:" + req["plaintext"]})
+		case "/v1/transit/decrypt/" + vaultKey:
+			plaintext := strings.TrimPrefix(req["ciphertext"], "vault:v1:")
+			if plaintext == req["ciphertext"] {
+				reply(http.StatusBadRequest, nil, "invalid ciphertext")
+				return
+			}
+			reply(http.StatusOK, map[string]string{"plaintext": plaintext})
+		default:
+			reply(http.StatusNotFound, nil)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultTransitProvider(t *testing.T) {
+	RegisterTestingT(t)
+	server := newVaultStandIn(t)
+
+	vault, err := cryptodata.NewVaultTransitProvider(cryptodata.VaultTransitConfig{
+		Address: server.URL,
+		Token:   vaultToken,
+		KeyName: vaultKey,
+		Encrypt: true,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	client := cryptodata.NewClient(cryptodata.ClientConfig{
+		KeyProviders: []cryptodata.KeyProvider{vault},
+	})
+
+	activeID, ok := client.ActiveKeyID()
+	Expect(ok).To(BeTrue())
+	Expect(activeID).To(Equal(vault.KeyID()))
+
+	secret := []byte(strings.Repeat("secret", 100))
+	encrypted, err := client.EncryptDataWithActiveKey(secret)
+	Expect(err).ToNot(HaveOccurred())
+	keyID, ok := cryptodata.EnvelopeKeyID(encrypted)
+	Expect(ok).To(BeTrue())
+	Expect(keyID).To(Equal(vault.KeyID()))
+
+	decrypted, err := client.DecryptData(encrypted)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(decrypted).To(Equal(secret))
+
+	// decrypting only provider decrypts values of JSON documents
+	decryptOnly, err := cryptodata.NewVaultTransitProvider(cryptodata.VaultTransitConfig{
+		Address: server.URL,
+		Token:   vaultToken,
+		KeyName: vaultKey,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	_, ok = decryptOnly.ActiveKeyID()
+	Expect(ok).To(BeFalse())
+	client = cryptodata.NewClient(cryptodata.ClientConfig{
+		KeyProviders: []cryptodata.KeyProvider{decryptOnly},
+	})
+	_, err = client.EncryptDataWithActiveKey(secret)
+	Expect(err).To(HaveOccurred())
+
+	document := `{"encrypted":true,"value":"$crypto$` + base64.URLEncoding.EncodeToString(encrypted) + `"}`
+	decryptedDocument, err := cryptodata.NewDecrypterJSON().Decrypt([]byte(document), client.DecryptData)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(decryptedDocument.([]byte))).To(ContainSubstring(string(secret)))
+}
+
+func TestVaultTransitProviderErrors(t *testing.T) {
+	RegisterTestingT(t)
+	server := newVaultStandIn(t)
+
+	_, err := cryptodata.NewVaultTransitProvider(cryptodata.VaultTransitConfig{Address: server.URL})
+	Expect(err).To(HaveOccurred())
+
+	vault, err := cryptodata.NewVaultTransitProvider(cryptodata.VaultTransitConfig{
+		Address: server.URL,
+		Token:   "invalid",
+		KeyName: vaultKey,
+		Encrypt: true,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	_, _, _, err = vault.WrapKey([]byte("data key"))
+	Expect(err).To(MatchError(ContainSubstring("permission denied")))
+
+	// keys of other providers are not handled
+	_, err = vault.UnwrapKey(cryptodata.KeyTypeRSA, vault.KeyID(), []byte("vault:v1:"))
+	Expect(err).To(Equal(cryptodata.ErrKeyNotFound))
+
+	// envelope encrypted by vault key cannot be decrypted without the vault
+	valid, err := cryptodata.NewVaultTransitProvider(cryptodata.VaultTransitConfig{
+		Address: server.URL,
+		Token:   vaultToken,
+		KeyName: vaultKey,
+		Encrypt: true,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	encrypted, err := cryptodata.NewClient(cryptodata.ClientConfig{
+		KeyProviders: []cryptodata.KeyProvider{valid},
+	}).EncryptDataWithActiveKey([]byte("secret"))
+	Expect(err).ToNot(HaveOccurred())
+	_, err = cryptodata.NewClient(cryptodata.ClientConfig{}).DecryptData(encrypted)
+	Expect(err).To(MatchError(ContainSubstring(valid.KeyID().String())))
+}
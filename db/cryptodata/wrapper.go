@@ -14,10 +14,60 @@
 
 package cryptodata
 
+import (
+	"encoding/base64"
+)
+
 // decryptData holds values required for decrypting
 type decryptData struct {
 	// Function used for decrypting arbitrary data later
 	decryptFunc DecryptFunc
 	// ArbitraryDecrypter is used to decrypt data
 	decrypter ArbitraryDecrypter
-}
\ No newline at end of file
+}
+
+// encryptData holds values required for encrypting
+type encryptData struct {
+	// Function used for encrypting arbitrary data, encrypting is disabled if nil
+	encryptFunc EncryptFunc
+	// ArbitraryEncrypter is used to encrypt data, encrypting is disabled if nil
+	encrypter ArbitraryEncrypter
+}
+
+// newEncryptData enables encrypting if both encryptFunc is provided and decrypter
+// is able to encrypt values as well (implements ArbitraryEncrypter). Values that
+// can be decrypted by decryptFunc (e.g. RSA-only encrypted values) are not encrypted again.
+func newEncryptData(decrypter ArbitraryDecrypter, decryptFunc DecryptFunc, encryptFunc EncryptFunc) encryptData {
+	encrypter, ok := decrypter.(ArbitraryEncrypter)
+	if !ok || encryptFunc == nil {
+		return encryptData{}
+	}
+	return encryptData{
+		encryptFunc: skipEncrypted(encryptFunc, decryptFunc),
+		encrypter:   encrypter,
+	}
+}
+
+// skipEncrypted returns EncryptFunc that leaves base64 encoded values which can be decrypted
+// by decryptFunc as they are (the decoded value is returned to be encoded again).
+func skipEncrypted(encryptFunc EncryptFunc, decryptFunc DecryptFunc) EncryptFunc {
+	if decryptFunc == nil {
+		return encryptFunc
+	}
+	return func(inData []byte) ([]byte, error) {
+		if decoded, err := base64.URLEncoding.DecodeString(string(inData)); err == nil {
+			if _, err := decryptFunc(decoded); err == nil {
+				return decoded, nil
+			}
+		}
+		return encryptFunc(inData)
+	}
+}
+
+// encrypt encrypts values inside data if encrypting is enabled
+func (e encryptData) encrypt(data interface{}) (interface{}, error) {
+	if e.encrypter == nil {
+		return data, nil
+	}
+	return e.encrypter.Encrypt(data, e.encryptFunc)
+}
@@ -14,18 +14,36 @@
 
 package cryptodata
 
-import "go.ligato.io/cn-infra/v2/db/keyval"
+import (
+	"context"
+	"fmt"
 
-// KvBytesPluginWrapper wraps keyval.KvBytesPlugin with additional support of reading encrypted data
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+)
+
+// KvBytesPluginWrapper wraps keyval.KvBytesPlugin with additional support of reading (and optionally
+// writing) encrypted data
 type KvBytesPluginWrapper struct {
 	keyval.KvBytesPlugin
 	decryptData
+	encryptData
 }
 
-// BytesBrokerWrapper wraps keyval.BytesBroker with additional support of reading encrypted data
+// BytesBrokerWrapper wraps keyval.BytesBroker with additional support of reading (and optionally
+// writing) encrypted data
 type BytesBrokerWrapper struct {
 	keyval.BytesBroker
 	decryptData
+	encryptData
+}
+
+// BytesTxnWrapper wraps keyval.BytesTxn with additional support of writing encrypted data
+type BytesTxnWrapper struct {
+	keyval.BytesTxn
+	encryptData
+	// first error that occurred while encrypting, it is returned from Commit
+	err error
 }
 
 // BytesWatcherWrapper wraps keyval.BytesWatcher with additional support of reading encrypted data
@@ -55,12 +73,21 @@ type BytesKeyValIteratorWrapper struct {
 // NewKvBytesPluginWrapper creates wrapper for provided CoreBrokerWatcher, adding support for decrypting encrypted
 // data
 func NewKvBytesPluginWrapper(cbw keyval.KvBytesPlugin, decrypter ArbitraryDecrypter, decryptFunc DecryptFunc) *KvBytesPluginWrapper {
+	return NewKvBytesPluginWrapperWithEncryption(cbw, decrypter, decryptFunc, nil)
+}
+
+// NewKvBytesPluginWrapperWithEncryption creates wrapper for provided CoreBrokerWatcher, adding support for decrypting
+// encrypted data and for encrypting data on write using encryptFunc. Data are encrypted only if the decrypter
+// implements ArbitraryEncrypter as well (e.g. DecrypterJSON).
+func NewKvBytesPluginWrapperWithEncryption(cbw keyval.KvBytesPlugin, decrypter ArbitraryDecrypter, decryptFunc DecryptFunc,
+	encryptFunc EncryptFunc) *KvBytesPluginWrapper {
 	return &KvBytesPluginWrapper{
 		KvBytesPlugin: cbw,
 		decryptData: decryptData{
 			decryptFunc: decryptFunc,
 			decrypter:   decrypter,
 		},
+		encryptData: newEncryptData(decrypter, decryptFunc, encryptFunc),
 	}
 }
 
@@ -86,11 +113,15 @@ func NewBytesWatcherWrapper(pb keyval.BytesWatcher, decrypter ArbitraryDecrypter
 	}
 }
 
-// NewBroker returns a BytesBroker instance with support for decrypting values that prepends given <keyPrefix> to all
-// keys in its calls.
+// NewBroker returns a BytesBroker instance with support for decrypting (and encrypting, if enabled) values that
+// prepends given <keyPrefix> to all keys in its calls.
 // To avoid using a prefix, pass keyval.Root constant as argument.
 func (cbw *KvBytesPluginWrapper) NewBroker(prefix string) keyval.BytesBroker {
-	return NewBytesBrokerWrapper(cbw.KvBytesPlugin.NewBroker(prefix), cbw.decrypter, cbw.decryptFunc)
+	return &BytesBrokerWrapper{
+		BytesBroker: cbw.KvBytesPlugin.NewBroker(prefix),
+		decryptData: cbw.decryptData,
+		encryptData: cbw.encryptData,
+	}
 }
 
 // NewWatcher returns a BytesWatcher instance with support for decrypting values that prepends given <keyPrefix> to all
@@ -101,6 +132,23 @@ func (cbw *KvBytesPluginWrapper) NewWatcher(prefix string) keyval.BytesWatcher {
 	return NewBytesWatcherWrapper(cbw.KvBytesPlugin.NewWatcher(prefix), cbw.decrypter, cbw.decryptFunc)
 }
 
+// Put encrypts values in data (if encrypting is enabled) and puts them to the store.
+func (cbb *BytesBrokerWrapper) Put(key string, data []byte, opts ...datasync.PutOption) error {
+	encrypted, err := cbb.encryptBytes(data)
+	if err != nil {
+		return err
+	}
+	return cbb.BytesBroker.Put(key, encrypted, opts...)
+}
+
+// NewTxn creates a transaction that encrypts values of put data (if encrypting is enabled).
+func (cbb *BytesBrokerWrapper) NewTxn() keyval.BytesTxn {
+	return &BytesTxnWrapper{
+		BytesTxn:    cbb.BytesBroker.NewTxn(),
+		encryptData: cbb.encryptData,
+	}
+}
+
 // GetValue retrieves and tries to decrypt one item under the provided key.
 func (cbb *BytesBrokerWrapper) GetValue(key string) (data []byte, found bool, revision int64, err error) {
 	data, found, revision, err = cbb.BytesBroker.GetValue(key)
@@ -131,6 +179,34 @@ func (cbb *BytesBrokerWrapper) ListValues(key string) (keyval.BytesKeyValIterato
 	}, nil
 }
 
+// Put adds put operation with encrypted data into the transaction.
+func (txn *BytesTxnWrapper) Put(key string, data []byte) keyval.BytesTxn {
+	if txn.err != nil {
+		return txn
+	}
+	encrypted, err := txn.encryptBytes(data)
+	if err != nil {
+		txn.err = err
+		return txn
+	}
+	txn.BytesTxn.Put(key, encrypted)
+	return txn
+}
+
+// Delete adds delete operation into the transaction.
+func (txn *BytesTxnWrapper) Delete(key string) keyval.BytesTxn {
+	txn.BytesTxn.Delete(key)
+	return txn
+}
+
+// Commit executes the transaction, unless encrypting of some data failed.
+func (txn *BytesTxnWrapper) Commit(ctx context.Context) error {
+	if txn.err != nil {
+		return txn.err
+	}
+	return txn.BytesTxn.Commit(ctx)
+}
+
 // Watch starts subscription for changes associated with the selected keys.
 // Watch events will be delivered to callback (not channel) <respChan>.
 // Channel <closeChan> can be used to close watching on respective key
@@ -197,3 +273,16 @@ func (r *BytesKeyValIteratorWrapper) GetNext() (kv keyval.BytesKeyVal, stop bool
 		decryptData: r.decryptData,
 	}, stop
 }
+
+// encryptBytes encrypts values inside data using encryptData
+func (e encryptData) encryptBytes(data []byte) ([]byte, error) {
+	objData, err := e.encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	outData, ok := objData.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("failed to encrypt data, encrypter returned %T instead of []byte", objData)
+	}
+	return outData, nil
+}
@@ -15,22 +15,37 @@
 package cryptodata
 
 import (
+	"context"
+	"fmt"
+
 	"google.golang.org/protobuf/proto"
 
 	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/keyval"
 )
 
-// KvProtoPluginWrapper wraps keyval.KvProtoPlugin with additional support of reading encrypted data
+// KvProtoPluginWrapper wraps keyval.KvProtoPlugin with additional support of reading (and optionally
+// writing) encrypted data
 type KvProtoPluginWrapper struct {
 	keyval.KvProtoPlugin
 	decryptData
+	encryptData
 }
 
-// ProtoBrokerWrapper wraps keyval.ProtoBroker with additional support of reading encrypted data
+// ProtoBrokerWrapper wraps keyval.ProtoBroker with additional support of reading (and optionally
+// writing) encrypted data
 type ProtoBrokerWrapper struct {
 	keyval.ProtoBroker
 	decryptData
+	encryptData
+}
+
+// ProtoTxnWrapper wraps keyval.ProtoTxn with additional support of writing encrypted data
+type ProtoTxnWrapper struct {
+	keyval.ProtoTxn
+	encryptData
+	// first error that occurred while encrypting, it is returned from Commit
+	err error
 }
 
 // ProtoWatcherWrapper wraps keyval.ProtoWatcher with additional support of reading encrypted data
@@ -59,12 +74,21 @@ type ProtoKeyValIteratorWrapper struct {
 
 // NewKvProtoPluginWrapper creates wrapper for provided KvProtoPlugin, adding support for decrypting encrypted data
 func NewKvProtoPluginWrapper(kvp keyval.KvProtoPlugin, decrypter ArbitraryDecrypter, decryptFunc DecryptFunc) *KvProtoPluginWrapper {
+	return NewKvProtoPluginWrapperWithEncryption(kvp, decrypter, decryptFunc, nil)
+}
+
+// NewKvProtoPluginWrapperWithEncryption creates wrapper for provided KvProtoPlugin, adding support for decrypting
+// encrypted data and for encrypting data on write using encryptFunc. Data are encrypted only if the decrypter
+// implements ArbitraryEncrypter as well (e.g. DecrypterProto).
+func NewKvProtoPluginWrapperWithEncryption(kvp keyval.KvProtoPlugin, decrypter ArbitraryDecrypter, decryptFunc DecryptFunc,
+	encryptFunc EncryptFunc) *KvProtoPluginWrapper {
 	return &KvProtoPluginWrapper{
 		KvProtoPlugin: kvp,
 		decryptData: decryptData{
 			decryptFunc: decryptFunc,
 			decrypter:   decrypter,
 		},
+		encryptData: newEncryptData(decrypter, decryptFunc, encryptFunc),
 	}
 }
 
@@ -90,11 +114,15 @@ func NewProtoWatcherWrapper(pb keyval.ProtoWatcher, decrypter ArbitraryDecrypter
 	}
 }
 
-// NewBroker returns a ProtoBroker instance with support for decrypting values that prepends given <keyPrefix> to all
-// keys in its calls.
+// NewBroker returns a ProtoBroker instance with support for decrypting (and encrypting, if enabled) values that
+// prepends given <keyPrefix> to all keys in its calls.
 // To avoid using a prefix, pass keyval.Root constant as argument.
 func (kvp *KvProtoPluginWrapper) NewBroker(prefix string) keyval.ProtoBroker {
-	return NewProtoBrokerWrapper(kvp.KvProtoPlugin.NewBroker(prefix), kvp.decrypter, kvp.decryptFunc)
+	return &ProtoBrokerWrapper{
+		ProtoBroker: kvp.KvProtoPlugin.NewBroker(prefix),
+		decryptData: kvp.decryptData,
+		encryptData: kvp.encryptData,
+	}
 }
 
 // NewWatcher returns a ProtoWatcher instance with support for decrypting values that prepends given <keyPrefix> to all
@@ -105,6 +133,24 @@ func (kvp *KvProtoPluginWrapper) NewWatcher(prefix string) keyval.ProtoWatcher {
 	return NewProtoWatcherWrapper(kvp.KvProtoPlugin.NewWatcher(prefix), kvp.decrypter, kvp.decryptFunc)
 }
 
+// Put encrypts registered fields of data (if encrypting is enabled) and puts the data to the store.
+// The provided data are not modified.
+func (db *ProtoBrokerWrapper) Put(key string, data proto.Message, opts ...datasync.PutOption) error {
+	encrypted, err := db.encryptProto(data)
+	if err != nil {
+		return err
+	}
+	return db.ProtoBroker.Put(key, encrypted, opts...)
+}
+
+// NewTxn creates a transaction that encrypts registered fields of put data (if encrypting is enabled).
+func (db *ProtoBrokerWrapper) NewTxn() keyval.ProtoTxn {
+	return &ProtoTxnWrapper{
+		ProtoTxn:    db.ProtoBroker.NewTxn(),
+		encryptData: db.encryptData,
+	}
+}
+
 // GetValue retrieves one item under the provided <key>. If the item exists,
 // it is unmarshaled into the <reqObj> and its fields are decrypted.
 func (db *ProtoBrokerWrapper) GetValue(key string, reqObj proto.Message) (bool, int64, error) {
@@ -130,6 +176,34 @@ func (db *ProtoBrokerWrapper) ListValues(key string) (keyval.ProtoKeyValIterator
 	}, nil
 }
 
+// Put adds put operation with encrypted data into the transaction.
+func (txn *ProtoTxnWrapper) Put(key string, data proto.Message) keyval.ProtoTxn {
+	if txn.err != nil {
+		return txn
+	}
+	encrypted, err := txn.encryptProto(data)
+	if err != nil {
+		txn.err = err
+		return txn
+	}
+	txn.ProtoTxn.Put(key, encrypted)
+	return txn
+}
+
+// Delete adds delete operation into the transaction.
+func (txn *ProtoTxnWrapper) Delete(key string) keyval.ProtoTxn {
+	txn.ProtoTxn.Delete(key)
+	return txn
+}
+
+// Commit executes the transaction, unless encrypting of some data failed.
+func (txn *ProtoTxnWrapper) Commit(ctx context.Context) error {
+	if txn.err != nil {
+		return txn.err
+	}
+	return txn.ProtoTxn.Commit(ctx)
+}
+
 // Watch starts subscription for changes associated with the selected keys.
 // Watch events will be delivered to callback (not channel) <respChan>.
 // Channel <closeChan> can be used to close watching on respective key
@@ -188,3 +262,16 @@ func (r *ProtoKeyValIteratorWrapper) GetNext() (kv keyval.ProtoKeyVal, stop bool
 		decryptData: r.decryptData,
 	}, stop
 }
+
+// encryptProto encrypts registered fields of data using encryptData
+func (e encryptData) encryptProto(data proto.Message) (proto.Message, error) {
+	objData, err := e.encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	outData, ok := objData.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("failed to encrypt data, encrypter returned %T instead of proto.Message", objData)
+	}
+	return outData, nil
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptodata_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"go.ligato.io/cn-infra/v2/db/cryptodata"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/bolt"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvproto"
+)
+
+// protoPlugin is bolt client accessed through proto wrapper
+type protoPlugin struct {
+	*kvproto.ProtoWrapper
+}
+
+func (p *protoPlugin) Disabled() bool            { return false }
+func (p *protoPlugin) OnConnect(cb func() error) { _ = cb() }
+func (p *protoPlugin) String() string            { return "bolt" }
+func (p *protoPlugin) Close() error              { return p.ProtoWrapper.Close() }
+func (p *protoPlugin) NewBroker(prefix string) keyval.ProtoBroker {
+	return p.ProtoWrapper.NewBroker(prefix)
+}
+
+func newBoltClient(t *testing.T) *bolt.Client {
+	client, err := bolt.NewClient(&bolt.Config{
+		DbPath:   filepath.Join(t.TempDir(), "db"),
+		FileMode: 432,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func newClient(keyType cryptodata.KeyType) *cryptodata.Client {
+	key, err := cryptodata.GenerateKey(rand.Reader, keyType)
+	Expect(err).ToNot(HaveOccurred())
+	return cryptodata.NewClient(cryptodata.ClientConfig{Keyring: cryptodata.NewKeyring(key)})
+}
+
+func getRawValue(db keyval.KvBytesPlugin, key string) []byte {
+	value, found, _, err := db.NewBroker(keyval.Root).GetValue(key)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	return value
+}
+
+// decodeEnvelope decodes base64 encoded envelope
+func decodeEnvelope(value string) []byte {
+	decoded, err := base64.URLEncoding.DecodeString(value)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(cryptodata.IsEnvelope(decoded)).To(BeTrue())
+	return decoded
+}
+
+func TestWrapBytesEncryptOnWrite(t *testing.T) {
+	RegisterTestingT(t)
+	db := newBoltClient(t)
+	client := newClient(cryptodata.KeyTypeX25519)
+	decrypter := cryptodata.NewDecrypterJSON()
+	broker := client.WrapBytes(db, decrypter).NewBroker(keyval.Root)
+
+	// put
+	plain := []byte(`{"encrypted":true,"user":"admin","password":"$crypto$secret"}`)
+	Expect(broker.Put("/put", plain)).To(Succeed())
+	raw := getRawValue(db, "/put")
+	Expect(string(raw)).ToNot(ContainSubstring("secret"))
+	Expect(string(raw)).To(ContainSubstring(`"user":"admin"`))
+
+	value, found, _, err := broker.GetValue("/put")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(string(value)).To(ContainSubstring(`"password":"secret"`))
+
+	// transaction
+	err = broker.NewTxn().
+		Put("/txn", []byte(`{"encrypted":true,"password":"$crypto$txn-secret"}`)).
+		Commit(context.Background())
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(getRawValue(db, "/txn"))).ToNot(ContainSubstring("txn-secret"))
+	value, _, _, err = broker.GetValue("/txn")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(value)).To(ContainSubstring(`"password":"txn-secret"`))
+
+	// already encrypted value is not encrypted twice
+	Expect(broker.Put("/again", raw)).To(Succeed())
+	Expect(getRawValue(db, "/again")).To(Equal(raw))
+
+	// values not marked as encrypted are written as they are
+	Expect(broker.Put("/plain", []byte(`{"password":"$crypto$secret"}`))).To(Succeed())
+	Expect(string(getRawValue(db, "/plain"))).To(ContainSubstring("$crypto$secret"))
+}
+
+func TestWrapProtoEncryptOnWrite(t *testing.T) {
+	RegisterTestingT(t)
+	db := newBoltClient(t)
+	kvp := &protoPlugin{ProtoWrapper: kvproto.NewProtoWrapper(db, &keyval.SerializerJSON{})}
+	client := newClient(cryptodata.KeyTypeP256)
+	decrypter := cryptodata.NewDecrypterProto()
+	decrypter.RegisterMapping(&wrapperspb.StringValue{}, []string{"Value"})
+	broker := client.WrapProto(kvp, decrypter).NewBroker(keyval.Root)
+
+	// put, the input message is not modified
+	msg := wrapperspb.String("secret")
+	Expect(broker.Put("/put", msg)).To(Succeed())
+	Expect(msg.GetValue()).To(Equal("secret"))
+	raw := &wrapperspb.StringValue{}
+	_, _, err := kvp.GetValue("/put", raw)
+	Expect(err).ToNot(HaveOccurred())
+	decodeEnvelope(raw.GetValue())
+
+	read := &wrapperspb.StringValue{}
+	found, _, err := broker.GetValue("/put", read)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(read.GetValue()).To(Equal("secret"))
+
+	// transaction
+	err = broker.NewTxn().Put("/txn", wrapperspb.String("txn-secret")).Commit(context.Background())
+	Expect(err).ToNot(HaveOccurred())
+	rawTxn := &wrapperspb.StringValue{}
+	_, _, err = kvp.GetValue("/txn", rawTxn)
+	Expect(err).ToNot(HaveOccurred())
+	decodeEnvelope(rawTxn.GetValue())
+	_, _, err = broker.GetValue("/txn", read)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(read.GetValue()).To(Equal("txn-secret"))
+
+	// already encrypted value is not encrypted twice
+	Expect(broker.Put("/again", raw)).To(Succeed())
+	again := &wrapperspb.StringValue{}
+	_, _, err = kvp.GetValue("/again", again)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(again.GetValue()).To(Equal(raw.GetValue()))
+}
+
+func TestWrapBytesEncryptError(t *testing.T) {
+	RegisterTestingT(t)
+	db := newBoltClient(t)
+	failing := func([]byte) ([]byte, error) { return nil, errors.New("encrypt failed") }
+	broker := cryptodata.NewKvBytesPluginWrapperWithEncryption(db, cryptodata.NewDecrypterJSON(), nil, failing).
+		NewBroker(keyval.Root)
+
+	value := []byte(`{"encrypted":true,"password":"$crypto$secret"}`)
+	Expect(broker.Put("/put", value)).To(MatchError(ContainSubstring("encrypt failed")))
+	err := broker.NewTxn().
+		Put("/plain", []byte(`{"password":"secret"}`)).
+		Put("/txn", value).
+		Commit(context.Background())
+	Expect(err).To(MatchError(ContainSubstring("encrypt failed")))
+	for _, key := range []string{"/put", "/plain", "/txn"} {
+		_, found, _, _ := db.GetValue(key)
+		Expect(found).To(BeFalse())
+	}
+}
+
+func TestWrapBytesLegacyValue(t *testing.T) {
+	RegisterTestingT(t)
+	db := newBoltClient(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).ToNot(HaveOccurred())
+	client := cryptodata.NewClient(cryptodata.ClientConfig{
+		PrivateKeys: []*rsa.PrivateKey{key},
+		PublicKeys:  []*rsa.PublicKey{&key.PublicKey},
+	})
+	broker := client.WrapBytes(db, cryptodata.NewDecrypterJSON()).NewBroker(keyval.Root)
+
+	// RSA-only encrypted value is written as it is
+	encrypted, err := client.EncryptData([]byte("secret"), &key.PublicKey)
+	Expect(err).ToNot(HaveOccurred())
+	legacy := []byte(`{"encrypted":true,"password":"$crypto$` + base64.URLEncoding.EncodeToString(encrypted) + `"}`)
+	Expect(broker.Put("/legacy", legacy)).To(Succeed())
+	Expect(getRawValue(db, "/legacy")).To(MatchJSON(legacy))
+
+	value, found, _, err := broker.GetValue("/legacy")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(value).To(MatchJSON(`{"encrypted":true,"password":"secret"}`))
+}
+
+func TestClientConcurrentUse(t *testing.T) {
+	RegisterTestingT(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).ToNot(HaveOccurred())
+	client := cryptodata.NewClient(cryptodata.ClientConfig{
+		PrivateKeys: []*rsa.PrivateKey{key},
+		PublicKeys:  []*rsa.PublicKey{&key.PublicKey},
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := strings.Repeat("x", i)
+			encrypted, err := client.EncryptData([]byte(data), &key.PublicKey)
+			if err == nil {
+				var decrypted []byte
+				decrypted, err = client.DecryptData(encrypted)
+				if err == nil && string(decrypted) != data {
+					err = rsa.ErrDecryption
+				}
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		Expect(err).ToNot(HaveOccurred())
+	}
+}
@@ -25,6 +25,7 @@ import (
 
 	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvlock"
 	"go.ligato.io/cn-infra/v2/logging"
 	"go.ligato.io/cn-infra/v2/logging/logrus"
 )
@@ -51,6 +52,8 @@ type Client struct {
 	mu       sync.RWMutex
 	watchers watchers
 
+	locks *kvlock.LocalLocker
+
 	wg   sync.WaitGroup
 	quit chan struct{}
 }
@@ -66,7 +69,13 @@ func NewClient(cfg *Config) (client *Client, err error) {
 	boltLogger.Infof("bolt path: %v", db.Path())
 
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(rootBucket)
+		for _, bucket := range [][]byte{rootBucket, ttlBucket, expiryBucket, lifetimeBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		// keys with client lifetime left by previous client are not valid anymore
+		_, err := removeClientLifetime(tx)
 		return err
 	})
 	if err != nil {
@@ -79,21 +88,36 @@ func NewClient(cfg *Config) (client *Client, err error) {
 		quit:       make(chan struct{}),
 		updateChan: make(chan *updateTx, UpdatesChannelSize),
 		watchers:   make(watchers),
+		locks:      kvlock.NewLocalLocker(),
 	}
 
-	c.wg.Add(1)
+	c.wg.Add(2)
 	go c.startUpdater()
+	go c.startReaper()
+	if cfg.SnapshotInterval > 0 && cfg.SnapshotDir != "" {
+		c.wg.Add(1)
+		go c.startSnapshotter()
+	}
 
 	return c, nil
 }
 
-// Close closes Bolt database.
+// Close closes Bolt database. Keys with client lifetime are removed.
 func (c *Client) Close() error {
+	c.removeKeys(removeClientLifetime)
 	close(c.quit)
 	c.wg.Wait()
+	c.locks.Close()
 	return c.db.Close()
 }
 
+// NewLocker creates a new instance of keyval.Locker with locks held
+// in the memory of this process, since Bolt database cannot be shared
+// between processes. <prefix> is prepended to the names of all locks.
+func (c *Client) NewLocker(prefix string) keyval.Locker {
+	return c.locks.Prefixed(prefix)
+}
+
 // GetValue returns data for the given key
 func (c *Client) GetValue(key string) (data []byte, found bool, revision int64, err error) {
 	boltLogger.Debugf("GetValue: %q", key)
@@ -112,14 +136,17 @@ func (c *Client) GetValue(key string) (data []byte, found bool, revision int64,
 	return data, found, 0, err
 }
 
-// Put stores given data for the key
+// Put stores given data for the key. Options WithTTL and WithClientLifetimeTTL
+// are supported, expired keys are removed periodically.
 func (c *Client) Put(key string, data []byte, opts ...datasync.PutOption) (err error) {
 	boltLogger.Debugf("Put: %q (len=%d)", key, len(data))
 
-	prevVal, err := c.safeUpdate(&update{
+	u := &update{
 		key:   []byte(key),
 		value: data,
-	})
+	}
+	applyPutOptions(u, opts)
+	prevVal, err := c.safeUpdate(u)
 	if err != nil {
 		return err
 	}
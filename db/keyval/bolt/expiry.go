@@ -0,0 +1,183 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/boltdb/bolt"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+)
+
+// DefaultExpiryCheckInterval is the default interval between removals
+// of expired keys.
+const DefaultExpiryCheckInterval = time.Second
+
+var (
+	// ttlBucket maps keys with TTL to their expiration time.
+	ttlBucket = []byte("ttl")
+	// expiryBucket is the index of keys with TTL ordered by expiration time.
+	// Keys in the bucket are composed of the expiration time followed by the key.
+	expiryBucket = []byte("expiry")
+	// lifetimeBucket holds keys with client lifetime.
+	lifetimeBucket = []byte("lifetime")
+)
+
+// expiryKey returns the key of the expiry index entry for the given key.
+func expiryKey(expiry []byte, key []byte) []byte {
+	return append(append(make([]byte, 0, len(expiry)+len(key)), expiry...), key...)
+}
+
+// encodeExpiry encodes the expiration time so that the encoded
+// times are ordered.
+func encodeExpiry(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	return b
+}
+
+// updateExpiry updates the expiry index and client lifetime keys
+// for the applied update.
+func updateExpiry(tx *bolt.Tx, u *update) error {
+	ttls, index, lifetime := tx.Bucket(ttlBucket), tx.Bucket(expiryBucket), tx.Bucket(lifetimeBucket)
+
+	if expiry := ttls.Get(u.key); expiry != nil {
+		if err := index.Delete(expiryKey(expiry, u.key)); err != nil {
+			return err
+		}
+		if err := ttls.Delete(u.key); err != nil {
+			return err
+		}
+	}
+	if err := lifetime.Delete(u.key); err != nil {
+		return err
+	}
+	if u.value == nil {
+		return nil
+	}
+
+	if !u.expiry.IsZero() {
+		expiry := encodeExpiry(u.expiry)
+		if err := ttls.Put(u.key, expiry); err != nil {
+			return err
+		}
+		if err := index.Put(expiryKey(expiry, u.key), []byte{}); err != nil {
+			return err
+		}
+	}
+	if u.clientLifetime {
+		return lifetime.Put(u.key, []byte{})
+	}
+	return nil
+}
+
+// applyPutOptions sets TTL of the update according to the put options.
+func applyPutOptions(u *update, opts []datasync.PutOption) {
+	for _, o := range opts {
+		switch opt := o.(type) {
+		case *datasync.WithTTLOpt:
+			if opt.TTL > 0 {
+				u.expiry = time.Now().Add(opt.TTL)
+			}
+		case *datasync.WithClientLifetimeTTLOpt:
+			u.clientLifetime = true
+		}
+	}
+}
+
+// removeExpired returns function removing keys expired before <now>.
+func removeExpired(now time.Time) func(tx *bolt.Tx) ([]*kvPair, error) {
+	return func(tx *bolt.Tx) ([]*kvPair, error) {
+		var keys [][]byte
+		limit := encodeExpiry(now)
+		c := tx.Bucket(expiryBucket).Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k[:8], limit) <= 0; k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k[8:]...))
+		}
+		return removeKeys(tx, keys)
+	}
+}
+
+// removeClientLifetime removes all keys with client lifetime.
+func removeClientLifetime(tx *bolt.Tx) ([]*kvPair, error) {
+	var keys [][]byte
+	c := tx.Bucket(lifetimeBucket).Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	return removeKeys(tx, keys)
+}
+
+// removeKeys removes the keys together with their expiry and lifetime
+// entries and returns the removed key-value pairs.
+func removeKeys(tx *bolt.Tx, keys [][]byte) (removed []*kvPair, err error) {
+	bucket := tx.Bucket(rootBucket)
+	for _, key := range keys {
+		if value := bucket.Get(key); value != nil {
+			removed = append(removed, &kvPair{
+				Key:   string(key),
+				Value: append([]byte(nil), value...),
+			})
+			if err := bucket.Delete(key); err != nil {
+				return nil, err
+			}
+		}
+		if err := updateExpiry(tx, &update{key: key}); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+// startReaper periodically removes expired keys.
+func (c *Client) startReaper() {
+	defer c.wg.Done()
+
+	interval := c.cfg.ExpiryCheckInterval
+	if interval <= 0 {
+		interval = DefaultExpiryCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.removeKeys(removeExpired(time.Now()))
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// removeKeys removes keys selected by the function and notifies watchers.
+func (c *Client) removeKeys(remove func(tx *bolt.Tx) ([]*kvPair, error)) {
+	removed, err := c.safeRemove(remove)
+	if err != nil {
+		boltLogger.Warnf("Failed to remove keys: %v", err)
+		return
+	}
+	for _, pair := range removed {
+		boltLogger.Debugf("Removed %q", pair.Key)
+		c.bumpWatchers(&watchEvent{
+			Key:       pair.Key,
+			PrevValue: pair.Value,
+			Type:      datasync.Delete,
+		})
+	}
+}
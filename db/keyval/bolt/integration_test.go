@@ -17,17 +17,26 @@ package bolt
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/boltdb/bolt"
+	"github.com/gorilla/mux"
 	. "github.com/onsi/gomega"
+	"github.com/unrolled/render"
 
 	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/keyval"
 	"go.ligato.io/cn-infra/v2/logging"
 	"go.ligato.io/cn-infra/v2/logging/logrus"
+	"go.ligato.io/cn-infra/v2/rpc/rest"
+	access "go.ligato.io/cn-infra/v2/rpc/rest/security/model/access-security"
 )
 
 func init() {
@@ -533,3 +542,197 @@ func TestReuseCloseChannel(t *testing.T) {
 
 	Consistently(watchCh).ShouldNot(Receive())
 }
+
+func TestPutWithTTL(t *testing.T) {
+	RegisterTestingT(t)
+	client, err := NewClient(&Config{
+		DbPath:              filepath.Join(t.TempDir(), "bolt.db"),
+		FileMode:            432,
+		ExpiryCheckInterval: 10 * time.Millisecond,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	defer client.Close()
+
+	watchCh := make(chan keyval.BytesWatchResp, 10)
+	Expect(client.Watch(keyval.ToChan(watchCh), nil, "/key/")).To(Succeed())
+
+	Expect(client.Put("/key/ttl", []byte("expires"), datasync.WithTTL(50*time.Millisecond))).To(Succeed())
+	Expect(client.Put("/key/refreshed", []byte("expires"), datasync.WithTTL(50*time.Millisecond))).To(Succeed())
+	Expect(client.Put("/key/refreshed", []byte("persistent"))).To(Succeed())
+	for i := 0; i < 3; i++ {
+		Eventually(watchCh).Should(Receive())
+	}
+
+	var resp keyval.BytesWatchResp
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Delete))
+	Expect(resp.GetKey()).To(Equal("/key/ttl"))
+	Expect(resp.GetPrevValue()).To(Equal([]byte("expires")))
+
+	_, found, _, _ := client.GetValue("/key/ttl")
+	Expect(found).To(BeFalse())
+	Consistently(watchCh, 100*time.Millisecond).ShouldNot(Receive())
+	value, _, _, err := client.GetValue("/key/refreshed")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(value).To(Equal([]byte("persistent")))
+}
+
+func TestPutWithClientLifetimeTTL(t *testing.T) {
+	RegisterTestingT(t)
+	cfg := &Config{
+		DbPath:   filepath.Join(t.TempDir(), "bolt.db"),
+		FileMode: 432,
+	}
+	client, err := NewClient(cfg)
+	Expect(err).ToNot(HaveOccurred())
+
+	watchCh := make(chan keyval.BytesWatchResp, 10)
+	Expect(client.Watch(keyval.ToChan(watchCh), nil, "/key/")).To(Succeed())
+	Expect(client.Put("/key/lifetime", []byte("a"), datasync.WithClientLifetimeTTL())).To(Succeed())
+	Expect(client.Put("/key/persistent", []byte("b"))).To(Succeed())
+	Eventually(watchCh).Should(Receive())
+	Eventually(watchCh).Should(Receive())
+
+	Expect(client.Close()).To(Succeed())
+	var resp keyval.BytesWatchResp
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Delete))
+	Expect(resp.GetKey()).To(Equal("/key/lifetime"))
+
+	client, err = NewClient(cfg)
+	Expect(err).ToNot(HaveOccurred())
+	defer client.Close()
+	_, found, _, _ := client.GetValue("/key/lifetime")
+	Expect(found).To(BeFalse())
+	_, found, _, _ = client.GetValue("/key/persistent")
+	Expect(found).To(BeTrue())
+}
+
+func TestClientLifetimeAfterRestart(t *testing.T) {
+	RegisterTestingT(t)
+	cfg := &Config{
+		DbPath:   filepath.Join(t.TempDir(), "bolt.db"),
+		FileMode: 432,
+	}
+	client, err := NewClient(cfg)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(client.Put("/key/lifetime", []byte("a"), datasync.WithClientLifetimeTTL())).To(Succeed())
+
+	// simulate crash: the database is closed without removing the keys
+	close(client.quit)
+	client.wg.Wait()
+	Expect(client.db.Close()).To(Succeed())
+
+	client, err = NewClient(cfg)
+	Expect(err).ToNot(HaveOccurred())
+	defer client.Close()
+	_, found, _, _ := client.GetValue("/key/lifetime")
+	Expect(found).To(BeFalse())
+}
+
+func TestSnapshot(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	client, err := NewClient(&Config{DbPath: filepath.Join(dir, "bolt.db"), FileMode: 432})
+	Expect(err).ToNot(HaveOccurred())
+	defer client.Close()
+	Expect(client.Put("/key/a", []byte("a"))).To(Succeed())
+
+	var buf bytes.Buffer
+	size, err := client.Snapshot(&buf)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(size).To(BeEquivalentTo(buf.Len()))
+
+	// snapshot is a valid database with the data
+	snapshotPath := filepath.Join(dir, "restored.db")
+	Expect(ioutil.WriteFile(snapshotPath, buf.Bytes(), 0600)).To(Succeed())
+	restored, err := NewClient(&Config{DbPath: snapshotPath, FileMode: 432})
+	Expect(err).ToNot(HaveOccurred())
+	defer restored.Close()
+	val, found, _, err := restored.GetValue("/key/a")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(val).To(Equal([]byte("a")))
+}
+
+func TestPeriodicSnapshot(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	snapshotDir := filepath.Join(dir, "snapshots")
+	// snapshot of other database with the same prefix is not removed
+	other := filepath.Join(snapshotDir, "bolt-backup-20231018-100000.000"+snapshotExt)
+	Expect(os.MkdirAll(snapshotDir, 0755)).To(Succeed())
+	Expect(ioutil.WriteFile(other, nil, 0600)).To(Succeed())
+	client, err := NewClient(&Config{
+		DbPath:            filepath.Join(dir, "bolt.db"),
+		FileMode:          432,
+		SnapshotDir:       snapshotDir,
+		SnapshotInterval:  20 * time.Millisecond,
+		SnapshotRetention: 2,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	defer client.Close()
+
+	snapshots := func() []string {
+		files, _ := filepath.Glob(filepath.Join(snapshotDir, "bolt-2*"+snapshotExt))
+		return files
+	}
+	Eventually(snapshots).Should(HaveLen(2))
+	first := snapshots()
+	// older snapshots are removed as new ones are written
+	Eventually(snapshots).ShouldNot(ContainElement(first[0]))
+	Consistently(snapshots, 100*time.Millisecond).Should(HaveLen(2))
+	Expect(other).To(BeAnExistingFile())
+}
+
+func TestSnapshotHandler(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	p := NewPlugin()
+	p.Config = &Config{DbPath: filepath.Join(dir, "bolt.db"), FileMode: 432}
+	Expect(p.Init()).To(Succeed())
+	defer p.Close()
+	Expect(p.boltClient.Put("/key/a", []byte("a"))).To(Succeed())
+
+	rec := httptest.NewRecorder()
+	p.snapshotHandler(render.New())(rec, httptest.NewRequest("GET", p.snapshotPath(), nil))
+	Expect(rec.Code).To(Equal(http.StatusOK))
+	Expect(rec.Header().Get("Content-Type")).To(Equal("application/octet-stream"))
+	Expect(rec.Header().Get("Content-Length")).To(Equal(strconv.Itoa(rec.Body.Len())))
+	Expect(rec.Header().Get("Content-Disposition")).To(ContainSubstring("bolt-"))
+}
+
+// httpHandlers records paths of registered REST handlers.
+type httpHandlers struct {
+	paths []string
+}
+
+func (h *httpHandlers) RegisterHTTPHandler(path string, provider rest.HandlerProvider, methods ...string) *mux.Route {
+	h.paths = append(h.paths, path)
+	return nil
+}
+
+func (h *httpHandlers) RegisterPermissionGroup(group ...*access.PermissionGroup) {}
+
+func (h *httpHandlers) GetPort() int { return 0 }
+
+func TestSnapshotEndpoint(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+
+	// the endpoint is absent by default
+	p := NewPlugin()
+	Expect(p.HTTP).To(BeNil())
+	p.Config = &Config{DbPath: filepath.Join(dir, "bolt.db"), FileMode: 432}
+	Expect(p.Init()).To(Succeed())
+	Expect(p.AfterInit()).To(Succeed())
+	Expect(p.Close()).To(Succeed())
+
+	handlers := &httpHandlers{}
+	p = NewPlugin(UseHTTP(handlers))
+	p.Config = &Config{DbPath: filepath.Join(dir, "bolt.db"), FileMode: 432}
+	Expect(p.Init()).To(Succeed())
+	defer p.Close()
+	Expect(p.AfterInit()).To(Succeed())
+	Expect(handlers.paths).To(ConsistOf("/bolt/snapshot"))
+}
@@ -14,6 +14,10 @@
 
 package bolt
 
+import (
+	"go.ligato.io/cn-infra/v2/rpc/rest"
+)
+
 // DefaultPlugin is a default instance of Plugin.
 var DefaultPlugin = *NewPlugin()
 
@@ -41,3 +45,11 @@ func UseDeps(cb func(*Deps)) Option {
 		cb(&p.Deps)
 	}
 }
+
+// UseHTTP returns Option that sets HTTP handlers used to serve
+// snapshots of the database.
+func UseHTTP(h rest.HTTPHandlers) Option {
+	return func(p *Plugin) {
+		p.Deps.HTTP = h
+	}
+}
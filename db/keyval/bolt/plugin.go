@@ -15,12 +15,19 @@
 package bolt
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"time"
 
+	"github.com/unrolled/render"
+
 	"go.ligato.io/cn-infra/v2/db/keyval"
 	"go.ligato.io/cn-infra/v2/db/keyval/kvproto"
 	"go.ligato.io/cn-infra/v2/infra"
+	"go.ligato.io/cn-infra/v2/rpc/rest"
 )
 
 // Config represents configuration for Bolt plugin.
@@ -29,6 +36,14 @@ type Config struct {
 	FileMode        os.FileMode   `json:"file-mode"`
 	LockTimeout     time.Duration `json:"lock-timeout"`
 	FilterDupNotifs bool          `json:"filter-duplicate-notifications"`
+	// ExpiryCheckInterval is the interval between removals of keys with expired TTL.
+	ExpiryCheckInterval time.Duration `json:"expiry-check-interval"`
+	// SnapshotDir is the directory for periodic snapshots of the database.
+	SnapshotDir string `json:"snapshot-dir"`
+	// SnapshotInterval is the interval between periodic snapshots (disabled if zero).
+	SnapshotInterval time.Duration `json:"snapshot-interval"`
+	// SnapshotRetention is the number of the most recent periodic snapshots which are kept.
+	SnapshotRetention int `json:"snapshot-retention"`
 }
 
 // Plugin implements bolt plugin.
@@ -46,8 +61,11 @@ type Plugin struct {
 
 // Deps lists dependencies of the Bolt plugin.
 // If injected, Bolt plugin will use StatusCheck to signal the connection status.
+// If HTTP is set (see UseHTTP), snapshot of the database can be downloaded from the REST endpoint.
+// The endpoint is not authenticated by the plugin, so HTTP is not set by default.
 type Deps struct {
 	infra.PluginDeps
+	HTTP rest.HTTPHandlers // optional
 }
 
 // Disabled returns *true* if the plugin is not in use due to missing configuration.
@@ -84,6 +102,26 @@ func (p *Plugin) Init() (err error) {
 	return nil
 }
 
+// AfterInit registers REST handler for downloading snapshots of the database
+// if HTTP handlers were set:
+//
+//	> curl -X GET http://localhost:<port>/bolt/snapshot -o bolt.db
+func (p *Plugin) AfterInit() error {
+	if p.HTTP != nil && !p.disabled {
+		p.HTTP.RegisterHTTPHandler(p.snapshotPath(), p.snapshotHandler, "GET")
+		p.Log.Infof("Snapshots of %s are available at %s", p.Config.DbPath, p.snapshotPath())
+	}
+	return nil
+}
+
+// Snapshot writes a consistent copy of the database into <w>.
+func (p *Plugin) Snapshot(w io.Writer) (size int64, err error) {
+	if p.boltClient == nil {
+		return 0, fmt.Errorf("bolt database is not opened")
+	}
+	return p.boltClient.Snapshot(w)
+}
+
 // Close closes the Bolt client.
 func (p *Plugin) Close() error {
 	if p.boltClient != nil {
@@ -102,6 +140,46 @@ func (p *Plugin) NewWatcher(keyPrefix string) keyval.ProtoWatcher {
 	return p.protoWrapper.NewWatcher(keyPrefix)
 }
 
+// NewLocker creates new instance of prefixed locker with locks held in memory.
+func (p *Plugin) NewLocker(keyPrefix string) keyval.Locker {
+	return p.boltClient.NewLocker(keyPrefix)
+}
+
+// RawAccess allows to access data in the database as raw bytes (i.e. not formatted by protobuf).
+func (p *Plugin) RawAccess() keyval.KvBytesPlugin {
+	return p.boltClient
+}
+
+func (p *Plugin) snapshotPath() string {
+	return "/" + p.String() + "/snapshot"
+}
+
+// snapshotHandler sends a snapshot of the database as a Bolt database file. The snapshot is written
+// into a temporary file first, so that the read transaction is not held while the file is downloaded.
+func (p *Plugin) snapshotHandler(formatter *render.Render) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		now := time.Now()
+		tmp, err := ioutil.TempFile("", "."+p.String()+"-snapshot")
+		if err != nil {
+			p.Log.Errorf("Snapshot of %s failed: %v", p.Config.DbPath, err)
+			formatter.Text(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if _, err := p.boltClient.Snapshot(tmp); err != nil {
+			p.Log.Errorf("Snapshot of %s failed: %v", p.Config.DbPath, err)
+			formatter.Text(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		name := snapshotName(p.String(), now)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		http.ServeContent(w, req, name, now, tmp)
+	}
+}
+
 func (p *Plugin) getConfig() (*Config, error) {
 	var cfg Config
 	found, err := p.Cfg.LoadValue(&cfg)
@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	// DefaultSnapshotRetention is the default number of periodic snapshots kept in the snapshot directory.
+	DefaultSnapshotRetention = 3
+
+	// snapshotExt is the extension of snapshot files.
+	snapshotExt = ".snapshot"
+	// snapshotTimeFormat is the format of time in names of snapshot files,
+	// which keeps the lexical order of names equal to the order of creation.
+	snapshotTimeFormat = "20060102-150405.000"
+)
+
+// Snapshot writes a consistent copy of the whole database into <w>.
+// The copy is taken in a read transaction, therefore writes are not blocked
+// while the snapshot is written. The written copy is a valid Bolt database file.
+// The read transaction is held until the whole snapshot is written, which
+// prevents Bolt from reusing pages freed in the meantime, so <w> should not
+// be a slow writer (e.g. a network connection).
+func (c *Client) Snapshot(w io.Writer) (size int64, err error) {
+	err = c.db.View(func(tx *bolt.Tx) error {
+		size, err = tx.WriteTo(w)
+		return err
+	})
+	return size, err
+}
+
+// SnapshotToDir writes a snapshot of the database into a new file in <dir>
+// and returns its path. The file is written under a temporary name first and
+// renamed once it is complete, therefore the directory contains only complete snapshots.
+func (c *Client) SnapshotToDir(dir string) (path string, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := snapshotName(c.cfg.DbPath, time.Now())
+	path = filepath.Join(dir, name)
+
+	tmp, err := ioutil.TempFile(dir, "."+name+".tmp")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = c.Snapshot(tmp); err != nil {
+		return "", err
+	}
+	if err = tmp.Sync(); err != nil {
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// snapshotName returns name of the snapshot of the database taken at the given time.
+func snapshotName(dbPath string, t time.Time) string {
+	return fmt.Sprintf("%s-%s%s", snapshotBase(dbPath), t.UTC().Format(snapshotTimeFormat), snapshotExt)
+}
+
+// snapshotBase returns the database file name without extension, used as prefix of snapshot names.
+func snapshotBase(dbPath string) string {
+	return strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+}
+
+// isSnapshotOf returns true if the file name is a name of the snapshot of the database,
+// i.e. the database name is followed only by the time of the snapshot. Snapshots of other
+// databases sharing the prefix of the name (e.g. "db" and "db-backup") are not matched.
+func isSnapshotOf(dbPath, name string) bool {
+	prefix := snapshotBase(dbPath) + "-"
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, snapshotExt) {
+		return false
+	}
+	_, err := time.Parse(snapshotTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), snapshotExt))
+	return err == nil
+}
+
+// removeOldSnapshots removes snapshots of the database from <dir>
+// except for the <retain> most recent ones.
+func (c *Client) removeOldSnapshots(dir string, retain int) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var snapshots []string
+	for _, file := range files {
+		if !file.IsDir() && isSnapshotOf(c.cfg.DbPath, file.Name()) {
+			snapshots = append(snapshots, filepath.Join(dir, file.Name()))
+		}
+	}
+	if len(snapshots) <= retain {
+		return nil
+	}
+	sort.Strings(snapshots)
+	for _, path := range snapshots[:len(snapshots)-retain] {
+		boltLogger.Debugf("Removing old snapshot %s", path)
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startSnapshotter periodically writes snapshots of the database into the snapshot
+// directory and removes the old ones.
+func (c *Client) startSnapshotter() {
+	defer c.wg.Done()
+
+	retain := c.cfg.SnapshotRetention
+	if retain <= 0 {
+		retain = DefaultSnapshotRetention
+	}
+	ticker := time.NewTicker(c.cfg.SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			path, err := c.SnapshotToDir(c.cfg.SnapshotDir)
+			if err != nil {
+				boltLogger.Errorf("Periodic snapshot failed: %v", err)
+				continue
+			}
+			boltLogger.Debugf("Snapshot written to %s", path)
+			if err := c.removeOldSnapshots(c.cfg.SnapshotDir, retain); err != nil {
+				boltLogger.Warnf("Failed to remove old snapshots: %v", err)
+			}
+		case <-c.quit:
+			return
+		}
+	}
+}
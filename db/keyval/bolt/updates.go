@@ -36,24 +36,51 @@ const minimumTimeout = time.Second / 100
 
 type updateTx struct {
 	updates []*update
-	done    chan *result
+	// remove removes keys selected by the function instead of applying updates
+	remove func(tx *bolt.Tx) ([]*kvPair, error)
+	done   chan *result
 }
 
 type update struct {
 	key   []byte
 	value []byte
+	// expiry is the time when the key expires (zero if the key does not expire)
+	expiry time.Time
+	// clientLifetime marks keys removed once the client is closed
+	clientLifetime bool
 }
 
 type result struct {
 	prevValue []byte
+	removed   []*kvPair
 	err       error
 }
 
 func (c *Client) safeUpdate(updates ...*update) (prevVal []byte, err error) {
-	tx := &updateTx{
+	r, err := c.sendUpdate(&updateTx{
 		updates: updates,
 		done:    make(chan *result, 1),
+	})
+	if err != nil {
+		return nil, err
 	}
+	return r.prevValue, r.err
+}
+
+// safeRemove removes keys selected by the <remove> function in the updater
+// and returns the removed key-value pairs.
+func (c *Client) safeRemove(remove func(tx *bolt.Tx) ([]*kvPair, error)) ([]*kvPair, error) {
+	r, err := c.sendUpdate(&updateTx{
+		remove: remove,
+		done:   make(chan *result, 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.removed, r.err
+}
+
+func (c *Client) sendUpdate(tx *updateTx) (*result, error) {
 	timeoutDur := DefaultSafeUpdateTimeout
 	if timeoutDur < minimumTimeout {
 		timeoutDur = minimumTimeout
@@ -71,7 +98,7 @@ func (c *Client) safeUpdate(updates ...*update) (prevVal []byte, err error) {
 		if r == nil {
 			return nil, errors.New("bolt: update failed")
 		}
-		return r.prevValue, r.err
+		return r, nil
 	case <-time.After(timeoutDur):
 		return nil, errors.New("bolt: update timeout")
 	}
@@ -83,7 +110,11 @@ func (c *Client) startUpdater() {
 		select {
 		case utx := <-c.updateChan:
 			r := &result{}
-			r.err = c.db.Update(func(tx *bolt.Tx) error {
+			r.err = c.db.Update(func(tx *bolt.Tx) (err error) {
+				if utx.remove != nil {
+					r.removed, err = utx.remove(tx)
+					return err
+				}
 				bucket := tx.Bucket(rootBucket)
 				if len(utx.updates) == 1 {
 					u := utx.updates[0]
@@ -104,6 +135,9 @@ func (c *Client) startUpdater() {
 					if err != nil {
 						return err
 					}
+					if err := updateExpiry(tx, u); err != nil {
+						return err
+					}
 				}
 				return nil
 			})
@@ -40,7 +40,7 @@ type BytesBroker interface {
 }
 
 // BytesBrokerWithAtomic extends BytesBroker with atomic operations.
-// Currently only etcd plugin supports atomic operations.
+// Currently etcd, redis and consul plugins support atomic operations.
 type BytesBrokerWithAtomic interface {
 	BytesBroker
 
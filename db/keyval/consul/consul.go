@@ -15,11 +15,14 @@
 package consul
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/keyval"
@@ -44,6 +47,18 @@ func transformKey(key string) string {
 // Client serves as a client for Consul KV storage and implements keyval.CoreBrokerWatcher interface.
 type Client struct {
 	client *api.Client
+
+	// sessionTTL is the TTL of the session holding keys put with the client lifetime TTL
+	sessionTTL time.Duration
+
+	sessionMu sync.Mutex
+	// session holding keys put with the client lifetime TTL (created with the first use)
+	session string
+	// heldKeys are keys put with TTL by this client and their sessions
+	heldKeys map[string]*keySession
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
 }
 
 // NewClient creates new client for Consul using given address.
@@ -60,16 +75,60 @@ func NewClient(cfg *api.Config) (store *Client, err error) {
 	consulLogger.Infof("consul peers: %v", peers)
 
 	return &Client{
-		client: c,
+		client:     c,
+		sessionTTL: defaultSessionTTL,
+		heldKeys:   make(map[string]*keySession),
+		closeCh:    make(chan struct{}),
 	}, nil
 
 }
 
 // Put stores given data for the key.
+// The key put with datasync.WithTTL is held by a new session with the given TTL
+// (at least 10s, Consul may remove the key up to twice the TTL later).
+// The key put with datasync.WithClientLifetimeTTL is held by a session which is
+// renewed until the client is closed. Once the session expires, Consul removes
+// the key.
 func (c *Client) Put(key string, data []byte, opts ...datasync.PutOption) error {
 	consulLogger.Debugf("Put: %q", key)
-	p := &api.KVPair{Key: transformKey(key), Value: data}
-	_, err := c.client.KV().Put(p, nil)
+	key = transformKey(key)
+
+	session, err := c.putSession(key, opts)
+	if err != nil {
+		return err
+	}
+
+	if held := c.heldKey(key); held != nil && (session == nil || held.id != session.id) {
+		// release writes the value only if the key is still held by the session
+		p := &api.KVPair{Key: key, Value: data, Session: held.id}
+		released, _, err := c.client.KV().Release(p, nil)
+		if err != nil {
+			return err
+		}
+		c.releaseKey(key)
+		if released && session == nil {
+			return nil
+		}
+	}
+
+	if session != nil {
+		p := &api.KVPair{Key: key, Value: data, Session: session.id}
+		acquired, _, err := c.client.KV().Acquire(p, nil)
+		if err == nil && !acquired {
+			err = fmt.Errorf("key %q is held by another session", key)
+		}
+		if err != nil {
+			if !session.lifetime {
+				c.destroySession(session.id)
+			}
+			return err
+		}
+		c.holdKey(key, session)
+		return nil
+	}
+
+	p := &api.KVPair{Key: key, Value: data}
+	_, err = c.client.KV().Put(p, nil)
 	if err != nil {
 		return err
 	}
@@ -77,6 +136,69 @@ func (c *Client) Put(key string, data []byte, opts ...datasync.PutOption) error
 	return nil
 }
 
+// PutIfNotExists puts given key-value pair into Consul if there is no value set for the key.
+// If the put was successful, <succeeded> is returned as true. If the key already exists, <succeeded> is returned
+// as false and the value for the key is untouched.
+func (c *Client) PutIfNotExists(key string, data []byte) (succeeded bool, err error) {
+	consulLogger.Debugf("PutIfNotExists: %q", key)
+	// zero ModifyIndex requires the key not to exist
+	p := &api.KVPair{Key: transformKey(key), Value: data, ModifyIndex: 0}
+	succeeded, _, err = c.client.KV().CAS(p, nil)
+	return succeeded, err
+}
+
+// CompareAndSwap compares the value currently stored under the given key with the expected <oldData>,
+// and only if the expected and actual data match, the value is then changed to <newData>.
+// The value is changed using check-and-set with the ModifyIndex of the compared value, therefore
+// it cannot be interleaved with another change of the key.
+func (c *Client) CompareAndSwap(key string, oldData, newData []byte) (swapped bool, err error) {
+	consulLogger.Debugf("CompareAndSwap: %q", key)
+	key = transformKey(key)
+	for {
+		pair, _, err := c.client.KV().Get(key, nil)
+		if err != nil {
+			return false, err
+		}
+		if pair == nil || !bytes.Equal(pair.Value, oldData) {
+			return false, nil
+		}
+		p := &api.KVPair{Key: key, Value: newData, ModifyIndex: pair.ModifyIndex}
+		swapped, _, err = c.client.KV().CAS(p, nil)
+		if err != nil || swapped {
+			return swapped, err
+		}
+		// the key was changed in the meantime, compare again
+	}
+}
+
+// CompareAndDelete compares the value currently stored under the given key with the expected <data>,
+// and only if the expected and actual data match, the value is then removed from Consul.
+// The value is removed using check-and-set with the ModifyIndex of the compared value, therefore
+// it cannot be interleaved with another change of the key.
+func (c *Client) CompareAndDelete(key string, data []byte) (deleted bool, err error) {
+	consulLogger.Debugf("CompareAndDelete: %q", key)
+	key = transformKey(key)
+	for {
+		pair, _, err := c.client.KV().Get(key, nil)
+		if err != nil {
+			return false, err
+		}
+		if pair == nil || !bytes.Equal(pair.Value, data) {
+			return false, nil
+		}
+		p := &api.KVPair{Key: key, ModifyIndex: pair.ModifyIndex}
+		deleted, _, err = c.client.KV().DeleteCAS(p, nil)
+		if err != nil {
+			return false, err
+		}
+		if deleted {
+			c.releaseKey(key)
+			return true, nil
+		}
+		// the key was changed in the meantime, compare again
+	}
+}
+
 // NewTxn creates new transaction.
 func (c *Client) NewTxn() keyval.BytesTxn {
 	return &txn{
@@ -123,6 +245,7 @@ func (c *Client) Delete(key string, opts ...datasync.DelOption) (existed bool, e
 	if _, err := c.client.KV().Delete(transformKey(key), nil); err != nil {
 		return false, err
 	}
+	c.releaseKey(transformKey(key))
 
 	return true, nil
 }
@@ -208,6 +331,8 @@ func (c *Client) watch(resp func(watchResp keyval.BytesWatchResp), closeCh chan
 							value: ev.Value,
 							rev:   ev.Revision,
 						}
+						// key held by this client may have been removed by expiration of its session
+						c.pruneKey(ev.Key)
 					}
 					resp(r)
 				}
@@ -329,8 +454,12 @@ func (c *Client) watchPrefix(ctx context.Context, prefix string) <-chan watchRes
 	return ch
 }
 
-// Close returns nil.
+// Close stops renewal of the session holding the keys put with the client lifetime TTL.
+// The session is destroyed and Consul removes the keys.
 func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+	})
 	return nil
 }
 
@@ -385,6 +514,24 @@ func (pdb *BrokerWatcher) NewTxn() keyval.BytesTxn {
 	return pdb.Client.NewTxn()
 }
 
+// PutIfNotExists calls 'PutIfNotExists' function of the underlying Client.
+// KeyPrefix defined in constructor is prepended to the key argument.
+func (pdb *BrokerWatcher) PutIfNotExists(key string, data []byte) (succeeded bool, err error) {
+	return pdb.Client.PutIfNotExists(pdb.prefixKey(key), data)
+}
+
+// CompareAndSwap calls 'CompareAndSwap' function of the underlying Client.
+// KeyPrefix defined in constructor is prepended to the key argument.
+func (pdb *BrokerWatcher) CompareAndSwap(key string, oldData, newData []byte) (swapped bool, err error) {
+	return pdb.Client.CompareAndSwap(pdb.prefixKey(key), oldData, newData)
+}
+
+// CompareAndDelete calls 'CompareAndDelete' function of the underlying Client.
+// KeyPrefix defined in constructor is prepended to the key argument.
+func (pdb *BrokerWatcher) CompareAndDelete(key string, data []byte) (deleted bool, err error) {
+	return pdb.Client.CompareAndDelete(pdb.prefixKey(key), data)
+}
+
 // GetValue calls 'GetValue' function of the underlying BytesConnectionEtcd.
 // KeyPrefix defined in constructor is prepended to the key argument.
 func (pdb *BrokerWatcher) GetValue(key string) (data []byte, found bool, revision int64, err error) {
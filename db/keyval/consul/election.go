@@ -0,0 +1,226 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+)
+
+// observeRetryInterval is the interval between attempts to observe
+// the leader after failed query.
+const observeRetryInterval = time.Second
+
+// election implements keyval.Election using Consul lock on the election key.
+// The value of the leader is stored as the value of the key.
+type election struct {
+	client *Client
+	key    string
+
+	mu       sync.Mutex
+	lock     *api.Lock
+	session  string
+	lost     chan struct{}
+	resigned chan struct{}
+}
+
+// NewElection creates a new instance of keyval.Election for the given <prefix>.
+// Leadership is bound to the Consul session created by the campaign.
+func (c *Client) NewElection(prefix string) keyval.Election {
+	e := &election{
+		client: c,
+		key:    transformKey(prefix),
+		lost:   make(chan struct{}),
+	}
+	close(e.lost)
+	return e
+}
+
+// Campaign puts the caller into the election with the given <value>.
+// The call blocks until either the caller is elected as the leader or
+// the context is canceled.
+func (e *election) Campaign(ctx context.Context, value string) error {
+	consulLogger.Debugf("Campaign: %q", e.key)
+
+	e.mu.Lock()
+	leader, session := e.isLeader(), e.session
+	e.mu.Unlock()
+	if leader {
+		// update the value of the leader
+		_, _, err := e.client.client.KV().Acquire(&api.KVPair{
+			Key:     e.key,
+			Value:   []byte(value),
+			Flags:   api.LockFlagValue,
+			Session: session,
+		}, (&api.WriteOptions{}).WithContext(ctx))
+		return err
+	}
+
+	consulLock, err := e.client.client.LockOpts(&api.LockOptions{
+		Key:         e.key,
+		Value:       []byte(value),
+		SessionName: "cn-infra-election",
+	})
+	if err != nil {
+		return err
+	}
+
+	// abort the campaign once the context is canceled
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stopCh)
+		case <-done:
+		}
+	}()
+	leaderCh, err := consulLock.Lock(stopCh)
+	if err != nil {
+		return err
+	}
+	if leaderCh == nil {
+		return ctx.Err()
+	}
+
+	pair, _, err := e.client.client.KV().Get(e.key, nil)
+	if err != nil || pair == nil {
+		consulLock.Unlock()
+		if err == nil {
+			err = keyval.ErrNotLeader
+		}
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lock = consulLock
+	e.session = pair.Session
+	e.lost = make(chan struct{})
+	e.resigned = make(chan struct{})
+	go e.monitor(leaderCh, e.lost, e.resigned)
+	return nil
+}
+
+// monitor closes <lost> channel once either the Consul session
+// is invalidated or the leader resigns.
+func (e *election) monitor(leaderCh <-chan struct{}, lost, resigned chan struct{}) {
+	select {
+	case <-leaderCh:
+		consulLogger.WithField("election", e.key).Warn("Leadership lost")
+	case <-resigned:
+	}
+	close(lost)
+}
+
+// Resign gives up the leadership so that a new leader can be elected.
+func (e *election) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.isLeader() {
+		return keyval.ErrNotLeader
+	}
+	err := e.lock.Unlock()
+	close(e.resigned)
+	<-e.lost
+	if err == api.ErrLockNotHeld {
+		err = keyval.ErrNotLeader
+	}
+	return err
+}
+
+// Leader returns the value of the current leader.
+func (e *election) Leader(ctx context.Context) (string, error) {
+	pair, _, err := e.client.client.KV().Get(e.key, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	if pair == nil || pair.Session == "" {
+		return "", keyval.ErrNoLeader
+	}
+	return string(pair.Value), nil
+}
+
+// Observe returns a channel that receives the value of the current leader
+// whenever the leader changes.
+func (e *election) Observe(ctx context.Context) <-chan string {
+	leaders := make(chan string)
+	go func() {
+		defer close(leaders)
+
+		var (
+			lastIndex  uint64
+			lastLeader string
+			hasLeader  bool
+		)
+		for {
+			opts := (&api.QueryOptions{WaitIndex: lastIndex}).WithContext(ctx)
+			pair, meta, err := e.client.client.KV().Get(e.key, opts)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				consulLogger.Warnf("Observe %q failed: %v", e.key, err)
+				select {
+				case <-time.After(observeRetryInterval):
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			lastIndex = meta.LastIndex
+
+			if pair == nil || pair.Session == "" {
+				hasLeader = false
+				continue
+			}
+			if hasLeader && string(pair.Value) == lastLeader {
+				continue
+			}
+			hasLeader, lastLeader = true, string(pair.Value)
+			select {
+			case leaders <- lastLeader:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return leaders
+}
+
+// Lost returns a channel that is closed once the leadership is lost.
+func (e *election) Lost() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lost
+}
+
+// isLeader returns true if the leadership gained by the last Campaign
+// has not been lost yet. Must be called with mu locked.
+func (e *election) isLeader() bool {
+	select {
+	case <-e.lost:
+		return false
+	default:
+		return true
+	}
+}
@@ -15,12 +15,15 @@
 package consul
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil"
 	. "github.com/onsi/gomega"
 
+	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/keyval"
 	"go.ligato.io/cn-infra/v2/logging"
 	"go.ligato.io/cn-infra/v2/logging/logrus"
@@ -320,3 +323,149 @@ func TestWatch(t *testing.T) {
 		return ""
 	}).Should(Equal(watchKey + "val1"))
 }
+
+func TestAtomicOps(t *testing.T) {
+	ctx := setupTest(t)
+	defer ctx.teardownTest()
+
+	var broker keyval.BytesBrokerWithAtomic = ctx.client.NewBroker("atomic/").(keyval.BytesBrokerWithAtomic)
+
+	succeeded, err := broker.PutIfNotExists("key", []byte("v1"))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(succeeded).To(BeTrue())
+	succeeded, err = broker.PutIfNotExists("key", []byte("v2"))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(succeeded).To(BeFalse())
+
+	swapped, err := broker.CompareAndSwap("key", []byte("v2"), []byte("v3"))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(swapped).To(BeFalse())
+	swapped, err = broker.CompareAndSwap("key", []byte("v1"), []byte("v3"))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(swapped).To(BeTrue())
+	Expect(ctx.testSrv.GetKVString(t, "atomic/key")).To(Equal("v3"))
+
+	deleted, err := broker.CompareAndDelete("key", []byte("v1"))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(deleted).To(BeFalse())
+	deleted, err = broker.CompareAndDelete("key", []byte("v3"))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(deleted).To(BeTrue())
+
+	_, found, _, err := broker.GetValue("key")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeFalse())
+}
+
+func TestPutWithClientLifetimeTTL(t *testing.T) {
+	ctx := setupTest(t)
+	defer ctx.teardownTest()
+
+	err := ctx.client.Put("lifetime", []byte("val"), datasync.WithClientLifetimeTTL())
+	Expect(err).ToNot(HaveOccurred())
+	err = ctx.client.Put("ttl", []byte("val"), datasync.WithTTL(time.Minute))
+	Expect(err).ToNot(HaveOccurred())
+	err = ctx.client.Put("persistent", []byte("val"))
+	Expect(err).ToNot(HaveOccurred())
+
+	// overwritten without TTL, the key is released from its session
+	err = ctx.client.Put("ttl", []byte("val2"))
+	Expect(err).ToNot(HaveOccurred())
+	pair, _, err := ctx.client.client.KV().Get("ttl", nil)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(pair.Session).To(BeEmpty())
+	Expect(pair.Value).To(Equal([]byte("val2")))
+
+	// keys put with the client lifetime TTL are removed once the client is closed
+	other, err := NewClient(&api.Config{Address: ctx.testSrv.HTTPAddr})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(ctx.client.Close()).To(Succeed())
+	Eventually(func() bool {
+		_, found, _, _ := other.GetValue("lifetime")
+		return found
+	}, 5*time.Second).Should(BeFalse())
+
+	_, found, _, err := other.GetValue("ttl")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	_, found, _, err = other.GetValue("persistent")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+}
+
+func TestHeldKeysPruned(t *testing.T) {
+	ctx := setupTest(t)
+	defer ctx.teardownTest()
+
+	Expect(ctx.client.Watch(func(keyval.BytesWatchResp) {}, nil, "ttl")).To(Succeed())
+	err := ctx.client.Put("ttl", []byte("val"), datasync.WithTTL(time.Minute))
+	Expect(err).ToNot(HaveOccurred())
+	held := ctx.client.heldKey("ttl")
+	Expect(held).ToNot(BeNil())
+
+	// session invalidated before its TTL elapsed, Consul removes the key
+	_, err = ctx.client.client.Session().Destroy(held.id, nil)
+	Expect(err).ToNot(HaveOccurred())
+	Eventually(func() *keySession {
+		return ctx.client.heldKey("ttl")
+	}, 5*time.Second).Should(BeNil())
+
+	// key expired without watch
+	Expect(ctx.client.Put("expired", []byte("val"), datasync.WithTTL(minSessionTTL))).To(Succeed())
+	Expect(ctx.client.heldKey("expired")).ToNot(BeNil())
+	Eventually(func() *keySession {
+		return ctx.client.heldKey("expired")
+	}, 5*minSessionTTL, time.Second).Should(BeNil())
+}
+
+func TestLocker(t *testing.T) {
+	ctx := setupTest(t)
+	defer ctx.teardownTest()
+
+	locker := ctx.client.NewLocker("locks/")
+
+	lock, err := locker.TryLock(context.Background(), "lock1")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(lock.Name()).To(Equal("locks/lock1"))
+	Expect(lock.Token()).NotTo(BeZero())
+
+	_, err = locker.TryLock(context.Background(), "lock1")
+	Expect(err).To(Equal(keyval.ErrLockHeld))
+
+	Expect(lock.Unlock(context.Background())).To(Succeed())
+	Eventually(lock.Lost()).Should(BeClosed())
+
+	lock2, err := locker.Lock(context.Background(), "lock1")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(lock2.Token()).To(BeNumerically(">", lock.Token()))
+	Expect(lock2.Unlock(context.Background())).To(Succeed())
+}
+
+func TestElection(t *testing.T) {
+	ctx := setupTest(t)
+	defer ctx.teardownTest()
+
+	election1 := ctx.client.NewElection("election")
+	election2 := ctx.client.NewElection("election")
+
+	_, err := election1.Leader(context.Background())
+	Expect(err).To(Equal(keyval.ErrNoLeader))
+	Expect(election1.Lost()).To(BeClosed())
+
+	leaders := election2.Observe(context.Background())
+
+	Expect(election1.Campaign(context.Background(), "agent1")).To(Succeed())
+	Expect(election1.Lost()).NotTo(BeClosed())
+	Eventually(leaders).Should(Receive(Equal("agent1")))
+	leader, err := election2.Leader(context.Background())
+	Expect(err).ToNot(HaveOccurred())
+	Expect(leader).To(Equal("agent1"))
+
+	lost := election1.Lost()
+	Expect(election1.Resign(context.Background())).To(Succeed())
+	Expect(lost).To(BeClosed())
+
+	Expect(election2.Campaign(context.Background(), "agent2")).To(Succeed())
+	Eventually(leaders).Should(Receive(Equal("agent2")))
+	Expect(election2.Resign(context.Background())).To(Succeed())
+}
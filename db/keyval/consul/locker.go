@@ -0,0 +1,171 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+)
+
+// tryLockWaitTime is the time TryLock waits for the lock to be acquired.
+const tryLockWaitTime = 10 * time.Millisecond
+
+// locker implements keyval.Locker using Consul locks. Each lock is bound
+// to its own Consul session which is renewed until the lock is released.
+type locker struct {
+	client *Client
+	prefix string
+}
+
+// lock is a lock acquired from locker.
+type lock struct {
+	consulLock *api.Lock
+	name       string
+	token      int64
+
+	lost     chan struct{}
+	unlocked chan struct{}
+	once     sync.Once
+}
+
+// NewLocker creates a new instance of keyval.Locker that uses Consul
+// sessions to hold the locks. <prefix> is prepended to the names
+// of all locks.
+func (c *Client) NewLocker(prefix string) keyval.Locker {
+	return &locker{
+		client: c,
+		prefix: prefix,
+	}
+}
+
+// Lock acquires the lock with the given <name>. The call blocks until
+// either the lock is acquired or the context is canceled.
+func (l *locker) Lock(ctx context.Context, name string) (keyval.Lock, error) {
+	return l.lock(ctx, name, false)
+}
+
+// TryLock attempts to acquire the lock with the given <name> without
+// waiting. If the lock is held by another owner, keyval.ErrLockHeld
+// is returned.
+func (l *locker) TryLock(ctx context.Context, name string) (keyval.Lock, error) {
+	return l.lock(ctx, name, true)
+}
+
+func (l *locker) lock(ctx context.Context, name string, try bool) (keyval.Lock, error) {
+	key := transformKey(l.prefix + name)
+	consulLogger.Debugf("Lock: %q (try=%v)", key, try)
+
+	opts := &api.LockOptions{
+		Key:         key,
+		SessionName: "cn-infra-lock",
+		LockTryOnce: try,
+	}
+	if try {
+		opts.LockWaitTime = tryLockWaitTime
+	}
+	consulLock, err := l.client.client.LockOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// abort the lock attempt once the context is canceled
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stopCh)
+		case <-done:
+		}
+	}()
+	leaderCh, err := consulLock.Lock(stopCh)
+	if err != nil {
+		return nil, err
+	}
+	if leaderCh == nil {
+		// lock attempt was aborted
+		if try && ctx.Err() == nil {
+			return nil, keyval.ErrLockHeld
+		}
+		return nil, ctx.Err()
+	}
+
+	// modify index of the lock key increments with every acquisition
+	pair, _, err := l.client.client.KV().Get(key, nil)
+	if err != nil || pair == nil {
+		consulLock.Unlock()
+		if err == nil {
+			err = keyval.ErrLockNotHeld
+		}
+		return nil, err
+	}
+
+	lk := &lock{
+		consulLock: consulLock,
+		name:       l.prefix + name,
+		token:      int64(pair.ModifyIndex),
+		lost:       make(chan struct{}),
+		unlocked:   make(chan struct{}),
+	}
+	go lk.monitor(leaderCh)
+	return lk, nil
+}
+
+// monitor closes lost channel of the lock once either the Consul session
+// is invalidated or the lock is released.
+func (lk *lock) monitor(leaderCh <-chan struct{}) {
+	select {
+	case <-leaderCh:
+		consulLogger.WithField("lock", lk.name).Warn("Lock ownership lost")
+	case <-lk.unlocked:
+	}
+	close(lk.lost)
+}
+
+// Name returns the name of the lock.
+func (lk *lock) Name() string {
+	return lk.name
+}
+
+// Token returns the modify index of the lock key at the time the lock
+// was acquired.
+func (lk *lock) Token() int64 {
+	return lk.token
+}
+
+// Lost returns a channel that is closed once the lock is released or
+// the Consul session is invalidated.
+func (lk *lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Unlock releases the lock and destroys its Consul session.
+func (lk *lock) Unlock(ctx context.Context) error {
+	err := keyval.ErrLockNotHeld
+	lk.once.Do(func() {
+		err = lk.consulLock.Unlock()
+		close(lk.unlocked)
+	})
+	if err == api.ErrLockNotHeld {
+		err = keyval.ErrLockNotHeld
+	}
+	return err
+}
@@ -15,6 +15,10 @@
 package consul
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/hashicorp/consul/api"
 
 	"go.ligato.io/cn-infra/v2/datasync/resync"
@@ -31,8 +35,9 @@ const (
 
 // Config represents configuration for Consul plugin.
 type Config struct {
-	Address         string `json:"address"`
-	ReconnectResync bool   `json:"resync-after-reconnect"`
+	Address         string        `json:"address"`
+	ReconnectResync bool          `json:"resync-after-reconnect"`
+	SessionTTL      time.Duration `json:"session-ttl"`
 }
 
 // Plugin implements Consul as plugin.
@@ -49,6 +54,10 @@ type Plugin struct {
 
 	reconnectResync bool
 	lastConnErr     error
+
+	// ctx is canceled when the plugin is closed
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // Deps lists dependencies of the Consul plugin.
@@ -61,6 +70,8 @@ type Deps struct {
 
 // Init initializes Consul plugin.
 func (p *Plugin) Init() (err error) {
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
 	if p.Config == nil {
 		p.Config, err = p.getConfig()
 		if err != nil || p.disabled {
@@ -78,20 +89,31 @@ func (p *Plugin) Init() (err error) {
 		return err
 	}
 
+	if p.Config.SessionTTL != 0 {
+		p.client.sessionTTL = p.Config.SessionTTL
+	}
 	p.reconnectResync = p.Config.ReconnectResync
 	p.protoWrapper = kvproto.NewProtoWrapper(p.client, &keyval.SerializerJSON{})
 
-	// Register for providing status reports (polling mode)
-	if p.StatusCheck != nil {
+	return nil
+}
+
+// AfterInit registers Consul plugin to status check if needed.
+func (p *Plugin) AfterInit() error {
+	if p.StatusCheck != nil && !p.disabled {
+		// Register for providing status reports (polling mode)
 		p.StatusCheck.Register(p.PluginName, p.statusCheckProbe)
-	} else {
-		p.Log.Warnf("Unable to start status check for consul")
+		p.Log.Infof("Status check for %s was started", p.PluginName)
 	}
 
 	return nil
 }
 
+// Consul status check probe function
 func (p *Plugin) statusCheckProbe() (statuscheck.PluginState, error) {
+	if p.client == nil {
+		return statuscheck.Error, fmt.Errorf("no Consul connection available")
+	}
 	_, _, _, err := p.client.GetValue(healthCheckProbeKey)
 	if err != nil {
 		p.lastConnErr = err
@@ -119,7 +141,14 @@ func (p *Plugin) OnConnect(callback func() error) {
 }
 
 // Close closes Consul plugin.
+// Keys put with the client lifetime TTL are removed.
 func (p *Plugin) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	if p.client != nil {
+		return p.client.Close()
+	}
 	return nil
 }
 
@@ -152,6 +181,32 @@ func ConfigToClient(cfg *Config) (*api.Config, error) {
 	return clientCfg, nil
 }
 
+// NewBrokerWithAtomic creates new instance of prefixed (byte-oriented) broker with atomic operations.
+func (p *Plugin) NewBrokerWithAtomic(keyPrefix string) keyval.BytesBrokerWithAtomic {
+	return p.client.NewBroker(keyPrefix).(keyval.BytesBrokerWithAtomic)
+}
+
+// NewLocker creates new instance of locker that provides named locks held by Consul sessions.
+// <keyPrefix> is prepended to the names of all locks.
+func (p *Plugin) NewLocker(keyPrefix string) keyval.Locker {
+	return p.client.NewLocker(keyPrefix)
+}
+
+// NewElection creates new instance of leader election on a given prefix using Consul sessions.
+// If StatusCheck is injected, the state of the election is reported in the agent status.
+func (p *Plugin) NewElection(prefix string) keyval.Election {
+	election := p.client.NewElection(prefix)
+	if sw, ok := p.StatusCheck.(statuscheck.ElectionStatusWriter); ok {
+		go statuscheck.ReportElection(p.ctx, sw, prefix, election)
+	}
+	return election
+}
+
+// RawAccess allows to access data in the database as raw bytes (i.e. not formatted by protobuf).
+func (p *Plugin) RawAccess() keyval.KvBytesPlugin {
+	return p.client
+}
+
 // NewBroker creates new instance of prefixed broker that provides API with arguments of type proto.Message.
 func (p *Plugin) NewBroker(keyPrefix string) keyval.ProtoBroker {
 	return p.protoWrapper.NewBroker(keyPrefix)
@@ -0,0 +1,218 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+)
+
+const (
+	// defaultSessionTTL is the default TTL of the session holding the keys
+	// put with the client lifetime TTL.
+	defaultSessionTTL = 15 * time.Second
+	// minSessionTTL is the minimal TTL of session accepted by Consul.
+	minSessionTTL = 10 * time.Second
+	// sessionLockDelay replaces the default lock delay (15s) of Consul,
+	// which would prevent the key from being put again with a new session
+	// shortly after its previous session has expired.
+	sessionLockDelay = time.Millisecond
+)
+
+// keySession is a session holding the key put with TTL.
+type keySession struct {
+	id string
+	// lifetime is true for the session shared by all keys put with
+	// the client lifetime TTL.
+	lifetime bool
+	// ttl of the session which is not renewed
+	ttl time.Duration
+}
+
+// newSession creates a new session with the given TTL, which deletes
+// the keys it holds once it is invalidated.
+func (c *Client) newSession(name string, ttl time.Duration) (string, error) {
+	if ttl < minSessionTTL {
+		consulLogger.Debugf("TTL %v of session %s is raised to %v", ttl, name, minSessionTTL)
+		ttl = minSessionTTL
+	}
+	id, _, err := c.client.Session().Create(&api.SessionEntry{
+		Name:      name,
+		TTL:       ttl.String(),
+		Behavior:  api.SessionBehaviorDelete,
+		LockDelay: sessionLockDelay,
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session %s: %v", name, err)
+	}
+	return id, nil
+}
+
+// lifetimeSession returns the session shared by the keys put with the client
+// lifetime TTL. The session is created with the first use and renewed until
+// the client is closed. Once the client is closed, the session is destroyed
+// and Consul deletes all the keys it holds.
+func (c *Client) lifetimeSession() (string, error) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if c.session != "" {
+		return c.session, nil
+	}
+	ttl := c.sessionTTL
+	if ttl == 0 {
+		ttl = defaultSessionTTL
+	}
+	id, err := c.newSession("cn-infra-client-lifetime", ttl)
+	if err != nil {
+		return "", err
+	}
+	c.session = id
+
+	go func() {
+		err := c.client.Session().RenewPeriodic(ttl.String(), id, nil, c.closeCh)
+		if err != nil {
+			consulLogger.Warnf("Renewal of session %s failed, keys put with client lifetime TTL were removed: %v",
+				id, err)
+		}
+		c.sessionMu.Lock()
+		if c.session == id {
+			c.session = ""
+		}
+		for key, s := range c.heldKeys {
+			if s.id == id {
+				delete(c.heldKeys, key)
+			}
+		}
+		c.sessionMu.Unlock()
+	}()
+	return id, nil
+}
+
+// putSession returns the session which should hold the key put with the given
+// options, or nil if the key should not be held by any session.
+func (c *Client) putSession(key string, opts []datasync.PutOption) (*keySession, error) {
+	for _, o := range opts {
+		switch opt := o.(type) {
+		case *datasync.WithTTLOpt:
+			if opt.TTL > 0 {
+				id, err := c.newSession("cn-infra-ttl", opt.TTL)
+				if err != nil {
+					return nil, err
+				}
+				ttl := opt.TTL
+				if ttl < minSessionTTL {
+					ttl = minSessionTTL
+				}
+				return &keySession{id: id, ttl: ttl}, nil
+			}
+		case *datasync.WithClientLifetimeTTLOpt:
+			id, err := c.lifetimeSession()
+			if err != nil {
+				return nil, err
+			}
+			return &keySession{id: id, lifetime: true}, nil
+		}
+	}
+	return nil, nil
+}
+
+// heldKey returns the session which holds the key put by this client, or nil.
+func (c *Client) heldKey(key string) *keySession {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	return c.heldKeys[key]
+}
+
+// holdKey records the session holding the key put by this client.
+// The session which is not renewed is checked once its TTL elapses,
+// so that the key is forgotten after Consul invalidates the session.
+func (c *Client) holdKey(key string, s *keySession) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	c.heldKeys[key] = s
+	if !s.lifetime {
+		time.AfterFunc(s.ttl, func() { c.expireKey(key, s) })
+	}
+}
+
+// expireKey forgets the key once its session expires. Consul invalidates
+// the session up to twice the TTL later, therefore the check is repeated
+// while the key is still held by the session.
+func (c *Client) expireKey(key string, s *keySession) {
+	select {
+	case <-c.closeCh:
+		return
+	default:
+	}
+	if c.heldKey(key) != s {
+		return
+	}
+	if !c.pruneKey(key) {
+		time.AfterFunc(s.ttl, func() { c.expireKey(key, s) })
+	}
+}
+
+// pruneKey forgets the session holding the key, if the key does not exist
+// anymore or it is not held by the session (i.e. the session expired,
+// or the key was deleted or replaced by another client). Returns true
+// if the key is not held by this client anymore.
+func (c *Client) pruneKey(key string) bool {
+	s := c.heldKey(key)
+	if s == nil {
+		return true
+	}
+	pair, _, err := c.client.KV().Get(key, nil)
+	if err != nil {
+		consulLogger.Warnf("Failed to check session holding key %s: %v", key, err)
+		return false
+	}
+	if pair != nil && pair.Session == s.id {
+		return false
+	}
+	c.sessionMu.Lock()
+	if c.heldKeys[key] == s {
+		delete(c.heldKeys, key)
+	}
+	c.sessionMu.Unlock()
+	if !s.lifetime {
+		c.destroySession(s.id)
+	}
+	return true
+}
+
+// releaseKey forgets the session holding the key and destroys the session
+// if it was created just for the key.
+func (c *Client) releaseKey(key string) {
+	c.sessionMu.Lock()
+	s := c.heldKeys[key]
+	delete(c.heldKeys, key)
+	c.sessionMu.Unlock()
+
+	if s != nil && !s.lifetime {
+		c.destroySession(s.id)
+	}
+}
+
+// destroySession destroys the session and logs failure.
+func (c *Client) destroySession(id string) {
+	if _, err := c.client.Session().Destroy(id, nil); err != nil {
+		consulLogger.Warnf("Failed to destroy session %s: %v", id, err)
+	}
+}
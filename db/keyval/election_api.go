@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyval
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNoLeader is returned by Leader if there is no leader elected.
+	ErrNoLeader = errors.New("keyval: no leader elected")
+	// ErrNotLeader is returned by Resign if the caller is not the leader.
+	ErrNotLeader = errors.New("keyval: not a leader")
+)
+
+// Election allows multiple instances to compete for leadership on a given
+// prefix. Only one of them is the leader at a time. Leadership is bound
+// to the lease (session) of the data store client, i.e. a new leader
+// is elected automatically when the current one disappears.
+type Election interface {
+	// Campaign puts the caller into the election with the given <value>
+	// (e.g. the identity of the agent). The call blocks until either
+	// the caller is elected as the leader or the context is canceled.
+	// If the caller is already the leader, only its value is updated.
+	Campaign(ctx context.Context, value string) error
+	// Resign gives up the leadership so that a new leader can be elected.
+	// ErrNotLeader is returned if the caller is not the leader.
+	Resign(ctx context.Context) error
+	// Leader returns the value of the current leader. ErrNoLeader
+	// is returned if there is no leader elected.
+	Leader(ctx context.Context) (string, error)
+	// Observe returns a channel that receives the value of the current
+	// leader whenever the leader changes. The channel is closed once
+	// the context is canceled.
+	Observe(ctx context.Context) <-chan string
+	// Lost returns a channel that is closed once the leadership gained
+	// by the last successful Campaign is lost, e.g. when the lease has
+	// expired. The channel is closed also by Resign. If the caller is not
+	// the leader, the returned channel is already closed.
+	Lost() <-chan struct{}
+}
@@ -15,6 +15,7 @@
 package etcd
 
 import (
+	"sync"
 	"time"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
@@ -25,6 +26,7 @@ import (
 
 	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvlock"
 	"go.ligato.io/cn-infra/v2/logging"
 )
 
@@ -36,6 +38,10 @@ type BytesConnectionEtcd struct {
 	lessor     clientv3.Lease
 	session    *concurrency.Session
 	opTimeout  time.Duration
+	locks      *kvlock.LocalLocker
+
+	missedMu       sync.Mutex
+	onMissedEvents func(keyPrefix string)
 }
 
 // BytesBrokerWatcherEtcd uses BytesConnectionEtcd to access the datastore.
@@ -50,6 +56,9 @@ type BytesBrokerWatcherEtcd struct {
 	kv        clientv3.KV
 	watcher   clientv3.Watcher
 	opTimeout time.Duration
+	// conn and prefix are used to report watches which missed events
+	conn   *BytesConnectionEtcd
+	prefix string
 }
 
 // NewEtcdConnectionWithBytes creates new connection to etcd based on the given
@@ -68,11 +77,19 @@ func NewEtcdConnectionWithBytes(config ClientConfig, log logging.Logger) (*Bytes
 
 	l.Infof("Connected to Etcd (took %v)", time.Since(t))
 
+	if config.Namespace != "" {
+		UseNamespace(etcdClient, config.Namespace)
+		l.Infof("Using Etcd namespace %q", config.Namespace)
+	}
+
 	conn, err := NewEtcdConnectionUsingClient(etcdClient, log)
 	if err != nil {
 		return nil, err
 	}
 	conn.opTimeout = config.OpTimeout
+	if config.Namespace != "" {
+		conn.lessor = namespace.NewLease(conn.lessor, config.Namespace)
+	}
 
 	conn.session, err = concurrency.NewSession(etcdClient, concurrency.WithTTL(config.SessionTTL))
 	if err != nil {
@@ -91,12 +108,23 @@ func NewEtcdConnectionUsingClient(etcdClient *clientv3.Client, log logging.Logge
 		etcdClient: etcdClient,
 		lessor:     clientv3.NewLease(etcdClient),
 		opTimeout:  defaultOpTimeout,
+		locks:      kvlock.NewLocalLocker(),
 	}
 	return &conn, nil
 }
 
+// UseNamespace wraps the KV, watcher and lease of the given etcd client
+// so that all keys are transparently prefixed with <ns>.
+// It has to be called before the client is used to create the connection.
+func UseNamespace(etcdClient *clientv3.Client, ns string) {
+	etcdClient.KV = namespace.NewKV(etcdClient.KV, ns)
+	etcdClient.Watcher = namespace.NewWatcher(etcdClient.Watcher, ns)
+	etcdClient.Lease = namespace.NewLease(etcdClient.Lease, ns)
+}
+
 // Close closes the connection to ETCD.
 func (db *BytesConnectionEtcd) Close() error {
+	db.locks.Close()
 	if db.etcdClient != nil {
 		return db.etcdClient.Close()
 	}
@@ -116,6 +144,8 @@ func (db *BytesConnectionEtcd) NewBroker(prefix string) keyval.BytesBroker {
 		lessor:    db.lessor,
 		opTimeout: db.opTimeout,
 		watcher:   namespace.NewWatcher(db.etcdClient, prefix),
+		conn:      db,
+		prefix:    prefix,
 	}
 }
 
@@ -132,6 +162,8 @@ func (db *BytesConnectionEtcd) NewWatcher(prefix string) keyval.BytesWatcher {
 		lessor:    db.lessor,
 		opTimeout: db.opTimeout,
 		watcher:   namespace.NewWatcher(db.etcdClient, prefix),
+		conn:      db,
+		prefix:    prefix,
 	}
 }
 
@@ -179,7 +211,10 @@ func (pdb *BytesBrokerWatcherEtcd) Delete(key string, opts ...datasync.DelOption
 // Watch events will be delivered to <resp> callback.
 func (pdb *BytesBrokerWatcherEtcd) Watch(resp func(keyval.BytesWatchResp), closeChan chan string, keys ...string) error {
 	for _, key := range keys {
-		err := watchInternal(pdb.Logger, pdb.watcher, closeChan, key, resp)
+		err := watchInternal(pdb.Logger, pdb.conn.watchContext(), pdb.watcher, closeChan, key, resp,
+			func(keyPrefix string) {
+				pdb.conn.reportMissedEvents(pdb.prefix + keyPrefix)
+			})
 		if err != nil {
 			return err
 		}
@@ -245,7 +280,7 @@ func newTxnInternal(kv clientv3.KV) keyval.BytesTxn {
 // provided key prefix
 func (db *BytesConnectionEtcd) Watch(resp func(keyval.BytesWatchResp), closeChan chan string, keys ...string) error {
 	for _, key := range keys {
-		err := watchInternal(db.Logger, db.etcdClient, closeChan, key, resp)
+		err := watchInternal(db.Logger, db.watchContext(), db.etcdClient, closeChan, key, resp, db.reportMissedEvents)
 		if err != nil {
 			return err
 		}
@@ -253,54 +288,140 @@ func (db *BytesConnectionEtcd) Watch(resp func(keyval.BytesWatchResp), closeChan
 	return nil
 }
 
-// watchInternal starts the watch subscription for the key.
-func watchInternal(log logging.Logger, watcher clientv3.Watcher, closeCh chan string, prefix string, resp func(keyval.BytesWatchResp)) error {
-	ctx, cancel := context.WithCancel(context.Background())
-	recvChan := watcher.Watch(ctx, prefix, clientv3.WithPrefix(), clientv3.WithPrevKV())
+// OnMissedEvents sets callback called with the key prefix of a watch which has missed some events,
+// i.e. the watched revisions were compacted before the events could be delivered. The watch itself
+// is re-established automatically, but the subscribers should resync the data under the key prefix.
+func (db *BytesConnectionEtcd) OnMissedEvents(cb func(keyPrefix string)) {
+	db.missedMu.Lock()
+	defer db.missedMu.Unlock()
+	db.onMissedEvents = cb
+}
+
+func (db *BytesConnectionEtcd) reportMissedEvents(keyPrefix string) {
+	db.missedMu.Lock()
+	cb := db.onMissedEvents
+	db.missedMu.Unlock()
+	if cb == nil {
+		db.Warnf("Watch of key prefix %s has missed events, data under the prefix should be resynced", keyPrefix)
+		return
+	}
+	cb(keyPrefix)
+}
+
+// watchContext returns context of the client, which is canceled once the client is closed.
+func (db *BytesConnectionEtcd) watchContext() context.Context {
+	if ctx := db.etcdClient.Ctx(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// watchRetryInterval is the delay before a watch is re-established
+// after it was canceled by the server or its channel was closed.
+var watchRetryInterval = time.Second
+
+// watchInternal starts the watch subscription for the key. The watch is re-established
+// once it gets canceled, starting from the revision following the last received one,
+// so that no event is missed. If the revision was already compacted, the watch continues
+// from the compaction revision and <onMissed> is called to request resync of the prefix.
+func watchInternal(log logging.Logger, clientCtx context.Context, watcher clientv3.Watcher, closeCh chan string,
+	prefix string, resp func(keyval.BytesWatchResp), onMissed func(keyPrefix string)) error {
+
+	ctx, cancel := context.WithCancel(clientCtx)
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithPrevKV(),
+		clientv3.WithCreatedNotify(), clientv3.WithProgressNotify()}
+	recvChan := watcher.Watch(ctx, prefix, opts...)
 
 	go func(registeredKey string) {
-		var compactRev int64
+		defer cancel()
+		// revision up to which all events were received, known from the creation of the watch
+		// of current data, events and progress notifications
+		var lastRev int64
+		// revision the current watch was created from (0 for the current data)
+		var watchRev int64
+		rewatch := func(fromRev int64) {
+			watchRev = fromRev
+			recvChan = watcher.Watch(ctx, prefix, append(opts, clientv3.WithRev(fromRev))...)
+			log.WithFields(logging.Fields{
+				"prefix": prefix,
+				"rev":    fromRev,
+			}).Info("Watch was re-created")
+		}
+		// waitRetry returns false if the watch was closed in the meantime
+		waitRetry := func() bool {
+			select {
+			case <-time.After(watchRetryInterval):
+				return true
+			case closeVal, ok := <-closeCh:
+				return ok && closeVal != registeredKey
+			case <-ctx.Done():
+				return false
+			}
+		}
+		// resume continues watching after the last received revision. Without any revision
+		// received, events might have been missed and resync is requested.
+		resume := func() {
+			if lastRev == 0 {
+				rewatch(0)
+				onMissed(prefix)
+				return
+			}
+			rewatch(lastRev + 1)
+		}
 		for {
 			select {
 			case wresp, ok := <-recvChan:
 				if !ok {
+					if ctx.Err() != nil {
+						log.WithField("prefix", prefix).Debug("Watch ended")
+						return
+					}
 					log.WithField("prefix", prefix).Warn("Watch recv channel was closed")
-					if compactRev != 0 {
-						recvChan = watcher.Watch(context.Background(), prefix,
-							clientv3.WithPrefix(), clientv3.WithPrevKV(), clientv3.WithRev(compactRev))
-						log.WithFields(logging.Fields{
-							"prefix": prefix,
-							"rev":    compactRev,
-						}).Warn("Watch recv channel was re-created")
-						compactRev = 0
-						continue
+					if !waitRetry() {
+						return
 					}
-					return
+					resume()
+					continue
 				}
-				if wresp.Canceled {
-					log.WithField("prefix", prefix).Warn("Watch was canceled")
-				}
-				err := wresp.Err()
-				if err != nil {
+				if wresp.CompactRevision != 0 {
 					log.WithFields(logging.Fields{
 						"prefix": prefix,
-						"err":    err,
-					}).Warn("Watch returned error")
+						"rev":    wresp.CompactRevision,
+					}).Warn("Watched data were compacted, events were missed")
+					lastRev = wresp.CompactRevision - 1
+					rewatch(wresp.CompactRevision)
+					onMissed(prefix)
+					continue
 				}
-				compactRev = wresp.CompactRevision
-				if compactRev != 0 {
+				if err := wresp.Err(); err != nil {
 					log.WithFields(logging.Fields{
 						"prefix": prefix,
-						"rev":    compactRev,
-					}).Warn("Watched data were compacted ")
+						"err":    err,
+					}).Warn("Watch returned error")
 				}
 				for _, ev := range wresp.Events {
 					handleWatchEvent(log, resp, ev)
+					if ev.Kv.ModRevision > lastRev {
+						lastRev = ev.Kv.ModRevision
+					}
+				}
+				if wresp.Canceled {
+					log.WithField("prefix", prefix).Warn("Watch was canceled")
+					if !waitRetry() {
+						return
+					}
+					resume()
+					continue
+				}
+				// the header revision can be ahead of events not delivered yet, it is used
+				// only if the watch is known to be synced up to it
+				synced := wresp.IsProgressNotify() || (wresp.Created && watchRev == 0 && lastRev == 0)
+				if synced && wresp.Header.Revision > lastRev {
+					lastRev = wresp.Header.Revision
 				}
 
 			case closeVal, ok := <-closeCh:
 				if !ok || closeVal == registeredKey {
-					cancel()
 					log.WithField("prefix", prefix).Debug("Watch ended")
 					return
 				}
@@ -0,0 +1,165 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"errors"
+	"sync"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"golang.org/x/net/context"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// bytesElectionEtcd implements keyval.Election using etcd election
+// bound to the session of the connection.
+type bytesElectionEtcd struct {
+	logging.Logger
+	session  *concurrency.Session
+	election *concurrency.Election
+	prefix   string
+
+	mu       sync.Mutex
+	lost     chan struct{}
+	resigned chan struct{}
+}
+
+// NewElection creates a new instance of keyval.Election for the given <prefix>.
+// Leadership is bound to the session of this connection, therefore it is lost
+// once the session expires. There should be at most one campaigning instance
+// per connection and prefix.
+func (db *BytesConnectionEtcd) NewElection(prefix string) keyval.Election {
+	e := &bytesElectionEtcd{
+		Logger:  db.Logger,
+		session: db.session,
+		prefix:  prefix,
+		lost:    make(chan struct{}),
+	}
+	close(e.lost)
+	if db.session != nil {
+		e.election = concurrency.NewElection(db.session, prefix)
+	}
+	return e
+}
+
+// Campaign puts the caller into the election with the given <value>.
+// The call blocks until either the caller is elected as the leader or
+// the context is canceled.
+func (e *bytesElectionEtcd) Campaign(ctx context.Context, value string) error {
+	if e.election == nil {
+		return errors.New("etcd: election requires established session")
+	}
+
+	e.mu.Lock()
+	leader := e.isLeader()
+	e.mu.Unlock()
+	if leader {
+		return e.election.Proclaim(ctx, value)
+	}
+
+	if err := e.election.Campaign(ctx, value); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lost = make(chan struct{})
+	e.resigned = make(chan struct{})
+	go e.watchSession(e.lost, e.resigned)
+	return nil
+}
+
+// watchSession closes <lost> channel once either the session is done
+// or the leader resigns.
+func (e *bytesElectionEtcd) watchSession(lost, resigned chan struct{}) {
+	select {
+	case <-e.session.Done():
+		e.WithField("election", e.prefix).Warn("Session expired, leadership lost")
+	case <-resigned:
+	}
+	close(lost)
+}
+
+// Resign gives up the leadership so that a new leader can be elected.
+func (e *bytesElectionEtcd) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.election == nil || !e.isLeader() {
+		return keyval.ErrNotLeader
+	}
+	err := e.election.Resign(ctx)
+	close(e.resigned)
+	<-e.lost
+	return err
+}
+
+// Leader returns the value of the current leader.
+func (e *bytesElectionEtcd) Leader(ctx context.Context) (string, error) {
+	if e.election == nil {
+		return "", errors.New("etcd: election requires established session")
+	}
+	resp, err := e.election.Leader(ctx)
+	if err == concurrency.ErrElectionNoLeader {
+		return "", keyval.ErrNoLeader
+	} else if err != nil {
+		return "", err
+	}
+	return string(resp.Kvs[0].Value), nil
+}
+
+// Observe returns a channel that receives the value of the current leader
+// whenever the leader changes.
+func (e *bytesElectionEtcd) Observe(ctx context.Context) <-chan string {
+	leaders := make(chan string)
+	if e.election == nil {
+		close(leaders)
+		return leaders
+	}
+	go func() {
+		defer close(leaders)
+		for resp := range e.election.Observe(ctx) {
+			if len(resp.Kvs) == 0 {
+				continue
+			}
+			select {
+			case leaders <- string(resp.Kvs[0].Value):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return leaders
+}
+
+// Lost returns a channel that is closed once the leadership is lost.
+func (e *bytesElectionEtcd) Lost() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lost
+}
+
+// isLeader returns true if the leadership gained by the last Campaign
+// has not been lost yet. Must be called with mu locked.
+func (e *bytesElectionEtcd) isLeader() bool {
+	select {
+	case <-e.lost:
+		return false
+	default:
+		return true
+	}
+}
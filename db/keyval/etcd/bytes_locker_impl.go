@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"errors"
+	"sync"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"golang.org/x/net/context"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvlock"
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// bytesLockerEtcd implements keyval.Locker using etcd mutexes created
+// on the session of the connection.
+type bytesLockerEtcd struct {
+	logging.Logger
+	session *concurrency.Session
+	// locks serializes owners within this process, since all of them
+	// share the same session (and therefore the same etcd mutex key).
+	locks  *kvlock.LocalLocker
+	prefix string
+}
+
+// bytesLockEtcd is a lock acquired from bytesLockerEtcd.
+type bytesLockEtcd struct {
+	mutex *concurrency.Mutex
+	local keyval.Lock
+	name  string
+	token int64
+
+	lost     chan struct{}
+	unlocked chan struct{}
+	once     sync.Once
+}
+
+// NewLocker creates a new instance of keyval.Locker that uses etcd mutexes
+// bound to the session of this connection. Ownership of all locks is lost
+// once the session expires. <prefix> is prepended to the names of all locks.
+func (db *BytesConnectionEtcd) NewLocker(prefix string) keyval.Locker {
+	return &bytesLockerEtcd{
+		Logger:  db.Logger,
+		session: db.session,
+		locks:   db.locks,
+		prefix:  prefix,
+	}
+}
+
+// Lock acquires the lock with the given <name>. The call blocks until
+// either the lock is acquired or the context is canceled.
+func (l *bytesLockerEtcd) Lock(ctx context.Context, name string) (keyval.Lock, error) {
+	return l.lock(ctx, name, false)
+}
+
+// TryLock attempts to acquire the lock with the given <name> without
+// waiting. If the lock is held by another owner, keyval.ErrLockHeld
+// is returned.
+func (l *bytesLockerEtcd) TryLock(ctx context.Context, name string) (keyval.Lock, error) {
+	return l.lock(ctx, name, true)
+}
+
+func (l *bytesLockerEtcd) lock(ctx context.Context, name string, try bool) (keyval.Lock, error) {
+	if l.session == nil {
+		return nil, errors.New("etcd: locking requires established session")
+	}
+	key := l.prefix + name
+
+	var (
+		local keyval.Lock
+		err   error
+	)
+	if try {
+		local, err = l.locks.TryLock(ctx, key)
+	} else {
+		local, err = l.locks.Lock(ctx, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	mutex := concurrency.NewMutex(l.session, key)
+	if try {
+		err = mutex.TryLock(ctx)
+	} else {
+		err = mutex.Lock(ctx)
+	}
+	if err != nil {
+		local.Unlock(ctx)
+		if err == concurrency.ErrLocked {
+			return nil, keyval.ErrLockHeld
+		}
+		return nil, err
+	}
+
+	lock := &bytesLockEtcd{
+		mutex:    mutex,
+		local:    local,
+		name:     key,
+		token:    mutex.Header().Revision,
+		lost:     make(chan struct{}),
+		unlocked: make(chan struct{}),
+	}
+	go lock.watchSession(l.Logger, l.session)
+	return lock, nil
+}
+
+// watchSession closes lost channel of the lock once either the session
+// is done or the lock is released.
+func (lock *bytesLockEtcd) watchSession(log logging.Logger, session *concurrency.Session) {
+	select {
+	case <-session.Done():
+		log.WithField("lock", lock.name).Warn("Session expired, lock ownership lost")
+		lock.local.Unlock(context.Background())
+	case <-lock.unlocked:
+	}
+	close(lock.lost)
+}
+
+// Name returns the name of the lock.
+func (lock *bytesLockEtcd) Name() string {
+	return lock.name
+}
+
+// Token returns the revision at which the lock was acquired.
+func (lock *bytesLockEtcd) Token() int64 {
+	return lock.token
+}
+
+// Lost returns a channel that is closed once the lock is released or
+// the session expires.
+func (lock *bytesLockEtcd) Lost() <-chan struct{} {
+	return lock.lost
+}
+
+// Unlock releases the lock.
+func (lock *bytesLockEtcd) Unlock(ctx context.Context) error {
+	err := keyval.ErrLockNotHeld
+	lock.once.Do(func() {
+		err = lock.mutex.Unlock(ctx)
+		lock.local.Unlock(ctx)
+		close(lock.unlocked)
+	})
+	return err
+}
@@ -45,6 +45,10 @@ type Config struct {
 	ReconnectInterval     time.Duration `json:"reconnect-interval"`
 	SessionTTL            int           `json:"session-ttl"`
 	ExpandEnvVars         bool          `json:"expand-env-variables"`
+	Username              string        `json:"username"`
+	Password              string        `json:"password"`
+	PasswordFile          string        `json:"password-file"`
+	Namespace             string        `json:"namespace"`
 }
 
 // ClientConfig extends clientv3.Config with configuration options introduced
@@ -64,6 +68,10 @@ type ClientConfig struct {
 	// data according to the values of the current environment variables. References to undefined variables are replaced
 	// by the empty string.
 	ExpandEnvVars bool
+
+	// Namespace is a key prefix under which all keys, watches and leases of the client
+	// are transparently isolated (see go.etcd.io/etcd/client/v3/namespace).
+	Namespace string
 }
 
 const (
@@ -75,6 +83,9 @@ const (
 
 	// defaultSessionTTL defines the default TTL value (in seconds)
 	defaultSessionTTL = 5
+
+	// defaultEnvPrefix is a prefix of environment variables read by ConfigToClient.
+	defaultEnvPrefix = "ETCD"
 )
 
 // ConfigToClient transforms yaml configuration <yc> modelled by Config
@@ -84,9 +95,20 @@ const (
 // the function will query the ETCD_ENDPOINTS environment variable
 // for a non-empty value. If neither the config nor the environment specify the
 // endpoint location, a default address "127.0.0.1:2379" is assumed.
+// Credentials for the username/password authentication can be also set via
+// ETCD_USERNAME and ETCD_PASSWORD environment variables. Once authenticated,
+// the client refreshes its auth token using the credentials whenever the token
+// expires.
 // The function may return error only if TLS connection is selected and the
-// CA or client certificate is not accessible/valid.
+// CA or client certificate is not accessible/valid, or the password file
+// cannot be read.
 func ConfigToClient(yc *Config) (*ClientConfig, error) {
+	return configToClient(yc, defaultEnvPrefix)
+}
+
+// configToClient is ConfigToClient with environment variables prefixed by <envPrefix>
+// (instead of ETCD), which separates configuration of multiple etcd plugin instances.
+func configToClient(yc *Config, envPrefix string) (*ClientConfig, error) {
 	dialTimeout := defaultDialTimeout
 	if yc.DialTimeout != 0 {
 		dialTimeout = yc.DialTimeout
@@ -106,19 +128,35 @@ func ConfigToClient(yc *Config) (*ClientConfig, error) {
 		Endpoints:   yc.Endpoints,
 		DialTimeout: dialTimeout,
 	}
-	cfg := &ClientConfig{Config: clientv3Cfg, OpTimeout: opTimeout, SessionTTL: sessionTTL}
+	cfg := &ClientConfig{Config: clientv3Cfg, OpTimeout: opTimeout, SessionTTL: sessionTTL, Namespace: yc.Namespace}
 
 	if len(cfg.Endpoints) == 0 {
-		if ep := os.Getenv("ETCD_ENDPOINTS"); ep != "" {
+		if ep := os.Getenv(envPrefix + "_ENDPOINTS"); ep != "" {
 			cfg.Endpoints = strings.Split(ep, ",")
-		} else if ep := os.Getenv("ETCDV3_ENDPOINTS"); ep != "" { // this provides backwards compatiblity
+		} else if ep := os.Getenv("ETCDV3_ENDPOINTS"); ep != "" && envPrefix == defaultEnvPrefix { // this provides backwards compatiblity
 			cfg.Endpoints = strings.Split(ep, ",")
 		} else {
 			cfg.Endpoints = []string{"127.0.0.1:2379"}
 		}
 	}
 
-	cfg.ExpandEnvVars = yc.ExpandEnvVars || os.Getenv("ETCD_EXPAND_ENV_VARS") != ""
+	cfg.Username = yc.Username
+	if cfg.Username == "" {
+		cfg.Username = os.Getenv(envPrefix + "_USERNAME")
+	}
+	cfg.Password = yc.Password
+	if cfg.Password == "" && yc.PasswordFile != "" {
+		password, err := ioutil.ReadFile(yc.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password file: %v", err)
+		}
+		cfg.Password = strings.TrimSpace(string(password))
+	}
+	if cfg.Password == "" {
+		cfg.Password = os.Getenv(envPrefix + "_PASSWORD")
+	}
+
+	cfg.ExpandEnvVars = yc.ExpandEnvVars || os.Getenv(envPrefix+"_EXPAND_ENV_VARS") != ""
 
 	if yc.InsecureTransport {
 		cfg.TLS = nil
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/client/v3/concurrency"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3client"
 
 	"go.ligato.io/cn-infra/v2/datasync"
@@ -64,6 +65,12 @@ func TestDataBroker(t *testing.T) {
 	t.Run("testCompareAndDelete", testCompareAndDelete)
 	embd.CleanDs()
 	t.Run("compact", testCompact)
+	embd.CleanDs()
+	t.Run("locker", testLocker)
+	embd.CleanDs()
+	t.Run("election", testElection)
+	embd.CleanDs()
+	t.Run("namespace", testNamespace)
 }
 
 func setupBrokers(t *testing.T) {
@@ -469,3 +476,140 @@ func testCompact(t *testing.T) {
 	Expect(found).NotTo(BeTrue())
 	Expect(err).NotTo(BeNil())
 }
+
+func testLocker(t *testing.T) {
+	setupBrokers(t)
+	defer teardownBrokers()
+
+	// two connections with separate sessions represent two agents
+	conn1, conn2 := newSessionConnection(), newSessionConnection()
+	defer conn2.Close()
+	locker1, locker2 := conn1.NewLocker(prefix), conn2.NewLocker(prefix)
+	ctx := context.Background()
+
+	lock1, err := locker1.TryLock(ctx, "lock")
+	Expect(err).To(BeNil())
+	Expect(lock1.Name()).To(Equal(prefix + "lock"))
+
+	// held by the other agent
+	_, err = locker2.TryLock(ctx, "lock")
+	Expect(err).To(Equal(keyval.ErrLockHeld))
+	// held by the same agent
+	_, err = locker1.TryLock(ctx, "lock")
+	Expect(err).To(Equal(keyval.ErrLockHeld))
+
+	acquired := make(chan keyval.Lock, 1)
+	go func() {
+		lock2, err := locker2.Lock(ctx, "lock")
+		if err == nil {
+			acquired <- lock2
+		}
+	}()
+	Consistently(acquired, 200*time.Millisecond).ShouldNot(Receive())
+
+	Expect(lock1.Unlock(ctx)).To(Succeed())
+	Eventually(lock1.Lost()).Should(BeClosed())
+
+	var lock2 keyval.Lock
+	Eventually(acquired, time.Second).Should(Receive(&lock2))
+	Expect(lock2.Token()).To(BeNumerically(">", lock1.Token()))
+
+	// closing the session of the owner loses the lock
+	Expect(conn2.session.Close()).To(Succeed())
+	Eventually(lock2.Lost(), time.Second).Should(BeClosed())
+
+	lock1, err = locker1.TryLock(ctx, "lock")
+	Expect(err).To(BeNil())
+	Expect(lock1.Unlock(ctx)).To(Succeed())
+	conn1.Close()
+}
+
+func testElection(t *testing.T) {
+	setupBrokers(t)
+	defer teardownBrokers()
+
+	conn1, conn2 := newSessionConnection(), newSessionConnection()
+	defer conn1.Close()
+	defer conn2.Close()
+	election1, election2 := conn1.NewElection("/election"), conn2.NewElection("/election")
+	ctx := context.Background()
+
+	_, err := election1.Leader(ctx)
+	Expect(err).To(Equal(keyval.ErrNoLeader))
+	Expect(election1.Lost()).To(BeClosed())
+	Expect(election1.Resign(ctx)).To(Equal(keyval.ErrNotLeader))
+
+	observeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	leaders := election2.Observe(observeCtx)
+
+	Expect(election1.Campaign(ctx, "agent1")).To(Succeed())
+	Expect(election1.Lost()).NotTo(BeClosed())
+	Eventually(leaders).Should(Receive(Equal("agent1")))
+	leader, err := election2.Leader(ctx)
+	Expect(err).To(BeNil())
+	Expect(leader).To(Equal("agent1"))
+
+	elected := make(chan struct{})
+	go func() {
+		if election2.Campaign(ctx, "agent2") == nil {
+			close(elected)
+		}
+	}()
+	Consistently(elected, 200*time.Millisecond).ShouldNot(BeClosed())
+
+	lost := election1.Lost()
+	Expect(election1.Resign(ctx)).To(Succeed())
+	Expect(lost).To(BeClosed())
+	Eventually(elected, time.Second).Should(BeClosed())
+	Eventually(leaders).Should(Receive(Equal("agent2")))
+
+	// closing the session of the leader loses the leadership
+	lost = election2.Lost()
+	Expect(conn2.session.Close()).To(Succeed())
+	Eventually(lost, time.Second).Should(BeClosed())
+}
+
+func testNamespace(t *testing.T) {
+	setupBrokers(t)
+	defer teardownBrokers()
+
+	client := v3client.New(embd.ETCD.Server)
+	UseNamespace(client, "/tenant/")
+	conn, err := NewEtcdConnectionUsingClient(client, logrus.DefaultLogger())
+	Expect(err).To(BeNil())
+	defer conn.Close()
+
+	// keys of the namespaced connection are stored under the namespace
+	Expect(conn.Put(key, []byte("data"))).To(Succeed())
+	data, found, _, err := broker.GetValue("/tenant/" + key)
+	Expect(err).To(BeNil())
+	Expect(found).To(BeTrue())
+	Expect(data).To(Equal([]byte("data")))
+
+	// keys outside of the namespace are not visible
+	Expect(broker.Put(key, []byte("other"))).To(Succeed())
+	data, found, _, err = conn.GetValue(key)
+	Expect(err).To(BeNil())
+	Expect(found).To(BeTrue())
+	Expect(data).To(Equal([]byte("data")))
+
+	// watch of the namespaced connection receives keys without the namespace
+	respChan := make(chan keyval.BytesWatchResp, 1)
+	err = conn.NewWatcher(prefix).Watch(keyval.ToChan(respChan), nil, watchKey)
+	Expect(err).To(BeNil())
+	Expect(broker.Put("/tenant/"+prefix+watchKey+"a", []byte("a"))).To(Succeed())
+	var resp keyval.BytesWatchResp
+	Eventually(respChan, time.Second).Should(Receive(&resp))
+	Expect(resp.GetKey()).To(Equal(watchKey + "a"))
+}
+
+// newSessionConnection creates a new connection with its own session,
+// representing a separate agent.
+func newSessionConnection() *BytesConnectionEtcd {
+	conn, err := NewEtcdConnectionUsingClient(v3client.New(embd.ETCD.Server), logrus.DefaultLogger())
+	Expect(err).To(BeNil())
+	conn.session, err = concurrency.NewSession(conn.etcdClient, concurrency.WithTTL(defaultSessionTTL))
+	Expect(err).To(BeNil())
+	return conn
+}
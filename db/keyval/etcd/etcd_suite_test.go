@@ -16,10 +16,13 @@ package etcd
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
 	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"golang.org/x/net/context"
@@ -321,6 +324,62 @@ func TestConfig(t *testing.T) {
 	Expect(etcdCfg.TLS).To(BeNil())
 }
 
+func TestConfigAuth(t *testing.T) {
+	RegisterTestingT(t)
+
+	passwordFile, err := ioutil.TempFile("", "etcd-password")
+	Expect(err).ToNot(HaveOccurred())
+	defer os.Remove(passwordFile.Name())
+	_, err = passwordFile.WriteString("secret\n")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(passwordFile.Close()).To(Succeed())
+
+	cfg := &Config{Username: "agent", PasswordFile: passwordFile.Name(), Namespace: "/tenant/"}
+	etcdCfg, err := ConfigToClient(cfg)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(etcdCfg.Username).To(Equal("agent"))
+	Expect(etcdCfg.Password).To(Equal("secret"))
+	Expect(etcdCfg.Namespace).To(Equal("/tenant/"))
+
+	cfg.PasswordFile = "/non-existing/password"
+	_, err = ConfigToClient(cfg)
+	Expect(err).To(HaveOccurred())
+}
+
+func TestConfigNamedInstance(t *testing.T) {
+	RegisterTestingT(t)
+
+	t.Setenv("ETCD_ENDPOINTS", "10.0.0.1:2379")
+	t.Setenv("ETCD_REMOTE_ENDPOINTS", "10.0.0.2:2379")
+	t.Setenv("ETCD_REMOTE_USERNAME", "remote")
+	t.Setenv("ETCD_REMOTE_PASSWORD", "secret")
+
+	p := NewPlugin(UseName("etcd-remote"))
+	Expect(p.envPrefix()).To(Equal("ETCD_REMOTE"))
+	etcdCfg, err := configToClient(&Config{}, p.envPrefix())
+	Expect(err).ToNot(HaveOccurred())
+	Expect(etcdCfg.Endpoints).To(Equal([]string{"10.0.0.2:2379"}))
+	Expect(etcdCfg.Username).To(Equal("remote"))
+	Expect(etcdCfg.Password).To(Equal("secret"))
+
+	p = NewPlugin()
+	Expect(p.envPrefix()).To(Equal("ETCD"))
+	etcdCfg, err = configToClient(&Config{}, p.envPrefix())
+	Expect(err).ToNot(HaveOccurred())
+	Expect(etcdCfg.Endpoints).To(Equal([]string{"10.0.0.1:2379"}))
+	Expect(etcdCfg.Username).To(BeEmpty())
+}
+
+func TestNewElectionWithoutConnection(t *testing.T) {
+	RegisterTestingT(t)
+
+	// connection is not established yet with delayed start
+	p := NewPlugin()
+	election, err := p.NewElection("/election")
+	Expect(err).To(HaveOccurred())
+	Expect(election).To(BeNil())
+}
+
 func TestTxnPut(t *testing.T) {
 	ctx := setupTest(t)
 	defer ctx.teardownTest()
@@ -344,3 +403,90 @@ func TestTxnDelete(t *testing.T) {
 	err := txn.Commit(context.Background())
 	Expect(err).ToNot(HaveOccurred())
 }
+
+// mockWatcher records revisions of created watches and serves queued channels.
+type mockWatcher struct {
+	revs  chan int64
+	chans chan chan clientv3.WatchResponse
+}
+
+func (mock *mockWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	mock.revs <- clientv3.OpGet(key, opts...).Rev()
+	return <-mock.chans
+}
+
+func (mock *mockWatcher) RequestProgress(ctx context.Context) error {
+	return nil
+}
+
+func (mock *mockWatcher) Close() error {
+	return nil
+}
+
+func TestWatchResumeAfterCompaction(t *testing.T) {
+	RegisterTestingT(t)
+
+	defer func(interval time.Duration) { watchRetryInterval = interval }(watchRetryInterval)
+	watchRetryInterval = time.Millisecond
+
+	watcher := &mockWatcher{
+		revs:  make(chan int64, 10),
+		chans: make(chan chan clientv3.WatchResponse, 10),
+	}
+	first := make(chan clientv3.WatchResponse, 10)
+	watcher.chans <- first
+
+	missed := make(chan string, 10)
+	events := make(chan keyval.BytesWatchResp, 10)
+	closeCh := make(chan string)
+	defer close(closeCh)
+
+	err := watchInternal(logrus.DefaultLogger(), context.Background(), watcher, closeCh, "/prefix/",
+		func(resp keyval.BytesWatchResp) { events <- resp },
+		func(keyPrefix string) { missed <- keyPrefix })
+	Expect(err).ToNot(HaveOccurred())
+	Expect(<-watcher.revs).To(BeEquivalentTo(0))
+
+	// event received, then compaction is reported
+	first <- clientv3.WatchResponse{Events: []*clientv3.Event{{
+		Type: mvccpb.PUT,
+		Kv:   &mvccpb.KeyValue{Key: []byte("/prefix/a"), Value: []byte("a"), ModRevision: 5},
+	}}}
+	Eventually(events).Should(Receive())
+	second := make(chan clientv3.WatchResponse, 10)
+	watcher.chans <- second
+	first <- clientv3.WatchResponse{CompactRevision: 10, Canceled: true}
+	Eventually(watcher.revs).Should(Receive(BeEquivalentTo(10)))
+	Eventually(missed).Should(Receive(Equal("/prefix/")))
+
+	// cancellation resumes the watch after the last received revision without resync
+	third := make(chan clientv3.WatchResponse, 10)
+	watcher.chans <- third
+	second <- clientv3.WatchResponse{Events: []*clientv3.Event{{
+		Type: mvccpb.PUT,
+		Kv:   &mvccpb.KeyValue{Key: []byte("/prefix/b"), Value: []byte("b"), ModRevision: 12},
+	}}}
+	Eventually(events).Should(Receive())
+	second <- clientv3.WatchResponse{Canceled: true}
+	Eventually(watcher.revs).Should(Receive(BeEquivalentTo(13)))
+	Consistently(missed, 50*time.Millisecond).ShouldNot(Receive())
+
+	// header revision of a batch of events does not move the resume revision past
+	// events which were not delivered yet
+	fourth := make(chan clientv3.WatchResponse, 10)
+	watcher.chans <- fourth
+	third <- clientv3.WatchResponse{Header: etcdserverpb.ResponseHeader{Revision: 20}, Events: []*clientv3.Event{{
+		Type: mvccpb.PUT,
+		Kv:   &mvccpb.KeyValue{Key: []byte("/prefix/c"), Value: []byte("c"), ModRevision: 14},
+	}}}
+	Eventually(events).Should(Receive())
+	third <- clientv3.WatchResponse{Canceled: true}
+	Eventually(watcher.revs).Should(Receive(BeEquivalentTo(15)))
+
+	// progress notification confirms that all events up to its revision were received
+	fifth := make(chan clientv3.WatchResponse, 10)
+	watcher.chans <- fifth
+	fourth <- clientv3.WatchResponse{Header: etcdserverpb.ResponseHeader{Revision: 25}}
+	fourth <- clientv3.WatchResponse{Canceled: true}
+	Eventually(watcher.revs).Should(Receive(BeEquivalentTo(26)))
+}
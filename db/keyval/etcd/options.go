@@ -26,7 +26,7 @@ var DefaultPlugin = *NewPlugin()
 func NewPlugin(opts ...Option) *Plugin {
 	p := &Plugin{}
 
-	p.PluginName = "etcd"
+	p.PluginName = defaultPluginName
 	p.StatusCheck = &statuscheck.DefaultPlugin
 	p.Resync = &resync.DefaultPlugin
 
@@ -42,9 +42,26 @@ func NewPlugin(opts ...Option) *Plugin {
 // Option is a function that can be used in NewPlugin to customize Plugin.
 type Option func(*Plugin)
 
+// UseName returns Option that sets the name of the plugin instance. The name
+// distinguishes multiple etcd plugins connected to different clusters - each
+// instance reads its own config file (<name>.conf, <name>-config flag) and
+// environment variables (e.g. ETCD_REMOTE_ENDPOINTS for the name "etcd-remote").
+func UseName(name string) Option {
+	return func(p *Plugin) {
+		p.SetName(name)
+	}
+}
+
 // UseDeps returns Option that can inject custom dependencies.
 func UseDeps(cb func(*Deps)) Option {
 	return func(p *Plugin) {
 		cb(&p.Deps)
 	}
 }
+
+// UseConf returns Option which injects a particular configuration.
+func UseConf(conf Config) Option {
+	return func(p *Plugin) {
+		p.config = &conf
+	}
+}
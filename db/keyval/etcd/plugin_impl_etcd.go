@@ -17,6 +17,7 @@ package etcd
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,6 +30,8 @@ import (
 )
 
 const (
+	// defaultPluginName is the name of the default etcd plugin instance
+	defaultPluginName = "etcd"
 	// healthCheckProbeKey is a key used to probe Etcd state
 	healthCheckProbeKey = "/probe-etcd-connection"
 	// ETCD reconnect interval
@@ -60,6 +63,10 @@ type Plugin struct {
 
 	autoCompactDone chan struct{}
 	lastConnErr     error
+
+	// ctx is canceled when the plugin is closed
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // Deps lists dependencies of the etcd plugin.
@@ -81,6 +88,8 @@ type Deps struct {
 // Check clientv3.New from coreos/etcd for possible errors returned in case
 // the connection cannot be established.
 func (p *Plugin) Init() (err error) {
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
 	// Read ETCD configuration file. Returns error if does not exists.
 	p.config, err = p.getEtcdConfig()
 	if err != nil || p.disabled {
@@ -88,7 +97,7 @@ func (p *Plugin) Init() (err error) {
 	}
 
 	// Transforms .yaml config to ETCD client configuration
-	etcdClientCfg, err := ConfigToClient(p.config)
+	etcdClientCfg, err := configToClient(p.config, p.envPrefix())
 	if err != nil {
 		return err
 	}
@@ -127,6 +136,9 @@ func (p *Plugin) AfterInit() error {
 
 // Close shutdowns the connection.
 func (p *Plugin) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
 	return safeclose.Close(p.autoCompactDone)
 }
 
@@ -147,6 +159,26 @@ func (p *Plugin) NewBrokerWithAtomic(keyPrefix string) keyval.BytesBrokerWithAto
 	return p.connection.NewBroker(keyPrefix).(keyval.BytesBrokerWithAtomic)
 }
 
+// NewLocker creates new instance of locker that provides named locks bound to the session of the etcd connection.
+// <keyPrefix> is prepended to the names of all locks.
+func (p *Plugin) NewLocker(keyPrefix string) keyval.Locker {
+	return p.connection.NewLocker(keyPrefix)
+}
+
+// NewElection creates new instance of leader election on a given prefix bound to the session of the etcd connection.
+// If StatusCheck is injected, the state of the election is reported in the agent status.
+// Error is returned if the connection is not established yet (e.g. with delayed start).
+func (p *Plugin) NewElection(prefix string) (keyval.Election, error) {
+	if p.connection == nil {
+		return nil, fmt.Errorf("connection is not established")
+	}
+	election := p.connection.NewElection(prefix)
+	if sw, ok := p.StatusCheck.(statuscheck.ElectionStatusWriter); ok {
+		go statuscheck.ReportElection(p.ctx, sw, prefix, election)
+	}
+	return election, nil
+}
+
 // RawAccess allows to access data in the database as raw bytes (i.e. not formatted by protobuf).
 func (p *Plugin) RawAccess() keyval.KvBytesPlugin {
 	return p.connection
@@ -180,6 +212,7 @@ func (p *Plugin) GetPluginName() infra.PluginName {
 // CampaignInElection starts campaign in leader election on a given prefix. Multiple instances can compete on a given prefix.
 // Only one can be elected as leader at a time. The function call blocks until either context is canceled or the caller is elected as leader.
 // Upon successful call a resign callback that triggers new election is returned.
+// Use NewElection to also query and observe the current leader.
 func (p *Plugin) CampaignInElection(ctx context.Context, prefix string) (func(context.Context), error) {
 	if p.connection != nil {
 		return p.connection.CampaignInElection(ctx, prefix)
@@ -258,6 +291,19 @@ func (p *Plugin) configureConnection(expandEnvVars bool) {
 		serializer = &keyval.SerializerJSON{ExpandEnvVars: expandEnvVars}
 	}
 	p.protoWrapper = kvproto.NewProtoWrapper(p.connection, serializer)
+	p.connection.OnMissedEvents(p.resyncMissedEvents)
+}
+
+// resyncMissedEvents requests resync of subscriptions watching the key prefix, whose watch
+// has missed some events (e.g. due to compaction of the etcd history).
+func (p *Plugin) resyncMissedEvents(keyPrefix string) {
+	if p.Resync == nil {
+		p.Log.Warnf("Resync of key prefix %s after missed watch events could not start because of missing Resync plugin",
+			keyPrefix)
+		return
+	}
+	p.Log.Infof("Watch of key prefix %s has missed events, starting resync", keyPrefix)
+	go p.Resync.ResyncPrefixes(keyPrefix)
 }
 
 // ETCD status check probe function
@@ -284,6 +330,10 @@ func (p *Plugin) statusCheckProbe() (statuscheck.PluginState, error) {
 }
 
 func (p *Plugin) getEtcdConfig() (*Config, error) {
+	if p.config != nil {
+		// configuration injected using UseConf
+		return p.config, nil
+	}
 	var etcdCfg Config
 	found, err := p.Cfg.LoadValue(&etcdCfg)
 	if err != nil {
@@ -296,6 +346,16 @@ func (p *Plugin) getEtcdConfig() (*Config, error) {
 	return &etcdCfg, nil
 }
 
+// envPrefix returns prefix of environment variables used to configure the plugin.
+// The default instance uses ETCD, other named instances (e.g. "etcd-remote") use
+// the upper-cased plugin name (e.g. ETCD_REMOTE) to keep their configuration separated.
+func (p *Plugin) envPrefix() string {
+	if p.PluginName == "" || p.PluginName == defaultPluginName {
+		return defaultEnvPrefix
+	}
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(p.String()))
+}
+
 func (p *Plugin) startPeriodicAutoCompact(period time.Duration) {
 	p.autoCompactDone = make(chan struct{})
 	go func() {
@@ -20,9 +20,9 @@ func (pdb *BrokerWatcher) Put(key string, data []byte, opts ...datasync.PutOptio
 	return pdb.Client.Put(pdb.prefixKey(key), data, opts...)
 }
 
-// NewTxn calls client's 'NewTxn' method
+// NewTxn creates new transaction with keys prefixed by the broker prefix
 func (pdb *BrokerWatcher) NewTxn() keyval.BytesTxn {
-	return pdb.Client.NewTxn()
+	return &txn{c: pdb.Client, prefix: pdb.prefix}
 }
 
 // GetValue calls client's 'GetValue' method
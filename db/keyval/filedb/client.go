@@ -16,8 +16,10 @@ package filedb
 
 import (
 	"bytes"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"go.ligato.io/cn-infra/v2/db/keyval/filedb/database"
 	"go.ligato.io/cn-infra/v2/db/keyval/filedb/decoder"
@@ -29,6 +31,7 @@ import (
 
 	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvlock"
 	"go.ligato.io/cn-infra/v2/logging"
 )
 
@@ -47,8 +50,12 @@ type Client struct {
 	// Status reader is chosen according to status file extension.
 	statusDecoder decoder.API
 
-	// Internal database mirrors changes in file system. Since the configuration can be only read, it is up to client
-	// to handle difference between configuration revisions. Every database entry consists from three values:
+	// Files where new keys are written, selected by the longest matching key prefix. Keys without matching
+	// prefix are written to the status file.
+	writeTargets map[string]string
+
+	// Internal database mirrors changes in file system. It is up to client to handle difference between
+	// configuration revisions. Every database entry consists from three values:
 	//  - path (where the configuration is written)
 	//  - data key
 	//  - data value
@@ -64,10 +71,39 @@ type Client struct {
 
 	// A set of watchers for every key.
 	watchers map[string]chan keyedData
+
+	// Locks held by this process.
+	locks *kvlock.LocalLocker
+
+	// Serializes writes to files and processing of filesystem events. Guards also the expiry of keys.
+	writeMu             sync.Mutex
+	expiry              expiryState
+	expiryCheckInterval time.Duration
+	quit                chan struct{}
+	wg                  sync.WaitGroup
+}
+
+// ClientOption customizes Client created by NewClient.
+type ClientOption func(*Client)
+
+// WithExpiryCheckInterval returns ClientOption that sets the interval between removals of expired keys.
+func WithExpiryCheckInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.expiryCheckInterval = interval
+	}
+}
+
+// WithWriteTargets returns ClientOption that maps key prefixes to files where new keys with the prefix
+// are written. Existing keys are always rewritten in the file where they are stored.
+func WithWriteTargets(targets map[string]string) ClientOption {
+	return func(c *Client) {
+		c.writeTargets = targets
+	}
 }
 
 // NewClient initializes file watcher, database and registers paths provided via plugin configuration file
-func NewClient(cfgPaths []string, statusPath string, dcs []decoder.API, fsh filesystem.API, log logging.Logger) (*Client, error) {
+func NewClient(cfgPaths []string, statusPath string, dcs []decoder.API, fsh filesystem.API, log logging.Logger,
+	opts ...ClientOption) (*Client, error) {
 	// Init client object
 	c := &Client{
 		cfgPaths:   cfgPaths,
@@ -77,6 +113,10 @@ func NewClient(cfgPaths []string, statusPath string, dcs []decoder.API, fsh file
 		db:         database.NewDbClient(),
 		decoders:   dcs,
 		log:        log,
+		locks:      kvlock.NewLocalLocker(),
+	}
+	for _, o := range opts {
+		o(c)
 	}
 
 	// Init filesystem handler
@@ -86,20 +126,8 @@ func NewClient(cfgPaths []string, statusPath string, dcs []decoder.API, fsh file
 	}
 	// Decode initial configuration
 	for _, filePath := range filePaths {
-		if dc := c.getFileDecoder(filePath); dc != nil {
-			byteFile, err := c.fsHandler.ReadFile(filePath)
-			if err != nil {
-				return nil, errors.Errorf("failed to read file %s content: %v", filePath, err)
-			}
-			fileEntries, err := dc.Decode(byteFile)
-			file := &decoder.File{Path: filePath, Data: fileEntries}
-			if err != nil {
-				return nil, errors.Errorf("failed to decode file %s: %v", filePath, err)
-			}
-			// Put all the configuration to the database
-			for _, data := range file.Data {
-				c.db.Add(file.Path, &decoder.FileDataEntry{Key: data.Key, Value: data.Value})
-			}
+		if err := c.loadFile(filePath); err != nil {
+			return nil, err
 		}
 	}
 	// Validate and prepare the status file and decoder
@@ -121,10 +149,55 @@ func NewClient(cfgPaths []string, statusPath string, dcs []decoder.API, fsh file
 			return nil, errors.Errorf("failed to process status file, unexpected processing output: %v", err)
 		}
 	}
+	// Load content of files written by the client, so that their content is preserved
+	writablePaths := []string{c.statusPath}
+	for _, target := range c.writeTargets {
+		if c.getFileDecoder(target) == nil {
+			return nil, errors.Errorf("failed to get decoder for target file (unknown extension) %s", target)
+		}
+		writablePaths = append(writablePaths, target)
+	}
+	for _, path := range writablePaths {
+		if path != "" && c.fsHandler.FileExists(path) {
+			if err := c.loadFile(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c.statusPath != "" || len(c.writeTargets) > 0 {
+		// Remove expired keys and keys with client lifetime left by previous client
+		if err := c.loadExpiry(); err != nil {
+			return nil, err
+		}
+		c.quit = make(chan struct{})
+		c.wg.Add(1)
+		go c.startReaper(c.expiryCheckInterval)
+	}
 
 	return c, nil
 }
 
+// loadFile decodes the file and puts all its entries to the database. Files without known extension are skipped.
+func (c *Client) loadFile(filePath string) error {
+	dc := c.getFileDecoder(filePath)
+	if dc == nil {
+		return nil
+	}
+	byteFile, err := c.fsHandler.ReadFile(filePath)
+	if err != nil {
+		return errors.Errorf("failed to read file %s content: %v", filePath, err)
+	}
+	fileEntries, err := decodeFile(dc, filePath, byteFile)
+	if err != nil {
+		return errors.Errorf("failed to decode file %s: %v", filePath, err)
+	}
+	// Put all the configuration to the database
+	for _, data := range fileEntries {
+		c.db.Add(filePath, &decoder.FileDataEntry{Key: data.Key, Value: data.Value})
+	}
+	return nil
+}
+
 // GetPaths returns client file paths
 func (c *Client) GetPaths() []string {
 	return c.cfgPaths
@@ -162,46 +235,21 @@ func (c *Client) NewWatcher(prefix string) keyval.BytesWatcher {
 	}
 }
 
-// Put reads status file, add data to it and performs write
+// Put writes data to the file where the key is stored. New keys are written to the target file selected by the key
+// prefix, or to the status file. The file is replaced atomically and watchers are notified about the change.
+// Options WithTTL and WithClientLifetimeTTL are supported, expired keys are removed from files periodically.
 func (c *Client) Put(key string, data []byte, opts ...datasync.PutOption) error {
-	newEntry := &decoder.FileDataEntry{Key: key, Value: data}
-	statusDataEntries := c.db.GetDataForFile(c.statusPath)
-	// Add/update data
-	var updated bool
-	for _, statusDataEntry := range statusDataEntries {
-		if statusDataEntry.Key == key {
-			statusDataEntry.Value = data
-			updated = true
-			break
-		}
-	}
-	if !updated {
-		statusDataEntries = append(statusDataEntries, newEntry)
-	}
-	c.db.Add(c.statusPath, newEntry)
-	// Encode and write
-	stFileEntries, err := c.statusDecoder.Encode(statusDataEntries)
-	if err != nil {
-		return errors.Errorf("failed to write status to fileDB: unable to encode status file %s: %v", c.statusPath, err)
-	}
-	err = c.fsHandler.WriteFile(c.statusPath, stFileEntries)
-	if err != nil {
-		return errors.Errorf("failed to write status %s to fileDB: %v", c.statusPath, err)
-	}
-	return nil
-}
-
-// NewTxn is not supported, filesystem plugin does not allow to do changes to the configuration
-func (c *Client) NewTxn() keyval.BytesTxn {
-	c.log.Warnf("creating transaction chains in fileDB is currently not allowed")
-	return nil
+	_, err := c.commit([]fileOp{{key: key, value: data, opts: opts}})
+	return err
 }
 
 // GetValue returns a value for given key
 func (c *Client) GetValue(key string) (data []byte, found bool, revision int64, err error) {
 	var entry *decoder.FileDataEntry
 	entry, found = c.db.GetDataForKey(key)
-	data = entry.Value
+	if found {
+		data = entry.Value
+	}
 	return
 }
 
@@ -228,10 +276,20 @@ func (c *Client) ListKeys(prefix string) (keyval.BytesKeyIterator, error) {
 	return &bytesKeyIterator{len: len(keysWithoutPrefix), keys: keysWithoutPrefix, prefix: prefix}, nil
 }
 
-// Delete is not allowed for fileDB, configuration file is read-only
+// Delete removes the key from the file where it is stored. With option WithPrefix all keys with the given prefix
+// are removed.
 func (c *Client) Delete(key string, opts ...datasync.DelOption) (existed bool, err error) {
-	c.log.Warnf("deleting configuration from fileDB is currently not allowed")
-	return false, nil
+	keys := []string{key}
+	for _, o := range opts {
+		if _, ok := o.(*datasync.WithPrefixOpt); ok {
+			keys = keys[:0]
+			for _, entry := range c.db.GetDataForPrefix(key) {
+				keys = append(keys, entry.Key)
+			}
+		}
+	}
+	deleted, err := c.commit(deleteOps(keys))
+	return len(deleted) > 0, err
 }
 
 // Watch starts single watcher for every key prefix. Every watcher listens on its own data channel.
@@ -248,14 +306,29 @@ func (c *Client) Watch(resp func(response keyval.BytesWatchResp), closeChan chan
 	return nil
 }
 
-// Close closes all readers
+// Close closes all readers. Keys with client lifetime are removed from files.
 func (c *Client) Close() error {
+	if c.quit != nil {
+		close(c.quit)
+		c.wg.Wait()
+		c.quit = nil
+		c.removeKeys(func() []string {
+			return c.clientLifetimeKeys()
+		})
+	}
+	c.locks.Close()
 	if c.fsHandler != nil {
 		return c.fsHandler.Close()
 	}
 	return nil
 }
 
+// NewLocker returns locker with locks held in the memory of this process. <prefix> is prepended to the names
+// of all locks.
+func (c *Client) NewLocker(prefix string) keyval.Locker {
+	return c.locks.Prefixed(prefix)
+}
+
 // Awaits changes from data channel, prepares responses and sends them to the response function
 func (c *Client) watch(resp func(response keyval.BytesWatchResp), dataChan chan keyedData, closeChan chan string, key string) {
 	for {
@@ -281,6 +354,15 @@ func (c *Client) eventWatcher() {
 // OnEvent is common method called when new event from file system arrives. Different files may require different
 // reader, but the data processing is the same.
 func (c *Client) onEvent(event fsnotify.Event) {
+	c.writeMu.Lock()
+	events := c.processEvent(event)
+	c.writeMu.Unlock()
+	c.notify(events)
+}
+
+// processEvent updates the database according to the file changed by the event and returns events which should
+// be sent to watchers. Must be called with writeMu locked.
+func (c *Client) processEvent(event fsnotify.Event) (events []keyedData) {
 	// If file was removed, delete all configuration associated with it. Do the same action for
 	// rename, following action will be create with the new name which re-applies the configuration
 	// (if new name is in scope of the defined path)
@@ -292,26 +374,26 @@ func (c *Client) onEvent(event fsnotify.Event) {
 				path:      event.Name,
 				watchResp: watchResp{Op: datasync.Delete, Key: entry.Key, Value: nil, PrevValue: entry.Value},
 			}
-			c.sendToChannel(keyed)
-			c.db.DeleteFile(event.Name)
+			events = append(events, keyed)
 		}
-		return
+		c.db.DeleteFile(event.Name)
+		return events
 	}
 
 	// Read data from file
 	dc := c.getFileDecoder(event.Name)
 	if dc == nil {
-		return
+		return c.rescanDirectory(event.Name)
 	}
 	byteFile, err := c.fsHandler.ReadFile(event.Name)
 	if err != nil {
 		c.log.Errorf("failed to process filesystem event: file cannot be read %s: %v", event.Name, err)
-		return
+		return nil
 	}
-	decodedFileEntries, err := dc.Decode(byteFile)
+	decodedFileEntries, err := decodeFile(dc, event.Name, byteFile)
 	if err != nil {
 		c.log.Errorf("failed to process filesystem event: file cannot be decoded %s: %v", event.Name, err)
-		return
+		return nil
 	}
 	file := &decoder.File{Path: event.Name, Data: decodedFileEntries}
 	latestFile := &decoder.File{Path: event.Name, Data: c.db.GetDataForFile(event.Name)}
@@ -322,7 +404,7 @@ func (c *Client) onEvent(event fsnotify.Event) {
 			path:      event.Name,
 			watchResp: watchResp{Op: datasync.Delete, Key: fileDataEntry.Key, Value: nil, PrevValue: fileDataEntry.Value},
 		}
-		c.sendToChannel(keyed)
+		events = append(events, keyed)
 		c.db.Delete(event.Name, keyed.Key)
 	}
 	for _, fileDataEntry := range changed {
@@ -335,12 +417,39 @@ func (c *Client) onEvent(event fsnotify.Event) {
 			path:      event.Name,
 			watchResp: watchResp{Op: datasync.Put, Key: fileDataEntry.Key, Value: fileDataEntry.Value, PrevValue: prevVal},
 		}
-		c.sendToChannel(keyed)
+		events = append(events, keyed)
 		c.db.Add(event.Name, &decoder.FileDataEntry{
 			Key:   keyed.Key,
 			Value: keyed.Value,
 		})
 	}
+	return events
+}
+
+// Kubelet updates ConfigMap volume by replacement of the hidden "..data" symlink, while files with values
+// are symlinks into "..data" and do not change. All files of the directory layout are therefore processed again
+// once a hidden entry in its root changes.
+func (c *Client) rescanDirectory(name string) (events []keyedData) {
+	if !strings.HasPrefix(filepath.Base(name), "..") {
+		return nil
+	}
+	for _, dc := range c.decoders {
+		pd, ok := dc.(decoder.PathDecoder)
+		if !ok || filepath.Dir(name) != pd.Root() {
+			continue
+		}
+		files, err := c.fsHandler.GetFileNames([]string{pd.Root()})
+		if err != nil {
+			c.log.Errorf("failed to process filesystem event: directory cannot be read %s: %v", pd.Root(), err)
+			continue
+		}
+		for _, file := range files {
+			if c.getFileDecoder(file) == dc {
+				events = append(events, c.processEvent(fsnotify.Event{Name: file, Op: fsnotify.Write})...)
+			}
+		}
+	}
+	return events
 }
 
 // OnClose is called from filesystem watcher when the file system data channel is closed.
@@ -364,7 +473,16 @@ func (c *Client) sendToChannel(keyed keyedData) {
 }
 
 // Use known decoders to decide whether the file can or cannot be processed. If so, return proper decoder.
+// Files under the root of a directory layout are processed only by its decoder.
 func (c *Client) getFileDecoder(file string) decoder.API {
+	for _, dc := range c.decoders {
+		if pd, ok := dc.(decoder.PathDecoder); ok && pd.Contains(file) {
+			if pd.IsProcessable(file) {
+				return pd
+			}
+			return nil
+		}
+	}
 	for _, dc := range c.decoders {
 		if dc.IsProcessable(file) {
 			return dc
@@ -372,3 +490,11 @@ func (c *Client) getFileDecoder(file string) decoder.API {
 	}
 	return nil
 }
+
+// Decode file content, decoders deriving keys from the file path get the path as well.
+func decodeFile(dc decoder.API, path string, data []byte) ([]*decoder.FileDataEntry, error) {
+	if pd, ok := dc.(decoder.PathDecoder); ok {
+		return pd.DecodeFile(path, data)
+	}
+	return dc.Decode(data)
+}
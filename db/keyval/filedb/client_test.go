@@ -15,6 +15,9 @@
 package filedb_test
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -271,3 +274,319 @@ func TestJsonReaderWatcher(t *testing.T) {
 	_, ok = client.GetDataForKey("/test-path/vpp/config/interfaces/if2")
 	Expect(ok).To(BeFalse())
 }
+
+func TestStatusTTL(t *testing.T) {
+	RegisterTestingT(t)
+	statusPath := filepath.Join(t.TempDir(), "status.json")
+	decoders := []decoder.API{decoder.NewJSONDecoder()}
+
+	client, err := filedb.NewClient(nil, statusPath, decoders, filesystem.NewFsHandler(), log,
+		filedb.WithExpiryCheckInterval(10*time.Millisecond))
+	Expect(err).To(BeNil())
+
+	watchCh := make(chan keyval.BytesWatchResp, 10)
+	Expect(client.Watch(keyval.ToChan(watchCh), nil, "/status/")).To(Succeed())
+
+	Expect(client.Put("/status/ttl", []byte(`{"state":"ttl"}`), datasync.WithTTL(50*time.Millisecond))).To(Succeed())
+	Expect(client.Put("/status/lifetime", []byte(`{"state":"lifetime"}`), datasync.WithClientLifetimeTTL())).To(Succeed())
+	Expect(client.Put("/status/persistent", []byte(`{"state":"persistent"}`))).To(Succeed())
+
+	var resp keyval.BytesWatchResp
+	for i := 0; i < 3; i++ {
+		Eventually(watchCh).Should(Receive(&resp))
+		Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	}
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Delete))
+	Expect(resp.GetKey()).To(Equal("/status/ttl"))
+	_, found, _, _ := client.GetValue("/status/ttl")
+	Expect(found).To(BeFalse())
+	Expect(readStatusKeys(statusPath)).To(ConsistOf("/status/lifetime", "/status/persistent"))
+
+	Expect(client.Close()).To(Succeed())
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetKey()).To(Equal("/status/lifetime"))
+	Expect(readStatusKeys(statusPath)).To(ConsistOf("/status/persistent"))
+}
+
+func TestStatusClientLifetimeAfterRestart(t *testing.T) {
+	RegisterTestingT(t)
+	statusPath := filepath.Join(t.TempDir(), "status.json")
+	decoders := []decoder.API{decoder.NewJSONDecoder()}
+
+	client, err := filedb.NewClient(nil, statusPath, decoders, filesystem.NewFsHandler(), log)
+	Expect(err).To(BeNil())
+	Expect(client.Put("/status/lifetime", []byte(`{"state":"lifetime"}`), datasync.WithClientLifetimeTTL())).To(Succeed())
+	Expect(client.Put("/status/persistent", []byte(`{"state":"persistent"}`))).To(Succeed())
+	Expect(readStatusKeys(statusPath)).To(ConsistOf("/status/lifetime", "/status/persistent"))
+
+	// keys left by the client which was not closed are removed by the next client
+	client, err = filedb.NewClient(nil, statusPath, decoders, filesystem.NewFsHandler(), log)
+	Expect(err).To(BeNil())
+	defer client.Close()
+	Expect(readStatusKeys(statusPath)).To(ConsistOf("/status/persistent"))
+}
+
+func TestStatusExpirySaveFailure(t *testing.T) {
+	RegisterTestingT(t)
+	statusPath := filepath.Join(t.TempDir(), "status.json")
+	client, err := filedb.NewClient(nil, statusPath, []decoder.API{decoder.NewJSONDecoder()},
+		filesystem.NewFsHandler(), log)
+	Expect(err).To(BeNil())
+	defer client.Close()
+	// a directory in place of the expiry file makes storing of the expiry fail
+	Expect(os.Mkdir(statusPath+".ttl", os.ModePerm)).To(Succeed())
+
+	// the value is written even though its expiry cannot be stored
+	Expect(client.Put("/status/ttl", []byte(`{"state":"ttl"}`), datasync.WithTTL(time.Minute))).To(Succeed())
+	_, found, _, _ := client.GetValue("/status/ttl")
+	Expect(found).To(BeTrue())
+	Expect(readStatusKeys(statusPath)).To(ConsistOf("/status/ttl"))
+}
+
+func TestWriteTargets(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config", "config.json")
+	targetPath := filepath.Join(dir, "config", "target.json")
+	statusPath := filepath.Join(dir, "status.json")
+	Expect(os.MkdirAll(filepath.Dir(cfgPath), os.ModePerm)).To(Succeed())
+	Expect(os.WriteFile(cfgPath, []byte(`{"data":[{"key":"/agent/config/a","value":{"id":"a"}}]}`), 0644)).To(Succeed())
+
+	client, err := filedb.NewClient([]string{cfgPath}, statusPath, []decoder.API{decoder.NewJSONDecoder()},
+		filesystem.NewFsHandler(), log, filedb.WithWriteTargets(map[string]string{"/agent/config/": targetPath}))
+	Expect(err).To(BeNil())
+	defer client.Close()
+	filedb.RunEventWatcher(client)
+	time.Sleep(100 * time.Millisecond)
+
+	watchCh := make(chan keyval.BytesWatchResp, 10)
+	Expect(client.Watch(keyval.ToChan(watchCh), nil, "/agent/")).To(Succeed())
+
+	// existing key is rewritten in its file, new keys go to the target file and the status file
+	Expect(client.Put("/agent/config/a", []byte(`{"id":"a2"}`))).To(Succeed())
+	Expect(client.Put("/agent/config/b", []byte(`{"id":"b"}`))).To(Succeed())
+	Expect(client.Put("/agent/status/a", []byte(`{"state":"ok"}`))).To(Succeed())
+	Expect(readStatusKeys(cfgPath)).To(ConsistOf("/agent/config/a"))
+	Expect(readStatusKeys(targetPath)).To(ConsistOf("/agent/config/b"))
+	Expect(readStatusKeys(statusPath)).To(ConsistOf("/agent/status/a"))
+
+	var resp keyval.BytesWatchResp
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	Expect(resp.GetKey()).To(Equal("/agent/config/a"))
+	Expect(resp.GetValue()).To(BeEquivalentTo(`{"id":"a2"}`))
+	Expect(resp.GetPrevValue()).To(BeEquivalentTo(`{"id":"a"}`))
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetKey()).To(Equal("/agent/config/b"))
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetKey()).To(Equal("/agent/status/a"))
+
+	existed, err := client.Delete("/agent/config/a")
+	Expect(err).To(BeNil())
+	Expect(existed).To(BeTrue())
+	existed, err = client.Delete("/agent/config/missing")
+	Expect(err).To(BeNil())
+	Expect(existed).To(BeFalse())
+	Expect(readStatusKeys(cfgPath)).To(BeEmpty())
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Delete))
+	Expect(resp.GetKey()).To(Equal("/agent/config/a"))
+	Expect(resp.GetPrevValue()).To(BeEquivalentTo(`{"id":"a2"}`))
+	// own writes are not reported again by the file system watcher
+	Consistently(watchCh, 200*time.Millisecond).ShouldNot(Receive())
+
+	// the watch of the replaced file is renewed, external changes are still reported
+	Expect(os.WriteFile(cfgPath, []byte(`{"data":[{"key":"/agent/config/c","value":{"id":"c"}}]}`), 0644)).To(Succeed())
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	Expect(resp.GetKey()).To(Equal("/agent/config/c"))
+}
+
+func TestTxn(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	targetPath := filepath.Join(dir, "target.json")
+	statusPath := filepath.Join(dir, "status.json")
+
+	client, err := filedb.NewClient(nil, statusPath, []decoder.API{decoder.NewJSONDecoder(), decoder.NewYAMLDecoder()},
+		filesystem.NewFsHandler(), log, filedb.WithWriteTargets(map[string]string{"/agent/config/": targetPath}))
+	Expect(err).To(BeNil())
+	defer client.Close()
+
+	broker := client.NewBroker("/agent/")
+	Expect(broker.Put("status/old", []byte(`{"state":"old"}`))).To(Succeed())
+
+	watchCh := make(chan keyval.BytesWatchResp, 10)
+	Expect(client.Watch(keyval.ToChan(watchCh), nil, "/agent/")).To(Succeed())
+
+	err = broker.NewTxn().
+		Put("config/a", []byte(`{"id":"a"}`)).
+		Put("config/b", []byte(`{"id":"b"}`)).
+		Delete("config/b").
+		Put("status/new", []byte(`{"state":"new"}`)).
+		Delete("status/old").
+		Commit(context.Background())
+	Expect(err).To(BeNil())
+	Expect(readStatusKeys(targetPath)).To(ConsistOf("/agent/config/a"))
+	Expect(readStatusKeys(statusPath)).To(ConsistOf("/agent/status/new"))
+
+	var keys []string
+	var resp keyval.BytesWatchResp
+	for i := 0; i < 3; i++ {
+		Eventually(watchCh).Should(Receive(&resp))
+		keys = append(keys, resp.GetKey())
+	}
+	Expect(keys).To(ConsistOf("/agent/config/a", "/agent/status/new", "/agent/status/old"))
+	Consistently(watchCh, 100*time.Millisecond).ShouldNot(Receive())
+
+	// transaction with a key which cannot be encoded leaves all files untouched
+	err = client.NewTxn().
+		Put("/agent/config/c", []byte(`{"id":"c"}`)).
+		Put("/agent/status/invalid", []byte(`not json`)).
+		Commit(context.Background())
+	Expect(err).ToNot(BeNil())
+	Expect(readStatusKeys(targetPath)).To(ConsistOf("/agent/config/a"))
+	Expect(readStatusKeys(statusPath)).To(ConsistOf("/agent/status/new"))
+	_, found, _, _ := client.GetValue("/agent/config/c")
+	Expect(found).To(BeFalse())
+
+	// all keys with the prefix are removed from all files
+	existed, err := client.Delete("/agent/", datasync.WithPrefix())
+	Expect(err).To(BeNil())
+	Expect(existed).To(BeTrue())
+	Expect(readStatusKeys(targetPath)).To(BeEmpty())
+	Expect(readStatusKeys(statusPath)).To(BeEmpty())
+}
+
+func TestNoTargetFile(t *testing.T) {
+	RegisterTestingT(t)
+	targetPath := filepath.Join(t.TempDir(), "target.json")
+
+	client, err := filedb.NewClient(nil, "", []decoder.API{decoder.NewJSONDecoder()}, filesystem.NewFsHandler(), log,
+		filedb.WithWriteTargets(map[string]string{"/agent/config/": targetPath}))
+	Expect(err).To(BeNil())
+	defer client.Close()
+
+	Expect(client.Put("/agent/config/a", []byte(`{"id":"a"}`))).To(Succeed())
+	Expect(client.Put("/agent/status/a", []byte(`{"state":"ok"}`))).ToNot(Succeed())
+	Expect(readStatusKeys(targetPath)).To(ConsistOf("/agent/config/a"))
+}
+
+func TestDirectoryLayout(t *testing.T) {
+	RegisterTestingT(t)
+	// simulate ConfigMap volume with values in a timestamped directory linked by "..data"
+	root := t.TempDir()
+	Expect(os.Mkdir(filepath.Join(root, "..v1"), os.ModePerm)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(root, "..v1", "if1"), []byte(`{"name":"if1"}`), 0644)).To(Succeed())
+	Expect(os.Symlink("..v1", filepath.Join(root, "..data"))).To(Succeed())
+	Expect(os.Symlink(filepath.Join("..data", "if1"), filepath.Join(root, "if1"))).To(Succeed())
+	// hidden files with known extension are not processed by other decoders
+	Expect(os.WriteFile(filepath.Join(root, "..v1", "raw.json"), []byte(`not json`), 0644)).To(Succeed())
+
+	decoders := []decoder.API{decoder.NewDirectoryDecoder(root, "/agent/config/"), decoder.NewJSONDecoder()}
+	client, err := filedb.NewClient([]string{root}, "", decoders, filesystem.NewFsHandler(), log)
+	Expect(err).To(BeNil())
+	defer client.Close()
+	filedb.RunEventWatcher(client)
+	time.Sleep(100 * time.Millisecond)
+
+	value, found, _, err := client.GetValue("/agent/config/if1")
+	Expect(err).To(BeNil())
+	Expect(found).To(BeTrue())
+	Expect(value).To(BeEquivalentTo(`{"name":"if1"}`))
+	_, found, _, _ = client.GetValue("/agent/config/..data")
+	Expect(found).To(BeFalse())
+
+	watchCh := make(chan keyval.BytesWatchResp, 10)
+	Expect(client.Watch(keyval.ToChan(watchCh), nil, "/agent/")).To(Succeed())
+
+	// kubelet replaces "..data" symlink, the file with the value does not change
+	Expect(os.Mkdir(filepath.Join(root, "..v2"), os.ModePerm)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(root, "..v2", "if1"), []byte(`{"name":"if1","mtu":1500}`), 0644)).To(Succeed())
+	Expect(os.Symlink("..v2", filepath.Join(root, "..data_tmp"))).To(Succeed())
+	Expect(os.Rename(filepath.Join(root, "..data_tmp"), filepath.Join(root, "..data"))).To(Succeed())
+
+	var resp keyval.BytesWatchResp
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	Expect(resp.GetKey()).To(Equal("/agent/config/if1"))
+	Expect(resp.GetValue()).To(BeEquivalentTo(`{"name":"if1","mtu":1500}`))
+	Expect(resp.GetPrevValue()).To(BeEquivalentTo(`{"name":"if1"}`))
+
+	// files in nested directories created after the start of the watcher are processed
+	Expect(os.Mkdir(filepath.Join(root, "nested"), os.ModePerm)).To(Succeed())
+	time.Sleep(100 * time.Millisecond)
+	Expect(os.WriteFile(filepath.Join(root, "nested", "if3"), []byte(`{"name":"if3"}`), 0644)).To(Succeed())
+	Eventually(watchCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	Expect(resp.GetKey()).To(Equal("/agent/config/nested/if3"))
+	Expect(resp.GetValue()).To(BeEquivalentTo(`{"name":"if3"}`))
+
+	// new keys are written to files derived from the key, deleted keys are removed with their files
+	Expect(client.Put("/agent/config/if2", []byte(`{"name":"if2"}`))).To(Succeed())
+	data, err := os.ReadFile(filepath.Join(root, "if2"))
+	Expect(err).To(BeNil())
+	Expect(data).To(BeEquivalentTo(`{"name":"if2"}`))
+	existed, err := client.Delete("/agent/config/if2")
+	Expect(err).To(BeNil())
+	Expect(existed).To(BeTrue())
+	Expect(filepath.Join(root, "if2")).ToNot(BeAnExistingFile())
+}
+
+func readStatusKeys(path string) (keys []string) {
+	data, err := os.ReadFile(path)
+	Expect(err).ToNot(HaveOccurred())
+	entries, err := decoder.NewJSONDecoder().Decode(data)
+	Expect(err).ToNot(HaveOccurred())
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
+
+// failingFsHandler fails to write the given file.
+type failingFsHandler struct {
+	*filesystem.Handler
+	failPath string
+}
+
+func (fsh *failingFsHandler) WriteFile(file string, data []byte) error {
+	if file == fsh.failPath {
+		return os.ErrPermission
+	}
+	return fsh.Handler.WriteFile(file, data)
+}
+
+func TestTxnRestoreOnWriteFailure(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	targetPath := filepath.Join(dir, "target.json")
+	statusPath := filepath.Join(dir, "status.json")
+	fsh := &failingFsHandler{Handler: filesystem.NewFsHandler()}
+
+	client, err := filedb.NewClient(nil, statusPath, []decoder.API{decoder.NewJSONDecoder()}, fsh, log,
+		filedb.WithWriteTargets(map[string]string{"/agent/config/": targetPath}))
+	Expect(err).To(BeNil())
+	defer client.Close()
+
+	Expect(client.Put("/agent/status/a", []byte(`{"state":"a"}`))).To(Succeed())
+	original, err := os.ReadFile(statusPath)
+	Expect(err).To(BeNil())
+
+	// the status file is written before the target file, which fails
+	fsh.failPath = targetPath
+	err = client.NewTxn().
+		Put("/agent/status/b", []byte(`{"state":"b"}`)).
+		Put("/agent/config/a", []byte(`{"id":"a"}`)).
+		Commit(context.Background())
+	Expect(err).ToNot(BeNil())
+
+	restored, err := os.ReadFile(statusPath)
+	Expect(err).To(BeNil())
+	Expect(restored).To(Equal(original))
+	_, err = os.Stat(targetPath)
+	Expect(os.IsNotExist(err)).To(BeTrue())
+	_, found, _, _ := client.GetValue("/agent/status/b")
+	Expect(found).To(BeFalse())
+}
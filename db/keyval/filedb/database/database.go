@@ -39,6 +39,8 @@ type FilesSystemDB interface {
 	GetDataForFile(path string) []*decoder.FileDataEntry
 	// GetDataForKey returns data for key with flag whether the data was found or not
 	GetDataForKey(key string) (*decoder.FileDataEntry, bool)
+	// GetPathForKey returns path of the file where the key is stored with flag whether the key was found or not
+	GetPathForKey(key string) (string, bool)
 }
 
 // DbClient is database client
@@ -159,3 +161,16 @@ func (c *DbClient) GetDataForKey(key string) (*decoder.FileDataEntry, bool) {
 	}
 	return nil, false
 }
+
+// GetPathForKey returns path of the file which contains given key.
+func (c *DbClient) GetPathForKey(key string) (string, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	for path, file := range c.db {
+		if _, ok := file[key]; ok {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -256,3 +256,23 @@ func toMap(dataSet []*decoder.FileDataEntry) map[string][]byte {
 	}
 	return dataMap
 }
+
+func TestGetPathForKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	db := database.NewDbClient()
+	db.Add(file1, &decoder.FileDataEntry{Key: ifKey1, Value: []byte(ifKey1)})
+	db.Add(file2, &decoder.FileDataEntry{Key: bdKey1, Value: []byte(bdKey1)})
+
+	path, ok := db.GetPathForKey(ifKey1)
+	Expect(ok).To(BeTrue())
+	Expect(path).To(Equal(file1))
+
+	path, ok = db.GetPathForKey(bdKey1)
+	Expect(ok).To(BeTrue())
+	Expect(path).To(Equal(file2))
+
+	db.Delete(file2, bdKey1)
+	_, ok = db.GetPathForKey(bdKey1)
+	Expect(ok).To(BeFalse())
+}
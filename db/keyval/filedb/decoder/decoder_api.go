@@ -26,6 +26,20 @@ type API interface {
 	Decode(data []byte) ([]*FileDataEntry, error)
 }
 
+// PathDecoder is implemented by decoders which derive keys from the path of the file instead of its content.
+type PathDecoder interface {
+	API
+	// DecodeFile decodes content of the file with given path into common file representation
+	DecodeFile(path string, data []byte) ([]*FileDataEntry, error)
+	// FilePath returns path of the file where the key is stored, or false if the key does not belong to the decoder
+	FilePath(key string) (path string, ok bool)
+	// Root returns directory with all the files processed by the decoder
+	Root() string
+	// Contains returns true for every path under the root directory, including paths which are not processable
+	// (e.g. hidden files), so that no other decoder processes them
+	Contains(file string) bool
+}
+
 // File is common structure of a decoded file with path and list of key-value data
 type File struct {
 	Path string
@@ -0,0 +1,116 @@
+//  Copyright (c) 2018 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package decoder
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DirectoryDecoder processes directory layout where every file under the root directory holds a single value.
+// Key of the value is the relative path of the file prefixed with the key prefix. The layout matches ConfigMap
+// volumes, hidden files and directories (e.g. "..data" maintained by kubelet) are skipped.
+type DirectoryDecoder struct {
+	root      string
+	keyPrefix string
+}
+
+// NewDirectoryDecoder creates a new directory decoder for given root directory. Keys of all values start
+// with <keyPrefix>.
+func NewDirectoryDecoder(root, keyPrefix string) *DirectoryDecoder {
+	return &DirectoryDecoder{
+		root:      filepath.Clean(root),
+		keyPrefix: keyPrefix,
+	}
+}
+
+// Root returns the root directory
+func (dd *DirectoryDecoder) Root() string {
+	return dd.root
+}
+
+// IsProcessable returns true for all not hidden files under the root directory
+func (dd *DirectoryDecoder) IsProcessable(file string) bool {
+	rel, ok := dd.relPath(file)
+	return ok && validRelPath(rel)
+}
+
+// Contains returns true for all paths under the root directory. Hidden entries, like files in the timestamped
+// directory kubelet links by "..data", are claimed as well even if they are not processable, so that they are
+// not decoded by other decoders according to their extension.
+func (dd *DirectoryDecoder) Contains(file string) bool {
+	_, ok := dd.relPath(file)
+	return ok
+}
+
+// Returns slash separated path of the file relative to the root, or false if the file is not under the root
+func (dd *DirectoryDecoder) relPath(file string) (string, bool) {
+	rel, err := filepath.Rel(dd.root, file)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// FilePath returns path of the file for given key
+func (dd *DirectoryDecoder) FilePath(key string) (string, bool) {
+	if !strings.HasPrefix(key, dd.keyPrefix) {
+		return "", false
+	}
+	rel := strings.TrimPrefix(key, dd.keyPrefix)
+	if !validRelPath(rel) {
+		return "", false
+	}
+	return filepath.Join(dd.root, filepath.FromSlash(rel)), true
+}
+
+// Encode returns value of the single entry stored in the file
+func (dd *DirectoryDecoder) Encode(data []*FileDataEntry) ([]byte, error) {
+	switch len(data) {
+	case 0:
+		return []byte{}, nil
+	case 1:
+		return data[0].Value, nil
+	}
+	return nil, fmt.Errorf("directory layout allows single value per file, got %d", len(data))
+}
+
+// Decode is not supported, key is derived from the file path (see DecodeFile)
+func (dd *DirectoryDecoder) Decode(byteSet []byte) ([]*FileDataEntry, error) {
+	return nil, fmt.Errorf("directory layout requires path of the file to be decoded")
+}
+
+// DecodeFile returns single entry with the key derived from the file path and the file content as value
+func (dd *DirectoryDecoder) DecodeFile(path string, byteSet []byte) ([]*FileDataEntry, error) {
+	rel, err := filepath.Rel(dd.root, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file %s: %v", path, err)
+	}
+	return []*FileDataEntry{{Key: dd.keyPrefix + filepath.ToSlash(rel), Value: byteSet}}, nil
+}
+
+// Relative path is valid if it does not leave the root and none of its elements is hidden
+func validRelPath(rel string) bool {
+	if rel == "" {
+		return false
+	}
+	for _, elem := range strings.Split(rel, "/") {
+		if elem == "" || strings.HasPrefix(elem, ".") {
+			return false
+		}
+	}
+	return true
+}
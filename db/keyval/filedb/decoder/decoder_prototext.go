@@ -0,0 +1,121 @@
+//  Copyright (c) 2018 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package decoder
+
+//go:generate protoc --proto_path=. --go_out=paths=source_relative:. model/textfile/textfile.proto
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"go.ligato.io/cn-infra/v2/db/keyval/filedb/decoder/model/textfile"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvregistry"
+)
+
+// Default extension supported by this decoder
+var defaultProtoTextExt = ".txtpb"
+
+// TypeResolver returns proto message type of the value stored under the given key, or nil if the type
+// is not known.
+type TypeResolver func(key string) protoreflect.MessageType
+
+// ProtoTextDecoder can be used to decode files in protobuf text format (see textfile.File). Every value is stored
+// as google.protobuf.Any and converted to json, which is the format of values in fileDB. Types of values must be
+// linked into the binary so that they can be found in the global proto registry.
+type ProtoTextDecoder struct {
+	extensions []string
+	types      TypeResolver
+}
+
+// NewProtoTextDecoder creates a new proto text decoder instance. Types of values written to files are selected
+// by the key using <types> resolver, types registered in kvregistry are used if the resolver is nil.
+func NewProtoTextDecoder(types TypeResolver, extensions ...string) *ProtoTextDecoder {
+	if types == nil {
+		types = kvregistry.MessageType
+	}
+	return &ProtoTextDecoder{
+		extensions: append(extensions, defaultProtoTextExt),
+		types:      types,
+	}
+}
+
+// IsProcessable returns true if decoder is able to decode provided file
+func (pd *ProtoTextDecoder) IsProcessable(file string) bool {
+	for _, ext := range pd.extensions {
+		if strings.HasSuffix(file, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode provided file entries into proto text byte set
+func (pd *ProtoTextDecoder) Encode(data []*FileDataEntry) ([]byte, error) {
+	file := &textfile.File{}
+	for _, dataEntry := range data {
+		msgType := pd.types(dataEntry.Key)
+		if msgType == nil {
+			return nil, fmt.Errorf("failed to encode %s to proto text: unknown message type", dataEntry.Key)
+		}
+		msg := msgType.New().Interface()
+		if err := protojson.Unmarshal(dataEntry.Value, msg); err != nil {
+			return nil, fmt.Errorf("failed to encode %s to proto text: %v", dataEntry.Key, err)
+		}
+		value, err := anypb.New(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s to proto text: %v", dataEntry.Key, err)
+		}
+		file.Data = append(file.Data, &textfile.Entry{Key: dataEntry.Key, Value: value})
+	}
+	return prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(file)
+}
+
+// Decode provided proto text file
+func (pd *ProtoTextDecoder) Decode(byteSet []byte) ([]*FileDataEntry, error) {
+	if len(byteSet) == 0 {
+		return []*FileDataEntry{}, nil
+	}
+	// Decode to type-specific structure
+	file := &textfile.File{}
+	if err := prototext.Unmarshal(byteSet, file); err != nil {
+		return nil, fmt.Errorf("failed to decode proto text file: %v", err)
+	}
+	// Convert to common file data entry list structure
+	var dataEntries []*FileDataEntry
+	for _, dataEntry := range file.Data {
+		if dataEntry.Value == nil {
+			return nil, fmt.Errorf("failed to decode proto text file: missing value of %s", dataEntry.Key)
+		}
+		msg, err := dataEntry.Value.UnmarshalNew()
+		if err == protoregistry.NotFound {
+			return nil, fmt.Errorf("failed to decode proto text file: unknown type %s of %s",
+				dataEntry.Value.TypeUrl, dataEntry.Key)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode proto text file: value of %s: %v", dataEntry.Key, err)
+		}
+		value, err := protojson.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert value of %s to json: %v", dataEntry.Key, err)
+		}
+		dataEntries = append(dataEntries, &FileDataEntry{Key: dataEntry.Key, Value: value})
+	}
+	return dataEntries, nil
+}
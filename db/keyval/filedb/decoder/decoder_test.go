@@ -15,9 +15,13 @@
 package decoder_test
 
 import (
+	"path/filepath"
 	"testing"
 
+	"google.golang.org/protobuf/reflect/protoreflect"
+
 	"go.ligato.io/cn-infra/v2/db/keyval/filedb/decoder"
+	"go.ligato.io/cn-infra/v2/health/statuscheck/model/status"
 
 	. "github.com/onsi/gomega"
 )
@@ -97,3 +101,116 @@ func TestCompareFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestTOMLDecoder(t *testing.T) {
+	RegisterTestingT(t)
+
+	dc := decoder.NewTOMLDecoder()
+	Expect(dc.IsProcessable("/path/to/file.toml")).To(BeTrue())
+	Expect(dc.IsProcessable("/path/to/file.json")).To(BeFalse())
+
+	data, err := dc.Decode([]byte(`
+[[data]]
+key = "/vnf-agent/agent1/config/if1"
+[data.value]
+name = "if1"
+mtu = 1500
+ips = ["10.0.0.1/24"]
+
+[[data]]
+key = "/vnf-agent/agent1/config/rate"
+value = 1.5
+`))
+	Expect(err).To(BeNil())
+	Expect(data).To(HaveLen(2))
+	Expect(data[0].Key).To(Equal("/vnf-agent/agent1/config/if1"))
+	Expect(data[0].Value).To(MatchJSON(`{"name":"if1","mtu":1500,"ips":["10.0.0.1/24"]}`))
+	Expect(data[1].Value).To(MatchJSON(`1.5`))
+
+	// encoded file is decoded to the same values
+	encoded, err := dc.Encode(data)
+	Expect(err).To(BeNil())
+	Expect(string(encoded)).To(ContainSubstring("mtu = 1500\n"))
+	decoded, err := dc.Decode(encoded)
+	Expect(err).To(BeNil())
+	Expect(decoded).To(Equal(data))
+
+	_, err = dc.Decode([]byte(`data = [`))
+	Expect(err).ToNot(BeNil())
+}
+
+func TestProtoTextDecoder(t *testing.T) {
+	RegisterTestingT(t)
+
+	types := func(key string) protoreflect.MessageType {
+		if key == "/vnf-agent/agent1/check/status" {
+			return (&status.AgentStatus{}).ProtoReflect().Type()
+		}
+		return nil
+	}
+	dc := decoder.NewProtoTextDecoder(types)
+	Expect(dc.IsProcessable("/path/to/file.txtpb")).To(BeTrue())
+	Expect(dc.IsProcessable("/path/to/file.yaml")).To(BeFalse())
+
+	data, err := dc.Decode([]byte(`
+data {
+  key: "/vnf-agent/agent1/check/status"
+  value {
+    [type.googleapis.com/status.AgentStatus] { build_version: "v1" state: OK }
+  }
+}
+`))
+	Expect(err).To(BeNil())
+	Expect(data).To(HaveLen(1))
+	Expect(data[0].Key).To(Equal("/vnf-agent/agent1/check/status"))
+	Expect(data[0].Value).To(MatchJSON(`{"buildVersion":"v1","state":"OK"}`))
+
+	encoded, err := dc.Encode(data)
+	Expect(err).To(BeNil())
+	Expect(string(encoded)).To(ContainSubstring("[type.googleapis.com/status.AgentStatus]"))
+	decoded, err := dc.Decode(encoded)
+	Expect(err).To(BeNil())
+	Expect(decoded).To(HaveLen(1))
+	Expect(decoded[0].Value).To(MatchJSON(data[0].Value))
+
+	// values of keys without known type cannot be encoded
+	_, err = dc.Encode([]*decoder.FileDataEntry{{Key: "/vnf-agent/agent1/unknown", Value: []byte(`{}`)}})
+	Expect(err).ToNot(BeNil())
+	_, err = dc.Decode([]byte(`data { key: "/key" value { [type.googleapis.com/unknown.Type] {} } }`))
+	Expect(err).ToNot(BeNil())
+}
+
+func TestDirectoryDecoder(t *testing.T) {
+	RegisterTestingT(t)
+
+	root := filepath.FromSlash("/etc/config")
+	dc := decoder.NewDirectoryDecoder(root+"/", "/vnf-agent/agent1/config/")
+	Expect(dc.Root()).To(Equal(root))
+	Expect(dc.IsProcessable(filepath.Join(root, "if1"))).To(BeTrue())
+	Expect(dc.IsProcessable(filepath.Join(root, "vpp", "if1.json"))).To(BeTrue())
+	// hidden entries maintained by kubelet and files outside the root are skipped
+	Expect(dc.IsProcessable(filepath.Join(root, "..data"))).To(BeFalse())
+	Expect(dc.IsProcessable(filepath.Join(root, "..2023_10_18_10_00_00.123", "if1"))).To(BeFalse())
+	Expect(dc.IsProcessable(root)).To(BeFalse())
+	Expect(dc.IsProcessable(filepath.FromSlash("/etc/other/if1"))).To(BeFalse())
+	// hidden entries are still claimed by the decoder
+	Expect(dc.Contains(filepath.Join(root, "..2023_10_18_10_00_00.123", "if1.json"))).To(BeTrue())
+	Expect(dc.Contains(filepath.Join(root, "..data"))).To(BeTrue())
+	Expect(dc.Contains(root)).To(BeFalse())
+	Expect(dc.Contains(filepath.FromSlash("/etc/config-other/if1.json"))).To(BeFalse())
+
+	data, err := dc.DecodeFile(filepath.Join(root, "vpp", "if1"), []byte(`{"name":"if1"}`))
+	Expect(err).To(BeNil())
+	Expect(data).To(Equal([]*decoder.FileDataEntry{{Key: "/vnf-agent/agent1/config/vpp/if1", Value: []byte(`{"name":"if1"}`)}}))
+	encoded, err := dc.Encode(data)
+	Expect(err).To(BeNil())
+	Expect(encoded).To(BeEquivalentTo(`{"name":"if1"}`))
+
+	path, ok := dc.FilePath("/vnf-agent/agent1/config/vpp/if1")
+	Expect(ok).To(BeTrue())
+	Expect(path).To(Equal(filepath.Join(root, "vpp", "if1")))
+	_, ok = dc.FilePath("/vnf-agent/agent2/config/if1")
+	Expect(ok).To(BeFalse())
+	_, ok = dc.FilePath("/vnf-agent/agent1/config/../if1")
+	Expect(ok).To(BeFalse())
+}
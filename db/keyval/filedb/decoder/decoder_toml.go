@@ -0,0 +1,141 @@
+//  Copyright (c) 2018 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Default extension supported by this decoder
+var defaultTOMLExt = ".toml"
+
+// TOMLDecoder can be used to decode toml-type files
+type TOMLDecoder struct {
+	extensions []string
+}
+
+// Represents data structure of toml file used for configuration, i.e. array of tables:
+//
+//	[[data]]
+//	key = "/vnf-agent/agent1/config/..."
+//	[data.value]
+//	name = "value"
+type tomlFile struct {
+	Data []tomlFileEntry `toml:"data"`
+}
+
+// Single record of key-value, where value is modeled as generic toml value converted to/from json.
+type tomlFileEntry struct {
+	Key   string      `toml:"key"`
+	Value interface{} `toml:"value"`
+}
+
+// NewTOMLDecoder creates a new toml decoder instance
+func NewTOMLDecoder(extensions ...string) *TOMLDecoder {
+	return &TOMLDecoder{
+		extensions: append(extensions, defaultTOMLExt),
+	}
+}
+
+// IsProcessable returns true if decoder is able to decode provided file
+func (td *TOMLDecoder) IsProcessable(file string) bool {
+	for _, ext := range td.extensions {
+		if strings.HasSuffix(file, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode provided file entries into toml byte set
+func (td *TOMLDecoder) Encode(data []*FileDataEntry) ([]byte, error) {
+	// Convert to toml-specific structure
+	var tomlFileEntries []tomlFileEntry
+	for _, dataEntry := range data {
+		value, err := jsonToTOML(dataEntry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert value of %s to toml: %v", dataEntry.Key, err)
+		}
+		tomlFileEntries = append(tomlFileEntries, tomlFileEntry{
+			Key:   dataEntry.Key,
+			Value: value,
+		})
+	}
+	// Encode to type specific structure
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(&tomlFile{Data: tomlFileEntries}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode provided toml file
+func (td *TOMLDecoder) Decode(byteSet []byte) ([]*FileDataEntry, error) {
+	if len(byteSet) == 0 {
+		return []*FileDataEntry{}, nil
+	}
+	// Decode to type-specific structure
+	tomlFile := tomlFile{}
+	if _, err := toml.Decode(string(byteSet), &tomlFile); err != nil {
+		return nil, fmt.Errorf("failed to decode toml file: %v", err)
+	}
+	// Convert to common file data entry list structure
+	var dataEntries []*FileDataEntry
+	for _, dataEntry := range tomlFile.Data {
+		value, err := json.Marshal(dataEntry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert value of %s to json: %v", dataEntry.Key, err)
+		}
+		dataEntries = append(dataEntries, &FileDataEntry{Key: dataEntry.Key, Value: value})
+	}
+	return dataEntries, nil
+}
+
+// Converts json value to generic value which can be encoded to toml. Integers are kept as int64, since
+// toml distinguishes integers and floats.
+func jsonToTOML(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return convertNumbers(value), nil
+}
+
+func convertNumbers(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		if i, err := typed.Int64(); err == nil {
+			return i
+		}
+		f, _ := typed.Float64()
+		return f
+	case map[string]interface{}:
+		for k, v := range typed {
+			typed[k] = convertNumbers(v)
+		}
+	case []interface{}:
+		for i, v := range typed {
+			typed[i] = convertNumbers(v)
+		}
+	}
+	return value
+}
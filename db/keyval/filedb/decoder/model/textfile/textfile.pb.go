@@ -0,0 +1,236 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.17.3
+// source: model/textfile/textfile.proto
+
+// Package textfile provides data model of fileDB files in protobuf text format.
+
+package textfile
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// File is a list of key-value entries.
+type File struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []*Entry `protobuf:"bytes,1,rep,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *File) Reset() {
+	*x = File{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_model_textfile_textfile_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *File) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*File) ProtoMessage() {}
+
+func (x *File) ProtoReflect() protoreflect.Message {
+	mi := &file_model_textfile_textfile_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use File.ProtoReflect.Descriptor instead.
+func (*File) Descriptor() ([]byte, []int) {
+	return file_model_textfile_textfile_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *File) GetData() []*Entry {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Entry is a single key-value record, where value is a proto message of any registered type, e.g.:
+//
+//	data {
+//	  key: "/vnf-agent/vpp1/config/vpp/v2/interfaces/loop1"
+//	  value {
+//	    [type.googleapis.com/ligato.vpp.interfaces.Interface] { name: "loop1" type: SOFTWARE_LOOPBACK }
+//	  }
+//	}
+type Entry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key   string     `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value *anypb.Any `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *Entry) Reset() {
+	*x = Entry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_model_textfile_textfile_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entry) ProtoMessage() {}
+
+func (x *Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_model_textfile_textfile_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
+func (*Entry) Descriptor() ([]byte, []int) {
+	return file_model_textfile_textfile_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Entry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Entry) GetValue() *anypb.Any {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+var File_model_textfile_textfile_proto protoreflect.FileDescriptor
+
+var file_model_textfile_textfile_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x2f, 0x74, 0x65, 0x78, 0x74, 0x66, 0x69, 0x6c, 0x65,
+	0x2f, 0x74, 0x65, 0x78, 0x74, 0x66, 0x69, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x08, 0x74, 0x65, 0x78, 0x74, 0x66, 0x69, 0x6c, 0x65, 0x1a, 0x19, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x61, 0x6e, 0x79, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x2b, 0x0a, 0x04, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x23, 0x0a, 0x04,
+	0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x74, 0x65, 0x78,
+	0x74, 0x66, 0x69, 0x6c, 0x65, 0x2e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x22, 0x45, 0x0a, 0x05, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2a, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e,
+	0x79, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x42, 0x42, 0x5a, 0x40, 0x67, 0x6f, 0x2e, 0x6c,
+	0x69, 0x67, 0x61, 0x74, 0x6f, 0x2e, 0x69, 0x6f, 0x2f, 0x63, 0x6e, 0x2d, 0x69, 0x6e, 0x66, 0x72,
+	0x61, 0x2f, 0x76, 0x32, 0x2f, 0x64, 0x62, 0x2f, 0x6b, 0x65, 0x79, 0x76, 0x61, 0x6c, 0x2f, 0x66,
+	0x69, 0x6c, 0x65, 0x64, 0x62, 0x2f, 0x64, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x2f, 0x6d, 0x6f,
+	0x64, 0x65, 0x6c, 0x2f, 0x74, 0x65, 0x78, 0x74, 0x66, 0x69, 0x6c, 0x65, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_model_textfile_textfile_proto_rawDescOnce sync.Once
+	file_model_textfile_textfile_proto_rawDescData = file_model_textfile_textfile_proto_rawDesc
+)
+
+func file_model_textfile_textfile_proto_rawDescGZIP() []byte {
+	file_model_textfile_textfile_proto_rawDescOnce.Do(func() {
+		file_model_textfile_textfile_proto_rawDescData = protoimpl.X.CompressGZIP(file_model_textfile_textfile_proto_rawDescData)
+	})
+	return file_model_textfile_textfile_proto_rawDescData
+}
+
+var file_model_textfile_textfile_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_model_textfile_textfile_proto_goTypes = []interface{}{
+	(*File)(nil),      // 0: textfile.File
+	(*Entry)(nil),     // 1: textfile.Entry
+	(*anypb.Any)(nil), // 2: google.protobuf.Any
+}
+var file_model_textfile_textfile_proto_depIdxs = []int32{
+	1, // 0: textfile.File.data:type_name -> textfile.Entry
+	2, // 1: textfile.Entry.value:type_name -> google.protobuf.Any
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_model_textfile_textfile_proto_init() }
+func file_model_textfile_textfile_proto_init() {
+	if File_model_textfile_textfile_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_model_textfile_textfile_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*File); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_model_textfile_textfile_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Entry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_model_textfile_textfile_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_model_textfile_textfile_proto_goTypes,
+		DependencyIndexes: file_model_textfile_textfile_proto_depIdxs,
+		MessageInfos:      file_model_textfile_textfile_proto_msgTypes,
+	}.Build()
+	File_model_textfile_textfile_proto = out.File
+	file_model_textfile_textfile_proto_rawDesc = nil
+	file_model_textfile_textfile_proto_goTypes = nil
+	file_model_textfile_textfile_proto_depIdxs = nil
+}
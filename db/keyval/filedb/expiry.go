@@ -0,0 +1,173 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filedb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+)
+
+// DefaultExpiryCheckInterval is the default interval between removals of expired keys.
+const DefaultExpiryCheckInterval = time.Second
+
+// expirySuffix is appended to the path of the status file to get the path of the file where the expiry of keys
+// is stored. The file is not processed by any decoder.
+const expirySuffix = ".ttl"
+
+// expiryState is the expiry of keys written by the client, persisted so that the keys can be removed after
+// restart.
+type expiryState struct {
+	// Expiry maps keys with TTL to their expiration time
+	Expiry map[string]time.Time `json:"expiry,omitempty"`
+	// ClientLifetime lists keys removed once the client is closed
+	ClientLifetime map[string]bool `json:"client-lifetime,omitempty"`
+}
+
+// loadExpiry reads the persisted expiry of keys. Keys with client lifetime and expired keys left by previous
+// client are removed. The expiry is persisted only if the status file is defined, otherwise it is kept
+// in memory.
+func (c *Client) loadExpiry() error {
+	c.expiry = expiryState{
+		Expiry:         make(map[string]time.Time),
+		ClientLifetime: make(map[string]bool),
+	}
+	if c.statusPath == "" {
+		return nil
+	}
+	path := c.statusPath + expirySuffix
+	if !c.fsHandler.FileExists(path) {
+		return nil
+	}
+	data, err := c.fsHandler.ReadFile(path)
+	if err != nil {
+		return errors.Errorf("failed to read expiry file %s: %v", path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &c.expiry); err != nil {
+			return errors.Errorf("failed to decode expiry file %s: %v", path, err)
+		}
+	}
+	if c.expiry.Expiry == nil {
+		c.expiry.Expiry = make(map[string]time.Time)
+	}
+	if c.expiry.ClientLifetime == nil {
+		c.expiry.ClientLifetime = make(map[string]bool)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	keys := append(c.expiredKeys(time.Now()), c.clientLifetimeKeys()...)
+	// there are no watchers yet
+	_, err = c.commitLocked(deleteOps(keys))
+	return err
+}
+
+// saveExpiry persists the expiry of keys. Must be called with writeMu locked.
+func (c *Client) saveExpiry() error {
+	if c.statusPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(&c.expiry)
+	if err != nil {
+		return err
+	}
+	return c.fsHandler.WriteFile(c.statusPath+expirySuffix, data)
+}
+
+// updateExpiry sets the expiry of the key according to the put options and returns true if the expiry
+// has changed. Must be called with writeMu locked.
+func (c *Client) updateExpiry(key string, opts []datasync.PutOption) bool {
+	_, hadExpiry := c.expiry.Expiry[key]
+	hadLifetime := c.expiry.ClientLifetime[key]
+	delete(c.expiry.Expiry, key)
+	delete(c.expiry.ClientLifetime, key)
+
+	for _, o := range opts {
+		switch opt := o.(type) {
+		case *datasync.WithTTLOpt:
+			if opt.TTL > 0 {
+				c.expiry.Expiry[key] = time.Now().Add(opt.TTL)
+			}
+		case *datasync.WithClientLifetimeTTLOpt:
+			c.expiry.ClientLifetime[key] = true
+		}
+	}
+	_, hasExpiry := c.expiry.Expiry[key]
+	return hadExpiry || hadLifetime || hasExpiry || c.expiry.ClientLifetime[key]
+}
+
+// expiredKeys returns keys expired before <now>. Must be called with writeMu locked.
+func (c *Client) expiredKeys(now time.Time) (keys []string) {
+	for key, expiry := range c.expiry.Expiry {
+		if !expiry.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// clientLifetimeKeys returns keys with client lifetime. Must be called with writeMu locked.
+func (c *Client) clientLifetimeKeys() (keys []string) {
+	for key := range c.expiry.ClientLifetime {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// removeKeys removes the keys selected by the function from files and notifies watchers.
+func (c *Client) removeKeys(selectKeys func() []string) {
+	c.writeMu.Lock()
+	events, err := c.commitLocked(deleteOps(selectKeys()))
+	c.writeMu.Unlock()
+	if err != nil {
+		c.log.Warnf("failed to remove expired keys from fileDB: %v", err)
+	}
+	c.notify(events)
+}
+
+// deleteOps returns operations removing the keys.
+func deleteOps(keys []string) []fileOp {
+	ops := make([]fileOp, 0, len(keys))
+	for _, key := range keys {
+		ops = append(ops, fileOp{key: key, del: true})
+	}
+	return ops
+}
+
+// startReaper periodically removes expired keys.
+func (c *Client) startReaper(interval time.Duration) {
+	defer c.wg.Done()
+
+	if interval <= 0 {
+		interval = DefaultExpiryCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.removeKeys(func() []string {
+				return c.expiredKeys(time.Now())
+			})
+		case <-c.quit:
+			return
+		}
+	}
+}
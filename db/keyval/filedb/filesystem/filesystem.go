@@ -19,6 +19,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/pkg/errors"
@@ -32,8 +33,10 @@ type API interface {
 	CreateFile(file string) error
 	// ReadFile returns an content of given file
 	ReadFile(file string) ([]byte, error)
-	// WriteFile writes data to file
+	// WriteFile atomically replaces content of the file with data. The file is created if it does not exist.
 	WriteFile(file string, data []byte) error
+	// RemoveFile removes the file
+	RemoveFile(file string) error
 	// FileExists returns true if given path was found
 	FileExists(file string) bool
 	// GetFiles takes a list of file system paths an returns a list of individual file paths
@@ -44,10 +47,15 @@ type API interface {
 	Close() error
 }
 
+// Mode of files created by WriteFile
+const defaultFileMode os.FileMode = 0644
+
 // Handler is helper struct to manipulate with filesystem API
 type Handler struct {
 	log     logging.Logger
 	watcher *fsnotify.Watcher
+	// Watched paths, used to renew watches of files replaced by rename
+	watched map[string]bool
 }
 
 // NewFsHandler creates a new instance of file system handler
@@ -76,19 +84,54 @@ func (fsh *Handler) ReadFile(file string) ([]byte, error) {
 	return ioutil.ReadFile(file)
 }
 
-// WriteFile is an implementation of the file system API interface
+// WriteFile is an implementation of the file system API interface. Data are written to a temporary file
+// in the same directory, which then replaces the original file by rename, so that readers never see
+// a partially written file.
 func (fsh *Handler) WriteFile(file string, data []byte) error {
-	fileObj, err := os.OpenFile(file, os.O_TRUNC|os.O_WRONLY, os.ModePerm)
+	dir, name := filepath.Split(file)
+	if dir == "" {
+		dir = "."
+	} else if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return errors.Errorf("failed to create path for file %s: %v", file, err)
+	}
+	// Temporary file name does not end with known extension, decoders skip it
+	tmpFile, err := ioutil.TempFile(dir, "."+name+".tmp")
 	if err != nil {
-		return fmt.Errorf("failed to open status file %s for writing: %v", file, err)
+		return fmt.Errorf("failed to create temporary file for %s: %v", file, err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	mode := defaultFileMode
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write file %s: %v", file, err)
+	}
+	if err := tmpFile.Chmod(mode); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to set mode of file %s: %v", file, err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to sync file %s: %v", file, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close file %s: %v", file, err)
 	}
-	defer fileObj.Close()
-	if _, err := fileObj.Write(data); err != nil {
-		return fmt.Errorf("failed to write status file %s for writing: %v", file, err)
+	if err := os.Rename(tmpPath, file); err != nil {
+		return fmt.Errorf("failed to replace file %s: %v", file, err)
 	}
 	return nil
 }
 
+// RemoveFile is an implementation of the file system API interface
+func (fsh *Handler) RemoveFile(file string) error {
+	return os.Remove(file)
+}
+
 // FileExists is an implementation of the file system API interface
 func (fsh *Handler) FileExists(file string) bool {
 	if _, err := os.Stat(file); os.IsNotExist(err) {
@@ -125,8 +168,11 @@ func (fsh *Handler) Watch(paths []string, onEvent func(event fsnotify.Event), on
 	if err != nil {
 		return errors.Errorf("failed to init fileDB file system watcher: %v", err)
 	}
+	fsh.watched = make(map[string]bool)
 	for _, path := range paths {
 		fsh.watcher.Add(path)
+		fsh.watched[filepath.Clean(path)] = true
+		fsh.watchSubdirs(path)
 	}
 
 	go func() {
@@ -137,7 +183,9 @@ func (fsh *Handler) Watch(paths []string, onEvent func(event fsnotify.Event), on
 					onClose()
 					return
 				}
+				fsh.renewWatch(event)
 				onEvent(event)
+				fsh.watchCreatedDir(event, onEvent)
 			case err := <-fsh.watcher.Errors:
 				if err != nil {
 					fsh.log.Errorf("filesystem notification error %v", err)
@@ -149,8 +197,67 @@ func (fsh *Handler) Watch(paths []string, onEvent func(event fsnotify.Event), on
 	return nil
 }
 
+// Watch of a file is dropped once the file is replaced by rename (e.g. by WriteFile or by an editor). If the watched
+// file still exists, the watch is renewed so that further changes are not missed.
+func (fsh *Handler) renewWatch(event fsnotify.Event) {
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) == 0 || !fsh.watched[filepath.Clean(event.Name)] {
+		return
+	}
+	if !fsh.FileExists(event.Name) {
+		return
+	}
+	if err := fsh.watcher.Add(event.Name); err != nil && fsh.log != nil {
+		fsh.log.Warnf("failed to renew watch of %s: %v", event.Name, err)
+	}
+}
+
+// Watcher does not report changes in nested directories, all of them are therefore watched as well. Hidden
+// directories (e.g. timestamped directories of ConfigMap volumes) are skipped.
+func (fsh *Handler) watchSubdirs(path string) {
+	filepath.Walk(path, func(dir string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || dir == path {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err := fsh.watcher.Add(dir); err != nil && fsh.log != nil {
+			fsh.log.Warnf("failed to watch directory %s: %v", dir, err)
+		}
+		return nil
+	})
+}
+
+// Directory created under a watched path is watched as well. Files created in the directory before the watch
+// was added are reported as created.
+func (fsh *Handler) watchCreatedDir(event fsnotify.Event, onEvent func(event fsnotify.Event)) {
+	if event.Op&fsnotify.Create == 0 || strings.HasPrefix(filepath.Base(event.Name), ".") {
+		return
+	}
+	if info, err := os.Lstat(event.Name); err != nil || !info.IsDir() {
+		return
+	}
+	if err := fsh.watcher.Add(event.Name); err != nil {
+		if fsh.log != nil {
+			fsh.log.Warnf("failed to watch directory %s: %v", event.Name, err)
+		}
+		return
+	}
+	fsh.watchSubdirs(event.Name)
+	files, err := fsh.GetFileNames([]string{event.Name})
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		onEvent(fsnotify.Event{Name: file, Op: fsnotify.Create})
+	}
+}
+
 // Close the file watcher
 func (fsh *Handler) Close() error {
+	if fsh.watcher == nil {
+		return nil
+	}
 	return fsh.watcher.Close()
 }
 
@@ -105,6 +105,12 @@ func (mock *Mock) WriteFile(file string, data []byte) error {
 	return items[0].(error)
 }
 
+// RemoveFile mocks original method
+func (mock *Mock) RemoveFile(file string) error {
+	items := mock.getReturnValues("RemoveFile")
+	return items[0].(error)
+}
+
 // FileExists mocks original method
 func (mock *Mock) FileExists(file string) bool {
 	items := mock.getReturnValues("FileExists")
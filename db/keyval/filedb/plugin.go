@@ -15,6 +15,8 @@
 package filedb
 
 import (
+	"time"
+
 	"go.ligato.io/cn-infra/v2/db/keyval"
 	"go.ligato.io/cn-infra/v2/db/keyval/filedb/decoder"
 	"go.ligato.io/cn-infra/v2/db/keyval/filedb/filesystem"
@@ -46,7 +48,20 @@ type Deps struct {
 type Config struct {
 	ConfigPaths []string `json:"configuration-paths"`
 	StatusPath  string   `json:"status-path"`
-	// TODO possibly add option to store status to the same file as is the config
+	// DirectoryPaths are directories with layout where every file holds a single value (e.g. ConfigMap volumes).
+	DirectoryPaths []DirectoryPath `json:"directory-paths"`
+	// WriteTargets maps key prefixes to files where new keys are written. Keys without matching prefix
+	// are written to the status file.
+	WriteTargets map[string]string `json:"write-targets"`
+	// ExpiryCheckInterval is the interval between removals of keys with expired TTL.
+	ExpiryCheckInterval time.Duration `json:"expiry-check-interval"`
+}
+
+// DirectoryPath is a directory where the relative path of every file prefixed with KeyPrefix is the key
+// and the file content is the value.
+type DirectoryPath struct {
+	Path      string `json:"path"`
+	KeyPrefix string `json:"key-prefix"`
 }
 
 // Init reads file config and creates new client to communicate with file system
@@ -58,9 +73,17 @@ func (p *Plugin) Init() error {
 		return err
 	}
 
-	// Register decoders
-	decoders := []decoder.API{decoder.NewJSONDecoder(), decoder.NewYAMLDecoder()}
-	if p.client, err = NewClient(p.config.ConfigPaths, p.config.StatusPath, decoders, filesystem.NewFsHandler(), p.Log); err != nil {
+	// Register decoders, directory layouts take precedence over file extensions
+	var decoders []decoder.API
+	paths := p.config.ConfigPaths
+	for _, dir := range p.config.DirectoryPaths {
+		decoders = append(decoders, decoder.NewDirectoryDecoder(dir.Path, dir.KeyPrefix))
+		paths = append(paths, dir.Path)
+	}
+	decoders = append(decoders, decoder.NewJSONDecoder(), decoder.NewYAMLDecoder(), decoder.NewTOMLDecoder(),
+		decoder.NewProtoTextDecoder(nil))
+	if p.client, err = NewClient(paths, p.config.StatusPath, decoders, filesystem.NewFsHandler(), p.Log,
+		WithWriteTargets(p.config.WriteTargets), WithExpiryCheckInterval(p.config.ExpiryCheckInterval)); err != nil {
 		return err
 	}
 
@@ -113,6 +136,16 @@ func (p *Plugin) NewWatcher(keyPrefix string) keyval.ProtoWatcher {
 	return p.protoWrapper.NewWatcher(keyPrefix)
 }
 
+// RawAccess returns client providing access to data as raw bytes
+func (p *Plugin) RawAccess() keyval.KvBytesPlugin {
+	return p.client
+}
+
+// NewLocker returns new locker with locks held in memory
+func (p *Plugin) NewLocker(keyPrefix string) keyval.Locker {
+	return p.client.NewLocker(keyPrefix)
+}
+
 func (p *Plugin) getFileDBConfig() (*Config, error) {
 	var fileDbCfg Config
 	found, err := p.Cfg.LoadValue(&fileDbCfg)
@@ -0,0 +1,322 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filedb
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/filedb/decoder"
+)
+
+// fileOp is a single change of a key, either put or delete.
+type fileOp struct {
+	key   string
+	value []byte
+	del   bool
+	opts  []datasync.PutOption
+}
+
+// txn collects operations which are committed to files together.
+type txn struct {
+	c      *Client
+	prefix string
+	ops    []fileOp
+}
+
+// NewTxn creates new transaction. Files affected by the transaction are rewritten only once the transaction
+// is committed.
+func (c *Client) NewTxn() keyval.BytesTxn {
+	return &txn{c: c}
+}
+
+// Put adds a new 'put' operation to the transaction. The key is written to the file where it is already stored,
+// new keys are written to the target file selected by the key prefix.
+func (t *txn) Put(key string, data []byte) keyval.BytesTxn {
+	t.ops = append(t.ops, fileOp{key: t.prefixKey(key), value: data})
+	return t
+}
+
+// Delete adds a new 'delete' operation to the transaction. The key is removed from the file where it is stored.
+func (t *txn) Delete(key string) keyval.BytesTxn {
+	t.ops = append(t.ops, fileOp{key: t.prefixKey(key), del: true})
+	return t
+}
+
+// Commit writes all files affected by the transaction. Every file is replaced atomically by rename of a temporary
+// file. All files are encoded before the first one is written, so an invalid operation leaves all files untouched,
+// and files already written are restored to their original content if writing of another file fails.
+//
+// Files are rewritten from the stored key-value entries, therefore comments and formatting of the original file
+// are lost and keys are sorted. Use write targets dedicated to the fileDB to keep hand-written files intact.
+func (t *txn) Commit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := t.c.commit(t.ops)
+	return err
+}
+
+func (t *txn) prefixKey(key string) string {
+	if t.prefix == "" {
+		return key
+	}
+	return filepath.Join(t.prefix, key)
+}
+
+// stagedFile is content of a file modified by commit.
+type stagedFile struct {
+	entries map[string][]byte
+	dc      decoder.API
+	data    []byte
+	// entries as they are decoded from the written data
+	decoded []*decoder.FileDataEntry
+	// file is removed instead of written
+	remove bool
+}
+
+// commit applies the operations to files and the database and notifies watchers. Keys of entries removed
+// by delete operations are returned.
+func (c *Client) commit(ops []fileOp) (deleted []string, err error) {
+	c.writeMu.Lock()
+	events, err := c.commitLocked(ops)
+	c.writeMu.Unlock()
+
+	for _, keyed := range events {
+		if keyed.Op == datasync.Delete {
+			deleted = append(deleted, keyed.Key)
+		}
+	}
+	c.notify(events)
+	return deleted, err
+}
+
+// notify sends events to watchers. Must be called without writeMu locked, since watcher callbacks may write
+// to the fileDB.
+func (c *Client) notify(events []keyedData) {
+	for _, keyed := range events {
+		c.sendToChannel(keyed)
+	}
+}
+
+// commitLocked applies the operations to files and the database and returns events which should be sent to watchers.
+// Must be called with writeMu locked.
+func (c *Client) commitLocked(ops []fileOp) ([]keyedData, error) {
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	var (
+		files = make(map[string]*stagedFile)
+		// path of keys changed by previous operations, empty for deleted keys
+		owners = make(map[string]string)
+		// path and value of keys before the commit
+		prevPaths  = make(map[string]string)
+		prevValues = make(map[string][]byte)
+		// the last put operation of every key
+		puts    = make(map[string]fileOp)
+		changed []string
+	)
+	stage := func(path string) *stagedFile {
+		if file, ok := files[path]; ok {
+			return file
+		}
+		file := &stagedFile{entries: make(map[string][]byte)}
+		for _, entry := range c.db.GetDataForFile(path) {
+			file.entries[entry.Key] = entry.Value
+		}
+		files[path] = file
+		return file
+	}
+	for _, op := range ops {
+		path, found := owners[op.key]
+		if _, ok := owners[op.key]; ok {
+			found = path != ""
+		} else {
+			if path, found = c.db.GetPathForKey(op.key); found {
+				prevPaths[op.key] = path
+				prevValues[op.key] = stage(path).entries[op.key]
+			}
+			changed = append(changed, op.key)
+		}
+		if op.del {
+			if found {
+				delete(stage(path).entries, op.key)
+			}
+			owners[op.key] = ""
+			delete(puts, op.key)
+			continue
+		}
+		if !found {
+			if path = c.targetFile(op.key); path == "" {
+				return nil, errors.Errorf("failed to write key %s to fileDB: no target file for the key", op.key)
+			}
+		}
+		stage(path).entries[op.key] = op.value
+		owners[op.key] = path
+		puts[op.key] = op
+	}
+
+	// Encode all files before writing the first one
+	paths := make([]string, 0, len(files))
+	for path, file := range files {
+		if file.dc = c.getFileDecoder(path); file.dc == nil {
+			return nil, errors.Errorf("failed to write file %s to fileDB: unknown file extension", path)
+		}
+		entries := make([]*decoder.FileDataEntry, 0, len(file.entries))
+		for key, value := range file.entries {
+			entries = append(entries, &decoder.FileDataEntry{Key: key, Value: value})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Key < entries[j].Key
+		})
+		data, err := file.dc.Encode(entries)
+		if err != nil {
+			return nil, errors.Errorf("failed to write file %s to fileDB: unable to encode: %v", path, err)
+		}
+		file.data = data
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Decode all files before writing the first one. Values are stored as they are decoded from the file,
+	// so that the file watcher does not detect any change.
+	for _, path := range paths {
+		file := files[path]
+		// Files of directory layout without value are removed
+		if _, ok := file.dc.(decoder.PathDecoder); ok && len(file.entries) == 0 {
+			file.remove = true
+			continue
+		}
+		entries, err := decodeFile(file.dc, path, file.data)
+		if err != nil {
+			return nil, errors.Errorf("failed to write file %s to fileDB: unable to decode: %v", path, err)
+		}
+		file.decoded = entries
+	}
+	// Keep the original content, so that written files can be restored if writing of another file fails
+	originals := make(map[string][]byte)
+	for _, path := range paths {
+		if !c.fsHandler.FileExists(path) {
+			continue
+		}
+		data, err := c.fsHandler.ReadFile(path)
+		if err != nil {
+			return nil, errors.Errorf("failed to read file %s from fileDB: %v", path, err)
+		}
+		originals[path] = data
+	}
+	for i, path := range paths {
+		if err := c.writeStagedFile(path, files[path]); err != nil {
+			c.restoreFiles(paths[:i], originals)
+			return nil, err
+		}
+	}
+	written := make(map[string]bool)
+	for _, path := range paths {
+		c.db.DeleteFile(path)
+		for _, entry := range files[path].decoded {
+			c.db.Add(path, entry)
+		}
+		written[path] = true
+	}
+
+	var events []keyedData
+	var expiryChanged bool
+	for _, key := range changed {
+		prevPath, existed := prevPaths[key]
+		if path := owners[key]; path != "" {
+			if !written[path] {
+				continue
+			}
+			entry, _ := c.db.GetDataForKey(key)
+			events = append(events, keyedData{
+				path:      path,
+				watchResp: watchResp{Op: datasync.Put, Key: key, Value: entry.Value, PrevValue: prevValues[key]},
+			})
+			expiryChanged = c.updateExpiry(key, puts[key].opts) || expiryChanged
+		} else if !existed {
+			expiryChanged = c.updateExpiry(key, nil) || expiryChanged
+		} else if written[prevPath] {
+			events = append(events, keyedData{
+				path:      prevPath,
+				watchResp: watchResp{Op: datasync.Delete, Key: key, PrevValue: prevValues[key]},
+			})
+			expiryChanged = c.updateExpiry(key, nil) || expiryChanged
+		}
+	}
+	if expiryChanged {
+		// The files are already written, so the commit itself succeeded; failing here would make
+		// callers retry a write that has been applied.
+		if err := c.saveExpiry(); err != nil {
+			c.log.Warnf("failed to store expiry of keys: %v", err)
+		}
+	}
+	return events, nil
+}
+
+// writeStagedFile writes the encoded content of the file, or removes the file if it has no content left.
+func (c *Client) writeStagedFile(path string, file *stagedFile) error {
+	if file.remove {
+		if err := c.fsHandler.RemoveFile(path); err != nil && c.fsHandler.FileExists(path) {
+			return errors.Errorf("failed to remove file %s from fileDB: %v", path, err)
+		}
+		return nil
+	}
+	if err := c.fsHandler.WriteFile(path, file.data); err != nil {
+		return errors.Errorf("failed to write file %s to fileDB: %v", path, err)
+	}
+	return nil
+}
+
+// restoreFiles returns files to their original content. Files which did not exist before are removed.
+func (c *Client) restoreFiles(paths []string, originals map[string][]byte) {
+	for _, path := range paths {
+		var err error
+		if data, ok := originals[path]; ok {
+			err = c.fsHandler.WriteFile(path, data)
+		} else if c.fsHandler.FileExists(path) {
+			err = c.fsHandler.RemoveFile(path)
+		}
+		if err != nil {
+			c.log.Errorf("failed to restore file %s: %v", path, err)
+		}
+	}
+}
+
+// targetFile returns the file where a new key is written. Keys belonging to a directory layout are written
+// to the file derived from the key, otherwise the longest matching prefix of write targets is preferred
+// and the status file is used for keys without matching prefix.
+func (c *Client) targetFile(key string) string {
+	for _, dc := range c.decoders {
+		if pd, ok := dc.(decoder.PathDecoder); ok {
+			if path, ok := pd.FilePath(key); ok {
+				return path
+			}
+		}
+	}
+	target, longest := c.statusPath, -1
+	for prefix, path := range c.writeTargets {
+		if strings.HasPrefix(key, prefix) && len(prefix) > longest {
+			target, longest = path, len(prefix)
+		}
+	}
+	return target
+}
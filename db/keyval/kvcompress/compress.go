@@ -0,0 +1,229 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvcompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultThreshold is the default minimal size of values that are compressed.
+const DefaultThreshold = 1024
+
+// DefaultMaxSize is the default maximal size of decompressed values.
+const DefaultMaxSize = 64 << 20
+
+// ErrTooLarge is returned when the decompressed value exceeds the maximal size.
+var ErrTooLarge = errors.New("decompressed value exceeds maximal size")
+
+// Algorithm identifies the compression algorithm.
+type Algorithm byte
+
+const (
+	// Gzip compresses values using gzip.
+	Gzip Algorithm = iota + 1
+	// Zstd compresses values using Zstandard.
+	Zstd
+)
+
+// String returns the name of the algorithm.
+func (a Algorithm) String() string {
+	switch a {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	}
+	return fmt.Sprintf("Algorithm(%d)", byte(a))
+}
+
+// ParseAlgorithm returns the algorithm with the given name.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "gzip":
+		return Gzip, nil
+	case "zstd":
+		return Zstd, nil
+	}
+	return 0, fmt.Errorf("unknown compression algorithm %q", name)
+}
+
+// header is the prefix of compressed values. The header is followed by
+// a single byte with the algorithm and the compressed data. The leading zero
+// byte makes the header distinct from JSON values and from values encoded
+// in the proto wire format.
+var header = []byte{0, 'K', 'V', 'Z'}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdErr     error
+)
+
+// initZstd creates zstd encoder shared by all compressors.
+func initZstd() error {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+	})
+	return zstdErr
+}
+
+// Compressor compresses values exceeding the threshold.
+type Compressor struct {
+	algorithm Algorithm
+	threshold int
+	maxSize   int
+}
+
+// Option is a function that can be used in NewCompressor to customize Compressor.
+type Option func(*Compressor)
+
+// WithAlgorithm returns Option that sets the compression algorithm
+// (Gzip by default).
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(c *Compressor) {
+		c.algorithm = algorithm
+	}
+}
+
+// WithThreshold returns Option that sets the minimal size of values
+// that are compressed (DefaultThreshold by default).
+func WithThreshold(threshold int) Option {
+	return func(c *Compressor) {
+		c.threshold = threshold
+	}
+}
+
+// WithMaxSize returns Option that sets the maximal size of decompressed
+// values (DefaultMaxSize by default).
+func WithMaxSize(maxSize int) Option {
+	return func(c *Compressor) {
+		c.maxSize = maxSize
+	}
+}
+
+// NewCompressor creates a new Compressor with the provided Options.
+func NewCompressor(opts ...Option) *Compressor {
+	c := &Compressor{
+		algorithm: Gzip,
+		threshold: DefaultThreshold,
+		maxSize:   DefaultMaxSize,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Compress compresses the data if its size reaches the threshold.
+// The data is returned as it is if it is too small or if the compression
+// would not make it smaller.
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	if len(data) < c.threshold {
+		return data, nil
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)/2))
+	out.Write(header)
+	out.WriteByte(byte(c.algorithm))
+
+	switch c.algorithm {
+	case Gzip:
+		w := gzip.NewWriter(out)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case Zstd:
+		if err := initZstd(); err != nil {
+			return nil, err
+		}
+		out.Write(zstdEncoder.EncodeAll(data, nil))
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm: %v", c.algorithm)
+	}
+
+	if out.Len() >= len(data) {
+		return data, nil
+	}
+	return out.Bytes(), nil
+}
+
+// IsCompressed returns true if the data starts with the header
+// of compressed values.
+func IsCompressed(data []byte) bool {
+	return len(data) > len(header) && bytes.HasPrefix(data, header)
+}
+
+// Decompress decompresses the data compressed by any Compressor.
+// Data without the header of compressed values is returned as it is.
+// ErrTooLarge is returned if the decompressed data exceed the maximal size
+// of the Compressor.
+func (c *Compressor) Decompress(data []byte) ([]byte, error) {
+	return decompress(data, c.maxSize)
+}
+
+// Decompress decompresses the data compressed by any Compressor.
+// Data without the header of compressed values is returned as it is.
+// ErrTooLarge is returned if the decompressed data exceed DefaultMaxSize.
+func Decompress(data []byte) ([]byte, error) {
+	return decompress(data, DefaultMaxSize)
+}
+
+func decompress(data []byte, maxSize int) ([]byte, error) {
+	if !IsCompressed(data) {
+		return data, nil
+	}
+	algorithm := Algorithm(data[len(header)])
+	compressed := bytes.NewReader(data[len(header)+1:])
+
+	var r io.Reader
+	switch algorithm {
+	case Gzip:
+		gr, err := gzip.NewReader(compressed)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	case Zstd:
+		zr, err := zstd.NewReader(compressed, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm: %v", algorithm)
+	}
+
+	// Read one byte over the limit to detect values exceeding it
+	out, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxSize {
+		return nil, fmt.Errorf("%w (%d bytes)", ErrTooLarge, maxSize)
+	}
+	return out, nil
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvcompress_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/bolt"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvcompress"
+)
+
+// boltPlugin provides raw access to Bolt client.
+type boltPlugin struct {
+	keyval.KvProtoPlugin
+	client *bolt.Client
+}
+
+func (p *boltPlugin) RawAccess() keyval.KvBytesPlugin {
+	return p.client
+}
+
+func newClient(t *testing.T) *bolt.Client {
+	client, err := bolt.NewClient(&bolt.Config{
+		DbPath:   filepath.Join(t.TempDir(), "bolt.db"),
+		FileMode: 432,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCompress(t *testing.T) {
+	RegisterTestingT(t)
+	large := []byte(strings.Repeat("compressible data ", 100))
+
+	for _, algorithm := range []kvcompress.Algorithm{kvcompress.Gzip, kvcompress.Zstd} {
+		c := kvcompress.NewCompressor(kvcompress.WithAlgorithm(algorithm), kvcompress.WithThreshold(100))
+
+		data, err := c.Compress(large)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kvcompress.IsCompressed(data)).To(BeTrue())
+		Expect(len(data)).To(BeNumerically("<", len(large)))
+		data, err = kvcompress.Decompress(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(large))
+
+		// small values are not compressed
+		data, err = c.Compress([]byte("small"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("small")))
+	}
+
+	// values without header are read as they are
+	data, err := kvcompress.Decompress([]byte(`{"value":"plain"}`))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(data)).To(Equal(`{"value":"plain"}`))
+
+	_, err = kvcompress.ParseAlgorithm("lz4")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestDecompressMaxSize(t *testing.T) {
+	RegisterTestingT(t)
+	large := []byte(strings.Repeat("x", 10000))
+
+	for _, algorithm := range []kvcompress.Algorithm{kvcompress.Gzip, kvcompress.Zstd} {
+		c := kvcompress.NewCompressor(kvcompress.WithAlgorithm(algorithm), kvcompress.WithMaxSize(len(large)))
+		data, err := c.Compress(large)
+		Expect(err).ToNot(HaveOccurred())
+		out, err := c.Decompress(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(large))
+
+		c = kvcompress.NewCompressor(kvcompress.WithAlgorithm(algorithm), kvcompress.WithMaxSize(len(large)-1))
+		_, err = c.Decompress(data)
+		Expect(errors.Is(err, kvcompress.ErrTooLarge)).To(BeTrue())
+	}
+}
+
+func TestBytesWrapper(t *testing.T) {
+	RegisterTestingT(t)
+	client := newClient(t)
+	large := []byte(strings.Repeat("x", 2000))
+
+	kvp := kvcompress.NewKvBytesPluginWrapper(client, kvcompress.NewCompressor(kvcompress.WithAlgorithm(kvcompress.Zstd)))
+	broker := kvp.NewBroker("/agent/")
+
+	events := make(chan keyval.BytesWatchResp, 10)
+	Expect(kvp.NewWatcher("/agent/").Watch(func(resp keyval.BytesWatchResp) {
+		events <- resp
+	}, nil, "large")).To(Succeed())
+
+	Expect(broker.Put("large", large)).To(Succeed())
+	Expect(client.Put("/agent/plain", []byte("plain"))).To(Succeed())
+
+	raw, _, _, err := client.GetValue("/agent/large")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(kvcompress.IsCompressed(raw)).To(BeTrue())
+
+	data, found, _, err := broker.GetValue("large")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(data).To(Equal(large))
+	data, _, _, err = broker.GetValue("plain")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(string(data)).To(Equal("plain"))
+
+	it, err := broker.ListValues("")
+	Expect(err).ToNot(HaveOccurred())
+	values := map[string]string{}
+	for {
+		kv, stop := it.GetNext()
+		if stop {
+			break
+		}
+		values[kv.GetKey()] = string(kv.GetValue())
+	}
+	Expect(values).To(Equal(map[string]string{"large": string(large), "plain": "plain"}))
+
+	var resp keyval.BytesWatchResp
+	Eventually(events).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	Expect(resp.GetValue()).To(Equal(large))
+}
+
+func TestBytesWrapperErrors(t *testing.T) {
+	RegisterTestingT(t)
+	client := newClient(t)
+	large := []byte(strings.Repeat("x", 2000))
+
+	// transaction is not committed if any value cannot be compressed
+	invalid := kvcompress.NewCompressor(kvcompress.WithAlgorithm(kvcompress.Algorithm(9)))
+	broker := kvcompress.NewKvBytesPluginWrapper(client, invalid).NewBroker("/agent/")
+	err := broker.NewTxn().Put("small", []byte("small")).Put("large", large).Commit(context.Background())
+	Expect(err).To(HaveOccurred())
+	_, found, _, _ := client.GetValue("/agent/small")
+	Expect(found).To(BeFalse())
+
+	// values exceeding the maximal size are not returned
+	Expect(kvcompress.NewKvBytesPluginWrapper(client, kvcompress.NewCompressor()).
+		NewBroker("/agent/").Put("large", large)).To(Succeed())
+	broker = kvcompress.NewKvBytesPluginWrapper(client, kvcompress.NewCompressor(kvcompress.WithMaxSize(1000))).
+		NewBroker("/agent/")
+	_, _, _, err = broker.GetValue("large")
+	Expect(errors.Is(err, kvcompress.ErrTooLarge)).To(BeTrue())
+	it, err := broker.ListValues("")
+	Expect(err).ToNot(HaveOccurred())
+	kv, stop := it.GetNext()
+	Expect(stop).To(BeFalse())
+	Expect(kv.GetValue()).To(BeNil())
+}
+
+func TestProtoWrapper(t *testing.T) {
+	RegisterTestingT(t)
+	client := newClient(t)
+	value := wrapperspb.String(strings.Repeat("route ", 500))
+
+	kvp := kvcompress.NewKvProtoPluginWrapper(&boltPlugin{client: client}, kvcompress.NewCompressor())
+	broker := kvp.NewBroker("/agent/")
+	Expect(broker.Put("routes", value)).To(Succeed())
+
+	raw, _, _, err := client.GetValue("/agent/routes")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(kvcompress.IsCompressed(raw)).To(BeTrue())
+
+	var out wrapperspb.StringValue
+	found, _, err := broker.GetValue("routes", &out)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(proto.Equal(&out, value)).To(BeTrue())
+}
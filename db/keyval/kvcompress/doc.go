@@ -0,0 +1,27 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kvcompress provides support for wrapping key-value store with
+// a layer that transparently compresses values exceeding a size threshold.
+//
+// Compressed values start with a header identifying the compression
+// algorithm, values without the header are read as they are. Therefore
+// the wrapper can be used with data stores containing values written
+// without compression and values written through the wrapper can be read
+// by any client using the wrapper, regardless of its configuration.
+//
+// The size of decompressed values is limited (DefaultMaxSize by default,
+// see WithMaxSize), so that corrupted or malicious values cannot exhaust
+// the memory of readers.
+package kvcompress
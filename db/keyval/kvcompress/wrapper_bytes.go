@@ -0,0 +1,232 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvcompress
+
+import (
+	"context"
+	"fmt"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// KvBytesPluginWrapper wraps keyval.KvBytesPlugin with compression of written values
+// and decompression of read values.
+type KvBytesPluginWrapper struct {
+	keyval.KvBytesPlugin
+	compressor *Compressor
+}
+
+// BytesBrokerWrapper wraps keyval.BytesBroker with compression of written values
+// and decompression of read values.
+type BytesBrokerWrapper struct {
+	keyval.BytesBroker
+	compressor *Compressor
+}
+
+// BytesWatcherWrapper wraps keyval.BytesWatcher with decompression of watched values.
+type BytesWatcherWrapper struct {
+	keyval.BytesWatcher
+	compressor *Compressor
+}
+
+// NewKvBytesPluginWrapper creates wrapper for provided KvBytesPlugin, adding support
+// for compressing values.
+func NewKvBytesPluginWrapper(kvp keyval.KvBytesPlugin, compressor *Compressor) *KvBytesPluginWrapper {
+	return &KvBytesPluginWrapper{
+		KvBytesPlugin: kvp,
+		compressor:    compressor,
+	}
+}
+
+// NewBytesBrokerWrapper creates wrapper for provided BytesBroker, adding support
+// for compressing values.
+func NewBytesBrokerWrapper(broker keyval.BytesBroker, compressor *Compressor) *BytesBrokerWrapper {
+	return &BytesBrokerWrapper{
+		BytesBroker: broker,
+		compressor:  compressor,
+	}
+}
+
+// NewBytesWatcherWrapper creates wrapper for provided BytesWatcher, adding support
+// for decompressing watched values up to the maximal size of the compressor.
+func NewBytesWatcherWrapper(watcher keyval.BytesWatcher, compressor *Compressor) *BytesWatcherWrapper {
+	return &BytesWatcherWrapper{
+		BytesWatcher: watcher,
+		compressor:   compressor,
+	}
+}
+
+// NewBroker returns a BytesBroker instance with support for compressing values that prepends
+// given <keyPrefix> to all keys in its calls.
+// To avoid using a prefix, pass keyval.Root constant as argument.
+func (kvp *KvBytesPluginWrapper) NewBroker(prefix string) keyval.BytesBroker {
+	return NewBytesBrokerWrapper(kvp.KvBytesPlugin.NewBroker(prefix), kvp.compressor)
+}
+
+// NewWatcher returns a BytesWatcher instance with support for decompressing values that
+// prepends given <keyPrefix> to all keys during watch subscribe phase.
+// The prefix is removed from the key retrieved by GetKey() in BytesWatchResp.
+// To avoid using a prefix, pass keyval.Root constant as argument.
+func (kvp *KvBytesPluginWrapper) NewWatcher(prefix string) keyval.BytesWatcher {
+	return NewBytesWatcherWrapper(kvp.KvBytesPlugin.NewWatcher(prefix), kvp.compressor)
+}
+
+// Put compresses the data if it exceeds the threshold and puts it
+// under the provided key.
+func (b *BytesBrokerWrapper) Put(key string, data []byte, opts ...datasync.PutOption) error {
+	data, err := b.compressor.Compress(data)
+	if err != nil {
+		return err
+	}
+	return b.BytesBroker.Put(key, data, opts...)
+}
+
+// NewTxn creates a transaction which compresses the written values.
+func (b *BytesBrokerWrapper) NewTxn() keyval.BytesTxn {
+	txn := b.BytesBroker.NewTxn()
+	if txn == nil {
+		return nil
+	}
+	return &bytesTxnWrapper{BytesTxn: txn, compressor: b.compressor}
+}
+
+// GetValue retrieves and decompresses one item under the provided key.
+func (b *BytesBrokerWrapper) GetValue(key string) (data []byte, found bool, revision int64, err error) {
+	data, found, revision, err = b.BytesBroker.GetValue(key)
+	if err != nil || !found {
+		return data, found, revision, err
+	}
+	data, err = b.compressor.Decompress(data)
+	return data, found, revision, err
+}
+
+// ListValues returns an iterator that enables to traverse all items stored
+// under the provided <key>.
+func (b *BytesBrokerWrapper) ListValues(key string) (keyval.BytesKeyValIterator, error) {
+	kv, err := b.BytesBroker.ListValues(key)
+	if err != nil {
+		return kv, err
+	}
+	return &bytesKeyValIteratorWrapper{BytesKeyValIterator: kv, compressor: b.compressor}, nil
+}
+
+// Watch starts subscription for changes associated with the selected keys.
+// Watch events will be delivered to callback (not channel) <respChan>.
+// Channel <closeChan> can be used to close watching on respective key
+func (w *BytesWatcherWrapper) Watch(respChan func(keyval.BytesWatchResp), closeChan chan string, keys ...string) error {
+	return w.BytesWatcher.Watch(func(resp keyval.BytesWatchResp) {
+		respChan(&bytesWatchRespWrapper{BytesWatchResp: resp, compressor: w.compressor})
+	}, closeChan, keys...)
+}
+
+// bytesTxnWrapper wraps keyval.BytesTxn with compression of written values.
+type bytesTxnWrapper struct {
+	keyval.BytesTxn
+	compressor *Compressor
+	err        error
+}
+
+// Put adds put operation with compressed data into the transaction.
+// If the compression fails, the error is returned by Commit.
+func (t *bytesTxnWrapper) Put(key string, data []byte) keyval.BytesTxn {
+	if t.err != nil {
+		return t
+	}
+	compressed, err := t.compressor.Compress(data)
+	if err != nil {
+		t.err = fmt.Errorf("failed to compress value of %s: %w", key, err)
+		return t
+	}
+	t.BytesTxn.Put(key, compressed)
+	return t
+}
+
+// Delete adds delete operation into the transaction.
+func (t *bytesTxnWrapper) Delete(key string) keyval.BytesTxn {
+	t.BytesTxn.Delete(key)
+	return t
+}
+
+// Commit commits the transaction, unless compression of some value failed.
+func (t *bytesTxnWrapper) Commit(ctx context.Context) error {
+	if t.err != nil {
+		return t.err
+	}
+	return t.BytesTxn.Commit(ctx)
+}
+
+// bytesKeyValWrapper wraps keyval.BytesKeyVal with decompression of values.
+type bytesKeyValWrapper struct {
+	keyval.BytesKeyVal
+	compressor *Compressor
+}
+
+// GetValue returns the decompressed value of the pair.
+func (kv *bytesKeyValWrapper) GetValue() []byte {
+	return decompressOrNil(kv.compressor, kv.GetKey(), kv.BytesKeyVal.GetValue())
+}
+
+// GetPrevValue returns the decompressed previous value of the pair.
+func (kv *bytesKeyValWrapper) GetPrevValue() []byte {
+	return decompressOrNil(kv.compressor, kv.GetKey(), kv.BytesKeyVal.GetPrevValue())
+}
+
+// bytesWatchRespWrapper wraps keyval.BytesWatchResp with decompression of values.
+type bytesWatchRespWrapper struct {
+	keyval.BytesWatchResp
+	compressor *Compressor
+}
+
+// GetValue returns the decompressed value of the pair.
+func (r *bytesWatchRespWrapper) GetValue() []byte {
+	return decompressOrNil(r.compressor, r.GetKey(), r.BytesWatchResp.GetValue())
+}
+
+// GetPrevValue returns the decompressed previous value of the pair.
+func (r *bytesWatchRespWrapper) GetPrevValue() []byte {
+	return decompressOrNil(r.compressor, r.GetKey(), r.BytesWatchResp.GetPrevValue())
+}
+
+// bytesKeyValIteratorWrapper wraps keyval.BytesKeyValIterator with
+// decompression of values.
+type bytesKeyValIteratorWrapper struct {
+	keyval.BytesKeyValIterator
+	compressor *Compressor
+}
+
+// GetNext retrieves the following item from the context.
+// When there are no more items to get, <stop> is returned as *true*
+// and <kv> is simply *nil*.
+func (it *bytesKeyValIteratorWrapper) GetNext() (kv keyval.BytesKeyVal, stop bool) {
+	kv, stop = it.BytesKeyValIterator.GetNext()
+	if stop || kv == nil {
+		return kv, stop
+	}
+	return &bytesKeyValWrapper{BytesKeyVal: kv, compressor: it.compressor}, stop
+}
+
+// decompressOrNil decompresses the data of the key. Since the interfaces
+// of key-value pairs do not allow to return errors, the error is logged
+// and nil is returned if the data cannot be decompressed.
+func decompressOrNil(compressor *Compressor, key string, data []byte) []byte {
+	out, err := compressor.Decompress(data)
+	if err != nil {
+		logging.Errorf("failed to decompress value of %s: %v", key, err)
+		return nil
+	}
+	return out
+}
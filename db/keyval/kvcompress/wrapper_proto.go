@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvcompress
+
+import (
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvproto"
+)
+
+// KvPlugin is a key-value data store plugin providing access to raw data.
+type KvPlugin interface {
+	keyval.KvProtoPlugin
+	// RawAccess allows to access data in the data store as raw bytes.
+	RawAccess() keyval.KvBytesPlugin
+}
+
+// KvProtoPluginWrapper wraps KvPlugin with compression of written values and
+// decompression of read values. Since the values are compressed after they
+// are serialized, the wrapper needs raw access to the data store.
+type KvProtoPluginWrapper struct {
+	KvPlugin
+	compressor *Compressor
+	serializer keyval.Serializer
+}
+
+// NewKvProtoPluginWrapper creates wrapper for provided KvPlugin, adding support
+// for compressing values. The values are serialized using the <serializer>,
+// which should match the serializer of the wrapped plugin (JSON by default).
+func NewKvProtoPluginWrapper(kvp KvPlugin, compressor *Compressor, serializer ...keyval.Serializer) *KvProtoPluginWrapper {
+	w := &KvProtoPluginWrapper{
+		KvPlugin:   kvp,
+		compressor: compressor,
+		serializer: &keyval.SerializerJSON{},
+	}
+	if len(serializer) > 0 {
+		w.serializer = serializer[0]
+	}
+	return w
+}
+
+// NewBroker returns a ProtoBroker instance with support for compressing values that prepends
+// given <keyPrefix> to all keys in its calls.
+// To avoid using a prefix, pass keyval.Root constant as argument.
+func (kvp *KvProtoPluginWrapper) NewBroker(prefix string) keyval.ProtoBroker {
+	return kvp.protoWrapper().NewBroker(prefix)
+}
+
+// NewWatcher returns a ProtoWatcher instance with support for decompressing values that
+// prepends given <keyPrefix> to all keys during watch subscribe phase.
+// The prefix is removed from the key retrieved by GetKey() in ProtoWatchResp.
+// To avoid using a prefix, pass keyval.Root constant as argument.
+func (kvp *KvProtoPluginWrapper) NewWatcher(prefix string) keyval.ProtoWatcher {
+	return kvp.protoWrapper().NewWatcher(prefix)
+}
+
+// RawAccess returns KvBytesPlugin with support for compressing values.
+func (kvp *KvProtoPluginWrapper) RawAccess() keyval.KvBytesPlugin {
+	return NewKvBytesPluginWrapper(kvp.KvPlugin.RawAccess(), kvp.compressor)
+}
+
+// protoWrapper returns proto decorator of the raw access to the data store.
+// Raw access is resolved for every broker and watcher, since it may not be
+// available until the data store is connected.
+func (kvp *KvProtoPluginWrapper) protoWrapper() *kvproto.ProtoWrapper {
+	return kvproto.NewProtoWrapper(&coreBrokerWatcher{
+		KvBytesPluginWrapper: NewKvBytesPluginWrapper(kvp.KvPlugin.RawAccess(), kvp.compressor),
+	}, kvp.serializer)
+}
+
+// coreBrokerWatcher implements keyval.CoreBrokerWatcher using KvBytesPluginWrapper.
+type coreBrokerWatcher struct {
+	*KvBytesPluginWrapper
+}
+
+func (db *coreBrokerWatcher) Put(key string, data []byte, opts ...datasync.PutOption) error {
+	return db.NewBroker(keyval.Root).Put(key, data, opts...)
+}
+
+func (db *coreBrokerWatcher) NewTxn() keyval.BytesTxn {
+	return db.NewBroker(keyval.Root).NewTxn()
+}
+
+func (db *coreBrokerWatcher) GetValue(key string) (data []byte, found bool, revision int64, err error) {
+	return db.NewBroker(keyval.Root).GetValue(key)
+}
+
+func (db *coreBrokerWatcher) ListValues(key string) (keyval.BytesKeyValIterator, error) {
+	return db.NewBroker(keyval.Root).ListValues(key)
+}
+
+func (db *coreBrokerWatcher) ListKeys(prefix string) (keyval.BytesKeyIterator, error) {
+	return db.NewBroker(keyval.Root).ListKeys(prefix)
+}
+
+func (db *coreBrokerWatcher) Delete(key string, opts ...datasync.DelOption) (existed bool, err error) {
+	return db.NewBroker(keyval.Root).Delete(key, opts...)
+}
+
+func (db *coreBrokerWatcher) Watch(respChan func(keyval.BytesWatchResp), closeChan chan string, keys ...string) error {
+	return db.NewWatcher(keyval.Root).Watch(respChan, closeChan, keys...)
+}
+
+// Close does nothing, the data store is closed by its plugin.
+func (db *coreBrokerWatcher) Close() error {
+	return nil
+}
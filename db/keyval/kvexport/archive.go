@@ -0,0 +1,118 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvexport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"go.ligato.io/cn-infra/v2/db/keyval/filedb/decoder"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvexport/model/archive"
+)
+
+// writeArchive writes entries (with values as stored in the data store)
+// into the archive of the selected format.
+func (o *options) writeArchive(w io.Writer, entries []*archive.Entry) error {
+	if o.format == Proto {
+		for _, entry := range entries {
+			data, err := proto.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(protowire.AppendVarint(nil, uint64(len(data)))); err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dataEntries := make([]*decoder.FileDataEntry, 0, len(entries))
+	for _, entry := range entries {
+		value, err := o.encodeValue(entry.Key, entry.Value)
+		if err != nil {
+			return err
+		}
+		dataEntries = append(dataEntries, &decoder.FileDataEntry{Key: entry.Key, Value: value})
+	}
+	data, err := o.textDecoder().Encode(dataEntries)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readArchive reads entries from the archive of the selected format
+// and converts values into the form stored in the data store.
+func (o *options) readArchive(r io.Reader) ([]*archive.Entry, error) {
+	var entries []*archive.Entry
+	if o.format == Proto {
+		br := bufio.NewReader(r)
+		for {
+			size, err := binary.ReadUvarint(br)
+			if err == io.EOF {
+				return entries, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to read archive: %v", err)
+			}
+			if size > o.maxEntrySize {
+				return nil, fmt.Errorf("failed to read archive entry of %d bytes: %w", size, ErrEntryTooLarge)
+			}
+			data := make([]byte, size)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return nil, fmt.Errorf("failed to read archive: %v", err)
+			}
+			entry := &archive.Entry{}
+			if err := proto.Unmarshal(data, entry); err != nil {
+				return nil, fmt.Errorf("failed to decode archive entry: %v", err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %v", err)
+	}
+	dataEntries, err := o.textDecoder().Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, dataEntry := range dataEntries {
+		value, err := o.decodeValue(dataEntry.Key, dataEntry.Value)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &archive.Entry{Key: dataEntry.Key, Value: value})
+	}
+	return entries, nil
+}
+
+// textDecoder returns FileDB decoder for JSON or YAML archive.
+func (o *options) textDecoder() decoder.API {
+	if o.format == YAML {
+		return decoder.NewYAMLDecoder()
+	}
+	return decoder.NewJSONDecoder()
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate protoc --proto_path=. --go_out=paths=source_relative:. model/archive/archive.proto
+
+// Package kvexport provides export (backup) of key-value data stored under
+// a given prefix into a portable archive and import (restore) of the archive
+// back into a key-value data store.
+//
+// Archives in JSON and YAML formats use the same layout as files processed
+// by FileDB, i.e. the archive can be used also as FileDB configuration file.
+// Values of keys with known proto type are decoded, so the archive is
+// human-readable. Archive in proto format is a sequence of length-delimited
+// archive.Entry messages with values stored as they are.
+package kvexport
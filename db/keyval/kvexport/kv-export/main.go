@@ -0,0 +1,259 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"go.ligato.io/cn-infra/v2/config"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/etcd"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvexport"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvregistry"
+	"go.ligato.io/cn-infra/v2/logging/logrus"
+)
+
+// A simple utility to export key-value data from etcd into an archive file
+// and to import the archive back.
+
+// typeFlags collects <key template>=<proto message name> pairs.
+type typeFlags [][2]string
+
+func (tf *typeFlags) String() string {
+	var pairs []string
+	for _, pair := range *tf {
+		pairs = append(pairs, pair[0]+"="+pair[1])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (tf *typeFlags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("expected <key template>=<proto message name>, got %q", value)
+	}
+	*tf = append(*tf, [2]string{parts[0], parts[1]})
+	return nil
+}
+
+// newRegistry registers the proto types of values for key templates. No proto types are linked
+// into the utility, types are therefore looked up in the descriptor set file (generated by protoc
+// with --descriptor_set_out and --include_imports) if provided.
+func newRegistry(types typeFlags, descriptorSet string) (*kvregistry.Registry, error) {
+	registry := kvregistry.NewRegistry()
+	var files *protoregistry.Files
+	if descriptorSet != "" {
+		data, err := os.ReadFile(descriptorSet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read descriptor set: %v", err)
+		}
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(data, set); err != nil {
+			return nil, fmt.Errorf("failed to parse descriptor set %s: %v", descriptorSet, err)
+		}
+		if files, err = protodesc.NewFiles(set); err != nil {
+			return nil, fmt.Errorf("invalid descriptor set %s: %v", descriptorSet, err)
+		}
+	}
+	for _, pair := range types {
+		template, name := pair[0], protoreflect.FullName(pair[1])
+		if files == nil {
+			if err := registry.RegisterName(template, name); err != nil {
+				return nil, fmt.Errorf("type of %s: %v", template, err)
+			}
+			continue
+		}
+		desc, err := files.FindDescriptorByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("type of %s: message %s not found: %v", template, name, err)
+		}
+		msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("type of %s: %s is not a message", template, name)
+		}
+		if err := registry.RegisterDescriptor(template, msgDesc); err != nil {
+			return nil, fmt.Errorf("type of %s: %v", template, err)
+		}
+	}
+	return registry, nil
+}
+
+func main() {
+	var types typeFlags
+	cfgFile := flag.String("cfg", "", "etcd configuration file")
+	prefix := flag.String("prefix", "/", "key prefix to export")
+	file := flag.String("file", "", "archive file, standard input/output is used if empty")
+	format := flag.String("format", "", "archive format (json, yaml or proto), derived from the file extension if empty")
+	mode := flag.String("mode", "diff", "import mode (overwrite, skip or diff)")
+	protoValues := flag.Bool("proto-values", false, "values are stored in binary proto format instead of JSON")
+	descriptorSet := flag.String("descriptor-set", "", "file with proto descriptor set defining types of values")
+	flag.Var(&types, "type", "proto type of values under the key template as <key template>=<proto message name>, can be repeated")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 || *cfgFile == "" {
+		usage()
+		os.Exit(2)
+	}
+	log := logrus.DefaultLogger()
+
+	registry, err := newRegistry(types, *descriptorSet)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := []kvexport.Option{kvexport.WithTypes(registry.MessageType)}
+	if *protoValues {
+		opts = append(opts, kvexport.WithSerializer(&keyval.SerializerProto{}))
+	}
+	archiveFormat, err := parseFormat(*format, *file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts = append(opts, kvexport.WithFormat(archiveFormat))
+
+	etcdCfg := &etcd.Config{}
+	if err := config.ParseConfigFromYamlFile(*cfgFile, etcdCfg); err != nil {
+		log.Fatalf("failed to parse etcd config: %v", err)
+	}
+	clientCfg, err := etcd.ConfigToClient(etcdCfg)
+	if err != nil {
+		log.Fatalf("invalid etcd config: %v", err)
+	}
+	db, err := etcd.NewEtcdConnectionWithBytes(*clientCfg, log)
+	if err != nil {
+		log.Fatalf("failed to connect to etcd: %v", err)
+	}
+	defer db.Close()
+
+	switch flag.Arg(0) {
+	case "export":
+		var w io.Writer = os.Stdout
+		if *file != "" {
+			f, err := os.Create(*file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		n, err := kvexport.Export(db, *prefix, w, opts...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("exported %d keys under %s", n, *prefix)
+
+	case "import":
+		importMode, err := parseMode(*mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var r io.Reader = os.Stdin
+		if *file != "" {
+			f, err := os.Open(*file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			r = f
+		}
+		result, err := kvexport.Import(db, r, importMode, opts...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printResult(importMode, result)
+
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func parseFormat(format, file string) (kvexport.Format, error) {
+	switch format {
+	case "json":
+		return kvexport.JSON, nil
+	case "yaml":
+		return kvexport.YAML, nil
+	case "proto":
+		return kvexport.Proto, nil
+	case "":
+		if file == "" {
+			return kvexport.JSON, nil
+		}
+		return kvexport.FormatForFile(file)
+	}
+	return 0, fmt.Errorf("unknown archive format %q", format)
+}
+
+func parseMode(mode string) (kvexport.ImportMode, error) {
+	for _, m := range []kvexport.ImportMode{kvexport.Overwrite, kvexport.Skip, kvexport.Diff} {
+		if m.String() == mode {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown import mode %q", mode)
+}
+
+func printResult(mode kvexport.ImportMode, result *kvexport.ImportResult) {
+	for _, key := range result.Created {
+		fmt.Printf("+ %s\n", key)
+	}
+	for _, key := range result.Updated {
+		fmt.Printf("~ %s\n", key)
+	}
+	for _, key := range result.Skipped {
+		fmt.Printf("! %s (skipped)\n", key)
+	}
+	fmt.Printf("%s: %d created, %d updated, %d skipped, %d unchanged\n", mode,
+		len(result.Created), len(result.Updated), len(result.Skipped), len(result.Unchanged))
+}
+
+// Show info
+func usage() {
+	var buffer bytes.Buffer
+	buffer.WriteString(`
+	Export of key-value data from etcd into an archive and import
+	of the archive back into etcd.
+
+	./kv-export -cfg <etcd.conf> [-prefix <prefix>] [-file <archive>] export
+	./kv-export -cfg <etcd.conf> [-file <archive>] [-mode overwrite|skip|diff] import
+
+	Import runs in diff mode by default, i.e. only the differences
+	between the archive and etcd are printed.
+
+	Values with known proto type are stored in the archive as JSON.
+	Types are assigned to keys by key templates and looked up in
+	a descriptor set generated by protoc:
+
+	protoc --include_imports --descriptor_set_out=model.pb model.proto
+	./kv-export -cfg <etcd.conf> -descriptor-set model.pb \
+		-type '/vnf-agent/{label}/config/acl/{name}=acl.ACL' export
+
+	Options:
+`)
+	fmt.Fprint(os.Stderr, buffer.String())
+	flag.PrintDefaults()
+}
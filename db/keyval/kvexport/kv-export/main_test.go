@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestNewRegistry(t *testing.T) {
+	RegisterTestingT(t)
+
+	// message which is not linked into the utility
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("acl.proto"),
+			Package: proto.String("acl"),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("ACL"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("name"),
+					JsonName: proto.String("name"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				}},
+			}},
+		}},
+	}
+	data, err := proto.Marshal(set)
+	Expect(err).ToNot(HaveOccurred())
+	descriptorSet := filepath.Join(t.TempDir(), "acl.pb")
+	Expect(os.WriteFile(descriptorSet, data, 0600)).To(Succeed())
+
+	var types typeFlags
+	Expect(types.Set("/vnf-agent/{label}/config/acl/{name}=acl.ACL")).To(Succeed())
+	Expect(types.Set("invalid")).ToNot(Succeed())
+
+	_, err = newRegistry(types, "")
+	Expect(err).To(HaveOccurred())
+
+	registry, err := newRegistry(types, descriptorSet)
+	Expect(err).ToNot(HaveOccurred())
+	msgType := registry.MessageType("/vnf-agent/agent1/config/acl/acl1")
+	Expect(msgType).ToNot(BeNil())
+	Expect(string(msgType.Descriptor().FullName())).To(Equal("acl.ACL"))
+	Expect(registry.MessageType("/vnf-agent/agent1/config/route/r1")).To(BeNil())
+
+	// linked types are found without descriptor set
+	types = typeFlags{{"/archive/", "kvexport.Entry"}}
+	_, err = newRegistry(types, "")
+	Expect(err).To(HaveOccurred())
+	types = typeFlags{{"/archive/", "archive.Entry"}}
+	registry, err = newRegistry(types, "")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(registry.MessageType("/archive/e1")).ToNot(BeNil())
+}
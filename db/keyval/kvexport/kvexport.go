@@ -0,0 +1,210 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvexport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/filedb/decoder"
+)
+
+// DefaultMaxEntrySize is the default limit of the size of an entry
+// read from a proto archive.
+const DefaultMaxEntrySize = 64 << 20
+
+// ErrEntryTooLarge is returned by Import if an entry of the proto archive
+// exceeds the limit set by WithMaxEntrySize.
+var ErrEntryTooLarge = errors.New("kvexport: archive entry exceeds size limit")
+
+// Format is the format of the archive.
+type Format int
+
+const (
+	// JSON archive uses the layout of FileDB JSON files.
+	JSON Format = iota
+	// YAML archive uses the layout of FileDB YAML files.
+	YAML
+	// Proto archive is a sequence of length-delimited archive.Entry messages.
+	Proto
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case JSON:
+		return "json"
+	case YAML:
+		return "yaml"
+	case Proto:
+		return "proto"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// FormatForFile returns archive format according to the extension of the file.
+// Extensions recognized by FileDB decoders are used for JSON and YAML archives,
+// ".pb" and ".bin" for proto archives.
+func FormatForFile(path string) (Format, error) {
+	switch {
+	case decoder.NewJSONDecoder().IsProcessable(path):
+		return JSON, nil
+	case decoder.NewYAMLDecoder(".yml").IsProcessable(path):
+		return YAML, nil
+	case strings.HasSuffix(path, ".pb"), strings.HasSuffix(path, ".bin"):
+		return Proto, nil
+	}
+	return 0, fmt.Errorf("unknown archive format of file %s", path)
+}
+
+// TypeResolver returns proto message type of the value stored under the given key,
+// or nil if the type is not known. Types registered in kvregistry can be
+// resolved using kvregistry.MessageType.
+type TypeResolver func(key string) protoreflect.MessageType
+
+// PrefixTypes returns TypeResolver which selects the type of value according
+// to the longest key prefix from the given map.
+func PrefixTypes(types map[string]proto.Message) TypeResolver {
+	return func(key string) protoreflect.MessageType {
+		var (
+			msgType protoreflect.MessageType
+			longest = -1
+		)
+		for prefix, msg := range types {
+			if strings.HasPrefix(key, prefix) && len(prefix) > longest {
+				msgType, longest = msg.ProtoReflect().Type(), len(prefix)
+			}
+		}
+		return msgType
+	}
+}
+
+// Option is used to customize export and import.
+type Option func(*options)
+
+type options struct {
+	format       Format
+	types        TypeResolver
+	serializer   keyval.Serializer
+	maxEntrySize uint64
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		format:       JSON,
+		serializer:   &keyval.SerializerJSON{},
+		maxEntrySize: DefaultMaxEntrySize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithFormat sets format of the archive (JSON by default).
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithTypes sets resolver of proto types used to decode values for JSON
+// and YAML archives.
+func WithTypes(types TypeResolver) Option {
+	return func(o *options) {
+		o.types = types
+	}
+}
+
+// WithSerializer sets serializer used for proto-modelled values in the data
+// store (JSON serializer by default).
+func WithSerializer(serializer keyval.Serializer) Option {
+	return func(o *options) {
+		o.serializer = serializer
+	}
+}
+
+// WithMaxEntrySize sets the limit of the size of an entry read from a proto
+// archive (DefaultMaxEntrySize by default). The size of each entry is stored
+// in the archive, the limit protects from allocating memory for sizes read
+// from corrupted archives.
+func WithMaxEntrySize(size uint64) Option {
+	return func(o *options) {
+		o.maxEntrySize = size
+	}
+}
+
+// base64Prefix marks values which are neither valid JSON nor of a known proto
+// type, and therefore are stored in text archives as base64-encoded strings.
+const base64Prefix = "$base64$"
+
+// encodeValue converts value from the data store into JSON used in text archives.
+func (o *options) encodeValue(key string, value []byte) (json.RawMessage, error) {
+	if o.types != nil {
+		if msgType := o.types(key); msgType != nil {
+			msg := msgType.New().Interface()
+			if err := o.serializer.Unmarshal(value, msg); err != nil {
+				return nil, fmt.Errorf("failed to decode value of key %s as %s: %v",
+					key, msgType.Descriptor().FullName(), err)
+			}
+			return keyval.DefaultMarshaler.Marshal(msg)
+		}
+	}
+	if json.Valid(value) {
+		return value, nil
+	}
+	return json.Marshal(base64Prefix + base64.StdEncoding.EncodeToString(value))
+}
+
+// decodeValue converts JSON from text archive into value stored in the data store.
+func (o *options) decodeValue(key string, data json.RawMessage) ([]byte, error) {
+	if o.types != nil {
+		if msgType := o.types(key); msgType != nil {
+			msg := msgType.New().Interface()
+			if err := protojson.Unmarshal(data, msg); err != nil {
+				return nil, fmt.Errorf("failed to decode value of key %s as %s: %v",
+					key, msgType.Descriptor().FullName(), err)
+			}
+			return o.serializer.Marshal(msg)
+		}
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil && strings.HasPrefix(str, base64Prefix) {
+		return base64.StdEncoding.DecodeString(strings.TrimPrefix(str, base64Prefix))
+	}
+	return data, nil
+}
+
+// equalValues compares two values, JSON values are compared regardless
+// of the formatting.
+func equalValues(v1, v2 []byte) bool {
+	if bytes.Equal(v1, v2) {
+		return true
+	}
+	var c1, c2 bytes.Buffer
+	if json.Compact(&c1, v1) != nil || json.Compact(&c2, v2) != nil {
+		return false
+	}
+	return bytes.Equal(c1.Bytes(), c2.Bytes())
+}
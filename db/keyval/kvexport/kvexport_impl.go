@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvexport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvexport/model/archive"
+)
+
+// ImportMode defines how Import treats keys already present in the data store.
+type ImportMode int
+
+const (
+	// Overwrite replaces values of keys present in the data store.
+	Overwrite ImportMode = iota
+	// Skip leaves keys present in the data store untouched.
+	Skip
+	// Diff does not change the data store, only reports the differences.
+	Diff
+)
+
+// String returns the name of the import mode.
+func (m ImportMode) String() string {
+	switch m {
+	case Overwrite:
+		return "overwrite"
+	case Skip:
+		return "skip"
+	case Diff:
+		return "diff"
+	}
+	return fmt.Sprintf("ImportMode(%d)", int(m))
+}
+
+// ImportResult lists keys from the archive by the way they were imported.
+// In Diff mode, Created and Updated list keys that would be changed
+// by Overwrite mode.
+type ImportResult struct {
+	// Created lists keys not present in the data store before the import.
+	Created []string
+	// Updated lists keys whose values were replaced.
+	Updated []string
+	// Skipped lists keys with different values left untouched in Skip mode.
+	Skipped []string
+	// Unchanged lists keys with the same value in the archive and the data store.
+	Unchanged []string
+}
+
+// Export writes all key-value pairs stored under the given <prefix>
+// into the archive written to <w>. Returns number of exported pairs.
+func Export(db keyval.KvBytesPlugin, prefix string, w io.Writer, opts ...Option) (int, error) {
+	o := newOptions(opts)
+
+	it, err := db.NewBroker(keyval.Root).ListValues(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list values under %s: %v", prefix, err)
+	}
+	var entries []*archive.Entry
+	for {
+		kv, stop := it.GetNext()
+		if stop {
+			break
+		}
+		entries = append(entries, &archive.Entry{Key: kv.GetKey(), Value: kv.GetValue()})
+	}
+	if err := o.writeArchive(w, entries); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %v", err)
+	}
+	return len(entries), nil
+}
+
+// Import reads the archive from <r> and stores the key-value pairs into
+// the data store according to the given <mode>. If the data store supports
+// transactions, all the changes are applied in a single transaction.
+func Import(db keyval.KvBytesPlugin, r io.Reader, mode ImportMode, opts ...Option) (*ImportResult, error) {
+	o := newOptions(opts)
+
+	entries, err := o.readArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// load current values of all the keys present in the archive
+	broker := db.NewBroker(keyval.Root)
+	current := make(map[string][]byte)
+	if len(entries) > 0 {
+		prefix := commonPrefix(entries)
+		it, err := broker.ListValues(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list values under %s: %v", prefix, err)
+		}
+		for {
+			kv, stop := it.GetNext()
+			if stop {
+				break
+			}
+			current[kv.GetKey()] = kv.GetValue()
+		}
+	}
+
+	result := &ImportResult{}
+	var changes []*archive.Entry
+	for _, entry := range entries {
+		value, found := current[entry.Key]
+		switch {
+		case !found:
+			result.Created = append(result.Created, entry.Key)
+		case equalValues(value, entry.Value):
+			result.Unchanged = append(result.Unchanged, entry.Key)
+			continue
+		case mode == Skip:
+			result.Skipped = append(result.Skipped, entry.Key)
+			continue
+		default:
+			result.Updated = append(result.Updated, entry.Key)
+		}
+		changes = append(changes, entry)
+	}
+	if mode == Diff || len(changes) == 0 {
+		return result, nil
+	}
+
+	if txn := broker.NewTxn(); txn != nil {
+		for _, entry := range changes {
+			txn.Put(entry.Key, entry.Value)
+		}
+		if err := txn.Commit(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to commit import transaction: %v", err)
+		}
+		return result, nil
+	}
+	for _, entry := range changes {
+		if err := broker.Put(entry.Key, entry.Value); err != nil {
+			return nil, fmt.Errorf("failed to put value of key %s: %v", entry.Key, err)
+		}
+	}
+	return result, nil
+}
+
+// commonPrefix returns the longest prefix shared by keys of all the entries.
+func commonPrefix(entries []*archive.Entry) string {
+	prefix := entries[0].Key
+	for _, entry := range entries[1:] {
+		for !strings.HasPrefix(entry.Key, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
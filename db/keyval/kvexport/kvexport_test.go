@@ -0,0 +1,156 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvexport_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/bolt"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvexport"
+	"go.ligato.io/cn-infra/v2/health/statuscheck/model/status"
+)
+
+var types = kvexport.PrefixTypes(map[string]proto.Message{
+	"/agent/status/": &status.PluginStatus{},
+})
+
+func newClient(t *testing.T) *bolt.Client {
+	client, err := bolt.NewClient(&bolt.Config{
+		DbPath:   filepath.Join(t.TempDir(), "bolt.db"),
+		FileMode: 432,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func fillClient(client *bolt.Client) {
+	value, err := (&keyval.SerializerJSON{}).Marshal(&status.PluginStatus{Name: "etcd", State: status.OperationalState_OK})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(client.Put("/agent/status/etcd", value)).To(Succeed())
+	Expect(client.Put("/agent/config/json", []byte(`{"enabled":true}`))).To(Succeed())
+	Expect(client.Put("/agent/config/raw", []byte{0, 1, 2})).To(Succeed())
+	Expect(client.Put("/other/key", []byte("other"))).To(Succeed())
+}
+
+func TestExportJSON(t *testing.T) {
+	RegisterTestingT(t)
+	client := newClient(t)
+	fillClient(client)
+
+	var buf bytes.Buffer
+	n, err := kvexport.Export(client, "/agent/", &buf, kvexport.WithTypes(types))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(n).To(Equal(3))
+	Expect(buf.String()).To(ContainSubstring(`"key":"/agent/status/etcd","value":{"name":"etcd","state":"OK"}`))
+	Expect(buf.String()).To(ContainSubstring(`"value":{"enabled":true}`))
+	Expect(buf.String()).To(ContainSubstring(`"value":"$base64$AAEC"`))
+	Expect(buf.String()).ToNot(ContainSubstring("/other/key"))
+}
+
+func TestExportImport(t *testing.T) {
+	for _, format := range []kvexport.Format{kvexport.JSON, kvexport.YAML, kvexport.Proto} {
+		t.Run(format.String(), func(t *testing.T) {
+			RegisterTestingT(t)
+			src := newClient(t)
+			fillClient(src)
+
+			var buf bytes.Buffer
+			_, err := kvexport.Export(src, "/agent/", &buf, kvexport.WithFormat(format), kvexport.WithTypes(types))
+			Expect(err).ToNot(HaveOccurred())
+
+			dst := newClient(t)
+			Expect(dst.Put("/agent/config/json", []byte(`{"enabled":false}`))).To(Succeed())
+
+			result, err := kvexport.Import(dst, bytes.NewReader(buf.Bytes()), kvexport.Overwrite,
+				kvexport.WithFormat(format), kvexport.WithTypes(types))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Created).To(ConsistOf("/agent/status/etcd", "/agent/config/raw"))
+			Expect(result.Updated).To(ConsistOf("/agent/config/json"))
+
+			value, _, _, err := dst.GetValue("/agent/config/raw")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal([]byte{0, 1, 2}))
+			value, _, _, err = dst.GetValue("/agent/status/etcd")
+			Expect(err).ToNot(HaveOccurred())
+			msg := &status.PluginStatus{}
+			Expect((&keyval.SerializerJSON{}).Unmarshal(value, msg)).To(Succeed())
+			Expect(msg.Name).To(Equal("etcd"))
+			Expect(msg.State).To(Equal(status.OperationalState_OK))
+
+			// importing the same archive again changes nothing
+			result, err = kvexport.Import(dst, bytes.NewReader(buf.Bytes()), kvexport.Overwrite,
+				kvexport.WithFormat(format), kvexport.WithTypes(types))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Created).To(BeEmpty())
+			Expect(result.Updated).To(BeEmpty())
+			Expect(result.Unchanged).To(HaveLen(3))
+		})
+	}
+}
+
+func TestImportSkipAndDiff(t *testing.T) {
+	RegisterTestingT(t)
+	archive := `{"data":[{"key":"/agent/a","value":{"a":1}},{"key":"/agent/b","value":{"b":2}}]}`
+
+	client := newClient(t)
+	Expect(client.Put("/agent/a", []byte(`{"a":0}`))).To(Succeed())
+
+	result, err := kvexport.Import(client, bytes.NewBufferString(archive), kvexport.Diff)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(result.Created).To(ConsistOf("/agent/b"))
+	Expect(result.Updated).To(ConsistOf("/agent/a"))
+	_, found, _, _ := client.GetValue("/agent/b")
+	Expect(found).To(BeFalse())
+
+	result, err = kvexport.Import(client, bytes.NewBufferString(archive), kvexport.Skip)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(result.Created).To(ConsistOf("/agent/b"))
+	Expect(result.Skipped).To(ConsistOf("/agent/a"))
+	value, _, _, err := client.GetValue("/agent/a")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(value).To(Equal([]byte(`{"a":0}`)))
+	value, _, _, err = client.GetValue("/agent/b")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(value).To(Equal([]byte(`{"b":2}`)))
+}
+
+func TestImportEntrySizeLimit(t *testing.T) {
+	RegisterTestingT(t)
+	client := newClient(t)
+
+	// entry of a corrupted archive declares huge size
+	archive := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}
+	_, err := kvexport.Import(client, bytes.NewReader(archive), kvexport.Overwrite, kvexport.WithFormat(kvexport.Proto))
+	Expect(errors.Is(err, kvexport.ErrEntryTooLarge)).To(BeTrue())
+
+	src := newClient(t)
+	fillClient(src)
+	var buf bytes.Buffer
+	_, err = kvexport.Export(src, "/agent/", &buf, kvexport.WithFormat(kvexport.Proto))
+	Expect(err).ToNot(HaveOccurred())
+	_, err = kvexport.Import(client, bytes.NewReader(buf.Bytes()), kvexport.Overwrite,
+		kvexport.WithFormat(kvexport.Proto), kvexport.WithMaxEntrySize(10))
+	Expect(errors.Is(err, kvexport.ErrEntryTooLarge)).To(BeTrue())
+	_, err = kvexport.Import(client, bytes.NewReader(buf.Bytes()), kvexport.Overwrite, kvexport.WithFormat(kvexport.Proto))
+	Expect(err).ToNot(HaveOccurred())
+}
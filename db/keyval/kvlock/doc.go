@@ -0,0 +1,20 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kvlock provides an in-process implementation of the keyval.Locker
+// API. It is intended for data stores that can be opened by a single process
+// only (e.g. Bolt or FileDB), where locking inside the process is sufficient.
+// Clients of shared data stores use it to serialize lock owners within
+// the same process.
+package kvlock
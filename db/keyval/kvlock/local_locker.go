@@ -0,0 +1,168 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvlock
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+)
+
+var errLockerClosed = errors.New("kvlock: locker is closed")
+
+// LocalLocker implements keyval.Locker with locks held in the memory
+// of the current process.
+type LocalLocker struct {
+	mu     sync.Mutex
+	locks  map[string]*localLock // lock name -> current owner
+	tokens map[string]int64      // lock name -> last fencing token
+	closed bool
+}
+
+// localLock is a lock acquired from LocalLocker.
+type localLock struct {
+	locker *LocalLocker
+	name   string
+	token  int64
+	lost   chan struct{}
+}
+
+// prefixedLocker prepends prefix to the names of all locks.
+type prefixedLocker struct {
+	locker *LocalLocker
+	prefix string
+}
+
+// NewLocalLocker creates a new instance of LocalLocker.
+func NewLocalLocker() *LocalLocker {
+	return &LocalLocker{
+		locks:  make(map[string]*localLock),
+		tokens: make(map[string]int64),
+	}
+}
+
+// Prefixed returns a Locker that prepends given <prefix> to the names
+// of all locks. Lockers with the same prefix share the same set of locks.
+func (l *LocalLocker) Prefixed(prefix string) keyval.Locker {
+	return &prefixedLocker{locker: l, prefix: prefix}
+}
+
+// Lock acquires the lock with the given <name>. The call blocks until
+// either the lock is acquired or the context is canceled.
+func (l *LocalLocker) Lock(ctx context.Context, name string) (keyval.Lock, error) {
+	for {
+		lock, owner, err := l.tryLock(name)
+		if err != nil || lock != nil {
+			return lock, err
+		}
+		// wait for the current owner to release the lock
+		select {
+		case <-owner.lost:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// TryLock attempts to acquire the lock with the given <name> without
+// waiting. If the lock is held by another owner, keyval.ErrLockHeld
+// is returned.
+func (l *LocalLocker) TryLock(ctx context.Context, name string) (keyval.Lock, error) {
+	lock, _, err := l.tryLock(name)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, keyval.ErrLockHeld
+	}
+	return lock, nil
+}
+
+// Close releases all the locks held and makes the locker unusable.
+// Channels returned by Lost() of all held locks are closed.
+func (l *LocalLocker) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	for name, lock := range l.locks {
+		close(lock.lost)
+		delete(l.locks, name)
+	}
+	return nil
+}
+
+// tryLock either acquires the lock or returns its current owner.
+func (l *LocalLocker) tryLock(name string) (lock, owner *localLock, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, nil, errLockerClosed
+	}
+	if owner, held := l.locks[name]; held {
+		return nil, owner, nil
+	}
+	l.tokens[name]++
+	lock = &localLock{
+		locker: l,
+		name:   name,
+		token:  l.tokens[name],
+		lost:   make(chan struct{}),
+	}
+	l.locks[name] = lock
+	return lock, nil, nil
+}
+
+// Name returns the name of the lock.
+func (lock *localLock) Name() string {
+	return lock.name
+}
+
+// Token returns the fencing token of this acquisition of the lock.
+func (lock *localLock) Token() int64 {
+	return lock.token
+}
+
+// Lost returns a channel that is closed once the lock is released.
+func (lock *localLock) Lost() <-chan struct{} {
+	return lock.lost
+}
+
+// Unlock releases the lock.
+func (lock *localLock) Unlock(ctx context.Context) error {
+	l := lock.locker
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locks[lock.name] != lock {
+		return keyval.ErrLockNotHeld
+	}
+	delete(l.locks, lock.name)
+	close(lock.lost)
+	return nil
+}
+
+// Lock acquires the lock with the prefixed <name>.
+func (pl *prefixedLocker) Lock(ctx context.Context, name string) (keyval.Lock, error) {
+	return pl.locker.Lock(ctx, pl.prefix+name)
+}
+
+// TryLock attempts to acquire the lock with the prefixed <name>.
+func (pl *prefixedLocker) TryLock(ctx context.Context, name string) (keyval.Lock, error) {
+	return pl.locker.TryLock(ctx, pl.prefix+name)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvlock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+)
+
+func TestTryLock(t *testing.T) {
+	RegisterTestingT(t)
+	ctx := context.Background()
+	locker := NewLocalLocker()
+
+	lock, err := locker.TryLock(ctx, "lock1")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(lock.Name()).To(Equal("lock1"))
+	Expect(lock.Token()).To(BeEquivalentTo(1))
+
+	_, err = locker.TryLock(ctx, "lock1")
+	Expect(err).To(Equal(keyval.ErrLockHeld))
+
+	// other locks are independent
+	other, err := locker.TryLock(ctx, "lock2")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(other.Token()).To(BeEquivalentTo(1))
+
+	Expect(lock.Unlock(ctx)).To(Succeed())
+	Expect(lock.Lost()).To(BeClosed())
+	Expect(lock.Unlock(ctx)).To(Equal(keyval.ErrLockNotHeld))
+
+	lock, err = locker.TryLock(ctx, "lock1")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(lock.Token()).To(BeEquivalentTo(2))
+}
+
+func TestLockWaitsForRelease(t *testing.T) {
+	RegisterTestingT(t)
+	ctx := context.Background()
+	locker := NewLocalLocker()
+
+	first, err := locker.Lock(ctx, "lock")
+	Expect(err).ToNot(HaveOccurred())
+
+	acquired := make(chan keyval.Lock)
+	go func() {
+		second, err := locker.Lock(ctx, "lock")
+		Expect(err).ToNot(HaveOccurred())
+		acquired <- second
+	}()
+	Consistently(acquired, 100*time.Millisecond).ShouldNot(Receive())
+
+	Expect(first.Unlock(ctx)).To(Succeed())
+	var second keyval.Lock
+	Eventually(acquired).Should(Receive(&second))
+	Expect(second.Token()).To(BeNumerically(">", first.Token()))
+}
+
+func TestLockCanceled(t *testing.T) {
+	RegisterTestingT(t)
+	locker := NewLocalLocker()
+
+	_, err := locker.Lock(context.Background(), "lock")
+	Expect(err).ToNot(HaveOccurred())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = locker.Lock(ctx, "lock")
+	Expect(err).To(Equal(context.DeadlineExceeded))
+}
+
+func TestPrefixedAndClose(t *testing.T) {
+	RegisterTestingT(t)
+	ctx := context.Background()
+	locker := NewLocalLocker()
+
+	lock, err := locker.Prefixed("/agent1/").TryLock(ctx, "lock")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(lock.Name()).To(Equal("/agent1/lock"))
+
+	_, err = locker.TryLock(ctx, "/agent1/lock")
+	Expect(err).To(Equal(keyval.ErrLockHeld))
+	_, err = locker.Prefixed("/agent2/").TryLock(ctx, "lock")
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(locker.Close()).To(Succeed())
+	Expect(lock.Lost()).To(BeClosed())
+	_, err = locker.TryLock(ctx, "lock")
+	Expect(err).To(HaveOccurred())
+}
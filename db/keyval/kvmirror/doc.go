@@ -0,0 +1,24 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kvmirror implements a plugin that continuously replicates data
+// stored under selected key prefixes from one key-value data store
+// to another, e.g. from etcd to a local Bolt database.
+//
+// The replication starts with listing all the data under the prefix
+// in the source, followed by applying of watched changes. The revision
+// of each replicated key is periodically stored as a checkpoint in the
+// target, so that unchanged data does not have to be re-written after
+// restart.
+package kvmirror
@@ -0,0 +1,313 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvmirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+const (
+	// DefaultCheckpointPrefix is the default prefix of keys under which
+	// the checkpoints are stored in the target data store.
+	DefaultCheckpointPrefix = "/kvmirror/checkpoint"
+	// DefaultCheckpointInterval is the default interval of storing checkpoints.
+	DefaultCheckpointInterval = time.Second
+
+	// eventsChannelSize is the capacity of the channel with watched changes.
+	eventsChannelSize = 1000
+)
+
+// checkpoint is stored in the target data store. Revisions are stored
+// for each key, because some data stores (e.g. Redis) do not have
+// revisions global to the whole data store.
+type checkpoint struct {
+	Revisions map[string]int64 `json:"revisions"`
+}
+
+// Mirror replicates data stored under a key prefix from the source data store
+// to the target data store.
+type Mirror struct {
+	log    logging.Logger
+	source keyval.KvBytesPlugin
+	target keyval.BytesBroker
+	prefix string
+
+	checkpointKey      string
+	checkpointInterval time.Duration
+
+	events  chan keyval.BytesWatchResp
+	closeCh chan string
+	quit    chan struct{}
+	wg      sync.WaitGroup
+
+	// revisions of the keys replicated to the target
+	revisions map[string]int64
+	// changed is set if revisions were changed since the last checkpoint
+	changed bool
+	// resyncNeeded is set if a change failed to be applied to the target,
+	// the revision is not advanced until the data are resynced
+	resyncNeeded bool
+}
+
+// MirrorOption is used to customize Mirror.
+type MirrorOption func(*Mirror)
+
+// WithCheckpointPrefix sets prefix of the key with the checkpoint stored in the target.
+func WithCheckpointPrefix(prefix string) MirrorOption {
+	return func(m *Mirror) {
+		m.checkpointKey = strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(m.prefix, "/")
+	}
+}
+
+// WithCheckpointInterval sets interval of storing checkpoints.
+func WithCheckpointInterval(interval time.Duration) MirrorOption {
+	return func(m *Mirror) {
+		m.checkpointInterval = interval
+	}
+}
+
+// NewMirror creates a new instance of Mirror replicating data under the given
+// <prefix> from <source> to <target>.
+func NewMirror(source, target keyval.KvBytesPlugin, prefix string, log logging.Logger, opts ...MirrorOption) *Mirror {
+	m := &Mirror{
+		log:                log,
+		source:             source,
+		target:             target.NewBroker(keyval.Root),
+		prefix:             prefix,
+		checkpointInterval: DefaultCheckpointInterval,
+		events:             make(chan keyval.BytesWatchResp, eventsChannelSize),
+		closeCh:            make(chan string),
+		quit:               make(chan struct{}),
+		revisions:          make(map[string]int64),
+	}
+	WithCheckpointPrefix(DefaultCheckpointPrefix)(m)
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Start starts watching of the source, replicates the current data and
+// continues with replication of the watched changes in the background.
+func (m *Mirror) Start() error {
+	if err := m.loadCheckpoint(); err != nil {
+		return err
+	}
+
+	// start watching before listing to not miss any change
+	err := m.source.NewWatcher(keyval.Root).Watch(m.onChange, m.closeCh, m.prefix)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %v", m.prefix, err)
+	}
+
+	listed, err := m.resync()
+	if err != nil {
+		return err
+	}
+
+	m.wg.Add(1)
+	go m.watchChanges(listed)
+	return nil
+}
+
+// Stop stops the replication and stores the last checkpoint.
+func (m *Mirror) Stop() {
+	close(m.quit)
+	m.wg.Wait()
+	close(m.closeCh)
+}
+
+// onChange passes watched change to the replication loop.
+func (m *Mirror) onChange(resp keyval.BytesWatchResp) {
+	select {
+	case m.events <- resp:
+	case <-m.quit:
+	}
+}
+
+// resync replicates all data under the prefix, removes obsolete data from
+// the target and returns revisions of the replicated keys.
+func (m *Mirror) resync() (listed map[string]int64, err error) {
+	it, err := m.source.NewBroker(keyval.Root).ListValues(m.prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list values under %s: %v", m.prefix, err)
+	}
+	listed = make(map[string]int64)
+	var written, skipped int
+	for {
+		kv, stop := it.GetNext()
+		if stop {
+			break
+		}
+		listed[kv.GetKey()] = kv.GetRevision()
+		// data not changed since the last checkpoint are already replicated
+		if kv.GetRevision() != 0 && kv.GetRevision() == m.revisions[kv.GetKey()] {
+			skipped++
+			continue
+		}
+		if err := m.target.Put(kv.GetKey(), kv.GetValue()); err != nil {
+			return nil, fmt.Errorf("failed to replicate %s: %v", kv.GetKey(), err)
+		}
+		written++
+	}
+
+	keys, err := m.target.ListKeys(m.prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys under %s in target: %v", m.prefix, err)
+	}
+	var deleted int
+	for {
+		key, _, stop := keys.GetNext()
+		if stop {
+			break
+		}
+		if _, found := listed[key]; found || key == m.checkpointKey {
+			continue
+		}
+		if _, err := m.target.Delete(key); err != nil {
+			return nil, fmt.Errorf("failed to delete %s from target: %v", key, err)
+		}
+		deleted++
+	}
+	m.log.Infof("Mirror of %s resynced: %d written, %d unchanged, %d deleted",
+		m.prefix, written, skipped, deleted)
+
+	m.revisions = make(map[string]int64, len(listed))
+	for key, rev := range listed {
+		m.revisions[key] = rev
+	}
+	m.changed = true
+
+	if err := m.storeCheckpoint(); err != nil {
+		m.log.Warnf("Failed to store checkpoint of %s: %v", m.prefix, err)
+	}
+	return listed, nil
+}
+
+// watchChanges applies watched changes to the target. Changes older than
+// the data replicated by resync are skipped. If a change fails to be applied,
+// the following changes are skipped and the data are resynced at the next
+// checkpoint interval, until the resync succeeds.
+func (m *Mirror) watchChanges(listed map[string]int64) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case resp := <-m.events:
+			if m.resyncNeeded {
+				// the change is replicated by the resync
+				continue
+			}
+			if rev, ok := listed[resp.GetKey()]; ok && resp.GetRevision() != 0 && resp.GetRevision() <= rev {
+				continue
+			}
+			delete(listed, resp.GetKey())
+			if err := m.apply(resp); err != nil {
+				m.log.Errorf("Failed to replicate change of %s, %s will be resynced: %v",
+					resp.GetKey(), m.prefix, err)
+				m.resyncNeeded = true
+				continue
+			}
+			if resp.GetChangeType() == datasync.Delete {
+				delete(m.revisions, resp.GetKey())
+			} else {
+				m.revisions[resp.GetKey()] = resp.GetRevision()
+			}
+			m.changed = true
+
+		case <-ticker.C:
+			if m.resyncNeeded {
+				resynced, err := m.resync()
+				if err != nil {
+					m.log.Warnf("Resync of %s failed: %v", m.prefix, err)
+					continue
+				}
+				listed = resynced
+				m.resyncNeeded = false
+				continue
+			}
+			if err := m.storeCheckpoint(); err != nil {
+				m.log.Warnf("Failed to store checkpoint of %s: %v", m.prefix, err)
+			}
+
+		case <-m.quit:
+			if err := m.storeCheckpoint(); err != nil {
+				m.log.Warnf("Failed to store checkpoint of %s: %v", m.prefix, err)
+			}
+			return
+		}
+	}
+}
+
+// apply applies single change to the target.
+func (m *Mirror) apply(resp keyval.BytesWatchResp) error {
+	switch resp.GetChangeType() {
+	case datasync.Delete:
+		_, err := m.target.Delete(resp.GetKey())
+		return err
+	default:
+		return m.target.Put(resp.GetKey(), resp.GetValue())
+	}
+}
+
+// loadCheckpoint reads the checkpoint stored in the target.
+func (m *Mirror) loadCheckpoint() error {
+	data, found, _, err := m.target.GetValue(m.checkpointKey)
+	if !found {
+		// some data stores return error if the key is not found
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint of %s: %v", m.prefix, err)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		m.log.Warnf("Invalid checkpoint of %s ignored: %v", m.prefix, err)
+		return nil
+	}
+	if cp.Revisions != nil {
+		m.revisions = cp.Revisions
+	}
+	return nil
+}
+
+// storeCheckpoint writes the revisions of the replicated keys into the target,
+// if they have changed since the last write. Nothing is written while a failed
+// change waits for resync.
+func (m *Mirror) storeCheckpoint() error {
+	if !m.changed || m.resyncNeeded {
+		return nil
+	}
+	data, err := json.Marshal(&checkpoint{Revisions: m.revisions})
+	if err != nil {
+		return err
+	}
+	if err := m.target.Put(m.checkpointKey, data); err != nil {
+		return err
+	}
+	m.changed = false
+	return nil
+}
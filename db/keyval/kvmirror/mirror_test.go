@@ -0,0 +1,305 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvmirror_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3client"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/bolt"
+	"go.ligato.io/cn-infra/v2/db/keyval/consul"
+	"go.ligato.io/cn-infra/v2/db/keyval/etcd"
+	"go.ligato.io/cn-infra/v2/db/keyval/etcd/mocks"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvmirror"
+	"go.ligato.io/cn-infra/v2/db/keyval/redis"
+	"go.ligato.io/cn-infra/v2/logging/logrus"
+)
+
+var (
+	_ kvmirror.KvPlugin = &etcd.Plugin{}
+	_ kvmirror.KvPlugin = &bolt.Plugin{}
+	_ kvmirror.KvPlugin = &redis.Plugin{}
+	_ kvmirror.KvPlugin = &consul.Plugin{}
+)
+
+func newClient(t *testing.T, name string) *bolt.Client {
+	client, err := bolt.NewClient(&bolt.Config{
+		DbPath:   filepath.Join(t.TempDir(), name),
+		FileMode: 432,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// failingDB is a data store which fails writes of the given key
+// while failing is set.
+type failingDB struct {
+	keyval.KvBytesPlugin
+	key     string
+	failing int32
+}
+
+type failingBroker struct {
+	keyval.BytesBroker
+	db *failingDB
+}
+
+func (db *failingDB) NewBroker(prefix string) keyval.BytesBroker {
+	return &failingBroker{BytesBroker: db.KvBytesPlugin.NewBroker(prefix), db: db}
+}
+
+func (b *failingBroker) Put(key string, data []byte, opts ...datasync.PutOption) error {
+	if atomic.LoadInt32(&b.db.failing) == 1 && key == b.db.key {
+		return errors.New("write failed")
+	}
+	return b.BytesBroker.Put(key, data, opts...)
+}
+
+// revisionDB is a data store with revisions of each key kept in revs
+// (i.e. revisions are not global to the data store).
+type revisionDB struct {
+	keyval.KvBytesPlugin
+	revs map[string]int64
+}
+
+type revisionBroker struct {
+	keyval.BytesBroker
+	db *revisionDB
+}
+
+type revisionIterator struct {
+	keyval.BytesKeyValIterator
+	db *revisionDB
+}
+
+type revisionKeyVal struct {
+	keyval.BytesKeyVal
+	rev int64
+}
+
+func (db *revisionDB) NewBroker(prefix string) keyval.BytesBroker {
+	return &revisionBroker{BytesBroker: db.KvBytesPlugin.NewBroker(prefix), db: db}
+}
+
+func (b *revisionBroker) ListValues(prefix string) (keyval.BytesKeyValIterator, error) {
+	it, err := b.BytesBroker.ListValues(prefix)
+	return &revisionIterator{BytesKeyValIterator: it, db: b.db}, err
+}
+
+func (it *revisionIterator) GetNext() (keyval.BytesKeyVal, bool) {
+	kv, stop := it.BytesKeyValIterator.GetNext()
+	if stop {
+		return kv, stop
+	}
+	return &revisionKeyVal{BytesKeyVal: kv, rev: it.db.revs[kv.GetKey()]}, false
+}
+
+func (kv *revisionKeyVal) GetRevision() int64 {
+	return kv.rev
+}
+
+// watchCountingPlugin is a bolt plugin counting started watchers.
+type watchCountingPlugin struct {
+	*bolt.Plugin
+	watchers int32
+}
+
+type watchCountingDB struct {
+	keyval.KvBytesPlugin
+	plugin *watchCountingPlugin
+}
+
+func (p *watchCountingPlugin) RawAccess() keyval.KvBytesPlugin {
+	return &watchCountingDB{KvBytesPlugin: p.Plugin.RawAccess(), plugin: p}
+}
+
+func (db *watchCountingDB) NewWatcher(prefix string) keyval.BytesWatcher {
+	atomic.AddInt32(&db.plugin.watchers, 1)
+	return db.KvBytesPlugin.NewWatcher(prefix)
+}
+
+func newPlugin(t *testing.T, name string) *bolt.Plugin {
+	p := bolt.NewPlugin()
+	p.Config = &bolt.Config{DbPath: filepath.Join(t.TempDir(), name), FileMode: 432}
+	Expect(p.Init()).To(Succeed())
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func getValue(client *bolt.Client, key string) string {
+	value, found, _, _ := client.GetValue(key)
+	if !found {
+		return ""
+	}
+	return string(value)
+}
+
+func TestMirror(t *testing.T) {
+	RegisterTestingT(t)
+	source, target := newClient(t, "source.db"), newClient(t, "target.db")
+
+	Expect(source.Put("/agent/a", []byte("a"))).To(Succeed())
+	Expect(source.Put("/agent/b", []byte("b"))).To(Succeed())
+	Expect(source.Put("/other/c", []byte("c"))).To(Succeed())
+	Expect(target.Put("/agent/obsolete", []byte("x"))).To(Succeed())
+	Expect(target.Put("/agent/a", []byte("old"))).To(Succeed())
+
+	mirror := kvmirror.NewMirror(source, target, "/agent/", logrus.DefaultLogger())
+	Expect(mirror.Start()).To(Succeed())
+
+	// initial resync
+	Expect(getValue(target, "/agent/a")).To(Equal("a"))
+	Expect(getValue(target, "/agent/b")).To(Equal("b"))
+	Expect(getValue(target, "/other/c")).To(BeEmpty())
+	Expect(getValue(target, "/agent/obsolete")).To(BeEmpty())
+
+	// watched changes
+	Expect(source.Put("/agent/a", []byte("a2"))).To(Succeed())
+	Expect(source.Put("/agent/d", []byte("d"))).To(Succeed())
+	_, err := source.Delete("/agent/b")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(source.Put("/other/e", []byte("e"))).To(Succeed())
+
+	Eventually(func() string { return getValue(target, "/agent/a") }).Should(Equal("a2"))
+	Eventually(func() string { return getValue(target, "/agent/d") }).Should(Equal("d"))
+	Eventually(func() string { return getValue(target, "/agent/b") }).Should(BeEmpty())
+	Consistently(func() string { return getValue(target, "/other/e") }).Should(BeEmpty())
+
+	mirror.Stop()
+	Expect(source.Put("/agent/a", []byte("a3"))).To(Succeed())
+	Consistently(func() string { return getValue(target, "/agent/a") }).Should(Equal("a2"))
+}
+
+func TestMirrorCheckpoint(t *testing.T) {
+	var embd mocks.Embedded
+	embd.Start(t)
+	defer embd.Stop()
+	RegisterTestingT(t)
+
+	source, err := etcd.NewEtcdConnectionUsingClient(v3client.New(embd.ETCD.Server), logrus.DefaultLogger())
+	Expect(err).ToNot(HaveOccurred())
+	target := newClient(t, "target.db")
+
+	Expect(source.Put("/agent/a", []byte("a"))).To(Succeed())
+	Expect(source.Put("/agent/b", []byte("b"))).To(Succeed())
+
+	mirror := kvmirror.NewMirror(source, target, "/agent/", logrus.DefaultLogger())
+	Expect(mirror.Start()).To(Succeed())
+	Expect(getValue(target, "/agent/a")).To(Equal("a"))
+	Expect(getValue(target, "/agent/b")).To(Equal("b"))
+	Expect(getValue(target, kvmirror.DefaultCheckpointPrefix+"/agent/")).To(ContainSubstring("revision"))
+	mirror.Stop()
+
+	// data not changed since the checkpoint are not re-written after restart
+	Expect(target.Put("/agent/a", []byte("local"))).To(Succeed())
+	Expect(source.Put("/agent/b", []byte("b2"))).To(Succeed())
+
+	mirror = kvmirror.NewMirror(source, target, "/agent/", logrus.DefaultLogger())
+	Expect(mirror.Start()).To(Succeed())
+	defer mirror.Stop()
+	Expect(getValue(target, "/agent/a")).To(Equal("local"))
+	Expect(getValue(target, "/agent/b")).To(Equal("b2"))
+
+	// watched changes are replicated
+	Expect(source.Put("/agent/a", []byte("a2"))).To(Succeed())
+	Eventually(func() string { return getValue(target, "/agent/a") }).Should(Equal("a2"))
+}
+
+func TestMirrorFailedChange(t *testing.T) {
+	var embd mocks.Embedded
+	embd.Start(t)
+	defer embd.Stop()
+	RegisterTestingT(t)
+
+	source, err := etcd.NewEtcdConnectionUsingClient(v3client.New(embd.ETCD.Server), logrus.DefaultLogger())
+	Expect(err).ToNot(HaveOccurred())
+	target := newClient(t, "target.db")
+	failing := &failingDB{KvBytesPlugin: target, key: "/agent/a"}
+
+	Expect(source.Put("/agent/a", []byte("a"))).To(Succeed())
+	mirror := kvmirror.NewMirror(source, failing, "/agent/", logrus.DefaultLogger(),
+		kvmirror.WithCheckpointInterval(50*time.Millisecond))
+	Expect(mirror.Start()).To(Succeed())
+	Expect(getValue(target, "/agent/a")).To(Equal("a"))
+
+	// failed change is not covered by the checkpoint
+	atomic.StoreInt32(&failing.failing, 1)
+	Expect(source.Put("/agent/a", []byte("a2"))).To(Succeed())
+	Expect(source.Put("/agent/b", []byte("b2"))).To(Succeed())
+	Consistently(func() string { return getValue(target, "/agent/a") }, 200*time.Millisecond).Should(Equal("a"))
+	mirror.Stop()
+
+	// failed change is replicated after restart
+	atomic.StoreInt32(&failing.failing, 0)
+	mirror = kvmirror.NewMirror(source, failing, "/agent/", logrus.DefaultLogger(),
+		kvmirror.WithCheckpointInterval(50*time.Millisecond))
+	Expect(mirror.Start()).To(Succeed())
+	defer mirror.Stop()
+	Expect(getValue(target, "/agent/a")).To(Equal("a2"))
+	Expect(getValue(target, "/agent/b")).To(Equal("b2"))
+
+	// failed change is retried by resync while running
+	atomic.StoreInt32(&failing.failing, 1)
+	Expect(source.Put("/agent/a", []byte("a3"))).To(Succeed())
+	Consistently(func() string { return getValue(target, "/agent/a") }, 200*time.Millisecond).Should(Equal("a2"))
+	atomic.StoreInt32(&failing.failing, 0)
+	Eventually(func() string { return getValue(target, "/agent/a") }).Should(Equal("a3"))
+}
+
+func TestMirrorPerKeyRevisions(t *testing.T) {
+	RegisterTestingT(t)
+	client, target := newClient(t, "source.db"), newClient(t, "target.db")
+	source := &revisionDB{KvBytesPlugin: client, revs: map[string]int64{"/agent/a": 1, "/agent/b": 3}}
+
+	Expect(client.Put("/agent/a", []byte("a"))).To(Succeed())
+	Expect(client.Put("/agent/b", []byte("b"))).To(Succeed())
+	mirror := kvmirror.NewMirror(source, target, "/agent/", logrus.DefaultLogger())
+	Expect(mirror.Start()).To(Succeed())
+	mirror.Stop()
+
+	// key changed while the mirror was stopped has revision lower than other keys
+	Expect(client.Put("/agent/a", []byte("a2"))).To(Succeed())
+	source.revs["/agent/a"] = 2
+	Expect(target.Put("/agent/b", []byte("local"))).To(Succeed())
+
+	mirror = kvmirror.NewMirror(source, target, "/agent/", logrus.DefaultLogger())
+	Expect(mirror.Start()).To(Succeed())
+	defer mirror.Stop()
+	Expect(getValue(target, "/agent/a")).To(Equal("a2"))
+	Expect(getValue(target, "/agent/b")).To(Equal("local"))
+}
+
+func TestPluginStartsMirrorsOnce(t *testing.T) {
+	RegisterTestingT(t)
+	source := &watchCountingPlugin{Plugin: newPlugin(t, "source.db")}
+	p := kvmirror.NewPlugin(kvmirror.UseSource(source), kvmirror.UseTarget(newPlugin(t, "target.db")))
+	p.Config = &kvmirror.Config{Prefixes: []string{"/agent/", "/other/"}}
+	Expect(p.Init()).To(Succeed())
+	defer p.Close()
+
+	// data stores connected again
+	Expect(p.AfterInit()).To(Succeed())
+	Expect(p.AfterInit()).To(Succeed())
+	Expect(atomic.LoadInt32(&source.watchers)).To(BeEquivalentTo(2))
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvmirror
+
+// DefaultPlugin is a default instance of Plugin.
+var DefaultPlugin = *NewPlugin()
+
+// NewPlugin creates a new Plugin with the provided Options.
+func NewPlugin(opts ...Option) *Plugin {
+	p := &Plugin{}
+
+	p.PluginName = "kvmirror"
+
+	for _, o := range opts {
+		o(p)
+	}
+
+	p.PluginDeps.Setup()
+
+	return p
+}
+
+// Option is a function that can be used in NewPlugin to customize Plugin.
+type Option func(*Plugin)
+
+// UseDeps returns Option that can inject custom dependencies.
+func UseDeps(cb func(*Deps)) Option {
+	return func(p *Plugin) {
+		cb(&p.Deps)
+	}
+}
+
+// UseSource returns Option that sets the data store data are replicated from.
+func UseSource(kv KvPlugin) Option {
+	return func(p *Plugin) {
+		p.Source = kv
+	}
+}
+
+// UseTarget returns Option that sets the data store data are replicated to.
+func UseTarget(kv KvPlugin) Option {
+	return func(p *Plugin) {
+		p.Target = kv
+	}
+}
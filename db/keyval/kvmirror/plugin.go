@@ -0,0 +1,148 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvmirror
+
+import (
+	"sync"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/infra"
+)
+
+// Config represents configuration for kvmirror plugin.
+type Config struct {
+	// Prefixes lists key prefixes replicated from source to target.
+	Prefixes []string `json:"prefixes"`
+	// CheckpointPrefix is the prefix of keys with checkpoints stored in target.
+	CheckpointPrefix string `json:"checkpoint-prefix"`
+}
+
+// KvPlugin is a key-value data store plugin providing access to raw data.
+type KvPlugin interface {
+	keyval.KvProtoPlugin
+	// RawAccess allows to access data in the data store as raw bytes.
+	RawAccess() keyval.KvBytesPlugin
+}
+
+// Plugin replicates data from source to target data store.
+type Plugin struct {
+	Deps
+
+	*Config
+	// Plugin is disabled if there is no config file available
+	disabled bool
+
+	mu sync.Mutex
+	// mirrors started for the configured prefixes
+	mirrors map[string]*Mirror
+	closed  bool
+}
+
+// Deps lists dependencies of the kvmirror plugin.
+type Deps struct {
+	infra.PluginDeps
+	Source KvPlugin // inject
+	Target KvPlugin // inject
+}
+
+// Init loads the plugin configuration.
+func (p *Plugin) Init() (err error) {
+	if p.Config == nil {
+		p.Config, err = p.getConfig()
+		if err != nil || p.disabled {
+			return err
+		}
+	}
+	if p.Source == nil || p.Target == nil || p.Source.Disabled() || p.Target.Disabled() {
+		p.Log.Warn("Source or target data store is not available, mirroring is disabled")
+		p.disabled = true
+	}
+	return nil
+}
+
+// AfterInit starts mirroring once both source and target are connected.
+// Mirrors are started only once, even if the data stores reconnect.
+func (p *Plugin) AfterInit() error {
+	if p.disabled {
+		return nil
+	}
+	p.Source.OnConnect(func() error {
+		p.Target.OnConnect(p.startMirrors)
+		return nil
+	})
+	return nil
+}
+
+// Close stops mirroring. Mirrors are not started after Close.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, mirror := range p.mirrors {
+		mirror.Stop()
+	}
+	p.mirrors = nil
+	return nil
+}
+
+// Disabled returns *true* if the plugin is not in use.
+func (p *Plugin) Disabled() bool {
+	return p.disabled
+}
+
+// startMirrors starts mirrors of the prefixes which are not mirrored yet.
+func (p *Plugin) startMirrors() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	if p.mirrors == nil {
+		p.mirrors = make(map[string]*Mirror)
+	}
+	var opts []MirrorOption
+	if p.CheckpointPrefix != "" {
+		opts = append(opts, WithCheckpointPrefix(p.CheckpointPrefix))
+	}
+	for _, prefix := range p.Prefixes {
+		if _, started := p.mirrors[prefix]; started {
+			continue
+		}
+		mirror := NewMirror(p.Source.RawAccess(), p.Target.RawAccess(), prefix, p.Log, opts...)
+		if err := mirror.Start(); err != nil {
+			p.Log.Errorf("Failed to start mirror of %s from %s to %s: %v", prefix, p.Source, p.Target, err)
+			return err
+		}
+		p.Log.Infof("Mirror of %s from %s to %s started", prefix, p.Source, p.Target)
+		p.mirrors[prefix] = mirror
+	}
+	return nil
+}
+
+func (p *Plugin) getConfig() (*Config, error) {
+	var cfg Config
+	found, err := p.Cfg.LoadValue(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		p.Log.Info("kvmirror config not found, skip loading this plugin")
+		p.disabled = true
+		return nil, nil
+	}
+	return &cfg, nil
+}
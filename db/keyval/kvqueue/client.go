@@ -0,0 +1,521 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/bolt"
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// ErrNotConnected is returned by read operations and watch while
+// the data store is not connected.
+var ErrNotConnected = errors.New("kvqueue: data store is not connected")
+
+// IsUnavailable returns true if the error is caused by unavailability
+// of the data store (no connection, network error or timeout), i.e. the write
+// may succeed once the data store is available again. Only such writes are
+// queued, other errors are returned to the caller.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrNotConnected) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// gRPC errors (etcd client errors carry the code without gRPC status)
+	code := status.Code(err)
+	var codeErr interface{ Code() codes.Code }
+	if errors.As(err, &codeErr) {
+		code = codeErr.Code()
+	}
+	return code == codes.Unavailable || code == codes.DeadlineExceeded
+}
+
+// ConflictPolicy determines how queued writes are replayed if the key has
+// been changed in the data store since the write was queued.
+type ConflictPolicy string
+
+const (
+	// LastWriterWins applies queued writes regardless of the changes
+	// made in the meantime.
+	LastWriterWins ConflictPolicy = "last-writer-wins"
+	// SkipIfChanged drops queued writes of keys whose revision has changed
+	// since they were last read or written by this client.
+	SkipIfChanged ConflictPolicy = "skip-if-changed"
+)
+
+// Client implements keyval.CoreBrokerWatcher on top of another data store.
+// Writes that fail to be applied (or are made before the data store is
+// connected) are queued in Bolt database and replayed later. Reads and
+// watches are passed to the data store as they are.
+type Client struct {
+	logging.Logger
+	policy ConflictPolicy
+
+	mu    sync.Mutex
+	db    keyval.KvBytesPlugin
+	queue *queue
+	// last known revisions of keys, tracked only with SkipIfChanged policy
+	revisions *revisionCache
+}
+
+// NewClient creates a new Client with the queue stored in Bolt database
+// given by <cfg>. Writes are queued until the data store is connected
+// using Connect.
+func NewClient(cfg *bolt.Config, policy ConflictPolicy, log logging.Logger) (*Client, error) {
+	switch policy {
+	case "":
+		policy = LastWriterWins
+	case LastWriterWins, SkipIfChanged:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %q", policy)
+	}
+	q, err := openQueue(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if q.len > 0 {
+		log.Infof("%d writes are queued from previous run", q.len)
+	}
+	return &Client{
+		Logger:    log,
+		policy:    policy,
+		queue:     q,
+		revisions: newRevisionCache(maxTrackedRevisions),
+	}, nil
+}
+
+// Connect sets the data store the writes are applied to and replays
+// the queued writes.
+func (c *Client) Connect(db keyval.KvBytesPlugin) error {
+	c.mu.Lock()
+	c.db = db
+	c.mu.Unlock()
+	return c.Replay()
+}
+
+// Pending returns the number of queued writes.
+func (c *Client) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queue.len
+}
+
+// Rejected returns the number of queued writes that were rejected by the data
+// store during replay. Rejected writes are kept in the queue database under
+// the "/kvqueue/rejected/" prefix.
+func (c *Client) Rejected() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queue.rejected()
+}
+
+// Replay applies the queued writes to the data store in the order they were
+// made. Each write is removed from the queue once it is applied. Writes
+// rejected by the data store are logged and moved out of the queue, so that
+// they do not block the writes queued after them. Replay stops at the first
+// write that fails because the data store is unavailable and returns the error.
+func (c *Client) Replay() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.queue.len == 0 {
+		return nil
+	}
+	if c.db == nil {
+		return ErrNotConnected
+	}
+	ops, err := c.queue.list()
+	if err != nil {
+		return err
+	}
+	broker := c.db.NewBroker(keyval.Root)
+	var rejected int
+	for i, op := range ops {
+		if err := c.replay(broker, op); err != nil {
+			if IsUnavailable(err) {
+				c.Warnf("Replay of queued writes stopped after %d of %d: %v", i, len(ops), err)
+				return err
+			}
+			c.Errorf("Queued %s %v was rejected by the data store and is dropped: %v", op.Type, op.keys(), err)
+			if err := c.queue.reject(op, err); err != nil {
+				return err
+			}
+			rejected++
+			continue
+		}
+		if err := c.queue.remove(op); err != nil {
+			return err
+		}
+	}
+	c.Infof("Replayed %d queued writes (%d rejected)", len(ops)-rejected, rejected)
+	return c.queue.clearBaseRevisions()
+}
+
+// replay applies the queued operation unless it conflicts with changes
+// made in the meantime.
+func (c *Client) replay(broker keyval.BytesBroker, op *queuedOp) error {
+	keys := op.keys()
+	if c.policy == SkipIfChanged {
+		for _, key := range keys {
+			base, known, err := c.queue.baseRevision(key)
+			if err != nil {
+				return err
+			}
+			if !known {
+				continue
+			}
+			rev, err := currentRevision(broker, key)
+			if err != nil {
+				return err
+			}
+			if rev != base {
+				c.Warnf("Key %q has changed since the write was queued (revision %d -> %d), skipping queued %s",
+					key, base, rev, op.Type)
+				return nil
+			}
+		}
+	}
+	if err := apply(broker, op); err != nil {
+		return err
+	}
+	if c.policy == SkipIfChanged {
+		for _, key := range keys {
+			rev, err := currentRevision(broker, key)
+			if err != nil {
+				return err
+			}
+			c.revisions.set(key, rev)
+			if err := c.queue.setBaseRevision(key, rev); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write applies the operation to the data store if possible. The operation
+// is queued if the data store is unavailable, or if there are writes queued
+// before it. Errors other than unavailability are returned.
+// The lock is not held while the data store is accessed, so that a slow write
+// does not block other callers.
+func (c *Client) write(op *queuedOp, direct func(keyval.BytesBroker) error) error {
+	c.mu.Lock()
+	if c.queue.len > 0 || c.db == nil {
+		defer c.mu.Unlock()
+		return c.enqueue(op)
+	}
+	db := c.db
+	c.mu.Unlock()
+
+	broker := db.NewBroker(keyval.Root)
+	err := direct(broker)
+	if err == nil {
+		c.updateRevisions(broker, op.keys())
+		return nil
+	}
+	if !IsUnavailable(err) {
+		return err
+	}
+	c.Warnf("Failed to %s %v, write is queued: %v", op.Type, op.keys(), err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enqueue(op)
+}
+
+// enqueue adds the operation to the queue.
+func (c *Client) enqueue(op *queuedOp) error {
+	if c.policy == SkipIfChanged {
+		for _, key := range op.keys() {
+			rev, known := c.revisions.get(key)
+			if !known {
+				continue
+			}
+			_, queued, err := c.queue.baseRevision(key)
+			if err != nil {
+				return err
+			}
+			if !queued {
+				if err := c.queue.setBaseRevision(key, rev); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := c.queue.push(op); err != nil {
+		return fmt.Errorf("failed to queue %s %v: %v", op.Type, op.keys(), err)
+	}
+	c.Debugf("Queued %s %v (%d writes queued)", op.Type, op.keys(), c.queue.len)
+	return nil
+}
+
+// updateRevisions reads the current revisions of the keys after they were
+// written. The revision of a key is forgotten if it cannot be read.
+func (c *Client) updateRevisions(broker keyval.BytesBroker, keys []string) {
+	if c.policy != SkipIfChanged {
+		return
+	}
+	for _, key := range keys {
+		rev, err := currentRevision(broker, key)
+		c.mu.Lock()
+		if err != nil {
+			c.revisions.delete(key)
+		} else {
+			c.revisions.set(key, rev)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// connected returns the data store or ErrNotConnected.
+func (c *Client) connected() (keyval.KvBytesPlugin, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.db == nil {
+		return nil, ErrNotConnected
+	}
+	return c.db, nil
+}
+
+// Put writes the data under the key. If the data store is not available,
+// the write is queued and nil is returned. TTL options of queued writes
+// are applied from the time the write is replayed.
+func (c *Client) Put(key string, data []byte, opts ...datasync.PutOption) error {
+	return c.write(newPutOp(key, data, opts), func(broker keyval.BytesBroker) error {
+		return broker.Put(key, data, opts...)
+	})
+}
+
+// Delete removes the data stored under the key. If the data store is not
+// available, the removal is queued and <existed> is returned as false.
+func (c *Client) Delete(key string, opts ...datasync.DelOption) (existed bool, err error) {
+	err = c.write(newDeleteOp(key, opts), func(broker keyval.BytesBroker) (err error) {
+		existed, err = broker.Delete(key, opts...)
+		return err
+	})
+	return existed, err
+}
+
+// NewTxn creates a new transaction. If the data store is not available
+// when the transaction is committed, the whole transaction is queued.
+func (c *Client) NewTxn() keyval.BytesTxn {
+	return &txn{client: c, op: &queuedOp{Type: opTxn}}
+}
+
+// GetValue retrieves the value stored under the key from the data store.
+func (c *Client) GetValue(key string) (data []byte, found bool, revision int64, err error) {
+	db, err := c.connected()
+	if err != nil {
+		return nil, false, 0, err
+	}
+	data, found, revision, err = db.NewBroker(keyval.Root).GetValue(key)
+	if err == nil && c.policy == SkipIfChanged {
+		c.mu.Lock()
+		c.revisions.set(key, revision)
+		c.mu.Unlock()
+	}
+	return data, found, revision, err
+}
+
+// ListValues returns an iterator over the values stored under the key prefix.
+func (c *Client) ListValues(key string) (keyval.BytesKeyValIterator, error) {
+	db, err := c.connected()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewBroker(keyval.Root).ListValues(key)
+}
+
+// ListKeys returns an iterator over the keys with the given prefix.
+func (c *Client) ListKeys(prefix string) (keyval.BytesKeyIterator, error) {
+	db, err := c.connected()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewBroker(keyval.Root).ListKeys(prefix)
+}
+
+// Watch starts watching the keys in the data store.
+func (c *Client) Watch(respChan func(keyval.BytesWatchResp), closeChan chan string, keys ...string) error {
+	return c.NewWatcher(keyval.Root).Watch(respChan, closeChan, keys...)
+}
+
+// NewBroker returns a BytesBroker instance that prepends given <prefix>
+// to all keys in its calls.
+func (c *Client) NewBroker(prefix string) keyval.BytesBroker {
+	return &broker{client: c, prefix: prefix}
+}
+
+// NewWatcher returns a BytesWatcher instance that prepends given <prefix>
+// to the watched keys.
+func (c *Client) NewWatcher(prefix string) keyval.BytesWatcher {
+	return &watcher{client: c, prefix: prefix}
+}
+
+// Close closes the queue. The data store is not closed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queue.close()
+}
+
+// broker is a BytesBroker with key prefix.
+type broker struct {
+	client *Client
+	prefix string
+}
+
+// Put writes the data under the prefixed key.
+func (b *broker) Put(key string, data []byte, opts ...datasync.PutOption) error {
+	return b.client.Put(b.prefix+key, data, opts...)
+}
+
+// Delete removes the data stored under the prefixed key.
+func (b *broker) Delete(key string, opts ...datasync.DelOption) (existed bool, err error) {
+	return b.client.Delete(b.prefix+key, opts...)
+}
+
+// NewTxn creates a new transaction with prefixed keys.
+func (b *broker) NewTxn() keyval.BytesTxn {
+	return &txn{client: b.client, prefix: b.prefix, op: &queuedOp{Type: opTxn}}
+}
+
+// GetValue retrieves the value stored under the prefixed key.
+func (b *broker) GetValue(key string) (data []byte, found bool, revision int64, err error) {
+	return b.client.GetValue(b.prefix + key)
+}
+
+// ListValues returns an iterator over the values stored under the prefixed key.
+func (b *broker) ListValues(key string) (keyval.BytesKeyValIterator, error) {
+	db, err := b.client.connected()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewBroker(b.prefix).ListValues(key)
+}
+
+// ListKeys returns an iterator over the keys with the given prefix.
+func (b *broker) ListKeys(prefix string) (keyval.BytesKeyIterator, error) {
+	db, err := b.client.connected()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewBroker(b.prefix).ListKeys(prefix)
+}
+
+// watcher is a BytesWatcher with key prefix.
+type watcher struct {
+	client *Client
+	prefix string
+}
+
+// Watch starts watching the prefixed keys in the data store.
+func (w *watcher) Watch(respChan func(keyval.BytesWatchResp), closeChan chan string, keys ...string) error {
+	db, err := w.client.connected()
+	if err != nil {
+		return err
+	}
+	return db.NewWatcher(w.prefix).Watch(respChan, closeChan, keys...)
+}
+
+// txn is a transaction which is queued as a whole if it cannot be committed.
+type txn struct {
+	client *Client
+	prefix string
+	op     *queuedOp
+}
+
+// Put adds put operation into the transaction.
+func (t *txn) Put(key string, data []byte) keyval.BytesTxn {
+	t.op.Ops = append(t.op.Ops, &queuedOp{Type: opPut, Key: t.prefix + key, Value: data})
+	return t
+}
+
+// Delete adds delete operation into the transaction.
+func (t *txn) Delete(key string) keyval.BytesTxn {
+	t.op.Ops = append(t.op.Ops, &queuedOp{Type: opDelete, Key: t.prefix + key})
+	return t
+}
+
+// Commit commits the transaction, or queues it if the data store
+// is not available.
+func (t *txn) Commit(ctx context.Context) error {
+	return t.client.write(t.op, func(broker keyval.BytesBroker) error {
+		return commit(ctx, broker, t.op.Ops)
+	})
+}
+
+// apply applies the queued operation.
+func apply(broker keyval.BytesBroker, op *queuedOp) (err error) {
+	switch op.Type {
+	case opPut:
+		err = broker.Put(op.Key, op.Value, op.putOptions()...)
+	case opDelete:
+		_, err = broker.Delete(op.Key, op.delOptions()...)
+	case opTxn:
+		err = commit(context.Background(), broker, op.Ops)
+	default:
+		err = fmt.Errorf("unknown type of queued operation: %q", op.Type)
+	}
+	return err
+}
+
+// commit commits the operations in a single transaction.
+func commit(ctx context.Context, broker keyval.BytesBroker, ops []*queuedOp) error {
+	txn := broker.NewTxn()
+	if txn == nil {
+		return errors.New("transactions are not supported by the data store")
+	}
+	for _, op := range ops {
+		switch op.Type {
+		case opPut:
+			txn.Put(op.Key, op.Value)
+		case opDelete:
+			txn.Delete(op.Key)
+		}
+	}
+	return txn.Commit(ctx)
+}
+
+// currentRevision returns the revision of the key, or 0 if the key
+// does not exist.
+func currentRevision(broker keyval.BytesBroker, key string) (int64, error) {
+	_, found, rev, err := broker.GetValue(key)
+	if err != nil || !found {
+		return 0, err
+	}
+	return rev, nil
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvqueue_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/v3client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/bolt"
+	"go.ligato.io/cn-infra/v2/db/keyval/etcd"
+	"go.ligato.io/cn-infra/v2/db/keyval/etcd/mocks"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvqueue"
+	"go.ligato.io/cn-infra/v2/logging/logrus"
+)
+
+var (
+	_ kvqueue.KvPlugin = &kvqueue.Plugin{}
+	_ kvqueue.KvPlugin = &etcd.Plugin{}
+	_ kvqueue.KvPlugin = &bolt.Plugin{}
+)
+
+var (
+	errUnavailable = status.Error(codes.Unavailable, "data store unavailable")
+	errRejected    = errors.New("key rejected")
+)
+
+// flakyDB is a data store which fails all writes while it is down
+// and rejects writes of keys with "/rejected" prefix. Writes of keys
+// with "/slow" prefix wait until slow is closed.
+type flakyDB struct {
+	keyval.KvBytesPlugin
+	down    bool
+	putOpts map[string][]datasync.PutOption
+	slow    chan struct{}
+}
+
+type flakyBroker struct {
+	keyval.BytesBroker
+	db *flakyDB
+}
+
+func (db *flakyDB) NewBroker(prefix string) keyval.BytesBroker {
+	return &flakyBroker{BytesBroker: db.KvBytesPlugin.NewBroker(prefix), db: db}
+}
+
+func (b *flakyBroker) Put(key string, data []byte, opts ...datasync.PutOption) error {
+	if b.db.down {
+		return errUnavailable
+	}
+	if strings.HasPrefix(key, "/rejected") {
+		return errRejected
+	}
+	if strings.HasPrefix(key, "/slow") {
+		<-b.db.slow
+	}
+	if b.db.putOpts != nil {
+		b.db.putOpts[key] = opts
+	}
+	return b.BytesBroker.Put(key, data, opts...)
+}
+
+func (b *flakyBroker) Delete(key string, opts ...datasync.DelOption) (bool, error) {
+	if b.db.down {
+		return false, errUnavailable
+	}
+	return b.BytesBroker.Delete(key, opts...)
+}
+
+func newBoltClient(t *testing.T, path string) *bolt.Client {
+	client, err := bolt.NewClient(&bolt.Config{DbPath: path, FileMode: 432})
+	Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func newQueueClient(path string, policy kvqueue.ConflictPolicy) *kvqueue.Client {
+	client, err := kvqueue.NewClient(&bolt.Config{DbPath: path, FileMode: 432}, policy, logrus.DefaultLogger())
+	Expect(err).ToNot(HaveOccurred())
+	return client
+}
+
+func getValue(db keyval.KvBytesPlugin, key string) string {
+	value, found, _, _ := db.NewBroker(keyval.Root).GetValue(key)
+	if !found {
+		return ""
+	}
+	return string(value)
+}
+
+func TestQueueBeforeConnect(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	db := newBoltClient(t, filepath.Join(dir, "db"))
+
+	client := newQueueClient(filepath.Join(dir, "queue"), kvqueue.LastWriterWins)
+	Expect(client.Put("/a", []byte("a"))).To(Succeed())
+	Expect(client.NewBroker("/prefix/").Put("b", []byte("b"))).To(Succeed())
+	Expect(client.NewTxn().Put("/c", []byte("c")).Put("/d", []byte("d")).Commit(context.Background())).To(Succeed())
+	existed, err := client.Delete("/a")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(existed).To(BeFalse())
+	Expect(client.Pending()).To(Equal(4))
+	_, _, _, err = client.GetValue("/a")
+	Expect(err).To(Equal(kvqueue.ErrNotConnected))
+
+	// queue persists across restarts
+	Expect(client.Close()).To(Succeed())
+	client = newQueueClient(filepath.Join(dir, "queue"), kvqueue.LastWriterWins)
+	defer client.Close()
+	Expect(client.Pending()).To(Equal(4))
+
+	Expect(client.Connect(db)).To(Succeed())
+	Expect(client.Pending()).To(Equal(0))
+	Expect(getValue(db, "/a")).To(BeEmpty())
+	Expect(getValue(db, "/prefix/b")).To(Equal("b"))
+	Expect(getValue(db, "/c")).To(Equal("c"))
+	Expect(getValue(db, "/d")).To(Equal("d"))
+
+	// writes are applied directly once the queue is empty
+	Expect(client.Put("/e", []byte("e"))).To(Succeed())
+	Expect(client.Pending()).To(Equal(0))
+	Expect(getValue(client, "/e")).To(Equal("e"))
+}
+
+func TestQueueDuringOutage(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	db := &flakyDB{KvBytesPlugin: newBoltClient(t, filepath.Join(dir, "db"))}
+
+	client := newQueueClient(filepath.Join(dir, "queue"), kvqueue.LastWriterWins)
+	defer client.Close()
+	Expect(client.Connect(db)).To(Succeed())
+
+	Expect(client.Put("/a", []byte("a1"))).To(Succeed())
+	Expect(client.Pending()).To(Equal(0))
+
+	db.down = true
+	Expect(client.Put("/a", []byte("a2"))).To(Succeed())
+	Expect(client.Put("/b", []byte("b"))).To(Succeed())
+	Expect(client.Pending()).To(Equal(2))
+	Expect(client.Replay()).To(Equal(errUnavailable))
+	Expect(client.Pending()).To(Equal(2))
+
+	db.down = false
+	// order of writes is preserved while the queue is not empty
+	Expect(client.Put("/a", []byte("a3"))).To(Succeed())
+	Expect(client.Pending()).To(Equal(3))
+	Expect(getValue(db, "/a")).To(Equal("a1"))
+
+	Expect(client.Replay()).To(Succeed())
+	Expect(client.Pending()).To(Equal(0))
+	Expect(getValue(db, "/a")).To(Equal("a3"))
+	Expect(getValue(db, "/b")).To(Equal("b"))
+}
+
+func TestIsUnavailable(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(kvqueue.IsUnavailable(nil)).To(BeFalse())
+	Expect(kvqueue.IsUnavailable(errRejected)).To(BeFalse())
+	Expect(kvqueue.IsUnavailable(status.Error(codes.PermissionDenied, "denied"))).To(BeFalse())
+	Expect(kvqueue.IsUnavailable(errUnavailable)).To(BeTrue())
+	Expect(kvqueue.IsUnavailable(kvqueue.ErrNotConnected)).To(BeTrue())
+	Expect(kvqueue.IsUnavailable(context.DeadlineExceeded)).To(BeTrue())
+}
+
+func TestRejectedWrites(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	db := &flakyDB{KvBytesPlugin: newBoltClient(t, filepath.Join(dir, "db"))}
+
+	client := newQueueClient(filepath.Join(dir, "queue"), kvqueue.LastWriterWins)
+	defer client.Close()
+	Expect(client.Connect(db)).To(Succeed())
+
+	// errors other than unavailability are returned, not queued
+	Expect(client.Put("/rejected", []byte("x"))).To(MatchError(errRejected))
+	Expect(client.Pending()).To(Equal(0))
+
+	// rejected queued write does not block the writes queued after it
+	db.down = true
+	Expect(client.Put("/a", []byte("a"))).To(Succeed())
+	Expect(client.Put("/rejected", []byte("x"))).To(Succeed())
+	Expect(client.Put("/b", []byte("b"))).To(Succeed())
+	Expect(client.Pending()).To(Equal(3))
+	db.down = false
+
+	Expect(client.Replay()).To(Succeed())
+	Expect(client.Pending()).To(Equal(0))
+	Expect(getValue(db, "/a")).To(Equal("a"))
+	Expect(getValue(db, "/b")).To(Equal("b"))
+	rejected, err := client.Rejected()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(rejected).To(Equal(1))
+}
+
+func TestQueuedPutOptions(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	db := &flakyDB{
+		KvBytesPlugin: newBoltClient(t, filepath.Join(dir, "db")),
+		putOpts:       make(map[string][]datasync.PutOption),
+	}
+
+	client := newQueueClient(filepath.Join(dir, "queue"), kvqueue.LastWriterWins)
+	Expect(client.Put("/ttl", []byte("a"), datasync.WithTTL(time.Minute))).To(Succeed())
+	Expect(client.Put("/lifetime", []byte("b"), datasync.WithClientLifetimeTTL())).To(Succeed())
+
+	// options are persisted with the queued writes
+	Expect(client.Close()).To(Succeed())
+	client = newQueueClient(filepath.Join(dir, "queue"), kvqueue.LastWriterWins)
+	defer client.Close()
+	Expect(client.Connect(db)).To(Succeed())
+	Expect(client.Pending()).To(Equal(0))
+
+	Expect(db.putOpts["/ttl"]).To(ConsistOf(datasync.WithTTL(time.Minute)))
+	Expect(db.putOpts["/lifetime"]).To(ConsistOf(datasync.WithClientLifetimeTTL()))
+}
+
+func TestConflictPolicy(t *testing.T) {
+	var embd mocks.Embedded
+	embd.Start(t)
+	defer embd.Stop()
+	RegisterTestingT(t)
+
+	etcdDB, err := etcd.NewEtcdConnectionUsingClient(v3client.New(embd.ETCD.Server), logrus.DefaultLogger())
+	Expect(err).ToNot(HaveOccurred())
+	db := &flakyDB{KvBytesPlugin: etcdDB}
+
+	for _, policy := range []kvqueue.ConflictPolicy{kvqueue.LastWriterWins, kvqueue.SkipIfChanged} {
+		client := newQueueClient(filepath.Join(t.TempDir(), "queue"), policy)
+		Expect(client.Connect(db)).To(Succeed())
+
+		Expect(client.Put("/changed", []byte("v1"))).To(Succeed())
+		Expect(client.Put("/unchanged", []byte("v1"))).To(Succeed())
+		db.down = true
+		Expect(client.Put("/changed", []byte("queued"))).To(Succeed())
+		Expect(client.Put("/unchanged", []byte("queued"))).To(Succeed())
+		Expect(client.Put("/unchanged", []byte("queued2"))).To(Succeed())
+		Expect(etcdDB.Put("/changed", []byte("other"))).To(Succeed())
+		// changes of keys with the same prefix do not matter
+		Expect(etcdDB.Put("/unchanged/child", []byte("other"))).To(Succeed())
+		db.down = false
+
+		Expect(client.Replay()).To(Succeed())
+		Expect(getValue(db, "/unchanged")).To(Equal("queued2"))
+		if policy == kvqueue.SkipIfChanged {
+			Expect(getValue(db, "/changed")).To(Equal("other"))
+		} else {
+			Expect(getValue(db, "/changed")).To(Equal("queued"))
+		}
+		Expect(client.Close()).To(Succeed())
+	}
+}
+
+func TestSlowWriteDoesNotBlock(t *testing.T) {
+	RegisterTestingT(t)
+	dir := t.TempDir()
+	db := &flakyDB{KvBytesPlugin: newBoltClient(t, filepath.Join(dir, "db")), slow: make(chan struct{})}
+
+	client := newQueueClient(filepath.Join(dir, "queue"), kvqueue.SkipIfChanged)
+	defer client.Close()
+	Expect(client.Connect(db)).To(Succeed())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Put("/slow", []byte("slow"))
+	}()
+	Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+
+	// other writes and reads are not blocked by the slow write
+	Expect(client.Put("/fast", []byte("fast"))).To(Succeed())
+	Expect(client.Pending()).To(BeZero())
+	Expect(getValue(db, "/fast")).To(Equal("fast"))
+
+	close(db.slow)
+	Eventually(done).Should(Receive(BeNil()))
+	Expect(getValue(db, "/slow")).To(Equal("slow"))
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kvqueue implements a plugin that buffers writes into a key-value
+// data store while the data store is not reachable.
+//
+// Put and Delete operations that cannot be applied are stored in a durable
+// queue backed by a local Bolt database. Once the data store is connected
+// again, the queued operations are replayed in the order they were made.
+// All further writes are queued as well until the queue is drained,
+// therefore the order of writes is always preserved. Only writes failing
+// because the data store is unavailable are queued (see IsUnavailable),
+// other errors are returned to the caller. Queued writes rejected by the data
+// store during replay are logged and moved aside, so that they do not block
+// the rest of the queue.
+//
+// Operations replayed from the queue may overwrite changes made by other
+// clients in the meantime. This is controlled by the conflict policy:
+// with LastWriterWins the queued operation is always applied, whereas with
+// SkipIfChanged the operation is dropped if the revision of the key has
+// changed since it was last read or written by this agent.
+package kvqueue
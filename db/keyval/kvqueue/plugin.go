@@ -0,0 +1,225 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvqueue
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/db/keyval/bolt"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvproto"
+	"go.ligato.io/cn-infra/v2/infra"
+)
+
+// DefaultReplayInterval is the default interval between attempts
+// to replay queued writes.
+const DefaultReplayInterval = 5 * time.Second
+
+// Config represents configuration for kvqueue plugin.
+type Config struct {
+	// DbPath is the path to Bolt database file with the queue.
+	DbPath string `json:"db-path"`
+	// FileMode is the mode of the database file.
+	FileMode os.FileMode `json:"file-mode"`
+	// ConflictPolicy determines how queued writes of keys changed
+	// in the meantime are replayed.
+	ConflictPolicy ConflictPolicy `json:"conflict-policy"`
+	// ReplayInterval is the interval between attempts to replay queued writes.
+	ReplayInterval time.Duration `json:"replay-interval"`
+}
+
+// KvPlugin is a key-value data store plugin providing access to raw data.
+type KvPlugin interface {
+	keyval.KvProtoPlugin
+	// RawAccess allows to access data in the data store as raw bytes.
+	RawAccess() keyval.KvBytesPlugin
+}
+
+// Plugin wraps a key-value data store plugin and queues writes made while
+// the data store is not available. Without configuration, the plugin passes
+// all calls to the data store plugin as they are.
+type Plugin struct {
+	Deps
+
+	*Config
+	// Plugin is disabled if there is no config file available
+	disabled bool
+
+	client       *Client
+	protoWrapper *kvproto.ProtoWrapper
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Deps lists dependencies of the kvqueue plugin.
+type Deps struct {
+	infra.PluginDeps
+	KvPlugin   KvPlugin          // inject
+	Serializer keyval.Serializer // optional, by default the JSON serializer is used
+}
+
+// Init opens the queue.
+func (p *Plugin) Init() (err error) {
+	if p.Config == nil {
+		p.Config, err = p.getConfig()
+		if err != nil || p.disabled {
+			return err
+		}
+	}
+	if p.KvPlugin == nil || p.KvPlugin.Disabled() {
+		p.Log.Warn("Data store is not available, write queue is disabled")
+		p.disabled = true
+		return nil
+	}
+
+	p.client, err = NewClient(&bolt.Config{
+		DbPath:   p.DbPath,
+		FileMode: p.FileMode,
+	}, p.ConflictPolicy, p.Log)
+	if err != nil {
+		return err
+	}
+	serializer := p.Serializer
+	if serializer == nil {
+		serializer = &keyval.SerializerJSON{}
+	}
+	p.protoWrapper = kvproto.NewProtoWrapper(p.client, serializer)
+	return nil
+}
+
+// AfterInit connects the queue to the data store once it is available
+// and starts periodic replay of queued writes.
+func (p *Plugin) AfterInit() error {
+	if p.disabled {
+		return nil
+	}
+	p.KvPlugin.OnConnect(func() error {
+		if err := p.client.Connect(p.KvPlugin.RawAccess()); err != nil {
+			p.Log.Warnf("Failed to replay queued writes: %v", err)
+		}
+		return nil
+	})
+
+	interval := p.ReplayInterval
+	if interval <= 0 {
+		interval = DefaultReplayInterval
+	}
+	p.quit = make(chan struct{})
+	p.wg.Add(1)
+	go p.replayLoop(interval)
+	return nil
+}
+
+// replayLoop periodically replays queued writes.
+func (p *Plugin) replayLoop(interval time.Duration) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if p.client.Pending() == 0 {
+				continue
+			}
+			if err := p.client.Replay(); err != nil && err != ErrNotConnected {
+				p.Log.Debugf("Replay of queued writes failed: %v", err)
+			}
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Close stops replaying and closes the queue. Writes that remain queued
+// are replayed after restart.
+func (p *Plugin) Close() error {
+	if p.quit != nil {
+		close(p.quit)
+		p.wg.Wait()
+		p.quit = nil
+	}
+	if p.client != nil {
+		if n := p.client.Pending(); n > 0 {
+			p.Log.Warnf("%d writes remain queued", n)
+		}
+		return p.client.Close()
+	}
+	return nil
+}
+
+// NewBroker returns a ProtoBroker instance that prepends given <keyPrefix>
+// to all keys in its calls. Writes made through the broker are queued
+// while the data store is not available.
+func (p *Plugin) NewBroker(keyPrefix string) keyval.ProtoBroker {
+	if p.protoWrapper == nil {
+		if p.Disabled() {
+			return nil
+		}
+		return p.KvPlugin.NewBroker(keyPrefix)
+	}
+	return p.protoWrapper.NewBroker(keyPrefix)
+}
+
+// NewWatcher returns a ProtoWatcher instance of the data store.
+func (p *Plugin) NewWatcher(keyPrefix string) keyval.ProtoWatcher {
+	if p.Disabled() {
+		return nil
+	}
+	return p.KvPlugin.NewWatcher(keyPrefix)
+}
+
+// RawAccess allows to access data in the data store as raw bytes.
+// Writes are queued while the data store is not available.
+func (p *Plugin) RawAccess() keyval.KvBytesPlugin {
+	if p.client == nil {
+		if p.Disabled() {
+			return nil
+		}
+		return p.KvPlugin.RawAccess()
+	}
+	return p.client
+}
+
+// Disabled returns *true* if the data store plugin is not in use.
+func (p *Plugin) Disabled() bool {
+	return p.KvPlugin == nil || p.KvPlugin.Disabled()
+}
+
+// OnConnect executes the callback once the data store is connected.
+func (p *Plugin) OnConnect(callback func() error) {
+	if p.Disabled() {
+		p.Log.Warn("Data store is not available, OnConnect callback is not registered")
+		return
+	}
+	p.KvPlugin.OnConnect(callback)
+}
+
+func (p *Plugin) getConfig() (*Config, error) {
+	var cfg Config
+	found, err := p.Cfg.LoadValue(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		p.Log.Info("kvqueue config not found, writes are not queued")
+		p.disabled = true
+		return nil, nil
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval/bolt"
+)
+
+const (
+	// opsPrefix is the prefix of keys with queued operations. The prefix
+	// is followed by zero-padded sequence number to keep the order of keys.
+	opsPrefix = "/kvqueue/ops/"
+	// basePrefix is the prefix of keys with revisions the queued operations
+	// are based on. The prefix is followed by the key of the operation.
+	basePrefix = "/kvqueue/base/"
+	// rejectedPrefix is the prefix of keys with operations rejected
+	// by the data store. The prefix is followed by the sequence number
+	// of the operation.
+	rejectedPrefix = "/kvqueue/rejected/"
+)
+
+// opType is the type of queued operation.
+type opType string
+
+const (
+	opPut    opType = "put"
+	opDelete opType = "delete"
+	opTxn    opType = "txn"
+)
+
+// queuedOp is a write operation stored in the queue.
+type queuedOp struct {
+	id    string
+	Type  opType `json:"type"`
+	Key   string `json:"key,omitempty"`
+	Value []byte `json:"value,omitempty"`
+	// TTL and ClientLifetime are options of put
+	TTL            time.Duration `json:"ttl,omitempty"`
+	ClientLifetime bool          `json:"client-lifetime,omitempty"`
+	// Prefix is option of delete
+	Prefix bool `json:"prefix,omitempty"`
+	// Ops lists operations of the transaction
+	Ops []*queuedOp `json:"ops,omitempty"`
+	// Error is the reason the operation was rejected by the data store
+	Error string `json:"error,omitempty"`
+}
+
+// newPutOp creates put operation with the given options.
+func newPutOp(key string, value []byte, opts []datasync.PutOption) *queuedOp {
+	op := &queuedOp{Type: opPut, Key: key, Value: value}
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case *datasync.WithTTLOpt:
+			op.TTL = o.TTL
+		case *datasync.WithClientLifetimeTTLOpt:
+			op.ClientLifetime = true
+		}
+	}
+	return op
+}
+
+// newDeleteOp creates delete operation with the given options.
+func newDeleteOp(key string, opts []datasync.DelOption) *queuedOp {
+	op := &queuedOp{Type: opDelete, Key: key}
+	for _, opt := range opts {
+		if _, ok := opt.(*datasync.WithPrefixOpt); ok {
+			op.Prefix = true
+		}
+	}
+	return op
+}
+
+// putOptions returns the options of put operation.
+func (op *queuedOp) putOptions() (opts []datasync.PutOption) {
+	if op.TTL > 0 {
+		opts = append(opts, datasync.WithTTL(op.TTL))
+	}
+	if op.ClientLifetime {
+		opts = append(opts, datasync.WithClientLifetimeTTL())
+	}
+	return opts
+}
+
+// delOptions returns the options of delete operation.
+func (op *queuedOp) delOptions() (opts []datasync.DelOption) {
+	if op.Prefix {
+		opts = append(opts, datasync.WithPrefix())
+	}
+	return opts
+}
+
+// keys returns the keys written by the operation.
+func (op *queuedOp) keys() []string {
+	if op.Type != opTxn {
+		return []string{op.Key}
+	}
+	keys := make([]string, 0, len(op.Ops))
+	for _, txnOp := range op.Ops {
+		keys = append(keys, txnOp.Key)
+	}
+	return keys
+}
+
+// queue is a durable FIFO queue of write operations stored in Bolt.
+type queue struct {
+	db  *bolt.Client
+	seq uint64
+	len int
+}
+
+// openQueue opens the queue stored in Bolt database given by <cfg>.
+func openQueue(cfg *bolt.Config) (*queue, error) {
+	db, err := bolt.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	q := &queue{db: db}
+	keys, err := db.ListKeys(opsPrefix)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	for {
+		key, _, stop := keys.GetNext()
+		if stop {
+			break
+		}
+		seq, err := strconv.ParseUint(strings.TrimPrefix(key, opsPrefix), 10, 64)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("invalid key of queued operation %q: %v", key, err)
+		}
+		if seq > q.seq {
+			q.seq = seq
+		}
+		q.len++
+	}
+	return q, nil
+}
+
+// push appends the operation to the end of the queue.
+func (q *queue) push(op *queuedOp) error {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s%020d", opsPrefix, q.seq+1)
+	if err := q.db.Put(key, data); err != nil {
+		return err
+	}
+	q.seq++
+	q.len++
+	return nil
+}
+
+// list returns all queued operations in the order they were pushed.
+func (q *queue) list() (ops []*queuedOp, err error) {
+	it, err := q.db.ListValues(opsPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		kv, stop := it.GetNext()
+		if stop {
+			break
+		}
+		op := &queuedOp{id: kv.GetKey()}
+		if err := json.Unmarshal(kv.GetValue(), op); err != nil {
+			return nil, fmt.Errorf("invalid queued operation %q: %v", kv.GetKey(), err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// remove removes the operation from the queue.
+func (q *queue) remove(op *queuedOp) error {
+	if _, err := q.db.Delete(op.id); err != nil {
+		return err
+	}
+	q.len--
+	return nil
+}
+
+// reject moves the operation rejected by the data store out of the queue.
+// Rejected operations are kept in the database for inspection.
+func (q *queue) reject(op *queuedOp, reason error) error {
+	op.Error = reason.Error()
+	data, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	if err := q.db.Put(rejectedPrefix+strings.TrimPrefix(op.id, opsPrefix), data); err != nil {
+		return err
+	}
+	return q.remove(op)
+}
+
+// rejected returns the number of operations rejected by the data store.
+func (q *queue) rejected() (n int, err error) {
+	keys, err := q.db.ListKeys(rejectedPrefix)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, _, stop := keys.GetNext(); stop {
+			return n, nil
+		}
+		n++
+	}
+}
+
+// baseRevision returns the revision of the key the queued operations
+// are based on.
+func (q *queue) baseRevision(key string) (rev int64, known bool, err error) {
+	it, err := q.db.ListValues(basePrefix + key)
+	if err != nil {
+		return 0, false, err
+	}
+	for {
+		kv, stop := it.GetNext()
+		if stop {
+			return 0, false, nil
+		}
+		if kv.GetKey() == basePrefix+key {
+			rev, err = strconv.ParseInt(string(kv.GetValue()), 10, 64)
+			return rev, err == nil, err
+		}
+	}
+}
+
+// setBaseRevision stores the revision of the key the queued operations
+// are based on.
+func (q *queue) setBaseRevision(key string, rev int64) error {
+	return q.db.Put(basePrefix+key, []byte(strconv.FormatInt(rev, 10)))
+}
+
+// clearBaseRevisions removes all stored revisions, which is done once
+// the queue is empty.
+func (q *queue) clearBaseRevisions() error {
+	keys, err := q.db.ListKeys(basePrefix)
+	if err != nil {
+		return err
+	}
+	for {
+		key, _, stop := keys.GetNext()
+		if stop {
+			return nil
+		}
+		if _, err := q.db.Delete(key); err != nil {
+			return err
+		}
+	}
+}
+
+// close closes the underlying database.
+func (q *queue) close() error {
+	return q.db.Close()
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvqueue
+
+import "container/list"
+
+// maxTrackedRevisions is the maximum number of keys whose revisions
+// are remembered for SkipIfChanged policy.
+const maxTrackedRevisions = 10000
+
+// revisionCache holds the last known revisions of a limited number of keys.
+// Keys updated least recently are forgotten first. Writes queued for
+// a forgotten key are replayed regardless of the changes in the meantime.
+type revisionCache struct {
+	max   int
+	order *list.List // keys ordered from the least recently updated
+	items map[string]*list.Element
+}
+
+type revisionEntry struct {
+	key string
+	rev int64
+}
+
+func newRevisionCache(max int) *revisionCache {
+	return &revisionCache{
+		max:   max,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// get returns the last known revision of the key.
+func (c *revisionCache) get(key string) (rev int64, known bool) {
+	if item, ok := c.items[key]; ok {
+		return item.Value.(*revisionEntry).rev, true
+	}
+	return 0, false
+}
+
+// set stores the revision of the key, the least recently updated key
+// is forgotten if the cache is full.
+func (c *revisionCache) set(key string, rev int64) {
+	if item, ok := c.items[key]; ok {
+		item.Value.(*revisionEntry).rev = rev
+		c.order.MoveToBack(item)
+		return
+	}
+	c.items[key] = c.order.PushBack(&revisionEntry{key: key, rev: rev})
+	if c.order.Len() > c.max {
+		c.delete(c.order.Front().Value.(*revisionEntry).key)
+	}
+}
+
+// delete forgets the revision of the key.
+func (c *revisionCache) delete(key string) {
+	if item, ok := c.items[key]; ok {
+		c.order.Remove(item)
+		delete(c.items, key)
+	}
+}
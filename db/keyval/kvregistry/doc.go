@@ -0,0 +1,33 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kvregistry implements a registry mapping key templates to proto
+// message types. It allows generic code (REST handlers, dump tools, logging)
+// to decode values stored in a key-value data store without knowing their
+// types at compile time.
+//
+// Key template is a key with some of its segments (parts separated by '/')
+// replaced by named parameters:
+//
+//	/vnf-agent/{label}/config/acl/{name}
+//
+// Parameter {name} matches exactly one non-empty segment, parameter
+// {name...} matches one or more segments and can be used for values
+// containing slashes, e.g. IP prefixes. Template ending with '/' matches all
+// keys with the given prefix. If multiple templates match a key, the one with
+// more literal segments wins.
+//
+// Types are registered either as compiled proto messages, or by descriptors
+// for which dynamic messages (dynamicpb) are used.
+package kvregistry
@@ -0,0 +1,266 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvregistry
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/keyval"
+)
+
+// DefaultRegistry is the global registry used by the package-level functions.
+var DefaultRegistry = NewRegistry()
+
+// Registry maps key templates to proto message types.
+// It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	entries []*entry
+}
+
+// entry is a registered key template with its message type.
+type entry struct {
+	template *template
+	msgType  protoreflect.MessageType
+}
+
+// Match is the result of successful lookup of a key.
+type Match struct {
+	// Template is the key template matching the key.
+	Template string
+	// Params contains values of the template parameters.
+	Params map[string]string
+	// Type is the proto message type registered for the template.
+	Type protoreflect.MessageType
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register registers the type of the proto message <msg> for keys
+// matching the key template.
+func (r *Registry) Register(template string, msg proto.Message) error {
+	return r.RegisterType(template, msg.ProtoReflect().Type())
+}
+
+// RegisterType registers the proto message type for keys matching the key
+// template. Registering the same template again replaces its type.
+func (r *Registry) RegisterType(template string, msgType protoreflect.MessageType) error {
+	t, err := parseTemplate(template)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.template.pattern == template {
+			e.msgType = msgType
+			return nil
+		}
+	}
+	r.entries = append(r.entries, &entry{template: t, msgType: msgType})
+	return nil
+}
+
+// RegisterDescriptor registers dynamic message type with the given
+// descriptor for keys matching the key template.
+func (r *Registry) RegisterDescriptor(template string, desc protoreflect.MessageDescriptor) error {
+	return r.RegisterType(template, dynamicpb.NewMessageType(desc))
+}
+
+// RegisterName registers the message type with the given full name
+// for keys matching the key template. The type is looked up among the linked
+// proto types first, then among the registered proto files, in which case
+// dynamic message type is used.
+func (r *Registry) RegisterName(template string, name protoreflect.FullName) error {
+	msgType, err := protoregistry.GlobalTypes.FindMessageByName(name)
+	if err == nil {
+		return r.RegisterType(template, msgType)
+	}
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return fmt.Errorf("message %s not found: %v", name, err)
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return fmt.Errorf("%s is not a message", name)
+	}
+	return r.RegisterDescriptor(template, msgDesc)
+}
+
+// Unregister removes the key template from the registry.
+func (r *Registry) Unregister(template string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.entries {
+		if e.template.pattern == template {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Templates returns all registered key templates in the order
+// of registration.
+func (r *Registry) Templates() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	templates := make([]string, 0, len(r.entries))
+	for _, e := range r.entries {
+		templates = append(templates, e.template.pattern)
+	}
+	return templates
+}
+
+// Lookup returns the most specific key template matching the key.
+func (r *Registry) Lookup(key string) (*Match, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		best   *entry
+		params map[string]string
+	)
+	for _, e := range r.entries {
+		if best != nil && !e.template.moreSpecific(best.template) {
+			continue
+		}
+		if p, ok := e.template.match(key); ok {
+			best, params = e, p
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return &Match{
+		Template: best.template.pattern,
+		Params:   params,
+		Type:     best.msgType,
+	}, true
+}
+
+// MessageType returns the message type registered for the key, or nil
+// if no key template matches the key. The method can be used as
+// kvexport.TypeResolver.
+func (r *Registry) MessageType(key string) protoreflect.MessageType {
+	if m, ok := r.Lookup(key); ok {
+		return m.Type
+	}
+	return nil
+}
+
+// NewMessage returns a new empty message of the type registered for the key.
+func (r *Registry) NewMessage(key string) (proto.Message, error) {
+	msgType := r.MessageType(key)
+	if msgType == nil {
+		return nil, fmt.Errorf("no message type registered for key %q", key)
+	}
+	return msgType.New().Interface(), nil
+}
+
+// Decode unmarshals the value stored under the key using the <serializer>
+// (JSON by default) into a new message of the registered type.
+func (r *Registry) Decode(key string, data []byte, serializer ...keyval.Serializer) (proto.Message, error) {
+	msg, err := r.NewMessage(key)
+	if err != nil {
+		return nil, err
+	}
+	var s keyval.Serializer = &keyval.SerializerJSON{}
+	if len(serializer) > 0 {
+		s = serializer[0]
+	}
+	if err := s.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("failed to decode value of %q: %v", key, err)
+	}
+	return msg, nil
+}
+
+// DecodeKeyVal decodes the value of the datasync key-value pair.
+func (r *Registry) DecodeKeyVal(kv datasync.KeyVal) (proto.Message, error) {
+	msg, err := r.NewMessage(kv.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	if err := kv.GetValue(msg); err != nil {
+		return nil, fmt.Errorf("failed to decode value of %q: %v", kv.GetKey(), err)
+	}
+	return msg, nil
+}
+
+// DecodeBytesKeyVal decodes the value of the raw key-value pair using
+// the <serializer> (JSON by default). Keys of the pairs returned by prefixed
+// brokers are relative to the prefix, therefore the prefix has to be passed
+// as <keyPrefix>.
+func (r *Registry) DecodeBytesKeyVal(keyPrefix string, kv keyval.BytesKeyVal, serializer ...keyval.Serializer) (proto.Message, error) {
+	return r.Decode(keyPrefix+kv.GetKey(), kv.GetValue(), serializer...)
+}
+
+// Register registers the type of the proto message <msg> for keys matching
+// the key template in the DefaultRegistry.
+func Register(template string, msg proto.Message) error {
+	return DefaultRegistry.Register(template, msg)
+}
+
+// MustRegister is like Register but panics if the template is invalid.
+// It is intended to be called from init functions.
+func MustRegister(template string, msg proto.Message) {
+	if err := Register(template, msg); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterName registers the message type with the given full name for keys
+// matching the key template in the DefaultRegistry.
+func RegisterName(template string, name protoreflect.FullName) error {
+	return DefaultRegistry.RegisterName(template, name)
+}
+
+// Lookup returns the most specific key template from the DefaultRegistry
+// matching the key.
+func Lookup(key string) (*Match, bool) {
+	return DefaultRegistry.Lookup(key)
+}
+
+// MessageType returns the message type registered in the DefaultRegistry
+// for the key, or nil if there is none.
+func MessageType(key string) protoreflect.MessageType {
+	return DefaultRegistry.MessageType(key)
+}
+
+// Decode decodes the value stored under the key using the DefaultRegistry.
+func Decode(key string, data []byte, serializer ...keyval.Serializer) (proto.Message, error) {
+	return DefaultRegistry.Decode(key, data, serializer...)
+}
+
+// DecodeKeyVal decodes the value of the datasync key-value pair using
+// the DefaultRegistry.
+func DecodeKeyVal(kv datasync.KeyVal) (proto.Message, error) {
+	return DefaultRegistry.DecodeKeyVal(kv)
+}
+
+// DecodeBytesKeyVal decodes the value of the raw key-value pair using
+// the DefaultRegistry.
+func DecodeBytesKeyVal(keyPrefix string, kv keyval.BytesKeyVal, serializer ...keyval.Serializer) (proto.Message, error) {
+	return DefaultRegistry.DecodeBytesKeyVal(keyPrefix, kv, serializer...)
+}
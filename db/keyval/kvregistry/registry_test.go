@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvregistry_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"go.ligato.io/cn-infra/v2/datasync/syncbase"
+	"go.ligato.io/cn-infra/v2/db/keyval/kvregistry"
+)
+
+func TestLookup(t *testing.T) {
+	RegisterTestingT(t)
+	r := kvregistry.NewRegistry()
+	Expect(r.Register("/vnf-agent/{label}/config/acl/{name}", &wrapperspb.StringValue{})).To(Succeed())
+	Expect(r.Register("/vnf-agent/{label}/config/route/vrf/{vrf}/dst/{dst...}/gw/{gw}", &wrapperspb.Int64Value{})).To(Succeed())
+	Expect(r.Register("/vnf-agent/{label}/config/", &structpb.Struct{})).To(Succeed())
+	Expect(r.Register("/vnf-agent/{label}/config/acl/default", &wrapperspb.BoolValue{})).To(Succeed())
+
+	m, ok := r.Lookup("/vnf-agent/vpp1/config/acl/acl1")
+	Expect(ok).To(BeTrue())
+	Expect(m.Template).To(Equal("/vnf-agent/{label}/config/acl/{name}"))
+	Expect(m.Params).To(Equal(map[string]string{"label": "vpp1", "name": "acl1"}))
+	Expect(m.Type.Descriptor().FullName()).To(BeEquivalentTo("google.protobuf.StringValue"))
+
+	m, ok = r.Lookup("/vnf-agent/vpp1/config/route/vrf/0/dst/10.0.0.0/24/gw/10.0.0.1")
+	Expect(ok).To(BeTrue())
+	Expect(m.Params).To(HaveKeyWithValue("dst", "10.0.0.0/24"))
+	Expect(m.Params).To(HaveKeyWithValue("gw", "10.0.0.1"))
+
+	// the most specific template wins
+	Expect(r.MessageType("/vnf-agent/vpp1/config/acl/default").Descriptor().FullName()).
+		To(BeEquivalentTo("google.protobuf.BoolValue"))
+	// prefix template matches the rest
+	Expect(r.MessageType("/vnf-agent/vpp1/config/interface/eth0").Descriptor().FullName()).
+		To(BeEquivalentTo("google.protobuf.Struct"))
+	Expect(r.MessageType("/vnf-agent/vpp1/config")).To(BeNil())
+	Expect(r.MessageType("/other/key")).To(BeNil())
+
+	r.Unregister("/vnf-agent/{label}/config/acl/default")
+	Expect(r.Templates()).To(HaveLen(3))
+	Expect(r.Register("/vnf-agent/{label}/{label}", &structpb.Struct{})).ToNot(Succeed())
+	Expect(r.Register("/vnf-agent/{label", &structpb.Struct{})).ToNot(Succeed())
+}
+
+func TestDecode(t *testing.T) {
+	RegisterTestingT(t)
+	r := kvregistry.NewRegistry()
+	Expect(r.Register("/config/string/{name}", &wrapperspb.StringValue{})).To(Succeed())
+	Expect(r.RegisterName("/config/struct/{name}", "google.protobuf.Struct")).To(Succeed())
+	Expect(r.RegisterDescriptor("/config/dynamic/{name}", (&wrapperspb.StringValue{}).ProtoReflect().Descriptor())).To(Succeed())
+	Expect(r.RegisterName("/config/unknown/{name}", "unknown.Message")).ToNot(Succeed())
+
+	msg, err := r.Decode("/config/string/a", []byte(`"value"`))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(proto.Equal(msg, wrapperspb.String("value"))).To(BeTrue())
+
+	msg, err = r.DecodeKeyVal(syncbase.NewKeyValBytes("/config/struct/b", []byte(`{"x":1}`), 1))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(msg.(*structpb.Struct).Fields["x"].GetNumberValue()).To(BeEquivalentTo(1))
+
+	msg, err = r.Decode("/config/dynamic/c", []byte(`"dynamic"`))
+	Expect(err).ToNot(HaveOccurred())
+	field := msg.ProtoReflect().Descriptor().Fields().ByName("value")
+	Expect(msg.ProtoReflect().Get(field).String()).To(Equal("dynamic"))
+
+	_, err = r.Decode("/config/other/d", []byte(`{}`))
+	Expect(err).To(HaveOccurred())
+	_, err = r.Decode("/config/string/a", []byte(`{`))
+	Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvregistry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// template is a parsed key template.
+type template struct {
+	pattern  string
+	segments []segment
+	prefix   bool
+	literals int
+}
+
+// segment is a single segment of key template.
+type segment struct {
+	literal string
+	param   string
+	rest    bool
+}
+
+// parseTemplate parses the key template.
+func parseTemplate(pattern string) (*template, error) {
+	t := &template{pattern: pattern}
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") && len(pattern) > 1 {
+		t.prefix = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	params := make(map[string]bool)
+	for _, s := range strings.Split(pattern, "/")[1:] {
+		if !strings.HasPrefix(s, "{") {
+			if strings.ContainsAny(s, "{}") {
+				return nil, fmt.Errorf("invalid segment %q in key template %q", s, t.pattern)
+			}
+			t.segments = append(t.segments, segment{literal: s})
+			t.literals++
+			continue
+		}
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("invalid parameter %q in key template %q", s, t.pattern)
+		}
+		seg := segment{param: strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")}
+		if strings.HasSuffix(seg.param, "...") {
+			seg.param, seg.rest = strings.TrimSuffix(seg.param, "..."), true
+		}
+		if seg.param == "" || params[seg.param] {
+			return nil, fmt.Errorf("invalid parameter %q in key template %q", s, t.pattern)
+		}
+		params[seg.param] = true
+		t.segments = append(t.segments, seg)
+	}
+	return t, nil
+}
+
+// match matches the key against the template and returns values
+// of the template parameters.
+func (t *template) match(key string) (params map[string]string, ok bool) {
+	if !strings.HasPrefix(key, "/") {
+		key = "/" + key
+	}
+	params = make(map[string]string)
+	if !t.matchSegments(t.segments, strings.Split(key, "/")[1:], params) {
+		return nil, false
+	}
+	return params, true
+}
+
+func (t *template) matchSegments(segments []segment, parts []string, params map[string]string) bool {
+	if len(segments) == 0 {
+		// prefix template requires the key to continue after the prefix
+		return (len(parts) > 0) == t.prefix
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	seg := segments[0]
+	switch {
+	case seg.param == "":
+		return parts[0] == seg.literal && t.matchSegments(segments[1:], parts[1:], params)
+	case !seg.rest:
+		if parts[0] == "" || !t.matchSegments(segments[1:], parts[1:], params) {
+			return false
+		}
+		params[seg.param] = parts[0]
+		return true
+	}
+	// the longest match of the rest parameter is preferred
+	for n := len(parts); n > 0; n-- {
+		if t.matchSegments(segments[1:], parts[n:], params) {
+			params[seg.param] = strings.Join(parts[:n], "/")
+			return params[seg.param] != ""
+		}
+	}
+	return false
+}
+
+// moreSpecific returns true if the template takes precedence over the other
+// template when both of them match the same key.
+func (t *template) moreSpecific(other *template) bool {
+	if t.literals != other.literals {
+		return t.literals > other.literals
+	}
+	if len(t.segments) != len(other.segments) {
+		return len(t.segments) > len(other.segments)
+	}
+	return !t.prefix && other.prefix
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyval
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrLockHeld is returned by TryLock if the lock is currently held
+	// by another owner.
+	ErrLockHeld = errors.New("keyval: lock is held by another owner")
+	// ErrLockNotHeld is returned by Unlock if the lock is not held
+	// (anymore) by the caller.
+	ErrLockNotHeld = errors.New("keyval: lock is not held")
+)
+
+// Locker provides named locks shared by all clients of the same key-value
+// data store. Ownership of an acquired lock is bound to the lease (session)
+// of the data store client, i.e. the lock is released automatically when
+// the owner disappears.
+type Locker interface {
+	// Lock acquires the lock with the given <name>. The call blocks until
+	// either the lock is acquired or the context is canceled.
+	Lock(ctx context.Context, name string) (Lock, error)
+	// TryLock attempts to acquire the lock with the given <name> without
+	// waiting for the current owner to release it. If the lock is held by
+	// another owner, ErrLockHeld is returned.
+	TryLock(ctx context.Context, name string) (Lock, error)
+}
+
+// Lock represents a lock acquired by Locker.
+type Lock interface {
+	// Name returns the name of the lock.
+	Name() string
+	// Token returns the fencing token of this acquisition of the lock.
+	// Tokens of subsequent acquisitions of the same lock are strictly
+	// increasing, therefore they can be handed over to other services
+	// to reject requests from stale owners.
+	Token() int64
+	// Lost returns a channel that is closed once the ownership of the lock
+	// is lost, e.g. when the lease has expired or the connection to the data
+	// store was closed. The channel is closed also by Unlock.
+	Lost() <-chan struct{}
+	// Unlock releases the lock.
+	Unlock(ctx context.Context) error
+}
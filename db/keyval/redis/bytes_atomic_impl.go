@@ -0,0 +1,198 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// revisionKeyPrefix is a prefix of keys holding the revisions of data keys.
+// Keys with this prefix are hidden from listing and watching.
+const revisionKeyPrefix = "__rev__"
+
+// expiringKeyPrefix is a prefix of keys marking data keys with expiration
+// whose expiry was not reflected in the revision yet (see expiredScript).
+// Revision keys always continue with the hash tag after revisionKeyPrefix,
+// so the keys do not collide.
+const expiringKeyPrefix = revisionKeyPrefix + "exp__"
+
+var (
+	// putScript sets the value (with optional expiration in milliseconds)
+	// and returns the new revision of the key. The value with expiration
+	// is marked as expiring.
+	putScript = goredis.NewScript(`
+local rev = redis.call("INCR", KEYS[2])
+if tonumber(ARGV[2]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	redis.call("SET", KEYS[3], 1)
+else
+	redis.call("SET", KEYS[1], ARGV[1])
+	redis.call("DEL", KEYS[3])
+end
+return rev`)
+
+	// deleteScript removes the key and if it existed, returns the new revision
+	// of the key, otherwise returns 0.
+	deleteScript = goredis.NewScript(`
+if redis.call("DEL", KEYS[1]) > 0 then
+	redis.call("DEL", KEYS[3])
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+
+	// expiredScript increments the revision of the expired key and returns it.
+	// Every watcher runs the script, the revision is therefore incremented only
+	// by the watcher removing the expiring mark of the key.
+	expiredScript = goredis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 and redis.call("DEL", KEYS[3]) > 0 then
+	redis.call("INCR", KEYS[2])
+end
+return tonumber(redis.call("GET", KEYS[2]) or 0)`)
+
+	// putIfNotExistsScript sets the value only if the key does not exist yet
+	// and returns the new revision of the key, or 0 if the key exists.
+	putIfNotExistsScript = goredis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX") then
+	redis.call("DEL", KEYS[3])
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+
+	// compareAndSwapScript replaces the value only if it equals to the expected
+	// one and returns the new revision of the key, or 0 if the value differs.
+	compareAndSwapScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2])
+	redis.call("DEL", KEYS[3])
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+
+	// compareAndDeleteScript removes the key only if its value equals to the
+	// expected one and returns the new revision of the key, or 0 if the value differs.
+	compareAndDeleteScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	redis.call("DEL", KEYS[3])
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+)
+
+// revisionKey returns the key holding the revision of the given data key.
+// The revision is incremented by every change of the key done through this
+// package. The revision key does not expire and it is kept after the data
+// key is removed, so that the revision keeps increasing if the key is created
+// again.
+//
+// The revision key uses the hash tag of the data key, or the whole data key
+// as the hash tag, so that both keys belong to the same hash slot and can be
+// used together in a Lua script also with Redis cluster. Keys containing '}'
+// without a valid hash tag are therefore not supported by the cluster.
+func revisionKey(key string) string {
+	if tag := hashTag(key); tag != "" {
+		return revisionKeyPrefix + "{" + tag + "}" + key
+	}
+	return revisionKeyPrefix + "{" + key + "}"
+}
+
+// expiringKey returns the key marking the given data key as expiring.
+// It belongs to the same hash slot as the data key (see revisionKey).
+func expiringKey(key string) string {
+	return expiringKeyPrefix + strings.TrimPrefix(revisionKey(key), revisionKeyPrefix)
+}
+
+// isRevisionKey returns true if the key holds a revision of a data key.
+func isRevisionKey(key string) bool {
+	return strings.HasPrefix(key, revisionKeyPrefix)
+}
+
+// scriptKeys returns the keys of Lua scripts for the given data key.
+func scriptKeys(key string) []string {
+	return []string{key, revisionKey(key), expiringKey(key)}
+}
+
+// milliseconds converts duration into milliseconds used in Redis commands.
+func milliseconds(d time.Duration) int64 {
+	return d.Nanoseconds() / int64(time.Millisecond)
+}
+
+// PutIfNotExists puts given key-value pair into Redis if there is no value set for the key.
+// If the put was successful, <succeeded> is returned as true. If the key already exists, <succeeded> is returned
+// as false and the value for the key is untouched.
+func (db *BytesConnectionRedis) PutIfNotExists(key string, data []byte) (succeeded bool, err error) {
+	if db.closed {
+		return false, fmt.Errorf("PutIfNotExists(%s) called on a closed connection", key)
+	}
+	db.Debugf("PutIfNotExists(%s)", key)
+
+	rev, err := putIfNotExistsScript.Run(db.client, scriptKeys(key), data).Int64()
+	if err != nil {
+		return false, fmt.Errorf("PutIfNotExists(%s) failed: %s", key, err)
+	}
+	return rev != 0, nil
+}
+
+// CompareAndSwap replaces the value stored under the given key with <newData> only if the current value
+// matches <oldData>. The comparison and the value change are done atomically by a Lua script.
+func (db *BytesConnectionRedis) CompareAndSwap(key string, oldData, newData []byte) (swapped bool, err error) {
+	if db.closed {
+		return false, fmt.Errorf("CompareAndSwap(%s) called on a closed connection", key)
+	}
+	db.Debugf("CompareAndSwap(%s)", key)
+
+	rev, err := compareAndSwapScript.Run(db.client, scriptKeys(key), oldData, newData).Int64()
+	if err != nil {
+		return false, fmt.Errorf("CompareAndSwap(%s) failed: %s", key, err)
+	}
+	return rev != 0, nil
+}
+
+// CompareAndDelete removes the value stored under the given key only if it matches <data>.
+// The comparison and the removal are done atomically by a Lua script.
+func (db *BytesConnectionRedis) CompareAndDelete(key string, data []byte) (deleted bool, err error) {
+	if db.closed {
+		return false, fmt.Errorf("CompareAndDelete(%s) called on a closed connection", key)
+	}
+	db.Debugf("CompareAndDelete(%s)", key)
+
+	rev, err := compareAndDeleteScript.Run(db.client, scriptKeys(key), data).Int64()
+	if err != nil {
+		return false, fmt.Errorf("CompareAndDelete(%s) failed: %s", key, err)
+	}
+	return rev != 0, nil
+}
+
+// PutIfNotExists calls PutIfNotExists function of BytesConnectionRedis.
+// Prefix will be prepended to the key argument.
+func (pdb *BytesBrokerWatcherRedis) PutIfNotExists(key string, data []byte) (succeeded bool, err error) {
+	return pdb.delegate.PutIfNotExists(pdb.addPrefix(key), data)
+}
+
+// CompareAndSwap calls CompareAndSwap function of BytesConnectionRedis.
+// Prefix will be prepended to the key argument.
+func (pdb *BytesBrokerWatcherRedis) CompareAndSwap(key string, oldData, newData []byte) (swapped bool, err error) {
+	return pdb.delegate.CompareAndSwap(pdb.addPrefix(key), oldData, newData)
+}
+
+// CompareAndDelete calls CompareAndDelete function of BytesConnectionRedis.
+// Prefix will be prepended to the key argument.
+func (pdb *BytesBrokerWatcherRedis) CompareAndDelete(key string, data []byte) (deleted bool, err error) {
+	return pdb.delegate.CompareAndDelete(pdb.addPrefix(key), data)
+}
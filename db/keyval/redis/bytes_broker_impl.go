@@ -19,6 +19,8 @@ import (
 	"strings"
 	"time"
 
+	goredis "github.com/go-redis/redis"
+
 	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/keyval"
 	"go.ligato.io/cn-infra/v2/logging"
@@ -51,7 +53,8 @@ type bytesKeyIterator struct {
 
 // bytesKeyValIterator is an iterator returned by ListValues call.
 type bytesKeyValIterator struct {
-	values [][]byte
+	values    [][]byte
+	revisions []int64
 	bytesKeyIterator
 }
 
@@ -60,6 +63,7 @@ type bytesKeyVal struct {
 	key       string
 	value     []byte
 	prevValue []byte
+	revision  int64
 }
 
 // NewBytesConnection creates a new instance of BytesConnectionRedis using the provided
@@ -98,6 +102,7 @@ func (db *BytesConnectionRedis) NewTxn() keyval.BytesTxn {
 }
 
 // Put sets the key/value in Redis data store. Replaces value if the key already exists.
+// The revision of the key is incremented together with the value change.
 func (db *BytesConnectionRedis) Put(key string, data []byte, opts ...datasync.PutOption) error {
 	if db.closed {
 		return fmt.Errorf("Put(%s) called on a closed connection", key)
@@ -110,21 +115,26 @@ func (db *BytesConnectionRedis) Put(key string, data []byte, opts ...datasync.Pu
 			ttl = withTTL.TTL
 		}
 	}
-	err := db.client.Set(key, data, ttl).Err()
+	err := putScript.Run(db.client, scriptKeys(key), data, milliseconds(ttl)).Err()
 	if err != nil {
 		return fmt.Errorf("Set(%s) failed: %s", key, err)
 	}
 	return nil
 }
 
-// GetValue retrieves the value of the key from Redis.
+// GetValue retrieves the value of the key from Redis together with its revision.
 func (db *BytesConnectionRedis) GetValue(key string) (data []byte, found bool, revision int64, err error) {
 	if db.closed {
 		return nil, false, 0, fmt.Errorf("GetValue(%s) called on a closed connection", key)
 	}
 	db.Debugf("GetValue(%s)", key)
 
-	statusCmd := db.client.Get(key)
+	pipeline := db.client.Pipeline()
+	statusCmd := pipeline.Get(key)
+	revCmd := pipeline.Get(revisionKey(key))
+	if _, err = pipeline.Exec(); err != nil && err != GoRedisNil {
+		return nil, false, 0, fmt.Errorf("Get(%s) failed: %s", key, err)
+	}
 	data, err = statusCmd.Bytes()
 	if err != nil {
 		if err == GoRedisNil {
@@ -132,7 +142,11 @@ func (db *BytesConnectionRedis) GetValue(key string) (data []byte, found bool, r
 		}
 		return nil, false, 0, fmt.Errorf("Get(%s) failed: %s", key, err)
 	}
-	return data, true, 0, nil
+	revision, err = revCmd.Int64()
+	if err != nil && err != GoRedisNil {
+		return nil, false, 0, fmt.Errorf("Get(%s) failed: %s", revisionKey(key), err)
+	}
+	return data, true, revision, nil
 }
 
 // ListKeys returns an iterator used to traverse keys that start with the given match string.
@@ -154,6 +168,7 @@ func (db *BytesConnectionRedis) ListValues(match string) (keyval.BytesKeyValIter
 }
 
 // Delete deletes all the keys that start with the given match string.
+// The revisions of the deleted keys are incremented.
 func (db *BytesConnectionRedis) Delete(key string, opts ...datasync.DelOption) (found bool, err error) {
 	if db.closed {
 		return false, fmt.Errorf("Delete(%s) called on a closed connection", key)
@@ -188,11 +203,20 @@ func (db *BytesConnectionRedis) Delete(key string, opts ...datasync.DelOption) (
 		keysToDelete = append(keysToDelete, key)
 	}
 
-	intCmd := db.client.Del(keysToDelete...)
-	if intCmd.Err() != nil {
-		return false, fmt.Errorf("Delete(%s) failed: %s", key, intCmd.Err())
+	pipeline := db.client.Pipeline()
+	cmds := make([]*goredis.Cmd, len(keysToDelete))
+	for i, k := range keysToDelete {
+		cmds[i] = deleteScript.Eval(pipeline, scriptKeys(k))
+	}
+	if _, err := pipeline.Exec(); err != nil {
+		return false, fmt.Errorf("Delete(%s) failed: %s", key, err)
 	}
-	return (intCmd.Val() != 0), nil
+	for _, cmd := range cmds {
+		if rev, _ := cmd.Int64(); rev != 0 {
+			found = true
+		}
+	}
+	return found, nil
 }
 
 // Close closes the iterator. It returns either an error (if any occurs), or nil.
@@ -257,7 +281,7 @@ func (it *bytesKeyValIterator) GetNext() (kv keyval.BytesKeyVal, lastReceived bo
 		if len(it.keys) == 0 {
 			return nil, it.cursor == 0
 		}
-		it.values, err = getValues(it.db, it.keys)
+		it.values, it.revisions, err = getValues(it.db, it.keys)
 		if err != nil {
 			it.err = err
 			it.db.Errorf("GetNext() failed: %s (pattern %s)", err.Error(), it.pattern)
@@ -277,7 +301,7 @@ func (it *bytesKeyValIterator) GetNext() (kv keyval.BytesKeyVal, lastReceived bo
 		prevValue = it.values[it.index-1]
 	}
 
-	kv = &bytesKeyVal{key, value, prevValue}
+	kv = &bytesKeyVal{key, value, prevValue, it.revisions[it.index]}
 	it.index++
 
 	return kv, false
@@ -300,7 +324,7 @@ func (kv *bytesKeyVal) GetKey() string {
 
 // GetRevision returns the revision associated with the pair.
 func (kv *bytesKeyVal) GetRevision() int64 {
-	return 0
+	return kv.revision
 }
 
 func listKeys(db *BytesConnectionRedis, match string,
@@ -332,12 +356,13 @@ func listValues(db *BytesConnectionRedis, match string,
 		return nil, err
 	}
 	bkIterator := keyIterator.(*bytesKeyIterator)
-	values, err := getValues(db, bkIterator.keys)
+	values, revisions, err := getValues(db, bkIterator.keys)
 	if err != nil {
 		return nil, err
 	}
 	return &bytesKeyValIterator{
 		values:           values,
+		revisions:        revisions,
 		bytesKeyIterator: *bkIterator}, nil
 }
 
@@ -349,9 +374,7 @@ func scanKeys(db *BytesConnectionRedis, pattern string, cursor uint64) (keys []s
 			db.Errorf("Scan(%s) failed: %s", pattern, err)
 			return keys, next, err
 		}
-		if keys == nil {
-			keys = []string{}
-		}
+		keys = withoutRevisionKeys(keys)
 		count := len(keys)
 		if count > 0 || next == 0 {
 			db.Debugf("scanKeys(%s): got %d keys @ cursor %d (next cursor %d)", pattern, count, cursor, next)
@@ -361,30 +384,53 @@ func scanKeys(db *BytesConnectionRedis, pattern string, cursor uint64) (keys []s
 	}
 }
 
-func getValues(db *BytesConnectionRedis, keys []string) (values [][]byte, err error) {
+// withoutRevisionKeys filters out keys holding revisions of data keys.
+func withoutRevisionKeys(keys []string) []string {
+	filtered := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !isRevisionKey(key) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
+
+// getValues reads values and revisions of the keys. Each key is read by its own
+// command in a pipeline, since the keys may belong to different hash slots
+// of Redis cluster.
+func getValues(db *BytesConnectionRedis, keys []string) (values [][]byte, revisions []int64, err error) {
 	db.Debugf("getValues(%v)", keys)
 
 	if len(keys) == 0 {
-		return [][]byte{}, nil
-	}
-
-	sliceCmd := db.client.MGet(keys...)
-	if sliceCmd.Err() != nil {
-		return nil, fmt.Errorf("MGet(%v) failed: %s", keys, sliceCmd.Err())
-	}
-	vals := sliceCmd.Val()
-	values = make([][]byte, len(vals))
-	for i, v := range vals {
-		switch o := v.(type) {
-		case string:
-			values[i] = []byte(o)
-		case []byte:
-			values[i] = o
-		case nil:
-			values[i] = nil
+		return [][]byte{}, []int64{}, nil
+	}
+
+	pipeline := db.client.Pipeline()
+	valueCmds := make([]*goredis.StringCmd, len(keys))
+	revCmds := make([]*goredis.StringCmd, len(keys))
+	for i, key := range keys {
+		valueCmds[i] = pipeline.Get(key)
+		revCmds[i] = pipeline.Get(revisionKey(key))
+	}
+	if _, err = pipeline.Exec(); err != nil && err != GoRedisNil {
+		return nil, nil, fmt.Errorf("Get(%v) failed: %s", keys, err)
+	}
+
+	values = make([][]byte, len(keys))
+	revisions = make([]int64, len(keys))
+	for i := range keys {
+		value, err := valueCmds[i].Bytes()
+		if err != nil && err != GoRedisNil {
+			return nil, nil, fmt.Errorf("Get(%s) failed: %s", keys[i], err)
+		}
+		if err == nil {
+			values[i] = value
+		}
+		if revisions[i], err = revCmds[i].Int64(); err != nil && err != GoRedisNil {
+			return nil, nil, fmt.Errorf("Get(%s) failed: %s", revisionKey(keys[i]), err)
 		}
 	}
-	return values, nil
+	return values, revisions, nil
 }
 
 // ListValuesRange returns an iterator used to traverse values stored under the provided key.
@@ -0,0 +1,227 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+const (
+	// DefaultLockTTL is the default time after which a lock expires unless
+	// it is refreshed by its owner.
+	DefaultLockTTL = 10 * time.Second
+	// lockRetryInterval is the interval between attempts to acquire held lock.
+	lockRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	// acquireScript sets the lock key to the owner ID if it does not exist yet
+	// and returns the next fencing token, or 0 if the lock is held.
+	acquireScript = goredis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+
+	// refreshScript prolongs the lock if it is still held by the owner.
+	refreshScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// releaseScript removes the lock if it is still held by the owner.
+	releaseScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// bytesLockerRedis implements keyval.Locker using keys set with NX option
+// and expiration, which is periodically refreshed while the lock is held.
+type bytesLockerRedis struct {
+	db     *BytesConnectionRedis
+	prefix string
+	ttl    time.Duration
+}
+
+// bytesLockRedis is a lock acquired from bytesLockerRedis.
+type bytesLockRedis struct {
+	logging.Logger
+	db      *BytesConnectionRedis
+	name    string
+	keys    []string
+	owner   string
+	token   int64
+	ttl     time.Duration
+	lost    chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+// NewLocker creates a new instance of keyval.Locker that stores locks in Redis.
+// Each lock expires after DefaultLockTTL unless it is refreshed, which is done
+// in the background until the lock is released or the connection is closed.
+// <prefix> is prepended to the names of all locks.
+//
+// The lock key and the key with the fencing token share the same hash tag
+// (the prefixed lock name in curly braces), therefore the locker can be used
+// also with Redis cluster.
+func (db *BytesConnectionRedis) NewLocker(prefix string) keyval.Locker {
+	return &bytesLockerRedis{db: db, prefix: prefix, ttl: DefaultLockTTL}
+}
+
+// Lock acquires the lock with the given <name>. The call blocks until
+// either the lock is acquired or the context is canceled.
+func (l *bytesLockerRedis) Lock(ctx context.Context, name string) (keyval.Lock, error) {
+	for {
+		lock, err := l.TryLock(ctx, name)
+		if err != keyval.ErrLockHeld {
+			return lock, err
+		}
+		select {
+		case <-time.After(lockRetryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// TryLock attempts to acquire the lock with the given <name> without
+// waiting. If the lock is held by another owner, keyval.ErrLockHeld
+// is returned.
+func (l *bytesLockerRedis) TryLock(ctx context.Context, name string) (keyval.Lock, error) {
+	if l.db.closed {
+		return nil, fmt.Errorf("TryLock(%s) called on a closed connection", name)
+	}
+	key := l.prefix + name
+	l.db.Debugf("TryLock(%s)", key)
+
+	owner, err := newLockOwner()
+	if err != nil {
+		return nil, err
+	}
+	tag := "{" + key + "}"
+	keys := []string{tag, tag + ".fencing"}
+	token, err := acquireScript.Run(l.db.client, keys, owner, l.ttl.Nanoseconds()/int64(time.Millisecond)).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("TryLock(%s) failed: %s", key, err)
+	}
+	if token == 0 {
+		return nil, keyval.ErrLockHeld
+	}
+
+	lock := &bytesLockRedis{
+		Logger:  l.db.Logger,
+		db:      l.db,
+		name:    key,
+		keys:    keys,
+		owner:   owner,
+		token:   token,
+		ttl:     l.ttl,
+		lost:    make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	go lock.keepAlive()
+	return lock, nil
+}
+
+// keepAlive refreshes the expiration of the lock until the lock is released
+// or its ownership is lost.
+func (lock *bytesLockRedis) keepAlive() {
+	defer close(lock.lost)
+
+	ticker := time.NewTicker(lock.ttl / 3)
+	defer ticker.Stop()
+	lastRefresh := time.Now()
+
+	for {
+		select {
+		case <-lock.release:
+			return
+		case <-lock.db.closeCh:
+			lock.Warnf("Connection closed, lock %s ownership lost", lock.name)
+			return
+		case <-ticker.C:
+			refreshed, err := refreshScript.Run(lock.db.client, lock.keys[:1],
+				lock.owner, lock.ttl.Nanoseconds()/int64(time.Millisecond)).Int64()
+			if err != nil {
+				lock.Warnf("Failed to refresh lock %s: %v", lock.name, err)
+				if time.Since(lastRefresh) < lock.ttl {
+					continue
+				}
+			} else if refreshed != 0 {
+				lastRefresh = time.Now()
+				continue
+			}
+			lock.Warnf("Lock %s expired, ownership lost", lock.name)
+			return
+		}
+	}
+}
+
+// Name returns the name of the lock.
+func (lock *bytesLockRedis) Name() string {
+	return lock.name
+}
+
+// Token returns the value of the fencing counter of the lock at the time
+// the lock was acquired.
+func (lock *bytesLockRedis) Token() int64 {
+	return lock.token
+}
+
+// Lost returns a channel that is closed once the lock is released,
+// it expires or the connection is closed.
+func (lock *bytesLockRedis) Lost() <-chan struct{} {
+	return lock.lost
+}
+
+// Unlock releases the lock.
+func (lock *bytesLockRedis) Unlock(ctx context.Context) error {
+	err := keyval.ErrLockNotHeld
+	lock.once.Do(func() {
+		close(lock.release)
+		var released int64
+		released, err = releaseScript.Run(lock.db.client, lock.keys[:1], lock.owner).Int64()
+		if err != nil {
+			err = fmt.Errorf("Unlock(%s) failed: %s", lock.name, err)
+		} else if released == 0 {
+			err = keyval.ErrLockNotHeld
+		}
+		<-lock.lost
+	})
+	return err
+}
+
+// newLockOwner generates random identifier of the lock owner.
+func newLockOwner() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -500,6 +500,169 @@ func TestGetError(t *testing.T) {
 }
 */
 
+func TestAtomicOps(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	var broker keyval.BytesBrokerWithAtomic = bytesBrokerWatcher
+
+	succeeded, err := broker.PutIfNotExists("atomic", []byte("v1"))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(succeeded).Should(gomega.BeTrue())
+	succeeded, err = broker.PutIfNotExists("atomic", []byte("v2"))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(succeeded).Should(gomega.BeFalse())
+
+	swapped, err := broker.CompareAndSwap("atomic", []byte("v2"), []byte("v3"))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(swapped).Should(gomega.BeFalse())
+	swapped, err = broker.CompareAndSwap("atomic", []byte("v1"), []byte("v3"))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(swapped).Should(gomega.BeTrue())
+
+	val, found, _, err := broker.GetValue("atomic")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(found).Should(gomega.BeTrue())
+	gomega.Expect(val).Should(gomega.Equal([]byte("v3")))
+
+	deleted, err := broker.CompareAndDelete("atomic", []byte("v1"))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(deleted).Should(gomega.BeFalse())
+	deleted, err = broker.CompareAndDelete("atomic", []byte("v3"))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(deleted).Should(gomega.BeTrue())
+
+	_, found, _, err = broker.GetValue("atomic")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(found).Should(gomega.BeFalse())
+}
+
+func TestRevision(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	gomega.Expect(bytesBrokerWatcher.Put("revision", []byte("v1"))).Should(gomega.Succeed())
+	_, _, rev1, err := bytesBrokerWatcher.GetValue("revision")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(rev1).Should(gomega.BeNumerically(">", 0))
+
+	gomega.Expect(bytesBrokerWatcher.Put("revision", []byte("v2"))).Should(gomega.Succeed())
+	_, _, rev2, err := bytesBrokerWatcher.GetValue("revision")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(rev2).Should(gomega.BeNumerically(">", rev1))
+
+	// revision keeps increasing after the key is removed and created again within the retention period
+	found, err := bytesBrokerWatcher.Delete("revision")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(found).Should(gomega.BeTrue())
+	err = bytesBrokerWatcher.NewTxn().Put("revision", []byte("v3")).Commit(context.Background())
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+
+	keyVals, err := bytesBrokerWatcher.ListValues("revision")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	kv, last := keyVals.GetNext()
+	gomega.Expect(last).Should(gomega.BeFalse())
+	gomega.Expect(kv.GetKey()).Should(gomega.Equal("revision"))
+	gomega.Expect(kv.GetRevision()).Should(gomega.BeNumerically(">", rev2+1))
+	_, last = keyVals.GetNext()
+	gomega.Expect(last).Should(gomega.BeTrue())
+
+	// revision keys are not listed
+	keys, err := bytesConn.ListKeys("")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	for {
+		k, _, last := keys.GetNext()
+		if last {
+			break
+		}
+		gomega.Expect(k).ShouldNot(gomega.HavePrefix(revisionKeyPrefix))
+	}
+}
+
+func TestRevisionExpiry(t *testing.T) {
+	gomega.RegisterTestingT(t)
+	key := "revisionExpiry"
+
+	// revision key does not expire with the key
+	gomega.Expect(bytesConn.Put(key, []byte("v1"), datasync.WithTTL(ttl))).Should(gomega.Succeed())
+	_, _, rev1, err := bytesConn.GetValue(key)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(miniRedis.TTL(revisionKey(key))).Should(gomega.BeZero())
+	gomega.Expect(miniRedis.Exists(expiringKey(key))).Should(gomega.BeTrue())
+
+	// watchers of the expired key increment the revision only once
+	miniRedis.FastForward(ttl)
+	gomega.Expect(miniRedis.Exists(key)).Should(gomega.BeFalse())
+	for i := 0; i < 2; i++ {
+		rev, err := expiredScript.Run(bytesConn.client, scriptKeys(key)).Int64()
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		gomega.Expect(rev).Should(gomega.Equal(rev1 + 1))
+	}
+	gomega.Expect(miniRedis.Exists(expiringKey(key))).Should(gomega.BeFalse())
+
+	// revision keeps increasing when the deleted key is created again
+	gomega.Expect(bytesConn.Put(key, []byte("v2"))).Should(gomega.Succeed())
+	found, err := bytesConn.Delete(key)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(found).Should(gomega.BeTrue())
+	miniRedis.FastForward(24 * time.Hour)
+	gomega.Expect(bytesConn.Put(key, []byte("v3"))).Should(gomega.Succeed())
+	_, _, rev3, err := bytesConn.GetValue(key)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(rev3).Should(gomega.Equal(rev1 + 4))
+}
+
+func TestRevisionKey(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	for _, key := range []string{"key", "/vpp/config/{tag}/key", "{key"} {
+		gomega.Expect(getHashSlot(revisionKey(key))).Should(gomega.Equal(getHashSlot(key)), key)
+		gomega.Expect(getHashSlot(expiringKey(key))).Should(gomega.Equal(getHashSlot(key)), key)
+		gomega.Expect(expiringKey(key)).ShouldNot(gomega.Equal(revisionKey(key)))
+	}
+}
+
+func TestLocker(t *testing.T) {
+	gomega.RegisterTestingT(t)
+	ctx := context.Background()
+
+	locker := &bytesLockerRedis{db: bytesConn, prefix: "unit_test-locks/", ttl: 300 * time.Millisecond}
+
+	lock, err := locker.TryLock(ctx, "lock1")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(lock.Name()).Should(gomega.Equal("unit_test-locks/lock1"))
+	gomega.Expect(lock.Token()).Should(gomega.BeEquivalentTo(1))
+
+	_, err = locker.TryLock(ctx, "lock1")
+	gomega.Expect(err).Should(gomega.Equal(keyval.ErrLockHeld))
+
+	// lock is refreshed in the background
+	miniRedis.FastForward(200 * time.Millisecond)
+	gomega.Consistently(lock.Lost(), 400*time.Millisecond).ShouldNot(gomega.BeClosed())
+
+	acquired := make(chan keyval.Lock)
+	go func() {
+		second, err := locker.Lock(ctx, "lock1")
+		gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+		acquired <- second
+	}()
+	gomega.Consistently(acquired, 200*time.Millisecond).ShouldNot(gomega.Receive())
+
+	gomega.Expect(lock.Unlock(ctx)).Should(gomega.Succeed())
+	gomega.Expect(lock.Lost()).Should(gomega.BeClosed())
+	gomega.Expect(lock.Unlock(ctx)).Should(gomega.Equal(keyval.ErrLockNotHeld))
+
+	var second keyval.Lock
+	gomega.Eventually(acquired).Should(gomega.Receive(&second))
+	gomega.Expect(second.Token()).Should(gomega.BeEquivalentTo(2))
+
+	// lock expired and taken by another owner
+	miniRedis.FastForward(time.Second)
+	third, err := locker.TryLock(ctx, "lock1")
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Eventually(second.Lost()).Should(gomega.BeClosed())
+	gomega.Expect(second.Unlock(ctx)).Should(gomega.Equal(keyval.ErrLockNotHeld))
+	gomega.Expect(third.Unlock(ctx)).Should(gomega.Succeed())
+}
+
 func TestBrokerClosed(t *testing.T) {
 	gomega.RegisterTestingT(t)
 
@@ -64,7 +64,8 @@ func (tx *Txn) Delete(key string) keyval.BytesTxn {
 
 // Commit commits all operations in a transaction to the data store.
 // Commit is atomic - either all operations in the transaction are
-// committed to the data store, or none of them.
+// committed to the data store, or none of them. Revisions of the changed
+// keys are incremented within the same transaction.
 func (tx *Txn) Commit(ctx context.Context) (err error) {
 	if tx.db.closed {
 		return fmt.Errorf("Commit() called on a closed connection")
@@ -79,11 +80,13 @@ func (tx *Txn) Commit(ctx context.Context) (err error) {
 
 	pipeline := tx.db.client.TxPipeline()
 	for _, op := range tx.ops {
+		pipeline.Incr(revisionKey(op.key))
 		if op.del {
 			pipeline.Del(op.key)
 		} else {
 			pipeline.Set(op.key, op.value, 0)
 		}
+		pipeline.Del(expiringKey(op.key))
 	}
 	_, err = pipeline.Exec()
 	if err != nil {
@@ -126,16 +129,26 @@ func checkCrossSlot(tx *Txn) bool {
 }
 
 func getHashSlot(key string) uint16 {
-	var tag string
-	start := strings.Index(key, "{")
-	if start != -1 {
-		start++
-		tagSlice := key[start:]
-		end := strings.Index(tagSlice, "}")
-		if end != -1 {
-			tag = tagSlice[:end]
-		}
+	tag := hashTag(key)
+	if tag == "" {
+		tag = key
 	}
 	const redisHashSlotCount = 16384
 	return crc16.ChecksumCCITT([]byte(tag)) % redisHashSlotCount
 }
+
+// hashTag returns the hash tag of the key, i.e. the non-empty substring
+// between the first '{' and the first following '}', or empty string if
+// the key has no hash tag.
+func hashTag(key string) string {
+	start := strings.Index(key, "{")
+	if start == -1 {
+		return ""
+	}
+	start++
+	end := strings.Index(key[start:], "}")
+	if end == -1 {
+		return ""
+	}
+	return key[start : start+end]
+}
@@ -32,7 +32,7 @@ type BytesWatchPutResp struct {
 	key       string
 	value     []byte
 	prevValue []byte
-	rev       int64 // TODO Does Redis data have revision?
+	rev       int64
 }
 
 // NewBytesWatchPutResp creates an instance of BytesWatchPutResp.
@@ -68,7 +68,7 @@ func (resp *BytesWatchPutResp) GetRevision() int64 {
 // BytesWatchDelResp is sent when a key-value pair has been removed.
 type BytesWatchDelResp struct {
 	key string
-	rev int64 // TODO Does Redis data have revision?
+	rev int64
 }
 
 // NewBytesWatchDelResp creates an instance of BytesWatchDelResp.
@@ -163,6 +163,9 @@ func startWatch(db *BytesConnectionRedis, pubSub *goredis.PubSub,
 			db.Debugf("Receive %T: %s %s %s", msg, msg.Pattern, msg.Channel, msg.Payload)
 			key := msg.Channel[strings.Index(msg.Channel, ":")+1:]
 			db.Debugf("key = %s", key)
+			if isRevisionKey(key) {
+				continue
+			}
 			switch msg.Payload {
 			case "set":
 				// keyspace event does not carry value.  Need to retrieve it.
@@ -179,10 +182,20 @@ func startWatch(db *BytesConnectionRedis, pubSub *goredis.PubSub,
 				resp(NewBytesWatchPutResp(key, val, prevVal, rev))
 				prevVal = val
 			case "del", "expired":
+				var rev int64
+				if msg.Payload == "expired" {
+					// expiration is done by Redis, the revision is incremented by watchers
+					rev, err = expiredScript.Run(db.client, scriptKeys(key)).Int64()
+				} else {
+					rev, err = db.client.Get(revisionKey(key)).Int64()
+				}
+				if err != nil && err != GoRedisNil {
+					db.Errorf("revision of %s failed with error %s", key, err)
+				}
 				if trimPrefix != nil {
 					key = trimPrefix(key)
 				}
-				resp(NewBytesWatchDelResp(key, 0))
+				resp(NewBytesWatchDelResp(key, rev))
 			default:
 				db.Debugf("%T: %s %s %s -- not handled", msg, msg.Pattern, msg.Channel, msg.Payload)
 			}
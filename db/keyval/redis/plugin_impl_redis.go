@@ -104,6 +104,21 @@ func (p *Plugin) NewWatcher(keyPrefix string) keyval.ProtoWatcher {
 	return p.protoWrapper.NewWatcher(keyPrefix)
 }
 
+// NewBrokerWithAtomic creates new instance of prefixed (byte-oriented) broker with atomic operations.
+func (p *Plugin) NewBrokerWithAtomic(keyPrefix string) keyval.BytesBrokerWithAtomic {
+	return p.connection.NewBrokerWatcher(keyPrefix)
+}
+
+// NewLocker creates new instance of prefixed locker that stores locks in redis.
+func (p *Plugin) NewLocker(keyPrefix string) keyval.Locker {
+	return p.connection.NewLocker(keyPrefix)
+}
+
+// RawAccess allows to access data in the database as raw bytes (i.e. not formatted by protobuf).
+func (p *Plugin) RawAccess() keyval.KvBytesPlugin {
+	return p.connection
+}
+
 // Disabled returns *true* if the plugin is not in use due to missing
 // redis configuration.
 func (p *Plugin) Disabled() (disabled bool) {
@@ -15,6 +15,10 @@
 package cassandra
 
 import (
+	"context"
+	r "reflect"
+
+	"github.com/gocql/gocql"
 	"github.com/willfaught/gockle"
 
 	"go.ligato.io/cn-infra/v2/db/sql"
@@ -28,7 +32,14 @@ import (
 // db := NewBrokerUsingSession(session)
 // db.ListValues(...)
 func NewBrokerUsingSession(gocqlSession gockle.Session) *BrokerCassa {
-	return &BrokerCassa{session: gocqlSession}
+	return &BrokerCassa{session: gocqlSession, ctx: context.Background()}
+}
+
+// NewBrokerUsingGocqlSession is a Broker constructor for gocql session.
+// Unlike the broker using gockle.Session, queries of this broker can be
+// cancelled by context and consistency of transactions can be customized.
+func NewBrokerUsingGocqlSession(session *gocql.Session) *BrokerCassa {
+	return &BrokerCassa{session: gockle.NewSession(session), gocqlSession: session, ctx: context.Background()}
 }
 
 // BrokerCassa implements interface db.Broker. This implementation simplifies work with gocql in the way
@@ -36,11 +47,18 @@ func NewBrokerUsingSession(gocqlSession gockle.Session) *BrokerCassa {
 // The "SQL" queries are generated from the go structures (see more details in Put, Delete, Key, GetValue, ListValues).
 type BrokerCassa struct {
 	session gockle.Session
+	// gocqlSession is available only if the broker was created for gocql session
+	gocqlSession *gocql.Session
+	// ctx is used by methods without context (cancelled when the plugin is closed)
+	ctx context.Context
 }
 
 // ValIterator is an iterator returned by ListValues call
 type ValIterator struct {
 	Delegate gockle.Iterator
+
+	// number of rows to skip (see sql.OFFSET)
+	skip int
 }
 
 // ErrIterator is an iterator that stops immediately and just returns last error on Close()
@@ -57,15 +75,53 @@ func (pdb *BrokerCassa) Put(where sql.Expression, pointerToAStruct interface{} /
 	if err != nil {
 		return err
 	}
-	return pdb.session.Exec(statement, bindings...)
+	return pdb.exec(pdb.ctx, statement, bindings...)
 }
 
 // Exec - see the description in interface sql.Broker.ExecPut()
 // Exec runs statement (AS-IS) using gocql
 func (pdb *BrokerCassa) Exec(statement string, binding ...interface{}) error {
+	return pdb.exec(pdb.ctx, statement, binding...)
+}
+
+// withBrokerContext returns a context derived from <ctx> that is also
+// cancelled when the context of the broker is (i.e. when the plugin is closed).
+func (pdb *BrokerCassa) withBrokerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if pdb.ctx.Done() != nil {
+		go func() {
+			select {
+			case <-pdb.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+// exec runs statement using gocql session with <ctx> if available.
+func (pdb *BrokerCassa) exec(ctx context.Context, statement string, binding ...interface{}) error {
+	if pdb.gocqlSession != nil {
+		return pdb.gocqlSession.Query(statement, binding...).WithContext(ctx).Exec()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	return pdb.session.Exec(statement, binding...)
 }
 
+// scanIterator runs query using gocql session with <ctx> if available.
+func (pdb *BrokerCassa) scanIterator(ctx context.Context, statement string, binding ...interface{}) gockle.Iterator {
+	if pdb.gocqlSession != nil {
+		return &gocqlIterator{pdb.gocqlSession.Query(statement, binding...).WithContext(ctx).Iter()}
+	}
+	if err := ctx.Err(); err != nil {
+		return &errIterator{err}
+	}
+	return pdb.session.ScanIterator(statement, binding...)
+}
+
 // Delete - see the description in interface sql.Broker.ExecPut()
 // Delete generates statement & binding for gocql Exec()
 func (pdb *BrokerCassa) Delete(fromWhere sql.Expression) error {
@@ -73,13 +129,29 @@ func (pdb *BrokerCassa) Delete(fromWhere sql.Expression) error {
 	if err != nil {
 		return err
 	}
-	return pdb.session.Exec("DELETE"+statement, bindings...)
+	return pdb.exec(pdb.ctx, "DELETE"+statement, bindings...)
+}
+
+// Update - see the description in interface sql.Broker.Update()
+// Update generates statement & binding for gocql Exec()
+func (pdb *BrokerCassa) Update(update sql.Expression) error {
+	statement, bindings, err := ExpToString(update)
+	if err != nil {
+		return err
+	}
+	return pdb.exec(pdb.ctx, statement, bindings...)
 }
 
 // GetValue - see the description in interface sql.Broker.GetValue()
 // GetValue just iterate once for ListValues()
 func (pdb *BrokerCassa) GetValue(query sql.Expression, reqObj interface{}) (found bool, err error) {
-	it := pdb.ListValues(query)
+	return pdb.GetValueCtx(pdb.ctx, query, reqObj)
+}
+
+// GetValueCtx - see the description in interface sql.Broker.GetValueCtx()
+// The query is cancelled by the <ctx> only for broker using gocql session.
+func (pdb *BrokerCassa) GetValueCtx(ctx context.Context, query sql.Expression, reqObj interface{}) (found bool, err error) {
+	it := pdb.ListValuesCtx(ctx, query)
 	stop := it.GetNext(reqObj)
 	return !stop, it.Close()
 }
@@ -87,25 +159,50 @@ func (pdb *BrokerCassa) GetValue(query sql.Expression, reqObj interface{}) (foun
 // ListValues retrieves an iterator for elements stored under the provided key.
 // ListValues runs query (AS-IS) using gocql Scan Iterator.
 func (pdb *BrokerCassa) ListValues(query sql.Expression) sql.ValIterator {
-	queryStr, binding, err := SelectExpToString(query)
+	return pdb.ListValuesCtx(pdb.ctx, query)
+}
+
+// ListValuesCtx - see the description in interface sql.Broker.ListValuesCtx()
+// The query is cancelled by the <ctx> only for broker using gocql session.
+func (pdb *BrokerCassa) ListValuesCtx(ctx context.Context, query sql.Expression) sql.ValIterator {
+	queryStr, binding, offset, err := selectExpToString(query)
 	if err != nil {
 		return &ErrIterator{err}
 	}
 
-	it := pdb.session.ScanIterator(queryStr, binding...)
-	return &ValIterator{it}
+	it := pdb.scanIterator(ctx, queryStr, binding...)
+	return &ValIterator{Delegate: it, skip: offset}
 }
 
 // GetNext returns the following item from the result set. If data was returned, found is set to true.
 // argument "outVal" can be:
 // - pointer to structure
 // - map
+// - pointer to a value of single column result (e.g. sql.COUNT)
 func (it *ValIterator) GetNext(outVal interface{}) (stop bool) {
+	for ; it.skip > 0; it.skip-- {
+		var skipped interface{} = map[string]interface{}{}
+		if _, isMap := outVal.(map[string]interface{}); !isMap {
+			skipped = r.New(r.TypeOf(outVal).Elem()).Interface()
+		}
+		if stop := it.scan(skipped); stop {
+			return true
+		}
+	}
+	return it.scan(outVal)
+}
+
+func (it *ValIterator) scan(outVal interface{}) (stop bool) {
 	if m, ok := outVal.(map[string]interface{}); ok {
 		ok = it.Delegate.ScanMap(m)
 		return !ok //if not ok than stop
 	}
 
+	if val := r.Indirect(r.ValueOf(outVal)); val.Kind() != r.Struct || val.Type() == timeType {
+		ok := it.Delegate.Scan(outVal)
+		return !ok //if not ok than stop
+	}
+
 	_, ptrs := structs.ListExportedFieldsPtrs(outVal, cqlExported)
 	ok := it.Delegate.Scan(ptrs...)
 	return !ok //if not ok than stop
@@ -128,3 +225,37 @@ func (it *ErrIterator) GetNext(outVal interface{}) (stop bool) {
 func (it *ErrIterator) Close() error {
 	return it.LastError
 }
+
+// gocqlIterator adapts gocql.Iter to gockle.Iterator
+type gocqlIterator struct {
+	iter *gocql.Iter
+}
+
+func (it *gocqlIterator) Close() error {
+	return it.iter.Close()
+}
+
+func (it *gocqlIterator) Scan(results ...interface{}) bool {
+	return it.iter.Scan(results...)
+}
+
+func (it *gocqlIterator) ScanMap(results map[string]interface{}) bool {
+	return it.iter.MapScan(results)
+}
+
+// errIterator is gockle.Iterator that stops immediately and returns the error on Close()
+type errIterator struct {
+	err error
+}
+
+func (it *errIterator) Close() error {
+	return it.err
+}
+
+func (it *errIterator) Scan(results ...interface{}) bool {
+	return false
+}
+
+func (it *errIterator) ScanMap(results map[string]interface{}) bool {
+	return false
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra_test
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+	"go.ligato.io/cn-infra/v2/db/sql/cassandra"
+)
+
+// TestOrderByLimit checks generated ORDER BY & LIMIT clauses
+func TestOrderByLimit(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	query := sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.LastName, sql.EQ("Bond"))),
+		sql.ORDER_BY(sql.DESC(&UserTable.ID), sql.Field(&UserTable.FirstName)), sql.LIMIT(10))
+	sqlStr, binding, err := cassandra.SelectExpToString(query)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(sqlStr).Should(gomega.BeEquivalentTo(
+		"SELECT id, first_name, last_name FROM User WHERE last_name = ? ORDER BY id DESC, first_name LIMIT ?"))
+	gomega.Expect(binding).Should(gomega.Equal([]interface{}{"Bond", 10}))
+}
+
+// TestListValuesOffset checks that OFFSET is added to LIMIT and rows are skipped by the iterator
+func TestListValuesOffset(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	session := mockSession()
+	defer session.Close()
+	db := cassandra.NewBrokerUsingSession(session)
+
+	query := sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.LastName, sql.EQ("Bond"))),
+		sql.LIMIT(1), sql.OFFSET(1))
+	sqlStr, binding, err := cassandra.SelectExpToString(query)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(sqlStr).Should(gomega.BeEquivalentTo(
+		"SELECT id, first_name, last_name FROM User WHERE last_name = ? LIMIT ?"))
+	gomega.Expect(binding).Should(gomega.Equal([]interface{}{"Bond", 2}))
+
+	mockQuery(session, query, cells(JamesBond), cells(PeterBond))
+
+	users := &[]User{}
+	err = sql.SliceIt(users, db.ListValues(query))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(*users).Should(gomega.Equal([]User{*PeterBond}))
+}
+
+// TestCountAndAggregates checks generated COUNT & aggregate queries
+func TestCountAndAggregates(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	sqlStr, binding, err := cassandra.SelectExpToString(sql.COUNT(UserTable,
+		sql.WHERE(sql.Field(&UserTable.LastName, sql.EQ("Bond")))))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(sqlStr).Should(gomega.BeEquivalentTo("SELECT COUNT(*) FROM User WHERE last_name = ?"))
+	gomega.Expect(binding).Should(gomega.Equal([]interface{}{"Bond"}))
+
+	sqlStr, _, err = cassandra.SelectExpToString(sql.MAX(UserTable, &UserTable.FirstName))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(sqlStr).Should(gomega.BeEquivalentTo("SELECT MAX(first_name) FROM User"))
+}
+
+// TestUpdate checks partial update of selected fields
+func TestUpdate(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	session := mockSession()
+	defer session.Close()
+	db := cassandra.NewBrokerUsingSession(session)
+
+	update := sql.UPDATE(JamesBond, sql.SET(&JamesBond.FirstName, &JamesBond.LastName),
+		sql.WHERE(sql.PK(&JamesBond.ID)))
+	sqlStr, binding, err := cassandra.ExpToString(update)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(sqlStr).Should(gomega.BeEquivalentTo(
+		"UPDATE User SET first_name = ?, last_name = ? WHERE id = ?"))
+	gomega.Expect(binding).Should(gomega.HaveLen(3))
+
+	mockExec(session, sqlStr, binding)
+	err = db.Update(update)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+}
+
+// TestLikeAndIsNull checks LIKE operator & unsupported IS NULL and IS NOT NULL
+func TestLikeAndIsNull(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	sqlStr, _, err := cassandra.SelectExpToString(sql.FROM(UserTable,
+		sql.WHERE(sql.Field(&UserTable.LastName, sql.LIKE("Bo%")))))
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(sqlStr).Should(gomega.BeEquivalentTo(
+		"SELECT id, first_name, last_name FROM User WHERE last_name LIKE ?"))
+
+	_, _, err = cassandra.SelectExpToString(sql.FROM(UserTable,
+		sql.WHERE(sql.Field(&UserTable.LastName, sql.IS_NULL()))))
+	gomega.Expect(err).Should(gomega.Equal(cassandra.ErrUnsupportedExpression))
+
+	_, _, err = cassandra.SelectExpToString(sql.FROM(UserTable,
+		sql.WHERE(sql.Field(&UserTable.LastName, sql.IS_NOT_NULL()))))
+	gomega.Expect(err).Should(gomega.Equal(cassandra.ErrUnsupportedExpression))
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+	"go.ligato.io/cn-infra/v2/db/sql/cassandra"
+)
+
+// Device structure for testing of column types
+type Device struct {
+	ID       gocql.UUID        `cql:"id" pk:"id"`
+	Created  time.Time         `cql:"created" pk:"created"`
+	Address  net.IP            `cql:"address"`
+	Ports    []int32           `cql:"ports"`
+	Labels   map[string]string `cql:"labels"`
+	Firmware []byte            `cql:"firmware"`
+	Name     string            `cql:"name" dbtype:"ascii"`
+}
+
+// TestCreateTable checks generated CREATE TABLE statements
+func TestCreateTable(t *testing.T) {
+	gomega.RegisterTestingT(t)
+	schema := cassandra.NewSchemaBuilder()
+
+	statement, err := schema.CreateTable(&User{})
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(statement).Should(gomega.BeEquivalentTo(
+		"CREATE TABLE IF NOT EXISTS User (id text, first_name text, last_name text, PRIMARY KEY (id))"))
+
+	statement, err = schema.CreateTable(&CustomizedTablenameAndSchema{})
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(statement).Should(gomega.BeEquivalentTo(
+		"CREATE TABLE IF NOT EXISTS my_custom_schema.my_custom_name (id text, last_name text, PRIMARY KEY (id))"))
+
+	statement, err = schema.CreateTable(&Device{})
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(statement).Should(gomega.BeEquivalentTo(
+		"CREATE TABLE IF NOT EXISTS Device (id uuid, created timestamp, address inet, ports list<int>, " +
+			"labels map<text, text>, firmware blob, name ascii, PRIMARY KEY (id, created))"))
+
+	_, err = schema.CreateTable(&struct{ Name string }{})
+	gomega.Expect(err).Should(gomega.Equal(sql.ErrMissingPrimaryKey))
+}
+
+// TestCreateIndex checks generated CREATE INDEX & DROP TABLE statements
+func TestCreateIndex(t *testing.T) {
+	gomega.RegisterTestingT(t)
+	schema := cassandra.NewSchemaBuilder()
+
+	statement, err := schema.CreateIndex(UserTable, &UserTable.LastName)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(statement).Should(gomega.BeEquivalentTo(
+		"CREATE INDEX IF NOT EXISTS User_last_name_idx ON User (last_name)"))
+
+	entity := &CustomizedTablenameAndSchema{}
+	statement, err = schema.CreateIndex(entity, &entity.LastName)
+	gomega.Expect(err).ShouldNot(gomega.HaveOccurred())
+	gomega.Expect(statement).Should(gomega.BeEquivalentTo(
+		"CREATE INDEX IF NOT EXISTS my_custom_name_last_name_idx ON my_custom_schema.my_custom_name (last_name)"))
+
+	_, err = schema.CreateIndex(UserTable, &UserTable.ExportedButNotCql)
+	gomega.Expect(err).Should(gomega.HaveOccurred())
+
+	gomega.Expect(schema.DropTable(entity)).Should(gomega.BeEquivalentTo(
+		"DROP TABLE IF EXISTS my_custom_schema.my_custom_name"))
+	gomega.Expect(cassandra.CreateKeyspace("my_custom_schema", 3)).Should(gomega.BeEquivalentTo(
+		"CREATE KEYSPACE IF NOT EXISTS my_custom_schema WITH replication = " +
+			"{'class': 'SimpleStrategy', 'replication_factor': 3}"))
+}
@@ -15,15 +15,126 @@
 package cassandra
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/willfaught/gockle"
+
 	"go.ligato.io/cn-infra/v2/db/sql"
 )
 
+// Txn groups put & delete operations into Cassandra batch, which is executed
+// on Commit. The batch type and the consistency level can be set by
+// sql.WithBatchType and sql.WithConsistency options.
+type Txn struct {
+	broker     *BrokerCassa
+	options    sql.TxnOptions
+	statements []batchStatement
+	err        error
+	done       bool
+}
+
+type batchStatement struct {
+	statement string
+	bindings  []interface{}
+}
+
 // NewTxn creates a new Data Broker transaction. A transaction can
 // hold multiple operations that are all committed to the data
 // store together. After a transaction has been created, one or
 // more operations (put or delete) can be added to the transaction
 // before it is committed.
-func (pdb *BrokerCassa) NewTxn() sql.Txn {
-	// TODO Cassandra Batch/TXN
-	panic("not implemented")
+func (pdb *BrokerCassa) NewTxn(opts ...sql.TxnOption) sql.Txn {
+	return &Txn{broker: pdb, options: sql.NewTxnOptions(opts...)}
+}
+
+// Put adds update statement (see BrokerCassa.Put) into the batch.
+func (txn *Txn) Put(where sql.Expression, data interface{}) sql.Txn {
+	statement, bindings, err := PutExpToString(where, data)
+	txn.add(statement, bindings, err)
+	return txn
+}
+
+// Delete adds delete statement (see BrokerCassa.Delete) into the batch.
+func (txn *Txn) Delete(fromWhere sql.Expression) sql.Txn {
+	statement, bindings, err := ExpToString(fromWhere)
+	txn.add("DELETE"+statement, bindings, err)
+	return txn
+}
+
+func (txn *Txn) add(statement string, bindings []interface{}, err error) {
+	if err != nil {
+		if txn.err == nil {
+			txn.err = err
+		}
+		return
+	}
+	txn.statements = append(txn.statements, batchStatement{statement, bindings})
+}
+
+// Commit executes the batch. The consistency level and the <ctx> are used
+// only by broker using gocql session (see NewBrokerUsingGocqlSession).
+// The commit in progress is also cancelled when the plugin is closed.
+func (txn *Txn) Commit(ctx context.Context) error {
+	if txn.done {
+		return sql.ErrTxnDone
+	}
+	txn.done = true
+	ctx, cancel := txn.broker.withBrokerContext(ctx)
+	defer cancel()
+	if txn.err != nil {
+		return txn.err
+	}
+	batchType, err := parseBatchType(txn.options.BatchType)
+	if err != nil {
+		return err
+	}
+
+	if session := txn.broker.gocqlSession; session != nil {
+		batch := session.NewBatch(batchType).WithContext(ctx)
+		if txn.options.Consistency != "" {
+			consistency, err := gocql.ParseConsistencyWrapper(txn.options.Consistency)
+			if err != nil {
+				return err
+			}
+			batch.SetConsistency(consistency)
+		}
+		for _, stmt := range txn.statements {
+			batch.Query(stmt.statement, stmt.bindings...)
+		}
+		return session.ExecuteBatch(batch)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	batch := txn.broker.session.Batch(gockle.BatchKind(batchType))
+	for _, stmt := range txn.statements {
+		batch.Add(stmt.statement, stmt.bindings...)
+	}
+	return batch.Exec()
+}
+
+// Rollback discards the batch.
+func (txn *Txn) Rollback() error {
+	if txn.done {
+		return sql.ErrTxnDone
+	}
+	txn.done = true
+	txn.statements = nil
+	return nil
+}
+
+func parseBatchType(batchType string) (gocql.BatchType, error) {
+	switch strings.ToUpper(batchType) {
+	case "", "LOGGED":
+		return gocql.LoggedBatch, nil
+	case "UNLOGGED":
+		return gocql.UnloggedBatch, nil
+	case "COUNTER":
+		return gocql.CounterBatch, nil
+	}
+	return 0, fmt.Errorf("invalid batch type %q", batchType)
 }
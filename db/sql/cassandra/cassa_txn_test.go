@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/maraino/go-mock"
+	"github.com/onsi/gomega"
+	"github.com/willfaught/gockle"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+	"go.ligato.io/cn-infra/v2/db/sql/cassandra"
+)
+
+// mockBatch is a helper for testing. It setups mock batch of given kind
+func mockBatch(sessionMock *gockle.SessionMock, kind gocql.BatchType) *gockle.BatchMock {
+	batchMock := &gockle.BatchMock{}
+	batchMock.When("Add", mock.Any, mock.Any).Return()
+	batchMock.When("Exec").Return(nil)
+	sessionMock.When("Batch", gockle.BatchKind(kind)).Return(batchMock)
+	return batchMock
+}
+
+// TestTxnCommit checks that all statements of the transaction are executed in one batch
+func TestTxnCommit(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	session := mockSession()
+	defer session.Close()
+	db := cassandra.NewBrokerUsingSession(session)
+	batch := mockBatch(session, gocql.UnloggedBatch)
+
+	txn := db.NewTxn(sql.WithBatchType("unlogged")).
+		Put(sql.FieldEQ(&JamesBond.ID), JamesBond).
+		Delete(sql.FROM(PeterBond, sql.WHERE(sql.FieldEQ(&PeterBond.ID))))
+	gomega.Expect(txn.Commit(context.Background())).To(gomega.Succeed())
+	gomega.Expect(batch.Verify()).To(gomega.BeTrue())
+	gomega.Expect(txn.Commit(context.Background())).To(gomega.Equal(sql.ErrTxnDone))
+}
+
+// TestTxnRollback checks that rolled back transaction can not be committed
+func TestTxnRollback(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	session := mockSession()
+	defer session.Close()
+	db := cassandra.NewBrokerUsingSession(session)
+
+	txn := db.NewTxn().Put(sql.FieldEQ(&JamesBond.ID), JamesBond)
+	gomega.Expect(txn.Rollback()).To(gomega.Succeed())
+	gomega.Expect(txn.Rollback()).To(gomega.Equal(sql.ErrTxnDone))
+	gomega.Expect(txn.Commit(context.Background())).To(gomega.Equal(sql.ErrTxnDone))
+}
+
+// TestTxnErrors checks invalid batch type and cancelled context
+func TestTxnErrors(t *testing.T) {
+	gomega.RegisterTestingT(t)
+
+	session := mockSession()
+	defer session.Close()
+	db := cassandra.NewBrokerUsingSession(session)
+
+	err := db.NewTxn(sql.WithBatchType("partial")).
+		Put(sql.FieldEQ(&JamesBond.ID), JamesBond).
+		Commit(context.Background())
+	gomega.Expect(err).To(gomega.HaveOccurred())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = db.NewTxn().Put(sql.FieldEQ(&JamesBond.ID), JamesBond).Commit(ctx)
+	gomega.Expect(err).To(gomega.Equal(context.Canceled))
+
+	_, err = db.GetValueCtx(ctx, sql.FROM(JamesBond, sql.WHERE(sql.FieldEQ(&JamesBond.ID))), &User{})
+	gomega.Expect(err).To(gomega.Equal(context.Canceled))
+}
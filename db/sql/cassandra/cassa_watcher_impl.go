@@ -14,9 +14,15 @@
 
 package cassandra
 
-/*TODO use https://issues.apache.org/jira/browse/CASSANDRA-8844
-type WatcherCassa struct {
-	watcher    keyval.BytesWatcher
-	serializer keyval.Serializer
+import (
+	"time"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+)
+
+// NewWatcher returns watcher that polls Cassandra every <interval> and delivers
+// changes of the watched tables or queries (see sql.PollingWatcher).
+// Rows are identified by values of <keyColumns> (typically the primary key).
+func (pdb *BrokerCassa) NewWatcher(interval time.Duration, keyColumns ...string) *sql.PollingWatcher {
+	return sql.NewPollingWatcher(pdb, "cql", interval, keyColumns...)
 }
-*/
@@ -59,6 +59,12 @@ type Config struct {
 
 	//TLS used to configure TLS
 	TLS TLS `json:"tls"`
+
+	// interval between polls of watched tables (default: 1s)
+	WatchPollInterval time.Duration `json:"watch_poll_interval"`
+
+	// maximal number of rows of a watched table or query (default: 10000)
+	WatchMaxRows int `json:"watch_max_rows"`
 }
 
 // ClientConfig wrapping gocql ClusterConfig
@@ -15,8 +15,12 @@
 package cassandra
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"time"
 
+	"github.com/gocql/gocql"
 	"github.com/willfaught/gockle"
 
 	"go.ligato.io/cn-infra/v2/db/sql"
@@ -36,6 +40,16 @@ type Plugin struct {
 
 	clientConfig *ClientConfig
 	session      gockle.Session
+	gocqlSession *gocql.Session
+
+	// ctx is cancelled on Close to interrupt queries in progress
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	watchPollInterval time.Duration
+	watchMaxRows      int
+	watchersMu        sync.Mutex
+	watchers          []*sql.PollingWatcher
 }
 
 // Deps is here to group injected dependencies of plugin
@@ -55,12 +69,18 @@ var (
 	// ErrUnexportedEntityField is error returned when visitor entity has unexported field.
 	ErrUnexportedEntityField = errors.New("cassandra: visitor entity with unexported field")
 
+	// ErrUnsupportedExpression is error returned for expression that cannot be expressed in CQL.
+	ErrUnsupportedExpression = errors.New("cassandra: expression not supported by CQL")
+
 	// ErrInvalidEndpointConfig is error returned when endpoint and port are not in valid format.
 	ErrInvalidEndpointConfig = errors.New("cassandra: invalid configuration, endpoint and port not in valid format")
 )
 
 // Init is called at plugin startup. The session to Cassandra is established.
 func (p *Plugin) Init() (err error) {
+	if p.ctx == nil {
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+	}
 	if p.session != nil {
 		return nil // skip initialization
 	}
@@ -78,6 +98,9 @@ func (p *Plugin) Init() (err error) {
 		return err
 	}
 
+	p.watchPollInterval = cfg.WatchPollInterval
+	p.watchMaxRows = cfg.WatchMaxRows
+
 	// Init session
 	p.clientConfig, err = ConfigToClientConfig(&cfg)
 	if err != nil {
@@ -90,6 +113,7 @@ func (p *Plugin) Init() (err error) {
 			return err
 		}
 
+		p.gocqlSession = session
 		p.session = gockle.NewSession(session)
 	}
 
@@ -115,16 +139,51 @@ func (p *Plugin) AfterInit() error {
 
 // FromExistingSession is used mainly for testing
 func FromExistingSession(session gockle.Session) *Plugin {
-	return &Plugin{session: session}
+	p := &Plugin{session: session}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p
 }
 
-// NewBroker returns a Broker instance to work with Cassandra Data Base
+// NewBroker returns a Broker instance to work with Cassandra Data Base.
+// Queries of the broker are cancelled when the plugin is closed.
 func (p *Plugin) NewBroker() sql.Broker {
-	return NewBrokerUsingSession(p.session)
+	return p.newBroker()
+}
+
+func (p *Plugin) newBroker() *BrokerCassa {
+	broker := NewBrokerUsingSession(p.session)
+	if p.gocqlSession != nil {
+		broker = NewBrokerUsingGocqlSession(p.gocqlSession)
+	}
+	if p.ctx != nil {
+		broker.ctx = p.ctx
+	}
+	return broker
+}
+
+// NewWatcher returns watcher that polls the watched tables or queries
+// (see sql.PollingWatcher). Rows are identified by values of <keyColumns>.
+func (p *Plugin) NewWatcher(keyColumns ...string) sql.Watcher {
+	watcher := p.newBroker().NewWatcher(p.watchPollInterval, keyColumns...)
+	if p.watchMaxRows != 0 {
+		watcher.SetMaxRows(p.watchMaxRows)
+	}
+	p.watchersMu.Lock()
+	p.watchers = append(p.watchers, watcher)
+	p.watchersMu.Unlock()
+	return watcher
 }
 
 // Close resources
 func (p *Plugin) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.watchersMu.Lock()
+	for _, watcher := range p.watchers {
+		watcher.Close()
+	}
+	p.watchersMu.Unlock()
 	safeclose.Close(p.session)
 	return nil
 }
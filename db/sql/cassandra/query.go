@@ -49,16 +49,23 @@ func PutExpToString(whereCondition sql.Expression, entity interface{}) (sqlStr s
 // SelectExpToString converts expression to string & slice of bindings
 func SelectExpToString(fromWhere sql.Expression) (sqlStr string, bindings []interface{},
 	err error) {
+	sqlStr, bindings, _, err = selectExpToString(fromWhere)
+	return sqlStr, bindings, err
+}
+
+// selectExpToString converts expression to string & slice of bindings.
+// Since CQL does not support OFFSET, it is added to the LIMIT and returned
+// as number of rows that need to be skipped by the iterator.
+func selectExpToString(fromWhere sql.Expression) (sqlStr string, bindings []interface{}, offset int,
+	err error) {
 
 	findEntity := &findEntityVisitor{}
 	fromWhere.Accept(findEntity)
 
-	fromWhereStr := &toStringVisitor{entity: findEntity.entity}
+	fromWhereStr := &toStringVisitor{entity: findEntity.entity, limitIndex: -1}
 	fromWhere.Accept(fromWhereStr)
-
-	fieldsStr := selectFields(findEntity.entity)
-	if err != nil {
-		return "", nil, err
+	if err := fromWhereStr.finish(); err != nil {
+		return "", nil, 0, err
 	}
 	fromWhereBindings := fromWhereStr.Binding()
 
@@ -66,7 +73,12 @@ func SelectExpToString(fromWhere sql.Expression) (sqlStr string, bindings []inte
 	if strings.Contains(whereStr, "AND") {
 		whereStr = whereStr + " ALLOW FILTERING"
 	}
-	return "SELECT " + fieldsStr + whereStr, fromWhereBindings, nil
+	if exp, ok := fromWhere.(*sql.PrefixedExp); ok && strings.HasPrefix(exp.Prefix, "SELECT ") {
+		return whereStr, fromWhereBindings, fromWhereStr.offset, nil // aggregate
+	}
+
+	fieldsStr := selectFields(findEntity.entity)
+	return "SELECT " + fieldsStr + whereStr, fromWhereBindings, fromWhereStr.offset, nil
 }
 
 // ExpToString converts expression to string & slice of bindings
@@ -74,10 +86,10 @@ func ExpToString(exp sql.Expression) (sql string, bindings []interface{}, err er
 	findEntity := &findEntityVisitor{}
 	exp.Accept(findEntity)
 
-	stringer := &toStringVisitor{entity: findEntity.entity}
+	stringer := &toStringVisitor{entity: findEntity.entity, limitIndex: -1}
 	exp.Accept(stringer)
 
-	return stringer.String(), stringer.Binding(), stringer.lastError
+	return stringer.String(), stringer.Binding(), stringer.finish()
 }
 
 type toStringVisitor struct {
@@ -85,6 +97,23 @@ type toStringVisitor struct {
 	generated bytes.Buffer
 	binding   []interface{}
 	lastError error
+
+	// index of LIMIT binding (-1 if LIMIT is not used) & value of OFFSET
+	limitIndex int
+	offset     int
+}
+
+// finish adds OFFSET to the LIMIT (see selectExpToString)
+func (visitor *toStringVisitor) finish() error {
+	if visitor.lastError != nil || visitor.offset == 0 || visitor.limitIndex < 0 {
+		return visitor.lastError
+	}
+	limit, ok := visitor.binding[visitor.limitIndex].(int)
+	if !ok {
+		return ErrUnsupportedExpression
+	}
+	visitor.binding[visitor.limitIndex] = limit + visitor.offset
+	return nil
 }
 
 // String converts generated byte Buffer to string
@@ -99,20 +128,43 @@ func (visitor *toStringVisitor) Binding() []interface{} {
 
 // VisitPrefixedExp generates part of SQL expression
 func (visitor *toStringVisitor) VisitPrefixedExp(exp *sql.PrefixedExp) {
-	if exp.Prefix == "FROM" {
+	switch exp.Prefix {
+	case "FROM":
 		visitor.generated.WriteString(" FROM ")
 		visitor.generated.WriteString(sql.EntityTableName(visitor.entity))
-	} else {
+	case "UPDATE":
+		visitor.generated.WriteString("UPDATE ")
+		visitor.generated.WriteString(sql.EntityTableName(visitor.entity))
+	case " LIMIT ":
+		visitor.limitIndex = len(visitor.binding)
 		visitor.generated.WriteString(exp.Prefix)
-	}
-	if exp.AfterPrefix != nil {
+	case " OFFSET ":
+		// CQL does not support OFFSET, rows are skipped by the iterator
 		for _, exp := range exp.AfterPrefix {
+			for _, binding := range exp.GetBinding() {
+				offset, ok := binding.(int)
+				if !ok {
+					visitor.lastError = ErrUnsupportedExpression
+				}
+				visitor.offset += offset
+			}
+		}
+		return
+	case " IS NULL", " IS NOT NULL":
+		// CQL supports IS NOT NULL only in materialized views
+		visitor.lastError = ErrUnsupportedExpression
+		return
+	default:
+		visitor.generated.WriteString(exp.Prefix)
+	}
+	for _, exp := range exp.AfterPrefix {
+		if exp != nil {
 			exp.Accept(visitor)
 		}
 	}
 	visitor.generated.WriteString(exp.Suffix)
 
-	if exp.Prefix != "FROM" && exp.Binding != nil && len(exp.Binding) > 0 {
+	if exp.Prefix != "FROM" && exp.Prefix != "UPDATE" && exp.Binding != nil && len(exp.Binding) > 0 {
 		if visitor.binding != nil {
 			visitor.binding = append(visitor.binding, exp.Binding...)
 		} else {
@@ -147,36 +199,17 @@ func (visitor *toStringVisitor) VisitFieldExpression(exp *sql.FieldExpression) {
 
 // cqlExported checks the cql tag in StructField and parses the field name
 func cqlExported(field *r.StructField) (exported bool) {
-	cql := field.Tag.Get("cql")
-	if len(cql) > 0 {
-		if cql == "-" {
-			return false
-		}
-		return true
-	}
-	return true
+	return field.Tag.Get("cql") != "-"
 }
 
 // cqlExportedWithFieldName checks the cql tag in StructField and parses the field name
 func cqlExportedWithFieldName(field *r.StructField) (fieldName string, exported bool) {
-	cql := field.Tag.Get("cql")
-	if len(cql) > 0 {
-		if cql == "-" {
-			return cql, false
-		}
-		return cql, true
-	}
-	return field.Name, true
+	return sql.FieldColumnName(field, "cql")
 }
 
 // isFieldPK checks the pk tag in StructField and parses the field name
 func isFieldPK(field *r.StructField) (isPK bool) {
-	result := false
-	pk := field.Tag.Get("pk")
-	if len(pk) > 0 {
-		result = true
-	}
-	return result
+	return sql.IsPrimaryKey(field)
 }
 
 // filterOutPK used to filter out primary key from update statements only
@@ -185,11 +218,6 @@ func filterOutPK(field *r.StructField) (filterPK bool) {
 }
 
 func fieldName(field *r.StructField) (name string, exported bool) {
-	structExported := structs.FieldExported(field)
-	if !structExported {
-		return field.Name, structExported
-	}
-
 	return cqlExportedWithFieldName(field)
 }
 
@@ -288,15 +316,17 @@ type findEntityVisitor struct {
 	entity interface{}
 }
 
-// VisitPrefixedExp checks for "FROM" expression to find out the entity
+// VisitPrefixedExp checks for "FROM" or "UPDATE" expression to find out the entity
 func (visitor *findEntityVisitor) VisitPrefixedExp(exp *sql.PrefixedExp) {
-	if exp.Prefix == "FROM" {
+	if exp.Prefix == "FROM" || exp.Prefix == "UPDATE" {
 		if len(exp.Binding) == 1 && r.Indirect(r.ValueOf(exp.Binding[0])).Kind() == r.Struct {
 			visitor.entity = exp.Binding[0]
 		}
-	} else if exp.AfterPrefix != nil {
+	} else {
 		for _, exp := range exp.AfterPrefix {
-			exp.Accept(visitor)
+			if exp != nil {
+				exp.Accept(visitor)
+			}
 		}
 	}
 }
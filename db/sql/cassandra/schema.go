@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"fmt"
+	"net"
+	r "reflect"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+)
+
+// NewSchemaBuilder returns sql.SchemaBuilder that generates CQL DDL statements.
+// Column types are mapped from go types unless overridden by the sql.ColumnTypeTag.
+// The schema name of an entity (see sql.SchemaName) is the keyspace of the table.
+func NewSchemaBuilder() sql.SchemaBuilder {
+	return &schemaBuilder{}
+}
+
+// CreateKeyspace generates CREATE KEYSPACE IF NOT EXISTS statement
+// for the <keyspace> using SimpleStrategy with the <replicationFactor>.
+func CreateKeyspace(keyspace string, replicationFactor int) string {
+	return fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH replication = "+
+		"{'class': 'SimpleStrategy', 'replication_factor': %d}", keyspace, replicationFactor)
+}
+
+type schemaBuilder struct{}
+
+// CreateTable - see the description in interface sql.SchemaBuilder.CreateTable().
+func (s *schemaBuilder) CreateTable(entity interface{}) (string, error) {
+	return sql.CreateTableStatement(entity, "cql", cqlType)
+}
+
+// CreateIndex - see the description in interface sql.SchemaBuilder.CreateIndex().
+func (s *schemaBuilder) CreateIndex(entity interface{}, pointerToAField interface{}) (string, error) {
+	return sql.CreateIndexStatement(entity, pointerToAField, "cql")
+}
+
+// DropTable - see the description in interface sql.SchemaBuilder.DropTable().
+func (s *schemaBuilder) DropTable(entity interface{}) string {
+	return sql.DropTableStatement(entity)
+}
+
+var (
+	timeType = r.TypeOf(time.Time{})
+	uuidType = r.TypeOf(gocql.UUID{})
+	ipType   = r.TypeOf(net.IP{})
+)
+
+// cqlType maps the go type to CQL type (see marshalling of gocql)
+func cqlType(t r.Type) (string, error) {
+	if t.Kind() == r.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return "timestamp", nil
+	case uuidType:
+		return "uuid", nil
+	case ipType:
+		return "inet", nil
+	}
+
+	switch t.Kind() {
+	case r.Bool:
+		return "boolean", nil
+	case r.Int8:
+		return "tinyint", nil
+	case r.Int16:
+		return "smallint", nil
+	case r.Int32:
+		return "int", nil
+	case r.Int, r.Int64:
+		return "bigint", nil
+	case r.Float32:
+		return "float", nil
+	case r.Float64:
+		return "double", nil
+	case r.String:
+		return "text", nil
+	case r.Slice, r.Array:
+		if t.Elem().Kind() == r.Uint8 {
+			return "blob", nil
+		}
+		elem, err := cqlType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "list<" + elem + ">", nil
+	case r.Map:
+		key, err := cqlType(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := cqlType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "map<" + key + ", " + elem + ">", nil
+	}
+	return "", fmt.Errorf("unsupported column type %v", t)
+}
@@ -15,6 +15,9 @@
 package sql
 
 import (
+	"context"
+	gosql "database/sql"
+	"errors"
 	"io"
 )
 
@@ -28,8 +31,9 @@ type Broker interface {
 	//
 	Put(where Expression, inBinding interface{} /* TODO opts ...PutOption*/) error
 
-	// NewTxn creates a transaction / batch.
-	NewTxn() Txn
+	// NewTxn creates a transaction / batch customized by the options
+	// (see WithIsolation, WithConsistency, WithBatchType).
+	NewTxn(opts ...TxnOption) Txn
 
 	// GetValue retrieves one item based on the <query>. If the item exists,
 	// it is un-marshaled into the <outBinding>.
@@ -48,6 +52,9 @@ type Broker interface {
 	//
 	GetValue(query Expression, outBinding interface{}) (found bool, err error)
 
+	// GetValueCtx is like GetValue, but the query can be cancelled by the <ctx>.
+	GetValueCtx(ctx context.Context, query Expression, outBinding interface{}) (found bool, err error)
+
 	// ListValues returns an iterator that enables traversing all items
 	// returned by the <query>.
 	// Use utilities to:
@@ -76,6 +83,9 @@ type Broker interface {
 	//
 	ListValues(query Expression) ValIterator
 
+	// ListValuesCtx is like ListValues, but the query can be cancelled by the <ctx>.
+	ListValuesCtx(ctx context.Context, query Expression) ValIterator
+
 	// Delete removes data from the data store.
 	// Example usage 1:
 	//
@@ -93,6 +103,14 @@ type Broker interface {
 	//
 	Delete(fromWhere Expression) error
 
+	// Update updates selected fields of the rows matching the condition.
+	// Example usage:
+	//
+	//    JamesBond.Age = 42
+	//    err := db.Update(sql.UPDATE(JamesBond, sql.SET(&JamesBond.Age), sql.WHERE(sql.PK(&JamesBond.ID))))
+	//
+	Update(update Expression) error
+
 	// Executes the SQL statement (can be used, for example, to create
 	// "table/type" if not exits...)
 	// Example usage:
@@ -123,6 +141,68 @@ type Txn interface {
 	Put(where Expression, data interface{}) Txn
 	// Delete adds delete operation into the transaction.
 	Delete(fromWhere Expression) Txn
-	// Commit tries to commit the transaction.
-	Commit() error
+	// Commit tries to commit the transaction. The <ctx> can cancel
+	// the commit in progress (the transaction is then rolled back).
+	// The commit in progress is also cancelled when the plugin
+	// providing the broker is closed.
+	Commit(ctx context.Context) error
+	// Rollback discards the transaction.
+	Rollback() error
+}
+
+// ErrTxnDone is returned by Commit or Rollback of transaction
+// that has already been committed or rolled back.
+var ErrTxnDone = errors.New("sql: transaction has already been committed or rolled back")
+
+// TxnOptions are options of a transaction, each data store uses only
+// the options that are relevant for it.
+type TxnOptions struct {
+	// Isolation level of SQL transaction (default level of the database if zero).
+	Isolation gosql.IsolationLevel
+	// ReadOnly SQL transaction.
+	ReadOnly bool
+	// Consistency level of Cassandra batch, e.g. "QUORUM" (default consistency of the session if empty).
+	Consistency string
+	// BatchType of Cassandra batch: "LOGGED" (default), "UNLOGGED" or "COUNTER".
+	BatchType string
+}
+
+// TxnOption customizes the transaction.
+type TxnOption func(*TxnOptions)
+
+// NewTxnOptions returns TxnOptions with the <opts> applied.
+func NewTxnOptions(opts ...TxnOption) TxnOptions {
+	var options TxnOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
+
+// WithIsolation sets isolation level of SQL transaction.
+func WithIsolation(level gosql.IsolationLevel) TxnOption {
+	return func(o *TxnOptions) {
+		o.Isolation = level
+	}
+}
+
+// WithReadOnly makes SQL transaction read-only.
+func WithReadOnly() TxnOption {
+	return func(o *TxnOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// WithConsistency sets consistency level of Cassandra batch (e.g. "QUORUM", "LOCAL_ONE").
+func WithConsistency(consistency string) TxnOption {
+	return func(o *TxnOptions) {
+		o.Consistency = consistency
+	}
+}
+
+// WithBatchType sets type of Cassandra batch ("LOGGED", "UNLOGGED" or "COUNTER").
+func WithBatchType(batchType string) TxnOption {
+	return func(o *TxnOptions) {
+		o.BatchType = batchType
+	}
 }
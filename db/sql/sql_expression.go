@@ -56,7 +56,7 @@ func (exp *PrefixedExp) String() string {
 		return exp.Prefix
 	}
 
-	if exp.Prefix == "FROM" && len(exp.Binding) > 0 {
+	if (exp.Prefix == "FROM" || exp.Prefix == "UPDATE") && len(exp.Binding) > 0 {
 		return exp.Prefix + " " + EntityTableName(exp.Binding[0]) + " " + ExpsToString(exp.AfterPrefix)
 	}
 
@@ -66,13 +66,15 @@ func (exp *PrefixedExp) String() string {
 // ExpsToString joins (without separator) individual expression string representations.
 func ExpsToString(exps []Expression) string {
 	if exps != nil {
-		if len(exps) == 1 {
+		if len(exps) == 1 && exps[0] != nil {
 			return exps[0].String()
 		}
 
 		var buffer bytes.Buffer
 		for _, exp := range exps {
-			buffer.WriteString(exp.String())
+			if exp != nil {
+				buffer.WriteString(exp.String())
+			}
 		}
 
 		return buffer.String()
@@ -123,8 +125,117 @@ func SELECT(entity interface{}, afterKeyword Expression, binding ...interface{})
 // FROM keyword of an SQL expression.
 // Note, pointerToAStruct is assigned to Expression.binding.
 // The implementation is supposed to try to cast to the sql.TableName & sql.SchemaName.
-func FROM(pointerToAStruct interface{}, afterKeyword Expression) Expression {
-	return &PrefixedExp{"FROM", []Expression{afterKeyword}, "", []interface{}{pointerToAStruct}}
+//
+// Example usage:
+//
+// 		FROM(UserTable, WHERE(FieldEQ(&JamesBond.LastName)), ORDER_BY(DESC(&UserTable.Age)), LIMIT(10))
+func FROM(pointerToAStruct interface{}, afterKeyword ...Expression) Expression {
+	return &PrefixedExp{"FROM", afterKeyword, "", []interface{}{pointerToAStruct}}
+}
+
+// UPDATE keyword of an SQL statement. Use it with SET to update selected
+// fields of the rows matching the condition (see Broker.Update).
+// Note, pointerToAStruct is assigned to Expression.binding.
+//
+// Example usage:
+//
+// 		UPDATE(JamesBond, SET(&JamesBond.FirstName, &JamesBond.Age), WHERE(PK(&JamesBond.ID)))
+// 		// generates, for example, "UPDATE User SET first_name = ?, age = ? WHERE id = ?"
+func UPDATE(pointerToAStruct interface{}, afterKeyword ...Expression) Expression {
+	return &PrefixedExp{"UPDATE", afterKeyword, "", []interface{}{pointerToAStruct}}
+}
+
+// SET part of UPDATE statement. The fields are set to their current values.
+func SET(pointersToFields ...interface{}) Expression {
+	var inside []Expression
+	for i, pointerToAField := range pointersToFields {
+		if i > 0 {
+			inside = append(inside, Exp(", "))
+		}
+		inside = append(inside, FieldEQ(pointerToAField))
+	}
+	return &PrefixedExp{" SET ", inside, "", nil}
+}
+
+// COUNT of the rows matching the condition.
+// The result can be retrieved by GetValue into a pointer to an integer.
+//
+// Example usage:
+//
+// 		var count int64
+// 		found, err := db.GetValue(COUNT(UserTable, WHERE(Field(&UserTable.LastName, EQ("Bond")))), &count)
+func COUNT(pointerToAStruct interface{}, afterKeyword ...Expression) Expression {
+	return &PrefixedExp{"SELECT COUNT(*)", []Expression{FROM(pointerToAStruct, afterKeyword...)}, "", nil}
+}
+
+// MIN aggregate of the field values of rows matching the condition (see COUNT).
+func MIN(pointerToAStruct interface{}, pointerToAField interface{}, afterKeyword ...Expression) Expression {
+	return aggregate("MIN", pointerToAStruct, pointerToAField, afterKeyword...)
+}
+
+// MAX aggregate of the field values of rows matching the condition (see COUNT).
+func MAX(pointerToAStruct interface{}, pointerToAField interface{}, afterKeyword ...Expression) Expression {
+	return aggregate("MAX", pointerToAStruct, pointerToAField, afterKeyword...)
+}
+
+// SUM aggregate of the field values of rows matching the condition (see COUNT).
+func SUM(pointerToAStruct interface{}, pointerToAField interface{}, afterKeyword ...Expression) Expression {
+	return aggregate("SUM", pointerToAStruct, pointerToAField, afterKeyword...)
+}
+
+// AVG aggregate of the field values of rows matching the condition (see COUNT).
+func AVG(pointerToAStruct interface{}, pointerToAField interface{}, afterKeyword ...Expression) Expression {
+	return aggregate("AVG", pointerToAStruct, pointerToAField, afterKeyword...)
+}
+
+func aggregate(function string, pointerToAStruct interface{}, pointerToAField interface{},
+	afterKeyword ...Expression) Expression {
+	return &PrefixedExp{"SELECT " + function + "(", []Expression{
+		Field(pointerToAField), Exp(")"), FROM(pointerToAStruct, afterKeyword...),
+	}, "", nil}
+}
+
+// ORDER_BY keyword of an SQL expression. Orderings are fields (see Field)
+// optionally with direction (see ASC, DESC).
+//
+// Example usage:
+//
+// 		FROM(UserTable, WHERE(...), ORDER_BY(ASC(&UserTable.LastName), DESC(&UserTable.Age)))
+func ORDER_BY(orderings ...Expression) Expression {
+	var inside []Expression
+	for i, ordering := range orderings {
+		if i > 0 {
+			inside = append(inside, Exp(", "))
+		}
+		inside = append(inside, ordering)
+	}
+	return &PrefixedExp{" ORDER BY ", inside, "", nil}
+}
+
+// ASC orders by the field in ascending order (see ORDER_BY).
+func ASC(pointerToAField interface{}) Expression {
+	return Field(pointerToAField, Exp(" ASC"))
+}
+
+// DESC orders by the field in descending order (see ORDER_BY).
+func DESC(pointerToAField interface{}) Expression {
+	return Field(pointerToAField, Exp(" DESC"))
+}
+
+// LIMIT keyword of an SQL expression, limits the number of returned rows.
+func LIMIT(limit int) Expression {
+	return &PrefixedExp{" LIMIT ", []Expression{Exp("?", limit)}, "", nil}
+}
+
+// OFFSET keyword of an SQL expression, skips the first <offset> rows.
+// Use it after LIMIT for paged listing:
+//
+// 		FROM(UserTable, ORDER_BY(ASC(&UserTable.ID)), LIMIT(pageSize), OFFSET(page*pageSize))
+//
+// Implementations for data stores that do not support OFFSET (e.g. Cassandra)
+// skip the rows when iterating.
+func OFFSET(offset int) Expression {
+	return &PrefixedExp{" OFFSET ", []Expression{Exp("?", offset)}, "", nil}
 }
 
 // WHERE keyword of an SQL statement.
@@ -238,9 +349,9 @@ func GT(binding interface{}) (exp Expression) {
 	return &PrefixedExp{" > ", []Expression{Exp("?", binding)}, "", nil}
 }
 
-// GTE operator "=>" used in SQL expressions
+// GTE operator ">=" used in SQL expressions
 func GTE(binding interface{}) (exp Expression) {
-	return &PrefixedExp{" => ", []Expression{Exp("?", binding)}, "", nil}
+	return &PrefixedExp{" >= ", []Expression{Exp("?", binding)}, "", nil}
 }
 
 // LT operator "<" used in SQL expressions
@@ -248,9 +359,24 @@ func LT(binding interface{}) (exp Expression) {
 	return &PrefixedExp{" < ", []Expression{Exp("?", binding)}, "", nil}
 }
 
-// LTE operator "=<" used in SQL expressions
+// LTE operator "<=" used in SQL expressions
 func LTE(binding interface{}) (exp Expression) {
-	return &PrefixedExp{" =< ", []Expression{Exp("?", binding)}, "", nil}
+	return &PrefixedExp{" <= ", []Expression{Exp("?", binding)}, "", nil}
+}
+
+// LIKE operator used in SQL expressions
+func LIKE(pattern string) (exp Expression) {
+	return &PrefixedExp{" LIKE ", []Expression{Exp("?", pattern)}, "", nil}
+}
+
+// IS_NULL operator used in SQL expressions
+func IS_NULL() (exp Expression) {
+	return &PrefixedExp{" IS NULL", nil, "", nil}
+}
+
+// IS_NOT_NULL operator used in SQL expressions
+func IS_NOT_NULL() (exp Expression) {
+	return &PrefixedExp{" IS NOT NULL", nil, "", nil}
 }
 
 // Parenthesis expression that surrounds "inside Expression" with "(" and ")"
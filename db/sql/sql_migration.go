@@ -0,0 +1,227 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration is one versioned change of the database schema.
+type Migration struct {
+	// Version orders the migrations, it has to be unique and positive.
+	Version int64
+	// Description is stored along with the version of applied migration.
+	Description string
+	// Up applies the migration.
+	Up func(broker Broker) error
+	// Down reverts the migration (the migration is irreversible if nil).
+	Down func(broker Broker) error
+}
+
+// SchemaMigration is a row of the table with applied migrations.
+type SchemaMigration struct {
+	Version     int64     `cql:"version" sql:"version" pk:"version"`
+	Description string    `cql:"description" sql:"description"`
+	AppliedAt   time.Time `cql:"applied_at" sql:"applied_at"`
+}
+
+// MigrationsTable is the name of the table with applied migrations.
+var MigrationsTable = "schema_migrations"
+
+// TableName implements TableName interface.
+func (m *SchemaMigration) TableName() string {
+	return MigrationsTable
+}
+
+// Statements returns migration step that executes the <statements> in order.
+func Statements(statements ...string) func(broker Broker) error {
+	return func(broker Broker) error {
+		for _, statement := range statements {
+			if err := broker.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// CreateTables returns migration step that creates tables (see SchemaBuilder.CreateTable)
+// for the <entities>.
+func CreateTables(schema SchemaBuilder, entities ...interface{}) func(broker Broker) error {
+	return func(broker Broker) error {
+		for _, entity := range entities {
+			statement, err := schema.CreateTable(entity)
+			if err != nil {
+				return err
+			}
+			if err := broker.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// DropTables returns migration step that drops tables associated with the <entities>.
+func DropTables(schema SchemaBuilder, entities ...interface{}) func(broker Broker) error {
+	return func(broker Broker) error {
+		for _, entity := range entities {
+			if err := broker.Exec(schema.DropTable(entity)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Migrator applies and reverts versioned migrations. Versions of applied
+// migrations are stored in the MigrationsTable, which is created by the
+// Migrator if it does not exist yet.
+//
+// Note that migration and recording of its version are not executed atomically,
+// migrations should therefore be written to be safely re-applied.
+type Migrator struct {
+	broker     Broker
+	schema     SchemaBuilder
+	migrations []Migration
+}
+
+// NewMigrator creates Migrator for the <migrations> that are executed using the <broker>.
+func NewMigrator(broker Broker, schema SchemaBuilder, migrations ...Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("invalid migration version %d", migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migration.Version)
+		}
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %d has no Up step", migration.Version)
+		}
+	}
+	return &Migrator{broker: broker, schema: schema, migrations: sorted}, nil
+}
+
+// Version returns the highest version of applied migrations (zero if none was applied).
+func (m *Migrator) Version() (int64, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Up applies all migrations that were not applied yet.
+func (m *Migrator) Up() error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.Migrate(m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down reverts the most recently applied migration.
+func (m *Migrator) Down() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if applied[m.migrations[i].Version] {
+			return m.down(m.migrations[i])
+		}
+	}
+	return nil
+}
+
+// Migrate applies not yet applied migrations with version lower or equal
+// to the <version> (in ascending order) and reverts applied migrations
+// with higher version (in descending order).
+func (m *Migrator) Migrate(version int64) error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > version && applied[migration.Version] {
+			if err := m.down(migration); err != nil {
+				return err
+			}
+		}
+	}
+	for _, migration := range m.migrations {
+		if migration.Version <= version && !applied[migration.Version] {
+			if err := m.up(migration); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) up(migration Migration) error {
+	if err := migration.Up(m.broker); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %v", migration.Version, migration.Description, err)
+	}
+	record := &SchemaMigration{
+		Version:     migration.Version,
+		Description: migration.Description,
+		AppliedAt:   time.Now().UTC(),
+	}
+	return m.broker.Put(FieldEQ(&record.Version), record)
+}
+
+func (m *Migrator) down(migration Migration) error {
+	if migration.Down == nil {
+		return fmt.Errorf("migration %d (%s) is irreversible", migration.Version, migration.Description)
+	}
+	if err := migration.Down(m.broker); err != nil {
+		return fmt.Errorf("reverting migration %d (%s) failed: %v", migration.Version, migration.Description, err)
+	}
+	record := &SchemaMigration{Version: migration.Version}
+	return m.broker.Delete(FROM(record, WHERE(FieldEQ(&record.Version))))
+}
+
+// applied returns versions of applied migrations.
+func (m *Migrator) applied() (map[int64]bool, error) {
+	statement, err := m.schema.CreateTable(&SchemaMigration{})
+	if err != nil {
+		return nil, err
+	}
+	if err := m.broker.Exec(statement); err != nil {
+		return nil, err
+	}
+	var records []*SchemaMigration
+	if err := SliceIt(&records, m.broker.ListValues(FROM(&SchemaMigration{}, nil))); err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool, len(records))
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+	return applied, nil
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// DefaultPollInterval is the default interval between polls of PollingWatcher.
+const DefaultPollInterval = time.Second
+
+// DefaultMaxRows is the default maximal number of rows returned by a query
+// watched by PollingWatcher.
+const DefaultMaxRows = 10000
+
+// PollingWatcher implements Watcher for data stores without change notifications.
+// It periodically runs the watched queries and compares the results with
+// results of the previous run. Rows that appeared or changed are delivered
+// as Put, rows that disappeared as Delete.
+//
+// Rows are identified by values of the key columns (typically the primary key).
+// If no key column is given, the whole row identifies it and change of a row
+// is delivered as Delete of the old row followed by Put of the new one.
+//
+// Every poll reads all rows returned by the watched query and keeps them
+// in memory, therefore the cost grows with the size of the watched table.
+// Large tables should be watched by queries selecting only the needed columns
+// and rows. Polls returning more rows than the limit (DefaultMaxRows,
+// see SetMaxRows) fail.
+type PollingWatcher struct {
+	broker     Broker
+	columnTag  string
+	interval   time.Duration
+	keyColumns []string
+	maxRows    int
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewPollingWatcher creates PollingWatcher that runs queries using the <broker>
+// every <interval>. The <columnTag> is the struct tag used by the broker
+// implementation to map columns to struct fields (see WatchResp.GetValue).
+func NewPollingWatcher(broker Broker, columnTag string, interval time.Duration,
+	keyColumns ...string) *PollingWatcher {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &PollingWatcher{
+		broker:     broker,
+		columnTag:  columnTag,
+		interval:   interval,
+		keyColumns: keyColumns,
+		maxRows:    DefaultMaxRows,
+		closeCh:    make(chan struct{}),
+	}
+}
+
+// SetMaxRows sets the maximal number of rows returned by a watched query.
+// Zero or negative value removes the limit. It must be called before Watch.
+func (w *PollingWatcher) SetMaxRows(maxRows int) {
+	w.maxRows = maxRows
+}
+
+// Watch starts polling of each <statement>, which is either a SELECT query
+// or a table name (all rows of the table are watched). The current content
+// is read before Watch returns and only the following changes are delivered
+// to the <callback>. If any statement fails, none of them is watched.
+func (w *PollingWatcher) Watch(callback func(WatchResp), statement ...string) error {
+	queries := make([]string, len(statement))
+	initial := make([]map[string]map[string]interface{}, len(statement))
+	for i, stmt := range statement {
+		queries[i] = stmt
+		if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "SELECT ") {
+			queries[i] = "SELECT * FROM " + stmt
+		}
+		rows, err := w.poll(queries[i])
+		if err != nil {
+			return err
+		}
+		initial[i] = rows
+	}
+	for i, query := range queries {
+		w.wg.Add(1)
+		go w.watch(query, initial[i], callback)
+	}
+	return nil
+}
+
+// Close stops polling.
+func (w *PollingWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+	})
+	w.wg.Wait()
+	return nil
+}
+
+func (w *PollingWatcher) watch(query string, prev map[string]map[string]interface{}, callback func(WatchResp)) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rows, err := w.poll(query)
+			if err != nil {
+				logging.Warnf("Polling of %q failed: %v", query, err)
+				continue
+			}
+			for key, row := range prev {
+				if _, found := rows[key]; !found {
+					callback(&pollingWatchResp{changeType: datasync.Delete, key: key, row: row, columnTag: w.columnTag})
+				}
+			}
+			for key, row := range rows {
+				if prevRow, found := prev[key]; !found || !reflect.DeepEqual(prevRow, row) {
+					callback(&pollingWatchResp{changeType: datasync.Put, key: key, row: row, columnTag: w.columnTag})
+				}
+			}
+			prev = rows
+		case <-w.closeCh:
+			return
+		}
+	}
+}
+
+// poll runs the <query> and returns result rows indexed by the key.
+func (w *PollingWatcher) poll(query string) (map[string]map[string]interface{}, error) {
+	rows := map[string]map[string]interface{}{}
+	it := w.broker.ListValues(Exp(query))
+	for n := 1; ; n++ {
+		row := map[string]interface{}{}
+		if stop := it.GetNext(row); stop {
+			break
+		}
+		rows[w.rowKey(row)] = row
+		if w.maxRows > 0 && n > w.maxRows {
+			it.Close()
+			return nil, fmt.Errorf("query %q returned more than %d rows", query, w.maxRows)
+		}
+	}
+	return rows, it.Close()
+}
+
+// rowKey joins values of the key columns (or of all columns) with "/".
+func (w *PollingWatcher) rowKey(row map[string]interface{}) string {
+	columns := w.keyColumns
+	if len(columns) == 0 {
+		for column := range row {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+	}
+	values := make([]string, 0, len(columns))
+	for _, column := range columns {
+		value := row[column]
+		if b, isBytes := value.([]byte); isBytes {
+			value = string(b)
+		}
+		values = append(values, fmt.Sprint(value))
+	}
+	return strings.Join(values, "/")
+}
+
+// pollingWatchResp implements WatchResp for PollingWatcher.
+type pollingWatchResp struct {
+	changeType datasync.Op
+	key        string
+	row        map[string]interface{}
+	columnTag  string
+}
+
+// GetChangeType returns the type of the change.
+func (resp *pollingWatchResp) GetChangeType() datasync.Op {
+	return resp.changeType
+}
+
+// GetKey returns values of the key columns joined with "/".
+func (resp *pollingWatchResp) GetKey() string {
+	return resp.key
+}
+
+// GetValue copies the row into <outBinding>, which can be a map
+// or a pointer to a structure with fields mapped to the columns.
+func (resp *pollingWatchResp) GetValue(outBinding interface{}) error {
+	if m, ok := outBinding.(map[string]interface{}); ok {
+		for column, value := range resp.row {
+			m[column] = value
+		}
+		return nil
+	}
+
+	val := reflect.ValueOf(outBinding)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported watch value binding %T", outBinding)
+	}
+	val = val.Elem()
+	for _, column := range EntityColumns(outBinding, resp.columnTag) {
+		value, found := resp.row[column.Name]
+		if !found || value == nil {
+			continue
+		}
+		field := val.FieldByIndex(column.Field.Index)
+		rv := reflect.ValueOf(value)
+		switch {
+		case rv.Type().AssignableTo(field.Type()):
+			field.Set(rv)
+		case convertible(rv.Type(), field.Type()):
+			field.Set(rv.Convert(field.Type()))
+		default:
+			return fmt.Errorf("cannot assign column %s of type %T to field %s", column.Name, value, column.Field.Name)
+		}
+	}
+	return nil
+}
+
+// convertible allows conversions between numeric types and between
+// strings and byte slices (unlike reflect, it does not convert numbers to strings).
+func convertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	isNumber := func(t reflect.Type) bool {
+		return t.Kind() >= reflect.Int && t.Kind() <= reflect.Float64
+	}
+	isText := func(t reflect.Type) bool {
+		return t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+	}
+	return (isNumber(from) && isNumber(to)) || (isText(from) && isText(to))
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.ligato.io/cn-infra/v2/utils/structs"
+)
+
+// ColumnTypeTag is the struct tag that overrides the column type generated
+// for the field in CREATE TABLE statements (e.g. `dbtype:"varchar(64)"`).
+const ColumnTypeTag = "dbtype"
+
+// ErrMissingPrimaryKey is error returned when table is created for entity
+// without fields tagged as primary key.
+var ErrMissingPrimaryKey = errors.New("sql: entity has no primary key field")
+
+// SchemaBuilder generates DDL statements from the struct metadata
+// for a particular database.
+type SchemaBuilder interface {
+	// CreateTable generates CREATE TABLE IF NOT EXISTS statement for the table
+	// associated with the <entity>. Fields tagged with "pk" form the primary key.
+	CreateTable(entity interface{}) (string, error)
+
+	// CreateIndex generates CREATE INDEX IF NOT EXISTS statement for the column
+	// that the field (referenced by <pointerToAField>) of the <entity> is mapped to.
+	CreateIndex(entity interface{}, pointerToAField interface{}) (string, error)
+
+	// DropTable generates DROP TABLE IF EXISTS statement for the table
+	// associated with the <entity>.
+	DropTable(entity interface{}) string
+}
+
+// ColumnTypeFunc maps go type of a struct field to the column type of a database.
+type ColumnTypeFunc func(t reflect.Type) (string, error)
+
+// CreateTableStatement generates CREATE TABLE IF NOT EXISTS statement for
+// the <entity>. Column names are taken from the struct tag <tag> and column
+// types are either taken from ColumnTypeTag or mapped by <columnType>.
+func CreateTableStatement(entity interface{}, tag string, columnType ColumnTypeFunc) (string, error) {
+	columns := EntityColumns(entity, tag)
+	var defs, pk []string
+	for _, column := range columns {
+		typ := column.Field.Tag.Get(ColumnTypeTag)
+		if typ == "" {
+			var err error
+			if typ, err = columnType(column.Field.Type); err != nil {
+				return "", fmt.Errorf("column %s: %v", column.Name, err)
+			}
+		}
+		defs = append(defs, column.Name+" "+typ)
+		if column.PrimaryKey {
+			pk = append(pk, column.Name)
+		}
+	}
+	if len(pk) == 0 {
+		return "", ErrMissingPrimaryKey
+	}
+	defs = append(defs, "PRIMARY KEY ("+strings.Join(pk, ", ")+")")
+
+	return "CREATE TABLE IF NOT EXISTS " + EntityTableName(entity) + " (" + strings.Join(defs, ", ") + ")", nil
+}
+
+// CreateIndexStatement generates CREATE INDEX IF NOT EXISTS statement
+// for the column of the <entity> referenced by <pointerToAField>.
+// The index is named <table>_<column>_idx.
+func CreateIndexStatement(entity interface{}, pointerToAField interface{}, tag string) (string, error) {
+	field, found := structs.FindField(pointerToAField, entity)
+	if !found {
+		return "", fmt.Errorf("sql: field not found in %T", entity)
+	}
+	column, exported := FieldColumnName(field, tag)
+	if !exported {
+		return "", fmt.Errorf("sql: field %s is not mapped to a column", field.Name)
+	}
+	table := EntityTableName(entity)
+	name := table[strings.LastIndex(table, ".")+1:] + "_" + column + "_idx"
+
+	return "CREATE INDEX IF NOT EXISTS " + name + " ON " + table + " (" + column + ")", nil
+}
+
+// DropTableStatement generates DROP TABLE IF EXISTS statement for the <entity>.
+func DropTableStatement(entity interface{}) string {
+	return "DROP TABLE IF EXISTS " + EntityTableName(entity)
+}
@@ -14,7 +14,11 @@
 
 package sql
 
-import "reflect"
+import (
+	"reflect"
+
+	"go.ligato.io/cn-infra/v2/utils/structs"
+)
 
 // TableName interface specifies custom table name for SQL statements.
 type TableName interface {
@@ -54,3 +58,51 @@ func EntityTableName(entity interface{}) string {
 
 	return schemaName + "." + tableName
 }
+
+// PrimaryKeyTag is the struct tag that marks fields forming the primary key
+// of the table (e.g. `pk:"id"`).
+const PrimaryKeyTag = "pk"
+
+// Column describes mapping of one exported struct field to a table column.
+type Column struct {
+	// Name of the column.
+	Name string
+	// PrimaryKey is true if the field is tagged with PrimaryKeyTag.
+	PrimaryKey bool
+	// Field is the struct field mapped to the column.
+	Field *reflect.StructField
+}
+
+// FieldColumnName returns the name of the column that the struct <field>
+// is mapped to. The name is taken from the struct tag <tag> (e.g. "cql"
+// or "sql"). If the tag is not set, the field name is used instead.
+// Unexported fields and fields tagged with "-" are not mapped to any column.
+func FieldColumnName(field *reflect.StructField, tag string) (name string, exported bool) {
+	if !structs.FieldExported(field) {
+		return field.Name, false
+	}
+	if name = field.Tag.Get(tag); name != "" {
+		return name, name != "-"
+	}
+	return field.Name, true
+}
+
+// IsPrimaryKey checks the PrimaryKeyTag in the struct <field>.
+func IsPrimaryKey(field *reflect.StructField) bool {
+	return field.Tag.Get(PrimaryKeyTag) != ""
+}
+
+// EntityColumns lists columns of the table associated with the <entity>
+// (a struct or a pointer to a struct). Column names are taken from
+// the struct tag <tag>, see FieldColumnName.
+func EntityColumns(entity interface{}, tag string) []Column {
+	var columns []Column
+	for _, field := range structs.ListExportedFields(entity) {
+		name, exported := FieldColumnName(field, tag)
+		if !exported {
+			continue
+		}
+		columns = append(columns, Column{Name: name, PrimaryKey: IsPrimaryKey(field), Field: field})
+	}
+	return columns
+}
@@ -14,7 +14,12 @@
 
 package sql
 
-import "go.ligato.io/cn-infra/v2/datasync"
+import (
+	"time"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/logging"
+)
 
 // Watcher defines API for monitoring changes in a datastore.
 type Watcher interface {
@@ -29,27 +34,24 @@ type WatchResp interface {
 	// GetChangeType returns the type of the change.
 	GetChangeType() datasync.Op
 
+	// GetKey returns the key identifying the changed row.
+	GetKey() string
+
 	// GetValue returns the changed value.
+	// For Delete it returns the value before the row was removed.
 	GetValue(outBinding interface{}) error
 }
 
-// ToChan TODO (not implemented yet)
+// ToChan creates a callback that can be passed to the Watch function in order
+// to receive notifications through a channel. If the notification cannot be
+// delivered until timeout, it is dropped.
 func ToChan(respChan chan WatchResp, options ...interface{}) func(event WatchResp) {
-	return func(WatchResp) {
-		/*select {
+	return func(resp WatchResp) {
+		select {
 		case respChan <- resp:
-		case <-time.After(defaultOpTimeout):
-			log.Warn("Unable to deliver watch event before timeout.")
+			// success
+		case <-time.After(datasync.DefaultNotifTimeout):
+			logging.Warn("Unable to deliver watch event before timeout.")
 		}
-
-		select {
-		case wresp := <-recvChan:
-			for _, ev := range wresp.Events {
-				handleWatchEvent(respChan, ev)
-			}
-		case <-closeCh:
-			log.WithField("key", key).Debug("Watch ended")
-			return
-		}*/
 	}
 }
@@ -0,0 +1,275 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb
+
+import (
+	"context"
+	gosql "database/sql"
+	"reflect"
+	"strings"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+)
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (gosql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*gosql.Rows, error)
+}
+
+// NewBrokerUsingDB is a Broker constructor. Use it like this:
+//
+//	db, err := sql.Open("sqlite", "/tmp/data.db")
+//	defer db.Close()
+//	dialect, err := GetDialect(SQLite)
+//	broker := NewBrokerUsingDB(db, dialect)
+//	broker.ListValues(...)
+func NewBrokerUsingDB(db *gosql.DB, dialect Dialect) *BrokerSQL {
+	return &BrokerSQL{db: db, dialect: dialect, ctx: context.Background()}
+}
+
+// BrokerSQL implements interface sql.Broker over database/sql. The SQL statements
+// are generated from the go structures (see Put, Delete, GetValue, ListValues)
+// and rendered for the SQL dialect of the database.
+type BrokerSQL struct {
+	db      *gosql.DB
+	dialect Dialect
+	// ctx is used by methods without context (cancelled when the plugin is closed)
+	ctx context.Context
+}
+
+// ValIterator is an iterator returned by ListValues call
+type ValIterator struct {
+	rows      *gosql.Rows
+	lastError error
+}
+
+// ErrIterator is an iterator that stops immediately and just returns last error on Close()
+type ErrIterator struct {
+	LastError error
+}
+
+// Put - see the description in interface sql.Broker.Put().
+// The rows matching the <where> condition are updated and the entity is
+// inserted as a new row if no row matched (inserting fails if a row with
+// the same primary key exists but does not match the condition).
+// If the <where> condition is nil (or all columns of the entity form
+// the primary key), the row is inserted or updated using the upsert
+// statement of the dialect, which requires fields tagged as primary key.
+func (b *BrokerSQL) Put(where sql.Expression, pointerToAStruct interface{}) error {
+	return b.put(b.ctx, b.db, where, pointerToAStruct)
+}
+
+func (b *BrokerSQL) put(ctx context.Context, ex execer, where sql.Expression, entity interface{}) error {
+	columns := sql.EntityColumns(entity, ColumnTag)
+	if len(columns) == 0 {
+		return ErrNoColumns
+	}
+	var pkColumns []string
+	for _, column := range columns {
+		if column.PrimaryKey {
+			pkColumns = append(pkColumns, column.Name)
+		}
+	}
+	if where == nil || len(pkColumns) == len(columns) {
+		if len(pkColumns) == 0 {
+			return ErrNoPrimaryKey
+		}
+		statement := b.dialect.Upsert(sql.EntityTableName(entity), columnNames(columns), pkColumns)
+		return b.exec(ctx, ex, statement, columnValues(entity, columns)...)
+	}
+
+	statement, bindings, err := PutExpToString(where, entity)
+	if err != nil {
+		return err
+	}
+	res, err := ex.ExecContext(ctx, Rebind(b.dialect, statement), bindings...)
+	if err != nil {
+		return err
+	}
+	if updated, err := res.RowsAffected(); err != nil || updated > 0 {
+		return err
+	}
+	statement = "INSERT INTO " + sql.EntityTableName(entity) + " (" + strings.Join(columnNames(columns), ", ") +
+		") VALUES (" + strings.TrimPrefix(strings.Repeat(", ?", len(columns)), ", ") + ")"
+	return b.exec(ctx, ex, statement, columnValues(entity, columns)...)
+}
+
+// withBrokerContext returns a context derived from <ctx> that is also
+// cancelled when the context of the broker is (i.e. when the plugin is closed).
+func (b *BrokerSQL) withBrokerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if b.ctx.Done() != nil {
+		go func() {
+			select {
+			case <-b.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+// Exec - see the description in interface sql.Broker.Exec().
+// Exec runs statement (AS-IS) with "?" bind variables replaced
+// by placeholders of the dialect.
+func (b *BrokerSQL) Exec(statement string, bindings ...interface{}) error {
+	return b.exec(b.ctx, b.db, statement, bindings...)
+}
+
+func (b *BrokerSQL) exec(ctx context.Context, ex execer, statement string, bindings ...interface{}) error {
+	_, err := ex.ExecContext(ctx, Rebind(b.dialect, statement), bindings...)
+	return err
+}
+
+// Delete - see the description in interface sql.Broker.Delete().
+// Delete generates DELETE statement from the expression.
+func (b *BrokerSQL) Delete(fromWhere sql.Expression) error {
+	return b.delete(b.ctx, b.db, fromWhere)
+}
+
+func (b *BrokerSQL) delete(ctx context.Context, ex execer, fromWhere sql.Expression) error {
+	statement, bindings, err := ExpToString(fromWhere)
+	if err != nil {
+		return err
+	}
+	return b.exec(ctx, ex, "DELETE"+statement, bindings...)
+}
+
+// Update - see the description in interface sql.Broker.Update().
+// Update generates UPDATE statement from the expression.
+func (b *BrokerSQL) Update(update sql.Expression) error {
+	return b.update(b.ctx, b.db, update)
+}
+
+func (b *BrokerSQL) update(ctx context.Context, ex execer, update sql.Expression) error {
+	statement, bindings, err := ExpToString(update)
+	if err != nil {
+		return err
+	}
+	return b.exec(ctx, ex, statement, bindings...)
+}
+
+// GetValue - see the description in interface sql.Broker.GetValue().
+// GetValue just iterate once for ListValues()
+func (b *BrokerSQL) GetValue(query sql.Expression, reqObj interface{}) (found bool, err error) {
+	return b.GetValueCtx(b.ctx, query, reqObj)
+}
+
+// GetValueCtx - see the description in interface sql.Broker.GetValueCtx().
+func (b *BrokerSQL) GetValueCtx(ctx context.Context, query sql.Expression, reqObj interface{}) (found bool, err error) {
+	it := b.ListValuesCtx(ctx, query)
+	stop := it.GetNext(reqObj)
+	return !stop, it.Close()
+}
+
+// ListValues - see the description in interface sql.Broker.ListValues().
+// ListValues selects all columns of the entity referenced by FROM.
+func (b *BrokerSQL) ListValues(query sql.Expression) sql.ValIterator {
+	return b.listValues(b.ctx, b.db, query)
+}
+
+// ListValuesCtx - see the description in interface sql.Broker.ListValuesCtx().
+func (b *BrokerSQL) ListValuesCtx(ctx context.Context, query sql.Expression) sql.ValIterator {
+	return b.listValues(ctx, b.db, query)
+}
+
+func (b *BrokerSQL) listValues(ctx context.Context, ex execer, query sql.Expression) sql.ValIterator {
+	statement, bindings, err := SelectExpToString(query)
+	if err != nil {
+		return &ErrIterator{err}
+	}
+	rows, err := ex.QueryContext(ctx, Rebind(b.dialect, statement), bindings...)
+	if err != nil {
+		return &ErrIterator{err}
+	}
+	return &ValIterator{rows: rows}
+}
+
+// GetNext returns the following item from the result set. If data was returned, found is set to true.
+// argument "outVal" can be:
+// - pointer to structure
+// - map
+// - pointer to a value of single column result (e.g. sql.COUNT)
+func (it *ValIterator) GetNext(outVal interface{}) (stop bool) {
+	if it.lastError != nil || !it.rows.Next() {
+		return true
+	}
+	columns, err := it.rows.Columns()
+	if err != nil {
+		it.lastError = err
+		return true
+	}
+
+	ptrs := make([]interface{}, len(columns))
+	if m, ok := outVal.(map[string]interface{}); ok {
+		values := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if it.lastError = it.rows.Scan(ptrs...); it.lastError != nil {
+			return true
+		}
+		for i, column := range columns {
+			m[column] = values[i]
+		}
+		return false
+	}
+
+	val := reflect.Indirect(reflect.ValueOf(outVal))
+	if val.Kind() != reflect.Struct || val.Type() == timeType {
+		it.lastError = it.rows.Scan(outVal)
+		return it.lastError != nil
+	}
+	fields := map[string][]int{}
+	for _, column := range sql.EntityColumns(outVal, ColumnTag) {
+		fields[column.Name] = column.Field.Index
+	}
+	for i, column := range columns {
+		if index, found := fields[column]; found {
+			ptrs[i] = val.FieldByIndex(index).Addr().Interface()
+		} else {
+			ptrs[i] = new(interface{}) // column without field is ignored
+		}
+	}
+	it.lastError = it.rows.Scan(ptrs...)
+	return it.lastError != nil
+}
+
+// Close the iterator. Note, the error is important (may occur during scanning of the rows)
+func (it *ValIterator) Close() error {
+	err := it.rows.Close()
+	if it.lastError != nil {
+		return it.lastError
+	}
+	if err != nil {
+		return err
+	}
+	return it.rows.Err()
+}
+
+// GetNext returns the following item from the result set. If data was returned, found is set to true.
+// argument "outVal" can be:
+// - pointer to structure
+// - map
+func (it *ErrIterator) GetNext(outVal interface{}) (stop bool) {
+	return true
+}
+
+// Close the iterator. Note, the error is important (may occur during marshalling/un-marshalling)
+func (it *ErrIterator) Close() error {
+	return it.LastError
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb_test
+
+import (
+	"context"
+	gosql "database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+	"go.ligato.io/cn-infra/v2/db/sql/sqldb"
+	_ "go.ligato.io/cn-infra/v2/db/sql/sqldb/sqlite"
+)
+
+// User is simple structure for testing purposes
+type User struct {
+	ID          string `sql:"id" pk:"id"`
+	FirstName   string `sql:"first_name"`
+	LastName    string `sql:"last_name"`
+	Age         int    `sql:"age"`
+	NotInTable  string `sql:"-"`
+	notExported string
+}
+
+// Note is a structure without primary key
+type Note struct {
+	Author string `sql:"author"`
+	Text   string `sql:"text"`
+}
+
+// instance that represents users table (used in queries to define columns)
+var UserTable = &User{}
+
+func newBroker(t *testing.T) *sqldb.BrokerSQL {
+	p := sqldb.NewPlugin(sqldb.UseConf(sqldb.Config{
+		Dialect: sqldb.SQLite,
+		DSN:     filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)",
+	}))
+	Expect(p.Init()).To(Succeed())
+	t.Cleanup(func() { p.Close() })
+
+	broker := p.NewBroker().(*sqldb.BrokerSQL)
+	Expect(broker.Exec("CREATE TABLE User (id TEXT PRIMARY KEY, first_name TEXT, last_name TEXT, age INTEGER)")).To(Succeed())
+	Expect(broker.Exec("CREATE TABLE Note (author TEXT, text TEXT)")).To(Succeed())
+	return broker
+}
+
+func TestPutGetDelete(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond", Age: 40}
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+
+	var user User
+	found, err := broker.GetValue(sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.ID, sql.EQ("james")))), &user)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(user).To(Equal(User{ID: "james", FirstName: "James", LastName: "Bond", Age: 40}))
+
+	// put with the same primary key updates the row
+	james.Age = 41
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+	found, err = broker.GetValue(sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.ID, sql.EQ("james")))), &user)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(user.Age).To(Equal(41))
+
+	Expect(broker.Delete(sql.FROM(james, sql.WHERE(sql.FieldEQ(&james.ID))))).To(Succeed())
+	found, err = broker.GetValue(sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.ID, sql.EQ("james")))), &user)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeFalse())
+}
+
+func TestListValues(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	for _, user := range []*User{
+		{ID: "james", FirstName: "James", LastName: "Bond", Age: 40},
+		{ID: "peter", FirstName: "Peter", LastName: "Bond", Age: 30},
+		{ID: "john", FirstName: "John", LastName: "Doe", Age: 20},
+	} {
+		Expect(broker.Put(sql.FieldEQ(&user.ID), user)).To(Succeed())
+	}
+
+	query := sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.LastName, sql.EQ("Bond")),
+		sql.AND(), sql.Field(&UserTable.Age, sql.GTE(30))))
+	var users []*User
+	Expect(sql.SliceIt(&users, broker.ListValues(query))).To(Succeed())
+	Expect(users).To(ConsistOf(
+		&User{ID: "james", FirstName: "James", LastName: "Bond", Age: 40},
+		&User{ID: "peter", FirstName: "Peter", LastName: "Bond", Age: 30},
+	))
+
+	it := broker.ListValues(sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.ID, sql.EQ("john")))))
+	row := map[string]interface{}{}
+	Expect(it.GetNext(row)).To(BeFalse())
+	Expect(row).To(HaveKeyWithValue("first_name", "John"))
+	Expect(it.GetNext(row)).To(BeTrue())
+	Expect(it.Close()).To(Succeed())
+}
+
+func TestPutWithoutPK(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	note := &Note{Author: "james", Text: "hello"}
+	Expect(broker.Put(sql.FieldEQ(&note.Author), note)).To(Succeed())
+	note.Text = "hello again"
+	Expect(broker.Put(sql.FieldEQ(&note.Author), note)).To(Succeed())
+
+	var notes []*Note
+	Expect(sql.SliceIt(&notes, broker.ListValues(sql.FROM(&Note{}, nil)))).To(Succeed())
+	Expect(notes).To(ConsistOf(&Note{Author: "james", Text: "hello again"}))
+}
+
+func TestPutWhere(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond", Age: 40}
+	Expect(broker.Put(nil, james)).To(Succeed())
+
+	// the row with the same primary key does not match the condition
+	older := &User{ID: "james", FirstName: "James", LastName: "Bond", Age: 50}
+	Expect(broker.Put(sql.Exp("age > ?", 40), older)).ToNot(Succeed())
+	var user User
+	found, err := broker.GetValue(sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.ID, sql.EQ("james")))), &user)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(user.Age).To(Equal(40))
+
+	Expect(broker.Put(sql.Exp("age < ?", 50), older)).To(Succeed())
+	found, err = broker.GetValue(sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.ID, sql.EQ("james")))), &user)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(user.Age).To(Equal(50))
+
+	Expect(broker.Put(nil, &Note{Author: "james", Text: "hello"})).To(MatchError(sqldb.ErrNoPrimaryKey))
+}
+
+func TestTxn(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond"}
+	peter := &User{ID: "peter", FirstName: "Peter", LastName: "Bond"}
+	Expect(broker.NewTxn().
+		Put(sql.FieldEQ(&james.ID), james).
+		Put(sql.FieldEQ(&peter.ID), peter).
+		Commit(context.Background())).To(Succeed())
+
+	var users []*User
+	Expect(sql.SliceIt(&users, broker.ListValues(sql.FROM(UserTable, nil)))).To(Succeed())
+	Expect(users).To(HaveLen(2))
+
+	// failing operation rolls back the whole transaction
+	err := broker.NewTxn().
+		Delete(sql.FROM(james, sql.WHERE(sql.FieldEQ(&james.ID)))).
+		Delete(sql.FROM(&Note{}, sql.WHERE(sql.Exp("missing_column = ?", 1)))).
+		Commit(context.Background())
+	Expect(err).To(HaveOccurred())
+
+	users = nil
+	Expect(sql.SliceIt(&users, broker.ListValues(sql.FROM(UserTable, nil)))).To(Succeed())
+	Expect(users).To(HaveLen(2))
+}
+
+func TestTxnCancelledOnClose(t *testing.T) {
+	RegisterTestingT(t)
+	p := sqldb.NewPlugin(sqldb.UseConf(sqldb.Config{
+		Dialect: sqldb.SQLite,
+		DSN:     filepath.Join(t.TempDir(), "test.db"),
+	}))
+	Expect(p.Init()).To(Succeed())
+	broker := p.NewBroker()
+	Expect(broker.Exec("CREATE TABLE User (id TEXT PRIMARY KEY, first_name TEXT, last_name TEXT, age INTEGER)")).To(Succeed())
+
+	// the condition never finishes, so the commit runs until it is cancelled
+	endless := sql.Exp("id IN (WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) " +
+		"SELECT x FROM n WHERE x < 0)")
+	done := make(chan error, 1)
+	go func() {
+		done <- broker.NewTxn().Delete(sql.FROM(UserTable, sql.WHERE(endless))).Commit(context.Background())
+	}()
+	Consistently(done, 200*time.Millisecond).ShouldNot(Receive())
+
+	Expect(p.Close()).To(Succeed())
+	Eventually(done, 5*time.Second).Should(Receive(HaveOccurred()))
+}
+
+func TestTxnRollbackAndOptions(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond"}
+	txn := broker.NewTxn().Put(sql.FieldEQ(&james.ID), james)
+	Expect(txn.Rollback()).To(Succeed())
+	Expect(txn.Rollback()).To(Equal(sql.ErrTxnDone))
+	Expect(txn.Commit(context.Background())).To(Equal(sql.ErrTxnDone))
+
+	var users []*User
+	Expect(sql.SliceIt(&users, broker.ListValues(sql.FROM(UserTable, nil)))).To(Succeed())
+	Expect(users).To(BeEmpty())
+
+	txn = broker.NewTxn(sql.WithIsolation(gosql.LevelSerializable)).
+		Put(sql.FieldEQ(&james.ID), james)
+	Expect(txn.Commit(context.Background())).To(Succeed())
+	Expect(txn.Commit(context.Background())).To(Equal(sql.ErrTxnDone))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	peter := &User{ID: "peter", FirstName: "Peter", LastName: "Bond"}
+	err := broker.NewTxn().Put(sql.FieldEQ(&peter.ID), peter).Commit(ctx)
+	Expect(err).To(MatchError(context.Canceled))
+
+	users = nil
+	Expect(sql.SliceIt(&users, broker.ListValues(sql.FROM(UserTable, nil)))).To(Succeed())
+	Expect(users).To(ConsistOf(james))
+}
+
+func TestQueryWithCancelledContext(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond"}
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	user := &User{}
+	_, err := broker.GetValueCtx(ctx, sql.FROM(user, sql.WHERE(sql.Field(&user.ID, sql.EQ("james")))), user)
+	Expect(err).To(MatchError(context.Canceled))
+
+	var users []*User
+	err = sql.SliceIt(&users, broker.ListValuesCtx(ctx, sql.FROM(UserTable, nil)))
+	Expect(err).To(MatchError(ContainSubstring(context.Canceled.Error())))
+}
+
+func TestPostgresDialect(t *testing.T) {
+	RegisterTestingT(t)
+
+	dialect, err := sqldb.GetDialect(sqldb.PostgreSQL)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(sqldb.Rebind(dialect, "SELECT a FROM t WHERE b = ? AND c = '?' AND d IN(?,?)")).To(
+		Equal("SELECT a FROM t WHERE b = $1 AND c = '?' AND d IN($2,$3)"))
+	Expect(dialect.Upsert("users", []string{"id", "name"}, []string{"id"})).To(
+		Equal("INSERT INTO users (id, name) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET name = excluded.name"))
+
+	_, err = sqldb.GetDialect("oracle")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestExpToString(t *testing.T) {
+	RegisterTestingT(t)
+
+	sqlStr, bindings, err := sqldb.SelectExpToString(sql.FROM(UserTable,
+		sql.WHERE(sql.Field(&UserTable.LastName, sql.EQ("Bond")))))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(sqlStr).To(Equal("SELECT id, first_name, last_name, age FROM User WHERE last_name = ?"))
+	Expect(bindings).To(Equal([]interface{}{"Bond"}))
+
+	note := &Note{Author: "james", Text: "hello"}
+	sqlStr, bindings, err = sqldb.PutExpToString(sql.FieldEQ(&note.Author), note)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(sqlStr).To(Equal("UPDATE Note SET author = ?, text = ? WHERE author = ?"))
+	Expect(bindings).To(HaveLen(3))
+}
+
+func TestPagingAndAggregates(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	for _, user := range []*User{
+		{ID: "james", FirstName: "James", LastName: "Bond", Age: 40},
+		{ID: "peter", FirstName: "Peter", LastName: "Bond", Age: 30},
+		{ID: "john", FirstName: "John", LastName: "Doe", Age: 20},
+	} {
+		Expect(broker.Put(sql.FieldEQ(&user.ID), user)).To(Succeed())
+	}
+	Expect(broker.Exec("INSERT INTO User (id, age) VALUES (?, ?)", "anonymous", 10)).To(Succeed())
+
+	var page []*User
+	Expect(sql.SliceIt(&page, broker.ListValues(sql.FROM(UserTable,
+		sql.WHERE(sql.Field(&UserTable.LastName, sql.IS_NOT_NULL())),
+		sql.ORDER_BY(sql.DESC(&UserTable.Age)), sql.LIMIT(1), sql.OFFSET(1))))).To(Succeed())
+	Expect(page).To(HaveLen(1))
+	Expect(page[0].ID).To(Equal("peter"))
+
+	page = nil
+	Expect(sql.SliceIt(&page, broker.ListValues(sql.FROM(UserTable,
+		sql.WHERE(sql.Field(&UserTable.LastName, sql.LIKE("B%"))),
+		sql.ORDER_BY(sql.ASC(&UserTable.ID)), sql.OFFSET(1))))).To(Succeed())
+	Expect(page).To(HaveLen(1))
+	Expect(page[0].ID).To(Equal("peter"))
+
+	var count int64
+	found, err := broker.GetValue(sql.COUNT(UserTable, sql.WHERE(sql.Field(&UserTable.LastName, sql.EQ("Bond")))), &count)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(count).To(BeEquivalentTo(2))
+
+	found, err = broker.GetValue(sql.COUNT(UserTable, sql.WHERE(sql.Field(&UserTable.LastName, sql.IS_NULL()))), &count)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(count).To(BeEquivalentTo(1))
+
+	var sum int
+	_, err = broker.GetValue(sql.SUM(UserTable, &UserTable.Age), &sum)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(sum).To(Equal(100))
+
+	// update only selected fields
+	john := &User{ID: "john", FirstName: "Johnny", LastName: "ignored", Age: 21}
+	Expect(broker.Update(sql.UPDATE(john, sql.SET(&john.FirstName, &john.Age), sql.WHERE(sql.PK(&john.ID))))).To(Succeed())
+	var user User
+	found, err = broker.GetValue(sql.FROM(UserTable, sql.WHERE(sql.Field(&UserTable.ID, sql.EQ("john")))), &user)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(user).To(Equal(User{ID: "john", FirstName: "Johnny", LastName: "Doe", Age: 21}))
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of supported SQL dialects.
+const (
+	SQLite     = "sqlite"
+	PostgreSQL = "postgres"
+)
+
+// Dialect describes the differences between SQL databases
+// that matter for statements generated by the broker.
+type Dialect interface {
+	// Name returns the name of the dialect.
+	Name() string
+	// DriverName returns the name of the database/sql driver for the dialect.
+	DriverName() string
+	// Placeholder returns the bind variable for the n-th (starting from 1)
+	// argument of a statement.
+	Placeholder(n int) string
+	// Upsert returns a statement that inserts a row into the <table>,
+	// or updates the existing row with the same primary key.
+	Upsert(table string, columns []string, pkColumns []string) string
+	// ColumnType returns the column type for values of the go type <t>.
+	ColumnType(t reflect.Type) (string, error)
+}
+
+// GetDialect returns the Dialect with the given name.
+func GetDialect(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case SQLite, "sqlite3":
+		return &sqliteDialect{}, nil
+	case PostgreSQL, "postgresql", "pgx":
+		return &postgresDialect{}, nil
+	}
+	return nil, fmt.Errorf("unsupported SQL dialect: %q", name)
+}
+
+type sqliteDialect struct{}
+
+func (d *sqliteDialect) Name() string {
+	return SQLite
+}
+
+func (d *sqliteDialect) DriverName() string {
+	return "sqlite"
+}
+
+func (d *sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *sqliteDialect) Upsert(table string, columns []string, pkColumns []string) string {
+	return upsertOnConflict(table, columns, pkColumns)
+}
+
+func (d *sqliteDialect) ColumnType(t reflect.Type) (string, error) {
+	return columnType(t, map[reflect.Kind]string{
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int:     "INTEGER",
+		reflect.Int8:    "INTEGER",
+		reflect.Int16:   "INTEGER",
+		reflect.Int32:   "INTEGER",
+		reflect.Int64:   "INTEGER",
+		reflect.Uint:    "INTEGER",
+		reflect.Uint8:   "INTEGER",
+		reflect.Uint16:  "INTEGER",
+		reflect.Uint32:  "INTEGER",
+		reflect.Uint64:  "INTEGER",
+		reflect.Float32: "REAL",
+		reflect.Float64: "REAL",
+		reflect.String:  "TEXT",
+	}, "BLOB", "TIMESTAMP")
+}
+
+type postgresDialect struct{}
+
+func (d *postgresDialect) Name() string {
+	return PostgreSQL
+}
+
+func (d *postgresDialect) DriverName() string {
+	return "postgres"
+}
+
+func (d *postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (d *postgresDialect) Upsert(table string, columns []string, pkColumns []string) string {
+	return upsertOnConflict(table, columns, pkColumns)
+}
+
+func (d *postgresDialect) ColumnType(t reflect.Type) (string, error) {
+	return columnType(t, map[reflect.Kind]string{
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int:     "BIGINT",
+		reflect.Int8:    "SMALLINT",
+		reflect.Int16:   "SMALLINT",
+		reflect.Int32:   "INTEGER",
+		reflect.Int64:   "BIGINT",
+		reflect.Uint:    "BIGINT",
+		reflect.Uint8:   "SMALLINT",
+		reflect.Uint16:  "INTEGER",
+		reflect.Uint32:  "BIGINT",
+		reflect.Uint64:  "NUMERIC(20)",
+		reflect.Float32: "REAL",
+		reflect.Float64: "DOUBLE PRECISION",
+		reflect.String:  "TEXT",
+	}, "BYTEA", "TIMESTAMP WITH TIME ZONE")
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// columnType maps the go type <t> (dereferenced if it is a pointer)
+// to the column type using the types of the dialect.
+func columnType(t reflect.Type, kinds map[reflect.Kind]string, bytesType, timeStampType string) (string, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return timeStampType, nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return bytesType, nil
+	}
+	if typ, ok := kinds[t.Kind()]; ok {
+		return typ, nil
+	}
+	return "", fmt.Errorf("unsupported column type %v", t)
+}
+
+// upsertOnConflict generates INSERT ... ON CONFLICT statement understood
+// by both SQLite (3.24+) and PostgreSQL (9.5+).
+func upsertOnConflict(table string, columns []string, pkColumns []string) string {
+	var buf strings.Builder
+	buf.WriteString("INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (")
+	buf.WriteString(strings.TrimPrefix(strings.Repeat(", ?", len(columns)), ", "))
+	buf.WriteString(") ON CONFLICT (" + strings.Join(pkColumns, ", ") + ") DO ")
+
+	var updates []string
+	for _, column := range columns {
+		if !containsString(pkColumns, column) {
+			updates = append(updates, column+" = excluded."+column)
+		}
+	}
+	if len(updates) == 0 {
+		buf.WriteString("NOTHING")
+	} else {
+		buf.WriteString("UPDATE SET " + strings.Join(updates, ", "))
+	}
+	return buf.String()
+}
+
+// Rebind replaces "?" bind variables in the <statement> with placeholders
+// of the given <dialect>. Question marks inside quoted strings
+// and identifiers are left untouched.
+func Rebind(dialect Dialect, statement string) string {
+	if dialect.Placeholder(1) == "?" {
+		return statement
+	}
+	var (
+		buf   strings.Builder
+		quote rune
+		n     int
+	)
+	for _, c := range statement {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			buf.WriteString(dialect.Placeholder(n))
+			continue
+		}
+		buf.WriteRune(c)
+	}
+	return buf.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
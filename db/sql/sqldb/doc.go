@@ -0,0 +1,21 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqldb implements the SQL data broker API (see package sql)
+// on top of the Go standard library database/sql. SQL statements are
+// generated from Go structures and rendered for the SQL dialect of
+// the database in use (SQLite or PostgreSQL). The database driver
+// of the dialect is registered by importing the subpackage sqlite
+// or postgres.
+package sqldb
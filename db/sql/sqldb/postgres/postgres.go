@@ -0,0 +1,25 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package postgres registers the PostgreSQL driver (github.com/lib/pq)
+// used by the sqldb plugin for the "postgres" dialect.
+// Import it for its side effect only:
+//
+//	import _ "go.ligato.io/cn-infra/v2/db/sql/sqldb/postgres"
+package postgres
+
+import (
+	// PostgreSQL driver registered as "postgres"
+	_ "github.com/lib/pq"
+)
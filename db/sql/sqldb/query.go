@@ -0,0 +1,231 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+	"go.ligato.io/cn-infra/v2/utils/structs"
+)
+
+// ColumnTag is the struct tag with the name of the column that
+// a field is mapped to (e.g. `sql:"first_name"`). Fields without
+// the tag are mapped to columns named after the field.
+const ColumnTag = "sql"
+
+var (
+	// ErrMissingVisitorEntity is error returned when visitor is missing entity.
+	ErrMissingVisitorEntity = errors.New("sqldb: visitor is missing entity")
+
+	// ErrMissingEntityField is error returned when visitor entity is missing field.
+	ErrMissingEntityField = errors.New("sqldb: visitor entity is missing field")
+
+	// ErrUnexportedEntityField is error returned when visitor entity has unexported field.
+	ErrUnexportedEntityField = errors.New("sqldb: visitor entity with unexported field")
+
+	// ErrNoColumns is error returned when entity has no field mapped to a column.
+	ErrNoColumns = errors.New("sqldb: entity has no columns")
+
+	// ErrNoPrimaryKey is error returned when entity without primary key is put without where condition.
+	ErrNoPrimaryKey = errors.New("sqldb: entity has no primary key")
+)
+
+// SelectExpToString converts expression to string & slice of bindings.
+// The selected columns are the columns of the entity referenced by FROM,
+// unless the expression already selects the result (e.g. sql.COUNT).
+func SelectExpToString(fromWhere sql.Expression) (sqlStr string, bindings []interface{}, err error) {
+	if exp, ok := fromWhere.(*sql.PrefixedExp); ok && strings.HasPrefix(exp.Prefix, "SELECT ") {
+		return ExpToString(fromWhere)
+	}
+
+	findEntity := &findEntityVisitor{}
+	fromWhere.Accept(findEntity)
+	if findEntity.entity == nil {
+		return "", nil, ErrMissingVisitorEntity
+	}
+
+	fromWhereStr, bindings, err := ExpToString(fromWhere)
+	if err != nil {
+		return "", nil, err
+	}
+	return "SELECT " + strings.Join(columnNames(sql.EntityColumns(findEntity.entity, ColumnTag)), ", ") +
+		fromWhereStr, bindings, nil
+}
+
+// PutExpToString converts expression to UPDATE statement that sets all
+// non-primary-key columns of the <entity> & slice of bindings.
+func PutExpToString(whereCondition sql.Expression, entity interface{}) (sqlStr string, bindings []interface{},
+	err error) {
+
+	var columns []sql.Column
+	for _, column := range sql.EntityColumns(entity, ColumnTag) {
+		if !column.PrimaryKey {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		return "", nil, ErrNoColumns
+	}
+
+	whereStr := &toStringVisitor{entity: entity}
+	whereCondition.Accept(whereStr)
+	if whereStr.lastError != nil {
+		return "", nil, whereStr.lastError
+	}
+
+	sets := make([]string, 0, len(columns))
+	for _, column := range columns {
+		sets = append(sets, column.Name+" = ?")
+	}
+	bindings = append(columnValues(entity, columns), whereStr.Binding()...)
+	return "UPDATE " + sql.EntityTableName(entity) + " SET " + strings.Join(sets, ", ") +
+		" WHERE " + whereStr.String(), bindings, nil
+}
+
+// ExpToString converts expression to string & slice of bindings
+func ExpToString(exp sql.Expression) (sqlStr string, bindings []interface{}, err error) {
+	findEntity := &findEntityVisitor{}
+	exp.Accept(findEntity)
+
+	stringer := &toStringVisitor{entity: findEntity.entity}
+	exp.Accept(stringer)
+
+	return stringer.String(), stringer.Binding(), stringer.lastError
+}
+
+// columnNames returns names of the <columns>.
+func columnNames(columns []sql.Column) []string {
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+// columnValues returns values of the <entity> fields mapped to the <columns>.
+func columnValues(entity interface{}, columns []sql.Column) []interface{} {
+	val := reflect.Indirect(reflect.ValueOf(entity))
+	values := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		values = append(values, val.FieldByIndex(column.Field.Index).Interface())
+	}
+	return values
+}
+
+// noLimit is used for OFFSET without LIMIT (SQLite requires LIMIT before OFFSET)
+const noLimit = " LIMIT 9223372036854775807"
+
+type toStringVisitor struct {
+	entity    interface{}
+	generated bytes.Buffer
+	binding   []interface{}
+	lastError error
+	limit     bool
+}
+
+// String converts generated byte Buffer to string
+func (visitor *toStringVisitor) String() string {
+	return visitor.generated.String()
+}
+
+// Binding is a getter...
+func (visitor *toStringVisitor) Binding() []interface{} {
+	return visitor.binding
+}
+
+// VisitPrefixedExp generates part of SQL expression
+func (visitor *toStringVisitor) VisitPrefixedExp(exp *sql.PrefixedExp) {
+	switch exp.Prefix {
+	case "FROM":
+		visitor.generated.WriteString(" FROM ")
+		visitor.generated.WriteString(sql.EntityTableName(visitor.entity))
+	case "UPDATE":
+		visitor.generated.WriteString("UPDATE ")
+		visitor.generated.WriteString(sql.EntityTableName(visitor.entity))
+	case " LIMIT ":
+		visitor.limit = true
+		visitor.generated.WriteString(exp.Prefix)
+	case " OFFSET ":
+		if !visitor.limit {
+			visitor.generated.WriteString(noLimit)
+		}
+		visitor.generated.WriteString(exp.Prefix)
+	default:
+		visitor.generated.WriteString(exp.Prefix)
+	}
+	for _, exp := range exp.AfterPrefix {
+		if exp != nil {
+			exp.Accept(visitor)
+		}
+	}
+	visitor.generated.WriteString(exp.Suffix)
+
+	if exp.Prefix != "FROM" && exp.Prefix != "UPDATE" && len(exp.Binding) > 0 {
+		visitor.binding = append(visitor.binding, exp.Binding...)
+	}
+}
+
+// VisitFieldExpression generates part of SQL expression
+func (visitor *toStringVisitor) VisitFieldExpression(exp *sql.FieldExpression) {
+	if visitor.entity == nil {
+		visitor.lastError = ErrMissingVisitorEntity
+		return
+	}
+	field, found := structs.FindField(exp.PointerToAField, visitor.entity)
+	if !found {
+		visitor.lastError = ErrMissingEntityField
+		return
+	}
+	columnName, exported := sql.FieldColumnName(field, ColumnTag)
+	if !exported {
+		visitor.lastError = ErrUnexportedEntityField
+		return
+	}
+	visitor.generated.WriteString(columnName)
+
+	if exp.AfterField != nil {
+		exp.AfterField.Accept(visitor)
+	}
+}
+
+type findEntityVisitor struct {
+	entity interface{}
+}
+
+// VisitPrefixedExp checks for "FROM" or "UPDATE" expression to find out the entity
+func (visitor *findEntityVisitor) VisitPrefixedExp(exp *sql.PrefixedExp) {
+	if exp.Prefix == "FROM" || exp.Prefix == "UPDATE" {
+		if len(exp.Binding) == 1 && reflect.Indirect(reflect.ValueOf(exp.Binding[0])).Kind() == reflect.Struct {
+			visitor.entity = exp.Binding[0]
+		}
+	} else {
+		for _, exp := range exp.AfterPrefix {
+			if exp != nil {
+				exp.Accept(visitor)
+			}
+		}
+	}
+}
+
+// VisitFieldExpression just propagates to AfterFieldExpression
+func (visitor *findEntityVisitor) VisitFieldExpression(exp *sql.FieldExpression) {
+	if exp.AfterField != nil {
+		exp.AfterField.Accept(visitor)
+	}
+}
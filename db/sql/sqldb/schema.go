@@ -0,0 +1,45 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb
+
+import (
+	"go.ligato.io/cn-infra/v2/db/sql"
+)
+
+// NewSchemaBuilder returns sql.SchemaBuilder that generates DDL statements
+// for the <dialect>. Column types are mapped by the dialect
+// unless overridden by the sql.ColumnTypeTag.
+func NewSchemaBuilder(dialect Dialect) sql.SchemaBuilder {
+	return &schemaBuilder{dialect: dialect}
+}
+
+type schemaBuilder struct {
+	dialect Dialect
+}
+
+// CreateTable - see the description in interface sql.SchemaBuilder.CreateTable().
+func (s *schemaBuilder) CreateTable(entity interface{}) (string, error) {
+	return sql.CreateTableStatement(entity, ColumnTag, s.dialect.ColumnType)
+}
+
+// CreateIndex - see the description in interface sql.SchemaBuilder.CreateIndex().
+func (s *schemaBuilder) CreateIndex(entity interface{}, pointerToAField interface{}) (string, error) {
+	return sql.CreateIndexStatement(entity, pointerToAField, ColumnTag)
+}
+
+// DropTable - see the description in interface sql.SchemaBuilder.DropTable().
+func (s *schemaBuilder) DropTable(entity interface{}) string {
+	return sql.DropTableStatement(entity)
+}
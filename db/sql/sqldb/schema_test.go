@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+	"go.ligato.io/cn-infra/v2/db/sql/sqldb"
+)
+
+// Event structure for testing of column types
+type Event struct {
+	ID      int64      `sql:"id" pk:"id"`
+	Created time.Time  `sql:"created"`
+	Data    []byte     `sql:"data"`
+	Ratio   float64    `sql:"ratio"`
+	Done    *bool      `sql:"done"`
+	Name    string     `sql:"name" dbtype:"VARCHAR(64)"`
+	Parent  *time.Time `sql:"-"`
+}
+
+func TestCreateTable(t *testing.T) {
+	RegisterTestingT(t)
+
+	sqlite, _ := sqldb.GetDialect(sqldb.SQLite)
+	statement, err := sqldb.NewSchemaBuilder(sqlite).CreateTable(&Event{})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(statement).To(Equal("CREATE TABLE IF NOT EXISTS Event (id INTEGER, created TIMESTAMP, data BLOB, " +
+		"ratio REAL, done BOOLEAN, name VARCHAR(64), PRIMARY KEY (id))"))
+
+	postgres, _ := sqldb.GetDialect(sqldb.PostgreSQL)
+	statement, err = sqldb.NewSchemaBuilder(postgres).CreateTable(&Event{})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(statement).To(Equal("CREATE TABLE IF NOT EXISTS Event (id BIGINT, created TIMESTAMP WITH TIME ZONE, " +
+		"data BYTEA, ratio DOUBLE PRECISION, done BOOLEAN, name VARCHAR(64), PRIMARY KEY (id))"))
+
+	_, err = sqldb.NewSchemaBuilder(postgres).CreateTable(&Note{})
+	Expect(err).To(Equal(sql.ErrMissingPrimaryKey))
+}
+
+func TestMigrations(t *testing.T) {
+	RegisterTestingT(t)
+
+	p := sqldb.NewPlugin(sqldb.UseConf(sqldb.Config{
+		Dialect: sqldb.SQLite,
+		DSN:     filepath.Join(t.TempDir(), "test.db"),
+	}))
+	Expect(p.Init()).To(Succeed())
+	defer p.Close()
+	broker := p.NewBroker()
+	schema := p.Schema()
+
+	createIndex, err := schema.CreateIndex(UserTable, &UserTable.LastName)
+	Expect(err).ToNot(HaveOccurred())
+	migrator, err := sql.NewMigrator(broker, schema,
+		sql.Migration{
+			Version:     2,
+			Description: "index users by last name",
+			Up:          sql.Statements(createIndex),
+			Down:        sql.Statements("DROP INDEX User_last_name_idx"),
+		},
+		sql.Migration{
+			Version:     1,
+			Description: "create tables",
+			Up:          sql.CreateTables(schema, &User{}, &Event{}),
+			Down:        sql.DropTables(schema, &User{}, &Event{}),
+		},
+	)
+	Expect(err).ToNot(HaveOccurred())
+
+	version, err := migrator.Version()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(version).To(BeZero())
+
+	Expect(migrator.Up()).To(Succeed())
+	version, err = migrator.Version()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(version).To(BeEquivalentTo(2))
+
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond"}
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+
+	var applied []*sql.SchemaMigration
+	Expect(sql.SliceIt(&applied, broker.ListValues(sql.FROM(&sql.SchemaMigration{}, nil)))).To(Succeed())
+	Expect(applied).To(HaveLen(2))
+	Expect(applied[0].Description).To(Equal("create tables"))
+	Expect(applied[0].AppliedAt).To(BeTemporally("~", time.Now(), time.Minute))
+
+	// applying again is no-op
+	Expect(migrator.Up()).To(Succeed())
+
+	Expect(migrator.Down()).To(Succeed())
+	version, err = migrator.Version()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(version).To(BeEquivalentTo(1))
+
+	Expect(migrator.Migrate(0)).To(Succeed())
+	version, err = migrator.Version()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(version).To(BeZero())
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).ToNot(Succeed())
+
+	_, err = sql.NewMigrator(broker, schema,
+		sql.Migration{Version: 1, Up: sql.Statements()},
+		sql.Migration{Version: 1, Up: sql.Statements()},
+	)
+	Expect(err).To(HaveOccurred())
+}
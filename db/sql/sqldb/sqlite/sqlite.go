@@ -0,0 +1,25 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlite registers the pure Go SQLite driver (modernc.org/sqlite,
+// no cgo required) used by the sqldb plugin for the "sqlite" dialect.
+// Import it for its side effect only:
+//
+//	import _ "go.ligato.io/cn-infra/v2/db/sql/sqldb/sqlite"
+package sqlite
+
+import (
+	// SQLite driver registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb
+
+import (
+	"context"
+	gosql "database/sql"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+)
+
+// txnOp is one operation of a transaction executed during the commit.
+type txnOp func(ctx context.Context, ex execer) error
+
+// Txn allows to group operations into the transaction. Operations are executed
+// in the order they were added, inside a single database transaction, on Commit.
+type Txn struct {
+	broker  *BrokerSQL
+	options sql.TxnOptions
+	ops     []txnOp
+	done    bool
+}
+
+// NewTxn creates a new Data Broker transaction. A transaction can
+// hold multiple operations that are all committed to the data
+// store together. After a transaction has been created, one or
+// more operations (put or delete) can be added to the transaction
+// before it is committed.
+// Isolation level and read-only mode of the transaction can be set
+// by sql.WithIsolation and sql.WithReadOnly options.
+func (b *BrokerSQL) NewTxn(opts ...sql.TxnOption) sql.Txn {
+	return &Txn{broker: b, options: sql.NewTxnOptions(opts...)}
+}
+
+// Put adds put operation (see BrokerSQL.Put) into the transaction.
+func (txn *Txn) Put(where sql.Expression, data interface{}) sql.Txn {
+	txn.ops = append(txn.ops, func(ctx context.Context, ex execer) error {
+		return txn.broker.put(ctx, ex, where, data)
+	})
+	return txn
+}
+
+// Delete adds delete operation (see BrokerSQL.Delete) into the transaction.
+func (txn *Txn) Delete(fromWhere sql.Expression) sql.Txn {
+	txn.ops = append(txn.ops, func(ctx context.Context, ex execer) error {
+		return txn.broker.delete(ctx, ex, fromWhere)
+	})
+	return txn
+}
+
+// Commit executes all operations of the transaction. If any of them fails
+// or the <ctx> is cancelled (or the plugin is closed), the transaction is rolled
+// back and the error is returned.
+func (txn *Txn) Commit(ctx context.Context) error {
+	if txn.done {
+		return sql.ErrTxnDone
+	}
+	txn.done = true
+	ctx, cancel := txn.broker.withBrokerContext(ctx)
+	defer cancel()
+
+	tx, err := txn.broker.db.BeginTx(ctx, &gosql.TxOptions{
+		Isolation: txn.options.Isolation,
+		ReadOnly:  txn.options.ReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+	for _, op := range txn.ops {
+		if err := op(ctx, tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Rollback discards operations of the transaction.
+func (txn *Txn) Rollback() error {
+	if txn.done {
+		return sql.ErrTxnDone
+	}
+	txn.done = true
+	txn.ops = nil
+	return nil
+}
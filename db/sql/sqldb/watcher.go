@@ -0,0 +1,28 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb
+
+import (
+	"time"
+
+	"go.ligato.io/cn-infra/v2/db/sql"
+)
+
+// NewWatcher returns watcher that polls the database every <interval> and delivers
+// changes of the watched tables or queries (see sql.PollingWatcher).
+// Rows are identified by values of <keyColumns> (typically the primary key).
+func (b *BrokerSQL) NewWatcher(interval time.Duration, keyColumns ...string) *sql.PollingWatcher {
+	return sql.NewPollingWatcher(b, ColumnTag, interval, keyColumns...)
+}
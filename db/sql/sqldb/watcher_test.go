@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqldb_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/datasync"
+	"go.ligato.io/cn-infra/v2/db/sql"
+)
+
+func TestWatch(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond", Age: 40}
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+
+	watcher := broker.NewWatcher(10*time.Millisecond, "id")
+	defer watcher.Close()
+
+	respCh := make(chan sql.WatchResp, 10)
+	Expect(watcher.Watch(sql.ToChan(respCh), "User")).To(Succeed())
+	Consistently(respCh, 50*time.Millisecond).ShouldNot(Receive())
+
+	// new row
+	peter := &User{ID: "peter", FirstName: "Peter", LastName: "Bond", Age: 30}
+	Expect(broker.Put(sql.FieldEQ(&peter.ID), peter)).To(Succeed())
+	var resp sql.WatchResp
+	Eventually(respCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	Expect(resp.GetKey()).To(Equal("peter"))
+	var user User
+	Expect(resp.GetValue(&user)).To(Succeed())
+	Expect(user).To(Equal(*peter))
+
+	// changed row
+	james.Age = 41
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+	Eventually(respCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	Expect(resp.GetKey()).To(Equal("james"))
+	row := map[string]interface{}{}
+	Expect(resp.GetValue(row)).To(Succeed())
+	Expect(row).To(HaveKeyWithValue("age", BeEquivalentTo(41)))
+
+	// removed row
+	Expect(broker.Delete(sql.FROM(peter, sql.WHERE(sql.FieldEQ(&peter.ID))))).To(Succeed())
+	Eventually(respCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Delete))
+	Expect(resp.GetKey()).To(Equal("peter"))
+	user = User{}
+	Expect(resp.GetValue(&user)).To(Succeed())
+	Expect(user).To(Equal(*peter))
+
+	Consistently(respCh, 50*time.Millisecond).ShouldNot(Receive())
+}
+
+func TestWatchQuery(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	watcher := broker.NewWatcher(10 * time.Millisecond)
+	defer watcher.Close()
+
+	respCh := make(chan sql.WatchResp, 10)
+	Expect(watcher.Watch(sql.ToChan(respCh), "SELECT id, age FROM User WHERE last_name = 'Bond'")).To(Succeed())
+	Expect(watcher.Watch(sql.ToChan(respCh), "SELECT * FROM missing_table")).ToNot(Succeed())
+
+	john := &User{ID: "john", FirstName: "John", LastName: "Doe", Age: 20}
+	Expect(broker.Put(sql.FieldEQ(&john.ID), john)).To(Succeed())
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond", Age: 40}
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+
+	var resp sql.WatchResp
+	Eventually(respCh).Should(Receive(&resp))
+	Expect(resp.GetChangeType()).To(Equal(datasync.Put))
+	Expect(resp.GetKey()).To(Equal("40/james"))
+	var user User
+	Expect(resp.GetValue(&user)).To(Succeed())
+	Expect(user).To(Equal(User{ID: "james", Age: 40}))
+
+	// without key columns change is delivered as delete & put
+	james.Age = 41
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+	var changes []datasync.Op
+	for i := 0; i < 2; i++ {
+		Eventually(respCh).Should(Receive(&resp))
+		changes = append(changes, resp.GetChangeType())
+	}
+	Expect(changes).To(ConsistOf(datasync.Delete, datasync.Put))
+}
+
+func TestWatchFailure(t *testing.T) {
+	RegisterTestingT(t)
+	broker := newBroker(t)
+
+	watcher := broker.NewWatcher(10*time.Millisecond, "id")
+	defer watcher.Close()
+
+	// no statement is watched if any of them fails
+	respCh := make(chan sql.WatchResp, 10)
+	Expect(watcher.Watch(sql.ToChan(respCh), "User", "missing_table")).ToNot(Succeed())
+	james := &User{ID: "james", FirstName: "James", LastName: "Bond", Age: 40}
+	Expect(broker.Put(sql.FieldEQ(&james.ID), james)).To(Succeed())
+	Consistently(respCh, 50*time.Millisecond).ShouldNot(Receive())
+
+	// queries returning too many rows are not watched
+	peter := &User{ID: "peter", FirstName: "Peter", LastName: "Bond", Age: 30}
+	Expect(broker.Put(sql.FieldEQ(&peter.ID), peter)).To(Succeed())
+	watcher = broker.NewWatcher(10*time.Millisecond, "id")
+	defer watcher.Close()
+	watcher.SetMaxRows(1)
+	Expect(watcher.Watch(sql.ToChan(respCh), "User")).ToNot(Succeed())
+	Expect(watcher.Watch(sql.ToChan(respCh), "SELECT * FROM User WHERE id = 'james'")).To(Succeed())
+}
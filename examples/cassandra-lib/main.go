@@ -38,10 +38,10 @@ type User struct {
 	MiddleName string     `cql:"middle_name"`
 	LastName   string     `cql:"last_name"`
 	//NetIP      net.IP //mapped to native cassandra type
-	WrapIP *Wrapper01 //used for custom (un)marshalling
-	Udt03  *Udt03
-	Udt04  Udt04
-	UdtCol []Udt03
+	WrapIP *Wrapper01 `dbtype:"text"` //used for custom (un)marshalling
+	Udt03  *Udt03     `dbtype:"frozen<Udt03>"`
+	Udt04  Udt04      `dbtype:"frozen<Udt04>"`
+	UdtCol []Udt03    `dbtype:"list<frozen<Udt03>>"`
 }
 
 // SchemaName demo schema name
@@ -94,7 +94,7 @@ func loadConfig() (cassandra.Config, error) {
 }
 
 func exampleKeyspace(session *gocql.Session) (err error) {
-	return session.Query("CREATE KEYSPACE IF NOT EXISTS demo WITH replication = {'class': 'SimpleStrategy', 'replication_factor' : 1};").Exec()
+	return session.Query(cassandra.CreateKeyspace("demo", 1)).Exec()
 }
 
 func example(session *gocql.Session) (err error) {
@@ -111,10 +111,6 @@ func example(session *gocql.Session) (err error) {
 }
 
 func exampleDDL(session *gocql.Session) (err error) {
-	if err := session.Query("CREATE KEYSPACE IF NOT EXISTS demo WITH replication = {'class': 'SimpleStrategy', 'replication_factor' : 1};").
-		Exec(); err != nil {
-		return err
-	}
 	if err := session.Query(`CREATE TYPE IF NOT EXISTS demo.udt03 (
 		tx text,
 		tx2 text)`).Exec(); err != nil {
@@ -126,25 +122,20 @@ func exampleDDL(session *gocql.Session) (err error) {
 		return err
 	}
 
-	if err := session.Query(`CREATE TABLE IF NOT EXISTS demo.user (
-			userid uuid PRIMARY KEY,
-				first_name text,
-				middle_name text,
-				last_name text,
-				Udt03 frozen<Udt03>,
-				Udt04 frozen<Udt04>,
-				UdtCol list<frozen<Udt03>>,
-				NetIP inet,
-				WrapIP text,
-				emails set<text>,
-				topscores list<int>,
-				todo map<timestamp, text>
-		);`).
-		Exec(); err != nil {
+	// table & index are generated from the struct metadata
+	schema := cassandra.NewSchemaBuilder()
+	createTable, err := schema.CreateTable(UserTable)
+	if err != nil {
 		return err
 	}
-
-	return session.Query("CREATE INDEX IF NOT EXISTS demo_users_last_name ON demo.user (last_name);").Exec()
+	if err := session.Query(createTable).Exec(); err != nil {
+		return err
+	}
+	createIndex, err := schema.CreateIndex(UserTable, &UserTable.LastName)
+	if err != nil {
+		return err
+	}
+	return session.Query(createIndex).Exec()
 }
 
 func exampleDML(session *gocql.Session) (err error) {
@@ -73,8 +73,9 @@ func main() {
 	input := []byte(os.Args[1])
 	fmt.Printf("> Input value:\n%v\n", string(input))
 
-	// Encrypt input string using public key
-	encrypted, err := client.EncryptData(input, publicKey)
+	// Encrypt input string using random data key encrypted by public key,
+	// the size of the input is not limited by the size of the key
+	encrypted, err := client.EncryptDataEnvelope(input, publicKey)
 	if err != nil {
 		panic(err)
 	}
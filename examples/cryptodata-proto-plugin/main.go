@@ -15,15 +15,8 @@
 package main
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/base64"
-	"encoding/pem"
-	"io/ioutil"
 	"log"
 
-	"github.com/pkg/errors"
-
 	"go.ligato.io/cn-infra/v2/agent"
 	"go.ligato.io/cn-infra/v2/datasync"
 	"go.ligato.io/cn-infra/v2/db/cryptodata"
@@ -80,26 +73,13 @@ func (plugin *ExamplePlugin) String() string {
 
 // Init starts the consumer.
 func (plugin *ExamplePlugin) Init() error {
-	// Read public key
-	publicKey, err := readPublicKey("../cryptodata-lib/key-pub.pem")
-	if err != nil {
-		return err
-	}
-
-	// Prepare data
-	key1, err := plugin.encryptData("cryptoKey1", publicKey)
-	if err != nil {
-		return err
-	}
-	key2, err := plugin.encryptData("cryptoKey2", publicKey)
-	if err != nil {
-		return err
-	}
-	encryptedData := &ipsec.TunnelInterfaces{
+	// Prepare data, crypto keys are encrypted by the crypto layer on write
+	// using public key configured in cryptodata.conf
+	data := &ipsec.TunnelInterfaces{
 		Tunnels: []*ipsec.TunnelInterfaces_Tunnel{
 			{
 				Name:           "tunnel1",
-				LocalCryptoKey: key1,
+				LocalCryptoKey: "cryptoKey1",
 				IpAddresses: []string{
 					"192.168.0.1",
 					"192.168.0.2",
@@ -107,7 +87,7 @@ func (plugin *ExamplePlugin) Init() error {
 			},
 			{
 				Name:            "tunnel2",
-				RemoteCryptoKey: key2,
+				RemoteCryptoKey: "cryptoKey2",
 				IpAddresses: []string{
 					"192.168.0.5",
 					"192.168.0.8",
@@ -115,7 +95,7 @@ func (plugin *ExamplePlugin) Init() error {
 			},
 		},
 	}
-	plugin.Log.Infof("Putting value %v", encryptedData)
+	plugin.Log.Infof("Putting value %v", data)
 
 	// Prepare path for storing the data
 	key := plugin.etcdKey(ipsec.KeyPrefix)
@@ -136,8 +116,8 @@ func (plugin *ExamplePlugin) Init() error {
 	// Start watching
 	watcher.Watch(plugin.watchChanges, nil, key)
 
-	// Put proto data to ETCD
-	err = broker.Put(key, encryptedData)
+	// Put proto data to ETCD, crypto keys are encrypted with crypto layer
+	err := broker.Put(key, data)
 	if err != nil {
 		return err
 	}
@@ -187,35 +167,3 @@ func (plugin *ExamplePlugin) watchChanges(x datasync.ProtoWatchResp) {
 func (plugin *ExamplePlugin) etcdKey(label string) string {
 	return "/vnf-agent/" + plugin.ServiceLabel.GetAgentLabel() + "/api/v1/example/db/simple/" + label
 }
-
-// encryptData first encrypts the provided value using crypto layer and then encodes
-// the data with base64 for JSON compatibility
-func (plugin *ExamplePlugin) encryptData(value string, publicKey *rsa.PublicKey) (string, error) {
-	encryptedValue, err := plugin.CryptoData.EncryptData([]byte(value), publicKey)
-	if err != nil {
-		return "", err
-	}
-	return base64.URLEncoding.EncodeToString(encryptedValue), nil
-}
-
-// readPublicKey reads rsa public key from PEM file on provided path
-func readPublicKey(path string) (*rsa.PublicKey, error) {
-	bytes, err := ioutil.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	block, _ := pem.Decode(bytes)
-	if block == nil {
-		return nil, errors.New("failed to decode PEM for key " + path)
-	}
-	pubInterface, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return nil, err
-	}
-	publicKey, ok := pubInterface.(*rsa.PublicKey)
-	if !ok {
-		return nil, errors.New("failed to convert public key to rsa.PublicKey")
-	}
-
-	return publicKey, nil
-}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statuscheck
+
+import (
+	"context"
+	"time"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/health/statuscheck/model/status"
+)
+
+// ReportElection reports the state of the <election> running on the given
+// <prefix> in the global agent status. The state is reported whenever
+// the leader changes or the leadership of this agent is gained or lost,
+// the state of the plugin running the election is not affected.
+// The call blocks until the context is canceled.
+func ReportElection(ctx context.Context, sw ElectionStatusWriter, prefix string, election keyval.Election) {
+	leaders := election.Observe(ctx)
+	// leadership is re-checked periodically, since the leader may be observed
+	// before the campaign of this agent returns
+	ticker := time.NewTicker(PeriodicProbingTimeout)
+	defer ticker.Stop()
+
+	var (
+		leader   string
+		reported *status.ElectionStatus
+	)
+	for {
+		lost := election.Lost()
+		select {
+		case <-lost:
+			lost = nil
+		default:
+		}
+		isLeader := lost != nil
+		if reported == nil || reported.Leader != leader || reported.IsLeader != isLeader {
+			reported = &status.ElectionStatus{
+				Prefix:     prefix,
+				Leader:     leader,
+				IsLeader:   isLeader,
+				LastChange: time.Now().Unix(),
+			}
+			sw.ReportElectionStatus(reported)
+		}
+
+		select {
+		case newLeader, ok := <-leaders:
+			if !ok {
+				return
+			}
+			leader = newLeader
+		case <-lost:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statuscheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"go.ligato.io/cn-infra/v2/db/keyval"
+	"go.ligato.io/cn-infra/v2/health/statuscheck/model/status"
+)
+
+type testElection struct {
+	keyval.Election
+	leaders chan string
+	lost    chan struct{}
+}
+
+func (e *testElection) Observe(ctx context.Context) <-chan string {
+	return e.leaders
+}
+
+func (e *testElection) Lost() <-chan struct{} {
+	return e.lost
+}
+
+func (p *Plugin) pluginState(name string) (status.OperationalState, string) {
+	p.access.Lock()
+	defer p.access.Unlock()
+	return p.pluginStat[name].State, p.pluginStat[name].Error
+}
+
+func (p *Plugin) electionStatus(prefix string) *status.ElectionStatus {
+	p.access.Lock()
+	defer p.access.Unlock()
+	for _, election := range p.agentStat.Elections {
+		if election.Prefix == prefix {
+			return election
+		}
+	}
+	return nil
+}
+
+func TestReportElectionKeepsPluginState(t *testing.T) {
+	RegisterTestingT(t)
+
+	p := NewPlugin()
+	Expect(p.Init()).To(Succeed())
+	defer p.Close()
+	p.Register("etcd", nil)
+	p.ReportStateChange("etcd", OK, nil)
+
+	election := &testElection{
+		leaders: make(chan string),
+		lost:    make(chan struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		ReportElection(ctx, p, "/election/", election)
+		close(done)
+	}()
+
+	// initial state, this agent holds the leadership
+	Eventually(func() *status.ElectionStatus {
+		return p.electionStatus("/election/")
+	}).ShouldNot(BeNil())
+	Expect(p.electionStatus("/election/").IsLeader).To(BeTrue())
+
+	// error reported by the plugin after the election has started
+	p.ReportStateChange("etcd", Error, errors.New("connection lost"))
+
+	// leader change is reported, the plugin state is kept
+	election.leaders <- "agent1"
+	Eventually(func() string {
+		return p.electionStatus("/election/").Leader
+	}).Should(Equal("agent1"))
+	state, lastErr := p.pluginState("etcd")
+	Expect(state).To(Equal(status.OperationalState_ERROR))
+	Expect(lastErr).To(Equal("connection lost"))
+
+	// loss of the leadership is reported, the plugin state is kept
+	close(election.lost)
+	Eventually(func() bool {
+		return p.electionStatus("/election/").IsLeader
+	}).Should(BeFalse())
+	Consistently(func() status.OperationalState {
+		state, _ := p.pluginState("etcd")
+		return state
+	}, 100*time.Millisecond).Should(Equal(status.OperationalState_ERROR))
+
+	cancel()
+	Eventually(done).Should(BeClosed())
+}
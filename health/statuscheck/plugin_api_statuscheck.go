@@ -61,6 +61,15 @@ type PluginStatusWriter interface {
 	ReportStateChangeWithMeta(pluginName infra.PluginName, state PluginState, lastError error, meta proto.Message)
 }
 
+// ElectionStatusWriter allows to report the state of leader elections
+// in the global agent status. Unlike ReportStateChangeWithMeta, the state
+// of the reporting plugin is left untouched.
+type ElectionStatusWriter interface {
+	// ReportElectionStatus stores the state of the election running
+	// on the prefix of <election>. Unchanged state is filtered out.
+	ReportElectionStatus(election *status.ElectionStatus)
+}
+
 // AgentStatusReader allows to lookup agent status by other plugins.
 type AgentStatusReader interface {
 	// GetAgentStatus returns the current global operational state of the agent.
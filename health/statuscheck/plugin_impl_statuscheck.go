@@ -151,11 +151,19 @@ func (p *Plugin) ReportStateChangeWithMeta(pluginName infra.PluginName, state Pl
 	switch data := meta.(type) {
 	case *status.InterfaceStats_Interface:
 		p.reportInterfaceStateChange(data)
+	case *status.ElectionStatus:
+		p.reportElectionStateChange(data)
 	default:
 		p.Log.Debug("Unknown type of status metadata")
 	}
 }
 
+// ReportElectionStatus can be used to report the state of a leader election without changing
+// the state of any plugin.
+func (p *Plugin) ReportElectionStatus(election *status.ElectionStatus) {
+	p.reportElectionStateChange(election)
+}
+
 func (p *Plugin) reportStateChange(pluginName infra.PluginName, state PluginState, lastError error) {
 	p.access.Lock()
 	defer p.access.Unlock()
@@ -253,6 +261,26 @@ func (p *Plugin) reportInterfaceStateChange(data *status.InterfaceStats_Interfac
 	}
 }
 
+func (p *Plugin) reportElectionStateChange(data *status.ElectionStatus) {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	for i, election := range p.agentStat.Elections {
+		if election.Prefix == data.Prefix {
+			if election.Leader == data.Leader && election.IsLeader == data.IsLeader {
+				return
+			}
+			p.agentStat.Elections[i] = data
+			p.Log.Debugf("Election state data updated: %v", data)
+			p.publishAgentData()
+			return
+		}
+	}
+	p.agentStat.Elections = append(p.agentStat.Elections, data)
+	p.Log.Debugf("Election state data added: %v", data)
+	p.publishAgentData()
+}
+
 // publishAgentData writes the current global agent state into ETCD.
 func (p *Plugin) publishAgentData() error {
 	p.agentStat.LastUpdate = time.Now().Unix()